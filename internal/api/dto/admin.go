@@ -0,0 +1,29 @@
+package dto
+
+import (
+	"github.com/wangn-tech/tiny-douyin/internal/common/response"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/upload"
+)
+
+// DeadLetterListRequest 查看上传任务死信请求
+type DeadLetterListRequest struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=200"` // 查看数量（可选，默认 20）
+}
+
+// DeadLetterListResponse 查看上传任务死信响应
+type DeadLetterListResponse struct {
+	response.Response
+	DeadLetters []*upload.DeadLetter `json:"dead_letters"`
+}
+
+// DeadLetterReplayRequest 重放上传任务死信请求
+type DeadLetterReplayRequest struct {
+	Limit    int    `json:"limit" binding:"omitempty,min=1,max=200"` // 最多扫描的死信数量（可选，默认 20）
+	VideoIDs []uint `json:"video_ids"`                               // 只重放这些视频的任务（可选）
+}
+
+// DeadLetterReplayResponse 重放上传任务死信响应
+type DeadLetterReplayResponse struct {
+	response.Response
+	Replayed int `json:"replayed"` // 重放数量
+}
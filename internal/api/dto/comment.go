@@ -6,6 +6,7 @@ type CommentActionRequest struct {
 	ActionType  int32  `form:"action_type" binding:"required"` // 操作类型：1-发布评论，2-删除评论
 	CommentText string `form:"comment_text"`                   // 评论内容（发布评论时使用）
 	CommentID   uint   `form:"comment_id"`                     // 评论ID（删除评论时使用）
+	ReplyToID   uint   `form:"reply_to_id"`                    // 回复的评论ID（发布回复时使用，可以是顶级评论或回复）
 }
 
 // CommentActionResponse 评论操作响应
@@ -24,7 +25,23 @@ type CommentListRequest struct {
 type CommentListResponse struct {
 	StatusCode  int32      `json:"status_code"`  // 状态码
 	StatusMsg   string     `json:"status_msg"`   // 状态信息
-	CommentList []*Comment `json:"comment_list"` // 评论列表
+	CommentList []*Comment `json:"comment_list"` // 顶级评论列表（回复通过回复列表接口加载）
+}
+
+// CommentReplyListRequest 回复列表请求
+type CommentReplyListRequest struct {
+	CommentID uint `form:"comment_id" binding:"required,gt=0"`     // 顶级评论ID
+	Cursor    uint `form:"cursor"`                                 // 上一页返回的 next_cursor（可选，默认第一页）
+	Limit     int  `form:"limit" binding:"omitempty,min=1,max=50"` // 每页数量（可选，默认 20）
+}
+
+// CommentReplyListResponse 回复列表响应
+type CommentReplyListResponse struct {
+	StatusCode int32      `json:"status_code"` // 状态码
+	StatusMsg  string     `json:"status_msg"`  // 状态信息
+	ReplyList  []*Comment `json:"reply_list"`  // 回复列表（按发布时间正序）
+	NextCursor uint       `json:"next_cursor"` // 下一页游标
+	HasMore    bool       `json:"has_more"`    // 是否还有更多
 }
 
 // Comment 评论信息
@@ -33,4 +50,9 @@ type Comment struct {
 	User       *UserInfo `json:"user"`        // 评论用户信息
 	Content    string    `json:"content"`     // 评论内容
 	CreateDate string    `json:"create_date"` // 评论发布日期（MM-DD）
+
+	ParentID    uint      `json:"parent_id"`               // 回复的评论ID（顶级评论为 0）
+	RootID      uint      `json:"root_id"`                 // 所属顶级评论ID（顶级评论为 0）
+	ReplyToUser *UserInfo `json:"reply_to_user,omitempty"` // 被回复的用户（@提及，仅回复）
+	ReplyCount  int64     `json:"reply_count"`             // 回复数（仅顶级评论）
 }
@@ -1,10 +1,15 @@
 package dto
 
 // MessageActionRequest 发送消息请求
+// 图片消息以 multipart/form-data 提交，图片放在最后的 data 字段中
 type MessageActionRequest struct {
-	ToUserID   uint   `form:"to_user_id" binding:"required,gt=0"`     // 接收者用户ID
-	ActionType int32  `form:"action_type" binding:"required,oneof=1"` // 操作类型：1-发送消息
-	Content    string `form:"content" binding:"required"`             // 消息内容
+	ToUserID   uint   `form:"to_user_id" binding:"required,gt=0"`                             // 接收者用户ID
+	ActionType int32  `form:"action_type" binding:"required,oneof=1"`                         // 操作类型：1-发送消息
+	MsgType    string `form:"msg_type" binding:"omitempty,oneof=text image video user reply"` // 消息类型（可选，默认 text）
+	Content    string `form:"content"`                                                        // 消息内容（文本、回复消息必填，其他类型为可选的附言）
+	VideoID    uint   `form:"video_id"`                                                       // 分享的视频ID（video 消息）
+	CardUserID uint   `form:"card_user_id"`                                                   // 分享的用户ID（user 消息）
+	ReplyToID  uint   `form:"reply_to_id"`                                                    // 回复的消息ID（reply 消息）
 }
 
 // MessageActionResponse 发送消息响应
@@ -14,9 +19,13 @@ type MessageActionResponse struct {
 }
 
 // MessageChatRequest 获取聊天记录请求
+// 不指定游标时返回最新的一页；before_id 向前翻页（最新的在前），after_id 向后补齐（按 ID 正序）
 type MessageChatRequest struct {
-	ToUserID   uint  `form:"to_user_id" binding:"required,gt=0"` // 对方用户ID
-	PreMsgTime int64 `form:"pre_msg_time"`                       // 上次最新消息的时间戳（秒），可选，用于分页
+	ToUserID   uint  `form:"to_user_id" binding:"required,gt=0"`      // 对方用户ID
+	BeforeID   uint  `form:"before_id"`                               // 查询 ID 小于该值的更早消息（可选）
+	AfterID    uint  `form:"after_id"`                                // 查询 ID 大于该值的更新消息（可选）
+	Limit      int   `form:"limit" binding:"omitempty,min=1,max=100"` // 每页数量（可选，默认 50）
+	PreMsgTime int64 `form:"pre_msg_time"`                            // 上次最新消息的时间戳（秒），可选，未指定 ID 游标时使用（已废弃，同一秒内的消息可能遗漏）
 }
 
 // MessageChatResponse 获取聊天记录响应
@@ -24,13 +33,129 @@ type MessageChatResponse struct {
 	StatusCode  int32     `json:"status_code"`  // 状态码
 	StatusMsg   string    `json:"status_msg"`   // 状态信息
 	MessageList []Message `json:"message_list"` // 消息列表
+	HasMore     bool      `json:"has_more"`     // 游标方向上是否还有更多消息
+}
+
+// MessageSyncRequest 增量同步消息请求
+type MessageSyncRequest struct {
+	LastMessageID uint `form:"last_message_id"`                         // 上次同步返回的 last_message_id（可选，0 表示从头同步）
+	Limit         int  `form:"limit" binding:"omitempty,min=1,max=100"` // 每页数量（可选，默认 50）
+}
+
+// MessageSyncResponse 增量同步消息响应
+type MessageSyncResponse struct {
+	StatusCode    int32     `json:"status_code"`     // 状态码
+	StatusMsg     string    `json:"status_msg"`      // 状态信息
+	MessageList   []Message `json:"message_list"`    // 所有会话中的新消息（按 ID 正序，可能包含上次已返回的最近消息，客户端按 ID 去重）
+	LastMessageID uint      `json:"last_message_id"` // 下次同步使用的 last_message_id
+	HasMore       bool      `json:"has_more"`        // 是否还有更多（有时应立即继续同步）
 }
 
 // Message 消息信息
 type Message struct {
+	ID         uint          `json:"id"`                 // 消息ID
+	ToUserID   uint          `json:"to_user_id"`         // 接收者用户ID
+	FromUserID uint          `json:"from_user_id"`       // 发送者用户ID
+	Type       string        `json:"type"`               // 消息类型: text, image, video, user, reply
+	Content    string        `json:"content"`            // 消息内容
+	Image      *MessageImage `json:"image,omitempty"`    // 图片（image 消息）
+	Video      *MessageVideo `json:"video,omitempty"`    // 分享的视频（video 消息）
+	User       *UserInfo     `json:"user,omitempty"`     // 分享的用户名片（user 消息）
+	ReplyTo    *MessageReply `json:"reply_to,omitempty"` // 回复的消息（reply 消息）
+	CreateTime int64         `json:"create_time"`        // 消息发送时间（Unix时间戳，秒）
+	IsRead     bool          `json:"is_read"`            // 接收方是否已读（已读回执）
+	Recalled   bool          `json:"recalled"`           // 是否已撤回（撤回后内容为空，不返回附加数据）
+}
+
+// MessageImage 图片消息内容
+type MessageImage struct {
+	URL    string `json:"url"`    // 图片访问地址
+	Width  int    `json:"width"`  // 宽度（无法识别时为 0）
+	Height int    `json:"height"` // 高度（无法识别时为 0）
+}
+
+// MessageVideo 分享视频的预览
+type MessageVideo struct {
+	ID        uint   `json:"id"`                  // 视频ID
+	Available bool   `json:"available"`           // 视频是否可以观看（已删除或未就绪时为 false，不返回其他字段）
+	AuthorID  uint   `json:"author_id,omitempty"` // 作者ID
+	Title     string `json:"title,omitempty"`     // 标题
+	CoverURL  string `json:"cover_url,omitempty"` // 封面地址
+}
+
+// MessageReply 被回复消息的预览
+type MessageReply struct {
 	ID         uint   `json:"id"`           // 消息ID
-	ToUserID   uint   `json:"to_user_id"`   // 接收者用户ID
 	FromUserID uint   `json:"from_user_id"` // 发送者用户ID
-	Content    string `json:"content"`      // 消息内容
-	CreateTime int64  `json:"create_time"`  // 消息发送时间（Unix时间戳，秒）
+	Type       string `json:"type"`         // 消息类型
+	Content    string `json:"content"`      // 预览文字（非文本消息为 [图片] 等）
+}
+
+// MessageReadRequest 标记会话已读请求
+type MessageReadRequest struct {
+	ToUserID  uint `form:"to_user_id" binding:"required,gt=0"` // 对方用户ID
+	MessageID uint `form:"message_id"`                         // 已读到的消息ID（可选，默认会话最新消息）
+}
+
+// MessageReadResponse 标记会话已读响应
+type MessageReadResponse struct {
+	StatusCode        int32  `json:"status_code"`          // 状态码
+	StatusMsg         string `json:"status_msg"`           // 状态信息
+	LastReadMessageID uint   `json:"last_read_message_id"` // 当前已读到的消息ID
+	UnreadCount       int    `json:"unread_count"`         // 会话剩余未读消息数
+}
+
+// ConversationListRequest 会话列表请求
+type ConversationListRequest struct {
+	Cursor uint `form:"cursor"`                                 // 上一页返回的 next_cursor（可选，默认第一页）
+	Limit  int  `form:"limit" binding:"omitempty,min=1,max=50"` // 每页数量（可选，默认 20）
+}
+
+// ConversationListResponse 会话列表响应
+type ConversationListResponse struct {
+	StatusCode       int32          `json:"status_code"`       // 状态码
+	StatusMsg        string         `json:"status_msg"`        // 状态信息
+	ConversationList []Conversation `json:"conversation_list"` // 会话列表（最近有消息的在前）
+	NextCursor       uint           `json:"next_cursor"`       // 下一页游标
+	HasMore          bool           `json:"has_more"`          // 是否还有更多
+	UnreadCount      int64          `json:"unread_count"`      // 所有会话的未读消息总数
+}
+
+// Conversation 会话信息
+type Conversation struct {
+	User                  UserInfo `json:"user"`                      // 对方用户
+	LastMessage           *Message `json:"last_message,omitempty"`    // 最新消息
+	UnreadCount           int      `json:"unread_count"`              // 未读消息数
+	LastReadMessageID     uint     `json:"last_read_message_id"`      // 当前用户已读到的消息ID
+	PeerLastReadMessageID uint     `json:"peer_last_read_message_id"` // 对方已读到的消息ID（已读回执）
+	UpdateTime            int64    `json:"update_time"`               // 最新消息时间（Unix时间戳，秒）
+}
+
+// MessageReadReceipt 已读回执（WebSocket read 帧数据）
+type MessageReadReceipt struct {
+	UserID            uint `json:"user_id"`              // 标记已读的用户ID
+	PeerID            uint `json:"peer_id"`              // 会话对方用户ID
+	LastReadMessageID uint `json:"last_read_message_id"` // 已读到的消息ID
+}
+
+// MessageRecallRequest 撤回消息请求
+type MessageRecallRequest struct {
+	MessageID uint `form:"message_id" binding:"required,gt=0"` // 消息ID（只能撤回自己发送的消息）
+}
+
+// MessageDeleteRequest 删除消息请求（只对自己隐藏，对方仍可见）
+type MessageDeleteRequest struct {
+	MessageID uint `form:"message_id" binding:"required,gt=0"` // 消息ID
+}
+
+// MessageClearRequest 清空会话请求（只清空自己一侧）
+type MessageClearRequest struct {
+	ToUserID uint `form:"to_user_id" binding:"required,gt=0"` // 对方用户ID
+}
+
+// MessageRecallNotice 撤回通知（WebSocket recall 帧数据）
+type MessageRecallNotice struct {
+	MessageID  uint `json:"message_id"`   // 被撤回的消息ID
+	FromUserID uint `json:"from_user_id"` // 发送者用户ID
+	ToUserID   uint `json:"to_user_id"`   // 接收者用户ID
 }
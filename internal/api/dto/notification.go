@@ -0,0 +1,65 @@
+package dto
+
+import "github.com/wangn-tech/tiny-douyin/internal/common/response"
+
+// NotificationListRequest 通知列表请求
+type NotificationListRequest struct {
+	Cursor uint   `form:"cursor"`                                                   // 上一页返回的 next_cursor（可选，默认第一页）
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=50"`                   // 每页数量（可选，默认 20）
+	Type   string `form:"type" binding:"omitempty,oneof=like comment reply follow"` // 通知类型（可选，默认全部）
+}
+
+// NotificationListResponse 通知列表响应
+type NotificationListResponse struct {
+	response.Response
+	NotificationListData
+}
+
+// NotificationListData 通知列表数据
+type NotificationListData struct {
+	Notifications []Notification `json:"notification_list"` // 通知列表（最新的在前）
+	NextCursor    uint           `json:"next_cursor"`       // 下一页游标
+	HasMore       bool           `json:"has_more"`          // 是否还有更多
+	UnreadCount   int64          `json:"unread_count"`      // 未读通知总数
+}
+
+// Notification 通知信息
+type Notification struct {
+	ID         uint       `json:"id"`                   // 通知ID
+	Type       string     `json:"type"`                 // 通知类型: like, comment, reply, follow
+	Summary    string     `json:"summary"`              // 通知摘要（如 "A 和其他 12 人赞了你的作品"）
+	Actors     []UserInfo `json:"actors"`               // 最近触发通知的用户（最新的在前，最多 3 个）
+	ActorCount int        `json:"actor_count"`          // 触发通知的用户数
+	VideoID    uint       `json:"video_id,omitempty"`   // 相关视频ID（点赞、评论）
+	CommentID  uint       `json:"comment_id,omitempty"` // 相关评论ID（评论、回复）
+	Content    string     `json:"content,omitempty"`    // 评论内容
+	IsRead     bool       `json:"is_read"`              // 是否已读
+	CreateTime int64      `json:"create_time"`          // 最近一次触发的时间（Unix时间戳，秒）
+}
+
+// NotificationUnreadResponse 未读通知数响应
+type NotificationUnreadResponse struct {
+	response.Response
+	NotificationUnreadData
+}
+
+// NotificationUnreadData 未读通知数
+type NotificationUnreadData struct {
+	Total   int64 `json:"total"`   // 未读总数
+	Like    int64 `json:"like"`    // 未读点赞通知数
+	Comment int64 `json:"comment"` // 未读评论通知数
+	Reply   int64 `json:"reply"`   // 未读回复通知数
+	Follow  int64 `json:"follow"`  // 未读关注通知数
+}
+
+// NotificationReadRequest 标记通知已读请求
+type NotificationReadRequest struct {
+	NotificationID uint   `form:"notification_id"`                                          // 通知ID（可选，为空时标记全部）
+	Type           string `form:"type" binding:"omitempty,oneof=like comment reply follow"` // 只标记该类型的通知（可选，notification_id 为空时生效）
+}
+
+// NotificationReadResponse 标记通知已读响应
+type NotificationReadResponse struct {
+	response.Response
+	Updated int64 `json:"updated"` // 标记为已读的通知数
+}
@@ -51,6 +51,7 @@ type RelationFriendListResponse struct {
 // FriendInfo 好友信息（扩展 UserInfo，添加消息字段）
 type FriendInfo struct {
 	UserInfo
-	Message string `json:"message,omitempty"` // 和该好友的最新聊天消息（暂时为空，等消息模块实现）
-	MsgType int32  `json:"msg_type"`          // 消息类型：0-当前用户接收的消息，1-当前用户发送的消息
+	Message     string `json:"message,omitempty"` // 和该好友的最新聊天消息
+	MsgType     int32  `json:"msg_type"`          // 消息类型：0-当前用户接收的消息，1-当前用户发送的消息
+	UnreadCount int    `json:"unread_count"`      // 与该好友会话的未读消息数
 }
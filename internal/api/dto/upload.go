@@ -0,0 +1,57 @@
+package dto
+
+import (
+	"github.com/wangn-tech/tiny-douyin/internal/common/response"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/upload"
+)
+
+// ChunkUploadInitRequest 创建分片上传会话请求
+type ChunkUploadInitRequest struct {
+	Title     string `form:"title" binding:"max=128"`              // 视频标题（可选，不传则使用文件名）
+	FileName  string `form:"file_name" binding:"required,max=255"` // 文件名
+	Size      int64  `form:"size" binding:"required,min=1"`        // 文件大小（字节）
+	ChunkSize int64  `form:"chunk_size" binding:"omitempty,min=1"` // 分片大小（可选，默认 5MB）
+}
+
+// ChunkUploadRequest 上传分片请求（分片内容为请求体）
+type ChunkUploadRequest struct {
+	UploadID string `form:"upload_id" binding:"required"`   // 上传会话ID
+	Index    *int   `form:"index" binding:"required,min=0"` // 分片序号，从 0 开始
+}
+
+// ChunkUploadIDRequest 查询、完成、取消分片上传请求
+type ChunkUploadIDRequest struct {
+	UploadID string `form:"upload_id" binding:"required"` // 上传会话ID
+}
+
+// PresignUploadRequest 获取直传预签名表单请求
+type PresignUploadRequest struct {
+	Title       string `form:"title" binding:"max=128"`              // 视频标题（可选，不传则使用文件名）
+	FileName    string `form:"file_name" binding:"required,max=255"` // 文件名
+	Size        int64  `form:"size" binding:"required,min=1"`        // 文件大小（字节）
+	ContentType string `form:"content_type" binding:"required"`      // 内容类型（如 video/mp4）
+}
+
+// PresignUploadResponse 获取直传预签名表单响应
+type PresignUploadResponse struct {
+	response.Response
+	upload.DirectUpload
+}
+
+// ChunkUploadInitResponse 创建分片上传会话响应
+type ChunkUploadInitResponse struct {
+	response.Response
+	upload.ChunkSession
+}
+
+// ChunkUploadStatusResponse 查询分片上传进度响应
+type ChunkUploadStatusResponse struct {
+	response.Response
+	upload.ChunkStatus
+}
+
+// ChunkUploadCompleteResponse 完成分片上传响应
+type ChunkUploadCompleteResponse struct {
+	response.Response
+	VideoID uint `json:"video_id"` // 视频ID，可通过 /publish/status/ 查询处理状态
+}
@@ -48,14 +48,55 @@ type VideoListData struct {
 	Videos []Video
 }
 
+// VideoStatusRequest 查询视频处理状态请求
+type VideoStatusRequest struct {
+	VideoID uint `form:"video_id" binding:"required"` // 视频ID
+}
+
+// VideoURLRequest 获取视频访问地址请求
+type VideoURLRequest struct {
+	VideoID uint   `form:"video_id" binding:"required"`                   // 视频ID
+	Type    string `form:"type" binding:"omitempty,oneof=play hls cover"` // 地址类型: play（默认）, hls, cover
+}
+
+// VideoDeleteRequest 删除视频请求
+type VideoDeleteRequest struct {
+	VideoID uint `form:"video_id" binding:"required"` // 视频ID
+}
+
+// VideoDeleteResponse 删除视频响应
+type VideoDeleteResponse struct {
+	response.Response
+}
+
+// VideoStatusResponse 查询视频处理状态响应
+type VideoStatusResponse struct {
+	response.Response
+	VideoStatus
+}
+
+// VideoStatus 视频处理状态（Service 层返回）
+type VideoStatus struct {
+	VideoID    uint   `json:"video_id"`    // 视频ID
+	Status     string `json:"status"`      // 状态: uploading, processing, ready, failed
+	PlayURL    string `json:"play_url"`    // 视频播放地址（就绪后可用）
+	HLSURL     string `json:"hls_url"`     // HLS 主播放列表地址（就绪后可用）
+	CoverURL   string `json:"cover_url"`   // 视频封面地址（就绪后可用）
+	DurationMs int64  `json:"duration_ms"` // 时长（毫秒）
+	Width      int    `json:"width"`       // 宽度（像素）
+	Height     int    `json:"height"`      // 高度（像素）
+}
+
 // Video 视频信息
 type Video struct {
 	ID            uint     `json:"id"`             // 视频ID
 	Author        UserInfo `json:"author"`         // 作者信息
-	PlayURL       string   `json:"play_url"`       // 视频播放地址
+	PlayURL       string   `json:"play_url"`       // 视频播放地址（原视频）
+	HLSURL        string   `json:"hls_url"`        // HLS 主播放列表地址（未生成 HLS 时与 play_url 相同）
 	CoverURL      string   `json:"cover_url"`      // 视频封面地址
 	FavoriteCount int64    `json:"favorite_count"` // 点赞数
 	CommentCount  int64    `json:"comment_count"`  // 评论数
 	IsFavorite    bool     `json:"is_favorite"`    // 是否点赞
 	Title         string   `json:"title"`          // 视频标题
+	Status        string   `json:"status"`         // 视频状态（作者查看自己的发布列表时可能为未就绪状态）
 }
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/api/dto"
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/common/errc"
+	"github.com/wangn-tech/tiny-douyin/internal/common/response"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/upload"
+)
+
+// AdminHandler 管理接口处理器
+type AdminHandler struct {
+	deadLetterService upload.IDeadLetterService
+}
+
+// NewAdminHandler 创建 AdminHandler 实例（通过依赖注入）
+func NewAdminHandler(deadLetterService upload.IDeadLetterService) *AdminHandler {
+	return &AdminHandler{
+		deadLetterService: deadLetterService,
+	}
+}
+
+// ListDeadLetters 查看上传任务死信
+// GET /douyin/admin/upload/dead-letters/
+// 可选参数：limit
+func (h *AdminHandler) ListDeadLetters(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.DeadLetterListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, errc.ErrInvalidParams, "参数错误: "+err.Error())
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = constant.AdminDeadLetterDefaultLimit
+	}
+
+	letters, err := h.deadLetterService.List(ctx, req.Limit)
+	if err != nil {
+		global.Logger.Error("handler.ListDeadLetters.service_error",
+			zap.Error(err),
+		)
+		response.Error(c, errc.ErrInternalServer, "查看死信队列失败")
+		return
+	}
+
+	response.SuccessWithData(c, dto.DeadLetterListResponse{
+		Response: response.Response{
+			StatusCode: errc.Success,
+			StatusMsg:  errc.GetMsg(errc.Success),
+		},
+		DeadLetters: letters,
+	})
+}
+
+// ReplayDeadLetters 重放上传任务死信
+// POST /douyin/admin/upload/dead-letters/replay/
+// JSON 参数：limit（可选），video_ids（可选）
+func (h *AdminHandler) ReplayDeadLetters(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.DeadLetterReplayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errc.ErrInvalidParams, "参数错误: "+err.Error())
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = constant.AdminDeadLetterDefaultLimit
+	}
+
+	global.Logger.Info("handler.ReplayDeadLetters.request",
+		zap.Int("limit", req.Limit),
+		zap.Any("video_ids", req.VideoIDs),
+		zap.String("client_ip", c.ClientIP()),
+	)
+
+	replayed, err := h.deadLetterService.Replay(ctx, req.Limit, req.VideoIDs)
+	if err != nil {
+		global.Logger.Error("handler.ReplayDeadLetters.service_error",
+			zap.Int("replayed", replayed),
+			zap.Error(err),
+		)
+		response.Error(c, errc.ErrInternalServer, "重放死信失败")
+		return
+	}
+
+	response.SuccessWithData(c, dto.DeadLetterReplayResponse{
+		Response: response.Response{
+			StatusCode: errc.Success,
+			StatusMsg:  errc.GetMsg(errc.Success),
+		},
+		Replayed: replayed,
+	})
+}
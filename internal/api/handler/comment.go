@@ -26,7 +26,7 @@ func NewCommentHandler(commentService service.ICommentService) *CommentHandler {
 
 // CommentAction 评论操作（发布/删除）
 // @Summary 评论操作
-// @Description 用户对视频进行评论或删除评论
+// @Description 用户对视频进行评论、回复评论或删除评论（删除顶级评论时同时删除其下所有回复）
 // @Tags 评论
 // @Accept json
 // @Produce json
@@ -35,6 +35,7 @@ func NewCommentHandler(commentService service.ICommentService) *CommentHandler {
 // @Param action_type query int true "操作类型：1-发布评论，2-删除评论"
 // @Param comment_text query string false "评论内容（发布评论时使用）"
 // @Param comment_id query uint false "评论ID（删除评论时使用）"
+// @Param reply_to_id query uint false "回复的评论ID（发布回复时使用）"
 // @Success 200 {object} dto.CommentActionResponse
 // @Router /douyin/comment/action/ [post]
 func (h *CommentHandler) CommentAction(c *gin.Context) {
@@ -87,7 +88,7 @@ func (h *CommentHandler) CommentAction(c *gin.Context) {
 
 // GetCommentList 获取视频评论列表
 // @Summary 获取视频评论列表
-// @Description 获取指定视频的所有评论
+// @Description 获取指定视频的所有顶级评论，回复通过回复列表接口分页加载
 // @Tags 评论
 // @Accept json
 // @Produce json
@@ -131,3 +132,41 @@ func (h *CommentHandler) GetCommentList(c *gin.Context) {
 		zap.Int("count", len(comments)),
 	)
 }
+
+// GetCommentReplies 获取评论回复列表
+// @Summary 获取评论回复列表
+// @Description 按发布时间正序分页获取顶级评论下的回复（包括回复的回复）
+// @Tags 评论
+// @Accept json
+// @Produce json
+// @Param token query string true "用户token"
+// @Param comment_id query uint true "顶级评论ID"
+// @Param cursor query uint false "上一页返回的 next_cursor"
+// @Param limit query int false "每页数量，默认 20"
+// @Success 200 {object} dto.CommentReplyListResponse
+// @Router /douyin/comment/replies/ [get]
+func (h *CommentHandler) GetCommentReplies(c *gin.Context) {
+	// 绑定请求参数
+	var req dto.CommentReplyListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		global.Logger.Warn("handler.GetCommentReplies.bind_error",
+			zap.Error(err),
+		)
+		response.Error(c, errc.ErrInvalidParams, "参数错误")
+		return
+	}
+
+	// 调用 Service 层
+	resp, err := h.commentService.GetCommentReplies(c.Request.Context(), &req)
+	if err != nil {
+		global.Logger.Error("handler.GetCommentReplies.service_error",
+			zap.Uint("comment_id", req.CommentID),
+			zap.Error(err),
+		)
+		response.Error(c, errc.Failed, err.Error())
+		return
+	}
+
+	// 返回成功响应
+	c.JSON(http.StatusOK, resp)
+}
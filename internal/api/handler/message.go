@@ -1,39 +1,63 @@
 package handler
 
 import (
+	"errors"
+	"mime/multipart"
 	"net/http"
+	"path/filepath"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gorilla/websocket"
 	"github.com/wangn-tech/tiny-douyin/internal/api/dto"
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
 	"github.com/wangn-tech/tiny-douyin/internal/common/errc"
 	"github.com/wangn-tech/tiny-douyin/internal/common/response"
 	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/chat"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/upload"
 	"github.com/wangn-tech/tiny-douyin/internal/service"
 	"go.uber.org/zap"
 )
 
+// chatUpgrader WebSocket 升级器（跨域策略与 CORS 中间件保持一致，允许任意来源）
+var chatUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
 // MessageHandler 消息处理器
 type MessageHandler struct {
 	messageService service.IMessageService
+	uploadService  upload.IUploadService
+	hub            chat.IHub
 }
 
 // NewMessageHandler 创建 MessageHandler 实例
-func NewMessageHandler(messageService service.IMessageService) *MessageHandler {
+func NewMessageHandler(messageService service.IMessageService, uploadService upload.IUploadService, hub chat.IHub) *MessageHandler {
 	return &MessageHandler{
 		messageService: messageService,
+		uploadService:  uploadService,
+		hub:            hub,
 	}
 }
 
 // SendMessage 发送消息
 // @Summary 发送消息
-// @Description 用户向好友发送消息
+// @Description 用户向好友发送消息（文本、图片、分享视频、分享名片、回复）；图片消息以 multipart/form-data 提交
 // @Tags 消息
 // @Accept json
+// @Accept multipart/form-data
 // @Produce json
 // @Param token query string true "用户token"
 // @Param to_user_id query uint true "接收者用户ID"
 // @Param action_type query int true "操作类型：1-发送消息"
-// @Param content query string true "消息内容"
+// @Param msg_type query string false "消息类型：text（默认）、image、video、user、reply"
+// @Param content query string false "消息内容（文本、回复消息必填）"
+// @Param video_id query uint false "分享的视频ID（video 消息）"
+// @Param card_user_id query uint false "分享的用户ID（user 消息）"
+// @Param reply_to_id query uint false "回复的消息ID（reply 消息）"
+// @Param data formData file false "图片文件（image 消息，需放在表单最后）"
 // @Success 200 {object} dto.MessageActionResponse
 // @Router /douyin/message/action/ [post]
 func (h *MessageHandler) SendMessage(c *gin.Context) {
@@ -46,8 +70,17 @@ func (h *MessageHandler) SendMessage(c *gin.Context) {
 	}
 
 	// 绑定请求参数
+	// multipart 请求的表单字段已由 StreamMultipart 中间件读取到 Request.Form（同时包含 query 参数），不能再解析 multipart
 	var req dto.MessageActionRequest
-	if err := c.ShouldBindQuery(&req); err != nil {
+	var err error
+	if c.Request.PostForm != nil && c.ContentType() == binding.MIMEMultipartPOSTForm {
+		if err = binding.MapFormWithTag(&req, c.Request.Form, "form"); err == nil {
+			err = binding.Validator.ValidateStruct(&req)
+		}
+	} else {
+		err = c.ShouldBindQuery(&req)
+	}
+	if err != nil {
 		global.Logger.Warn("handler.SendMessage.bind_error",
 			zap.Error(err),
 		)
@@ -55,8 +88,31 @@ func (h *MessageHandler) SendMessage(c *gin.Context) {
 		return
 	}
 
+	// 图片消息：流式保存到临时目录（限制大小、识别图片类型），发送完成后清理
+	var image *upload.TempFile
+	if value, exists := c.Get(constant.ContextKeyUploadFile); exists && req.MsgType == constant.MessageTypeImage {
+		file := value.(*multipart.Part)
+		image, err = h.uploadService.SaveTempImage(file, filepath.Ext(file.FileName()), constant.MaxMessageImageSize)
+		if err != nil {
+			global.Logger.Warn("handler.SendMessage.save_image_error",
+				zap.Uint("from_user_id", userID.(uint)),
+				zap.Error(err),
+			)
+			switch {
+			case errors.Is(err, upload.ErrFileTooLarge):
+				response.ErrorWithCode(c, errc.ErrMessageImageTooLarge)
+			case errors.Is(err, upload.ErrUnsupportedFileType):
+				response.ErrorWithCode(c, errc.ErrMessageImageType)
+			default:
+				response.Error(c, errc.Failed, "保存图片失败")
+			}
+			return
+		}
+		defer h.uploadService.CleanupTempFile(image.Path)
+	}
+
 	// 调用 Service 层
-	err := h.messageService.SendMessage(c.Request.Context(), userID.(uint), &req)
+	err = h.messageService.SendMessage(c.Request.Context(), userID.(uint), &req, image)
 	if err != nil {
 		global.Logger.Error("handler.SendMessage.service_error",
 			zap.Uint("from_user_id", userID.(uint)),
@@ -83,13 +139,16 @@ func (h *MessageHandler) SendMessage(c *gin.Context) {
 
 // GetChatMessages 获取聊天记录
 // @Summary 获取聊天记录
-// @Description 获取与某个用户的聊天记录
+// @Description 按消息 ID 游标获取与某个用户的聊天记录：不指定游标时返回最新一页，before_id 向前翻页（最新的在前），after_id 向后补齐（按 ID 正序）
 // @Tags 消息
 // @Accept json
 // @Produce json
 // @Param token query string true "用户token"
 // @Param to_user_id query uint true "对方用户ID"
-// @Param pre_msg_time query int64 false "上次最新消息的时间戳（秒）"
+// @Param before_id query uint false "查询 ID 小于该值的更早消息"
+// @Param after_id query uint false "查询 ID 大于该值的更新消息"
+// @Param limit query int false "每页数量，默认 50"
+// @Param pre_msg_time query int64 false "上次最新消息的时间戳（秒，已废弃）"
 // @Success 200 {object} dto.MessageChatResponse
 // @Router /douyin/message/chat/ [get]
 func (h *MessageHandler) GetChatMessages(c *gin.Context) {
@@ -132,3 +191,325 @@ func (h *MessageHandler) GetChatMessages(c *gin.Context) {
 		zap.Int("message_count", len(resp.MessageList)),
 	)
 }
+
+// SyncMessages 增量同步消息
+// @Summary 增量同步消息
+// @Description 按 ID 正序返回所有会话中 ID 大于 last_message_id 的消息，用于断线重连或切换设备后补齐消息
+// @Tags 消息
+// @Accept json
+// @Produce json
+// @Param token query string true "用户token"
+// @Param last_message_id query uint false "已收到的最新消息ID，默认从头同步"
+// @Param limit query int false "每页数量，默认 50"
+// @Success 200 {object} dto.MessageSyncResponse
+// @Router /douyin/message/sync/ [get]
+func (h *MessageHandler) SyncMessages(c *gin.Context) {
+	// 获取当前用户ID（从JWT中间件中获取）
+	userID, exists := c.Get("user_id")
+	if !exists {
+		global.Logger.Warn("handler.SyncMessages.user_id_not_found")
+		response.Error(c, errc.ErrUnauthorized, "未授权")
+		return
+	}
+
+	// 绑定请求参数
+	var req dto.MessageSyncRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		global.Logger.Warn("handler.SyncMessages.bind_error",
+			zap.Error(err),
+		)
+		response.Error(c, errc.ErrInvalidParams, "参数错误")
+		return
+	}
+
+	// 调用 Service 层
+	resp, err := h.messageService.SyncMessages(c.Request.Context(), userID.(uint), &req)
+	if err != nil {
+		global.Logger.Error("handler.SyncMessages.service_error",
+			zap.Uint("user_id", userID.(uint)),
+			zap.Uint("last_message_id", req.LastMessageID),
+			zap.Error(err),
+		)
+		response.Error(c, errc.Failed, err.Error())
+		return
+	}
+
+	// 返回成功响应
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetConversationList 获取会话列表
+// @Summary 获取会话列表
+// @Description 按最新消息倒序分页获取私信会话，包含最新消息、未读数和双方已读位置
+// @Tags 消息
+// @Accept json
+// @Produce json
+// @Param token query string true "用户token"
+// @Param cursor query uint false "上一页返回的 next_cursor"
+// @Param limit query int false "每页数量，默认 20"
+// @Success 200 {object} dto.ConversationListResponse
+// @Router /douyin/message/conversations/ [get]
+func (h *MessageHandler) GetConversationList(c *gin.Context) {
+	// 获取当前用户ID（从JWT中间件中获取）
+	userID, exists := c.Get("user_id")
+	if !exists {
+		global.Logger.Warn("handler.GetConversationList.user_id_not_found")
+		response.Error(c, errc.ErrUnauthorized, "未授权")
+		return
+	}
+
+	// 绑定请求参数
+	var req dto.ConversationListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		global.Logger.Warn("handler.GetConversationList.bind_error",
+			zap.Error(err),
+		)
+		response.Error(c, errc.ErrInvalidParams, "参数错误")
+		return
+	}
+
+	// 调用 Service 层
+	resp, err := h.messageService.GetConversationList(c.Request.Context(), userID.(uint), &req)
+	if err != nil {
+		global.Logger.Error("handler.GetConversationList.service_error",
+			zap.Uint("user_id", userID.(uint)),
+			zap.Error(err),
+		)
+		response.Error(c, errc.Failed, err.Error())
+		return
+	}
+
+	// 返回成功响应
+	c.JSON(http.StatusOK, resp)
+}
+
+// MarkRead 标记会话已读
+// @Summary 标记会话已读
+// @Description 标记与某个用户的会话已读到指定消息，不传 message_id 时标记全部已读
+// @Tags 消息
+// @Accept json
+// @Produce json
+// @Param token query string true "用户token"
+// @Param to_user_id query uint true "对方用户ID"
+// @Param message_id query uint false "已读到的消息ID"
+// @Success 200 {object} dto.MessageReadResponse
+// @Router /douyin/message/read/ [post]
+func (h *MessageHandler) MarkRead(c *gin.Context) {
+	// 获取当前用户ID（从JWT中间件中获取）
+	userID, exists := c.Get("user_id")
+	if !exists {
+		global.Logger.Warn("handler.MarkMessagesRead.user_id_not_found")
+		response.Error(c, errc.ErrUnauthorized, "未授权")
+		return
+	}
+
+	// 绑定请求参数
+	var req dto.MessageReadRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		global.Logger.Warn("handler.MarkMessagesRead.bind_error",
+			zap.Error(err),
+		)
+		response.Error(c, errc.ErrInvalidParams, "参数错误")
+		return
+	}
+
+	// 调用 Service 层
+	resp, err := h.messageService.MarkRead(c.Request.Context(), userID.(uint), &req)
+	if err != nil {
+		global.Logger.Error("handler.MarkMessagesRead.service_error",
+			zap.Uint("user_id", userID.(uint)),
+			zap.Uint("to_user_id", req.ToUserID),
+			zap.Error(err),
+		)
+		response.Error(c, errc.Failed, err.Error())
+		return
+	}
+
+	// 返回成功响应
+	c.JSON(http.StatusOK, resp)
+}
+
+// RecallMessage 撤回消息
+// @Summary 撤回消息
+// @Description 撤回自己发送的消息（发送后一定时间内），双方看到的内容都被替换为撤回提示
+// @Tags 消息
+// @Accept json
+// @Produce json
+// @Param token query string true "用户token"
+// @Param message_id query uint true "消息ID"
+// @Success 200 {object} dto.MessageActionResponse
+// @Router /douyin/message/recall/ [post]
+func (h *MessageHandler) RecallMessage(c *gin.Context) {
+	// 获取当前用户ID（从JWT中间件中获取）
+	userID, exists := c.Get("user_id")
+	if !exists {
+		global.Logger.Warn("handler.RecallMessage.user_id_not_found")
+		response.Error(c, errc.ErrUnauthorized, "未授权")
+		return
+	}
+
+	// 绑定请求参数
+	var req dto.MessageRecallRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		global.Logger.Warn("handler.RecallMessage.bind_error",
+			zap.Error(err),
+		)
+		response.Error(c, errc.ErrInvalidParams, "参数错误")
+		return
+	}
+
+	// 调用 Service 层
+	if err := h.messageService.RecallMessage(c.Request.Context(), userID.(uint), &req); err != nil {
+		global.Logger.Error("handler.RecallMessage.service_error",
+			zap.Uint("user_id", userID.(uint)),
+			zap.Uint("message_id", req.MessageID),
+			zap.Error(err),
+		)
+		response.Error(c, errc.Failed, err.Error())
+		return
+	}
+
+	// 返回成功响应
+	c.JSON(http.StatusOK, &dto.MessageActionResponse{
+		StatusCode: errc.Success,
+		StatusMsg:  "撤回成功",
+	})
+}
+
+// DeleteMessage 删除消息
+// @Summary 删除消息
+// @Description 删除一条消息，只对自己隐藏，对方仍可见
+// @Tags 消息
+// @Accept json
+// @Produce json
+// @Param token query string true "用户token"
+// @Param message_id query uint true "消息ID"
+// @Success 200 {object} dto.MessageActionResponse
+// @Router /douyin/message/delete/ [post]
+func (h *MessageHandler) DeleteMessage(c *gin.Context) {
+	// 获取当前用户ID（从JWT中间件中获取）
+	userID, exists := c.Get("user_id")
+	if !exists {
+		global.Logger.Warn("handler.DeleteMessage.user_id_not_found")
+		response.Error(c, errc.ErrUnauthorized, "未授权")
+		return
+	}
+
+	// 绑定请求参数
+	var req dto.MessageDeleteRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		global.Logger.Warn("handler.DeleteMessage.bind_error",
+			zap.Error(err),
+		)
+		response.Error(c, errc.ErrInvalidParams, "参数错误")
+		return
+	}
+
+	// 调用 Service 层
+	if err := h.messageService.DeleteMessage(c.Request.Context(), userID.(uint), &req); err != nil {
+		global.Logger.Error("handler.DeleteMessage.service_error",
+			zap.Uint("user_id", userID.(uint)),
+			zap.Uint("message_id", req.MessageID),
+			zap.Error(err),
+		)
+		response.Error(c, errc.Failed, err.Error())
+		return
+	}
+
+	// 返回成功响应
+	c.JSON(http.StatusOK, &dto.MessageActionResponse{
+		StatusCode: errc.Success,
+		StatusMsg:  "删除成功",
+	})
+}
+
+// ClearConversation 清空聊天记录
+// @Summary 清空聊天记录
+// @Description 清空与某个用户的聊天记录并标记已读，只对自己生效，之后的新消息正常显示
+// @Tags 消息
+// @Accept json
+// @Produce json
+// @Param token query string true "用户token"
+// @Param to_user_id query uint true "对方用户ID"
+// @Success 200 {object} dto.MessageActionResponse
+// @Router /douyin/message/clear/ [post]
+func (h *MessageHandler) ClearConversation(c *gin.Context) {
+	// 获取当前用户ID（从JWT中间件中获取）
+	userID, exists := c.Get("user_id")
+	if !exists {
+		global.Logger.Warn("handler.ClearConversation.user_id_not_found")
+		response.Error(c, errc.ErrUnauthorized, "未授权")
+		return
+	}
+
+	// 绑定请求参数
+	var req dto.MessageClearRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		global.Logger.Warn("handler.ClearConversation.bind_error",
+			zap.Error(err),
+		)
+		response.Error(c, errc.ErrInvalidParams, "参数错误")
+		return
+	}
+
+	// 调用 Service 层
+	if err := h.messageService.ClearConversation(c.Request.Context(), userID.(uint), &req); err != nil {
+		global.Logger.Error("handler.ClearConversation.service_error",
+			zap.Uint("user_id", userID.(uint)),
+			zap.Uint("to_user_id", req.ToUserID),
+			zap.Error(err),
+		)
+		response.Error(c, errc.Failed, err.Error())
+		return
+	}
+
+	// 返回成功响应
+	c.JSON(http.StatusOK, &dto.MessageActionResponse{
+		StatusCode: errc.Success,
+		StatusMsg:  "清空成功",
+	})
+}
+
+// Connect 建立实时消息连接
+// @Summary 实时消息连接
+// @Description 升级为 WebSocket 连接，实时接收新消息；连接断开期间的消息通过增量同步接口补齐
+// @Tags 消息
+// @Param token query string true "用户token"
+// @Success 101
+// @Router /douyin/message/ws/ [get]
+func (h *MessageHandler) Connect(c *gin.Context) {
+	// 获取当前用户ID（从JWT中间件中获取）
+	userID, exists := c.Get("user_id")
+	if !exists {
+		global.Logger.Warn("handler.Connect.user_id_not_found")
+		response.Error(c, errc.ErrUnauthorized, "未授权")
+		return
+	}
+
+	// 升级失败时 Upgrader 已写入错误响应
+	conn, err := chatUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		global.Logger.Warn("handler.Connect.upgrade_error",
+			zap.Uint("user_id", userID.(uint)),
+			zap.Error(err),
+		)
+		return
+	}
+
+	if err := h.hub.Serve(userID.(uint), conn); err != nil {
+		global.Logger.Warn("handler.Connect.serve_error",
+			zap.Uint("user_id", userID.(uint)),
+			zap.Error(err),
+		)
+		_ = conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseTryAgainLater, ""),
+			time.Now().Add(constant.ChatWriteWait),
+		)
+		_ = conn.Close()
+		return
+	}
+
+	global.Logger.Info("handler.Connect.success",
+		zap.Uint("user_id", userID.(uint)),
+	)
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/api/dto"
+	"github.com/wangn-tech/tiny-douyin/internal/common/errc"
+	"github.com/wangn-tech/tiny-douyin/internal/common/response"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/service"
+)
+
+// NotificationHandler 站内通知处理器
+type NotificationHandler struct {
+	notificationService service.INotificationService
+}
+
+// NewNotificationHandler 创建 NotificationHandler 实例（依赖注入）
+func NewNotificationHandler(notificationService service.INotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+// GetNotificationList 获取通知列表
+// GET /douyin/notification/list/
+// 参数：cursor（可选，上一页返回的 next_cursor），limit（可选，默认 20），type（可选，like/comment/follow）
+func (h *NotificationHandler) GetNotificationList(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		global.Logger.Warn("handler.GetNotificationList.missing_user_id")
+		response.ErrorWithCode(c, errc.ErrUnauthorized)
+		return
+	}
+
+	var req dto.NotificationListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		global.Logger.Warn("handler.GetNotificationList.bind_error",
+			zap.Error(err),
+		)
+		response.Error(c, errc.ErrInvalidParams, err.Error())
+		return
+	}
+
+	data, err := h.notificationService.GetNotificationList(c.Request.Context(), userID.(uint), &req)
+	if err != nil {
+		global.Logger.Error("handler.GetNotificationList.service_error",
+			zap.Uint("user_id", userID.(uint)),
+			zap.Error(err),
+		)
+		response.Error(c, errc.ErrInternalServer, err.Error())
+		return
+	}
+
+	response.SuccessWithData(c, dto.NotificationListResponse{
+		Response: response.Response{
+			StatusCode: errc.Success,
+			StatusMsg:  errc.GetMsg(errc.Success),
+		},
+		NotificationListData: *data,
+	})
+}
+
+// GetUnreadCount 获取未读通知数
+// GET /douyin/notification/unread/
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		global.Logger.Warn("handler.GetUnreadNotificationCount.missing_user_id")
+		response.ErrorWithCode(c, errc.ErrUnauthorized)
+		return
+	}
+
+	data, err := h.notificationService.GetUnreadCount(c.Request.Context(), userID.(uint))
+	if err != nil {
+		global.Logger.Error("handler.GetUnreadNotificationCount.service_error",
+			zap.Uint("user_id", userID.(uint)),
+			zap.Error(err),
+		)
+		response.Error(c, errc.ErrInternalServer, err.Error())
+		return
+	}
+
+	response.SuccessWithData(c, dto.NotificationUnreadResponse{
+		Response: response.Response{
+			StatusCode: errc.Success,
+			StatusMsg:  errc.GetMsg(errc.Success),
+		},
+		NotificationUnreadData: *data,
+	})
+}
+
+// MarkRead 标记通知已读
+// POST /douyin/notification/read/
+// 参数：notification_id（可选，为空时标记全部），type（可选，只标记该类型的通知）
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		global.Logger.Warn("handler.MarkNotificationsRead.missing_user_id")
+		response.ErrorWithCode(c, errc.ErrUnauthorized)
+		return
+	}
+
+	var req dto.NotificationReadRequest
+	if err := c.ShouldBind(&req); err != nil {
+		global.Logger.Warn("handler.MarkNotificationsRead.bind_error",
+			zap.Error(err),
+		)
+		response.Error(c, errc.ErrInvalidParams, err.Error())
+		return
+	}
+
+	updated, err := h.notificationService.MarkRead(c.Request.Context(), userID.(uint), &req)
+	if err != nil {
+		global.Logger.Error("handler.MarkNotificationsRead.service_error",
+			zap.Uint("user_id", userID.(uint)),
+			zap.Error(err),
+		)
+		response.Error(c, errc.ErrInternalServer, err.Error())
+		return
+	}
+
+	response.SuccessWithData(c, dto.NotificationReadResponse{
+		Response: response.Response{
+			StatusCode: errc.Success,
+			StatusMsg:  errc.GetMsg(errc.Success),
+		},
+		Updated: updated,
+	})
+}
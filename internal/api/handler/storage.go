@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/common/errc"
+	"github.com/wangn-tech/tiny-douyin/internal/common/response"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/storage"
+)
+
+// StorageHandler 对象访问处理器（HLS 播放、本地存储的文件访问和预签名上传）
+// 这些接口由播放器和上传客户端直接访问，出错时只返回 HTTP 状态码
+type StorageHandler struct {
+	store     storage.IObjectStore
+	urlSigner storage.IURLSigner
+}
+
+// NewStorageHandler 创建 StorageHandler 实例（通过依赖注入）
+func NewStorageHandler(store storage.IObjectStore, urlSigner storage.IURLSigner) *StorageHandler {
+	return &StorageHandler{
+		store:     store,
+		urlSigner: urlSigner,
+	}
+}
+
+// Stream HLS 播放：校验播放令牌后代理播放列表，分片重定向到限时下载地址
+// 播放列表中的相对地址解析后仍在令牌覆盖的目录下，因此不需要改写播放列表
+// GET /douyin/media/{token}/{key}
+func (h *StorageHandler) Stream(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if err := h.urlSigner.VerifyStream(c.Param("token"), key); err != nil {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	if path.Ext(key) != ".m3u8" {
+		signedURL, err := h.urlSigner.SignKey(ctx, key)
+		if err != nil {
+			global.Logger.Error("handler.Stream.sign_error",
+				zap.String("key", key),
+				zap.Error(err),
+			)
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		c.Redirect(http.StatusFound, signedURL)
+		return
+	}
+
+	playlist, err := h.store.Get(ctx, key, 0, -1)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		global.Logger.Error("handler.Stream.get_error",
+			zap.String("key", key),
+			zap.Error(err),
+		)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	defer playlist.Close()
+
+	c.Header("Cache-Control", "private, no-cache")
+	c.DataFromReader(http.StatusOK, -1, constant.HLSContentTypes[".m3u8"], playlist, nil)
+}
+
+// Get 本地存储的文件访问（开启签名地址时校验签名）
+// GET {storage.local_url_prefix}/{key}
+func (h *StorageHandler) Get(c *gin.Context) {
+	verifier, ok := h.store.(storage.IURLVerifier)
+	if !ok {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	key := strings.TrimPrefix(c.Param("filepath"), "/")
+	if global.Config.SignedURL.Enabled {
+		if err := verifier.VerifyURL(key, c.Request.URL.Query()); err != nil {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+	}
+
+	// Stat 对目录返回 ErrObjectNotFound，避免列出目录内容
+	if _, err := h.store.Stat(c.Request.Context(), key); err != nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	p, err := verifier.Path(key)
+	if err != nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	c.File(p)
+}
+
+// Upload 接收预签名 POST 上传（仅本地存储，MinIO 由客户端直接上传）
+// POST {storage.local_url_prefix}/upload
+// 参数：预签名表单中的全部字段，file（文件，必须放在最后）
+func (h *StorageHandler) Upload(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	receiver, ok := h.store.(storage.IPostReceiver)
+	if !ok {
+		response.Error(c, errc.ErrInvalidParams, "当前存储不支持该上传方式")
+		return
+	}
+
+	value, exists := c.Get(constant.ContextKeyUploadFile)
+	file, ok := value.(*multipart.Part)
+	if !exists || !ok {
+		response.Error(c, errc.ErrInvalidParams, "请上传文件")
+		return
+	}
+
+	if err := receiver.ReceivePost(ctx, c.Request.PostForm, file); err != nil {
+		global.Logger.Warn("handler.StorageUpload.receive_error",
+			zap.Error(err),
+		)
+		if errors.Is(err, storage.ErrInvalidPolicy) || errors.Is(err, storage.ErrInvalidKey) {
+			response.Error(c, errc.ErrInvalidParams, err.Error())
+			return
+		}
+		response.Error(c, errc.ErrInternalServer, "保存文件失败")
+		return
+	}
+
+	response.Success(c)
+}
@@ -1,11 +1,14 @@
 package handler
 
 import (
-	"io"
+	"errors"
+	"mime/multipart"
+	"net/http"
 	"path/filepath"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"go.uber.org/zap"
 
 	"github.com/wangn-tech/tiny-douyin/internal/api/dto"
@@ -13,6 +16,7 @@ import (
 	"github.com/wangn-tech/tiny-douyin/internal/common/errc"
 	"github.com/wangn-tech/tiny-douyin/internal/common/response"
 	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/storage"
 	"github.com/wangn-tech/tiny-douyin/internal/pkg/upload"
 	"github.com/wangn-tech/tiny-douyin/internal/service"
 )
@@ -21,13 +25,22 @@ import (
 type VideoHandler struct {
 	videoService  service.IVideoService
 	uploadService upload.IUploadService
+	chunkService  upload.IChunkUploadService
+	directService upload.IDirectUploadService
 }
 
 // NewVideoHandler 创建 VideoHandler 实例（通过依赖注入）
-func NewVideoHandler(videoService service.IVideoService, uploadService upload.IUploadService) *VideoHandler {
+func NewVideoHandler(
+	videoService service.IVideoService,
+	uploadService upload.IUploadService,
+	chunkService upload.IChunkUploadService,
+	directService upload.IDirectUploadService,
+) *VideoHandler {
 	return &VideoHandler{
 		videoService:  videoService,
 		uploadService: uploadService,
+		chunkService:  chunkService,
+		directService: directService,
 	}
 }
 
@@ -35,8 +48,6 @@ func NewVideoHandler(videoService service.IVideoService, uploadService upload.IU
 // POST /douyin/publish/action/
 // 参数：token（必填），data（必填，视频文件），title（必填，视频标题）
 func (h *VideoHandler) PublishVideo(c *gin.Context) {
-	ctx := c.Request.Context()
-
 	// 从 JWT 中间件获取用户ID（中间件已处理 token 参数）
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -46,8 +57,9 @@ func (h *VideoHandler) PublishVideo(c *gin.Context) {
 	}
 
 	// 参数绑定（验证 token 和 title）
+	// 表单字段已由 StreamMultipart 中间件读取到 PostForm，这里不能再解析 multipart
 	var req dto.VideoPublishRequest
-	if err := c.ShouldBind(&req); err != nil {
+	if err := c.ShouldBindWith(&req, binding.FormPost); err != nil {
 		global.Logger.Warn("handler.PublishVideo.bind_error",
 			zap.Error(err),
 		)
@@ -55,100 +67,87 @@ func (h *VideoHandler) PublishVideo(c *gin.Context) {
 		return
 	}
 
-	// 获取上传的视频文件
-	file, err := c.FormFile("data")
-	if err != nil {
-		global.Logger.Warn("handler.PublishVideo.no_file",
-			zap.Error(err),
-		)
+	// 获取上传的视频文件（尚未读取的 multipart 文件字段）
+	value, exists := c.Get(constant.ContextKeyUploadFile)
+	file, ok := value.(*multipart.Part)
+	if !exists || !ok {
+		global.Logger.Warn("handler.PublishVideo.no_file")
 		response.Error(c, errc.ErrVideoFileInvalid, "请上传视频文件")
 		return
 	}
 
 	// 如果没有提供标题，使用文件名
 	if req.Title == "" {
-		req.Title = file.Filename
+		req.Title = file.FileName()
 	}
 
 	global.Logger.Info("handler.PublishVideo.request",
 		zap.Uint("user_id", userID.(uint)),
 		zap.String("title", req.Title),
-		zap.String("filename", file.Filename),
-		zap.Int64("size", file.Size),
+		zap.String("filename", file.FileName()),
 	)
 
-	// 读取文件内容
-	fileReader, err := file.Open()
+	// 流式保存到临时目录（限制大小、识别文件类型、计算校验和）
+	ext := filepath.Ext(file.FileName())
+	tempFile, err := h.uploadService.SaveTempStream(file, ext, constant.MaxVideoSize)
 	if err != nil {
-		global.Logger.Error("handler.PublishVideo.open_file_error",
+		global.Logger.Warn("handler.PublishVideo.save_temp_error",
 			zap.Error(err),
 		)
-		response.Error(c, errc.ErrVideoFileInvalid, "打开文件失败")
+		switch {
+		case errors.Is(err, upload.ErrFileTooLarge):
+			response.ErrorWithCode(c, errc.ErrVideoFileTooLarge)
+		case errors.Is(err, upload.ErrUnsupportedFileType):
+			response.ErrorWithCode(c, errc.ErrVideoFileType)
+		default:
+			response.Error(c, errc.ErrVideoUploadFailed, "保存临时文件失败")
+		}
 		return
 	}
-	defer fileReader.Close()
-
-	fileData, err := io.ReadAll(fileReader)
-	if err != nil {
-		global.Logger.Error("handler.PublishVideo.read_file_error",
-			zap.Error(err),
-		)
-		response.Error(c, errc.ErrVideoFileInvalid, "读取文件失败")
+	if _, ok := h.submitUpload(c, userID.(uint), &req, ext, tempFile); !ok {
 		return
 	}
 
-	// 保存到临时目录
-	ext := filepath.Ext(file.Filename)
-	tempFilePath, err := h.uploadService.SaveTempFile(fileData, ext)
-	if err != nil {
-		global.Logger.Error("handler.PublishVideo.save_temp_error",
-			zap.Error(err),
-		)
-		response.Error(c, errc.ErrVideoUploadFailed, "保存临时文件失败")
-		return
+	response.SuccessWithMsg(c, "视频发布成功，正在处理中")
+}
+
+// submitUpload 为已保存到临时目录的视频创建记录并发布上传任务，失败时写入错误响应
+func (h *VideoHandler) submitUpload(c *gin.Context, userID uint, req *dto.VideoPublishRequest, ext string, tempFile *upload.TempFile) (uint, bool) {
+	task := &upload.VideoUploadTask{
+		VideoPath:   tempFile.Path,
+		VideoName:   h.uploadService.GenerateObjectName(userID, ext),
+		ContentType: tempFile.ContentType,
 	}
+	return h.enqueueUpload(c, userID, req, task, tempFile.Checksum, tempFile.Size)
+}
 
-	// 生成 MinIO 对象名称
-	videoName := h.uploadService.GenerateObjectName(userID.(uint), ext)
-	coverName := h.uploadService.GenerateCoverObjectName(userID.(uint))
+// enqueueUpload 创建视频记录并提交上传任务，失败时清理临时文件并写入错误响应
+// task 需要填写视频来源（VideoPath 或 SourceObject）、VideoName 和 ContentType
+func (h *VideoHandler) enqueueUpload(c *gin.Context, userID uint, req *dto.VideoPublishRequest, task *upload.VideoUploadTask, checksum string, size int64) (uint, bool) {
+	ctx := c.Request.Context()
+	cleanup := func() {
+		if task.VideoPath != "" {
+			h.uploadService.CleanupTempFile(task.VideoPath)
+		}
+	}
 
-	// 先创建视频记录（使用临时 URL）
-	tempPlayURL := constant.VideoStatusUploading
-	tempCoverURL := constant.VideoStatusUploading
+	task.CoverName = h.uploadService.GenerateCoverObjectName(userID)
+	task.UserID = userID
+	task.Title = req.Title
+	task.Description = "" // VideoPublishRequest 没有 Description 字段
 
-	videoID, err := h.videoService.PublishVideo(ctx, &req, userID.(uint), tempPlayURL, tempCoverURL)
+	// 创建视频记录（uploading 状态，处理完成前不出现在视频流中），上传任务经发件箱投递到消息队列
+	videoID, err := h.videoService.PublishVideo(ctx, req, userID, checksum, size, task)
 	if err != nil {
 		global.Logger.Error("handler.PublishVideo.service_error",
 			zap.Error(err),
 		)
-		h.uploadService.CleanupTempFile(tempFilePath)
+		cleanup()
 		response.Error(c, errc.ErrVideoUploadFailed, err.Error())
-		return
+		return 0, false
 	}
 
-	// 发布上传任务到消息队列
-	task := &upload.VideoUploadTask{
-		VideoID:     videoID,
-		VideoPath:   tempFilePath,
-		VideoName:   videoName,
-		CoverName:   coverName,
-		ContentType: file.Header.Get("Content-Type"),
-		UserID:      userID.(uint),
-		Title:       req.Title,
-		Description: "", // VideoPublishRequest 没有 Description 字段
-	}
-
-	err = h.uploadService.PublishUploadTask(ctx, task)
-	if err != nil {
-		global.Logger.Error("handler.PublishVideo.publish_task_error",
-			zap.Error(err),
-		)
-		// 任务发布失败，但视频记录已创建，需要回滚或标记失败
-		response.Error(c, errc.ErrVideoProcessFailed, "发布上传任务失败")
-		return
-	}
-
-	response.SuccessWithMsg(c, "视频发布成功，正在处理中")
+	return videoID, true
 }
 
 // GetVideoFeed 获取视频流
@@ -257,3 +256,388 @@ func (h *VideoHandler) GetVideoList(c *gin.Context) {
 
 	response.SuccessWithData(c, resp)
 }
+
+// GetVideoStatus 查询视频处理状态
+// GET /douyin/publish/status/
+// 必需参数：video_id
+func (h *VideoHandler) GetVideoStatus(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 参数绑定
+	var req dto.VideoStatusRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		global.Logger.Warn("handler.GetVideoStatus.bind_error",
+			zap.Error(err),
+		)
+		response.Error(c, errc.ErrInvalidParams, "参数错误: "+err.Error())
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	currentUserID, _ := userID.(uint)
+
+	// 调用 Service 层
+	status, err := h.videoService.GetVideoStatus(ctx, req.VideoID, currentUserID)
+	if err != nil {
+		if err.Error() == errc.ErrMsg[errc.ErrVideoNotFound] {
+			response.ErrorWithCode(c, errc.ErrVideoNotFound)
+			return
+		}
+		global.Logger.Error("handler.GetVideoStatus.service_error",
+			zap.Uint("video_id", req.VideoID),
+			zap.Error(err),
+		)
+		response.Error(c, errc.ErrInternalServer, "查询视频状态失败")
+		return
+	}
+
+	// 在 Handler 层封装响应
+	resp := dto.VideoStatusResponse{
+		Response: response.Response{
+			StatusCode: errc.Success,
+			StatusMsg:  errc.GetMsg(errc.Success),
+		},
+		VideoStatus: *status,
+	}
+
+	response.SuccessWithData(c, resp)
+}
+
+// DeleteVideo 删除视频（只有作者可以删除）
+func (h *VideoHandler) DeleteVideo(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.VideoDeleteRequest
+	if err := c.ShouldBind(&req); err != nil {
+		global.Logger.Warn("handler.DeleteVideo.bind_error",
+			zap.Error(err),
+		)
+		response.Error(c, errc.ErrInvalidParams, "参数错误: "+err.Error())
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	currentUserID, _ := userID.(uint)
+
+	// 调用 Service 层
+	if err := h.videoService.DeleteVideo(ctx, req.VideoID, currentUserID); err != nil {
+		switch err.Error() {
+		case errc.ErrMsg[errc.ErrVideoNotFound]:
+			response.ErrorWithCode(c, errc.ErrVideoNotFound)
+		case errc.ErrMsg[errc.ErrVideoPermission]:
+			response.ErrorWithCode(c, errc.ErrVideoPermission)
+		default:
+			global.Logger.Error("handler.DeleteVideo.service_error",
+				zap.Uint("video_id", req.VideoID),
+				zap.Error(err),
+			)
+			response.Error(c, errc.ErrInternalServer, "删除视频失败")
+		}
+		return
+	}
+
+	response.SuccessWithData(c, dto.VideoDeleteResponse{
+		Response: response.Response{
+			StatusCode: errc.Success,
+			StatusMsg:  errc.GetMsg(errc.Success),
+		},
+	})
+}
+
+// RedirectVideoURL 重定向到视频的限时访问地址（供缓存了视频地址的客户端使用）
+// GET /douyin/video/url/?video_id=1&type=play
+// 参数：video_id（必填），type（可选：play（默认）, hls, cover）
+func (h *VideoHandler) RedirectVideoURL(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.VideoURLRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		global.Logger.Warn("handler.RedirectVideoURL.bind_error",
+			zap.Error(err),
+		)
+		response.Error(c, errc.ErrInvalidParams, "参数错误: "+err.Error())
+		return
+	}
+
+	signedURL, err := h.videoService.GetVideoURL(ctx, req.VideoID, req.Type)
+	if err != nil {
+		if err.Error() == errc.ErrMsg[errc.ErrVideoNotFound] {
+			response.ErrorWithCode(c, errc.ErrVideoNotFound)
+			return
+		}
+		global.Logger.Error("handler.RedirectVideoURL.service_error",
+			zap.Uint("video_id", req.VideoID),
+			zap.Error(err),
+		)
+		response.Error(c, errc.ErrInternalServer, "获取视频地址失败")
+		return
+	}
+
+	c.Redirect(http.StatusFound, signedURL)
+}
+
+// InitChunkUpload 创建分片上传会话
+// POST /douyin/publish/upload/init/
+// 参数：token（必填），file_name（必填），size（必填），title（可选），chunk_size（可选）
+func (h *VideoHandler) InitChunkUpload(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetUint("user_id")
+
+	var req dto.ChunkUploadInitRequest
+	if err := c.ShouldBind(&req); err != nil {
+		response.Error(c, errc.ErrInvalidParams, "参数错误: "+err.Error())
+		return
+	}
+	if req.Title == "" {
+		req.Title = req.FileName
+	}
+
+	session, err := h.chunkService.Init(ctx, userID, req.Title, req.FileName, req.Size, req.ChunkSize)
+	if err != nil {
+		respondUploadError(c, "handler.InitChunkUpload", err)
+		return
+	}
+
+	global.Logger.Info("handler.InitChunkUpload.success",
+		zap.Uint("user_id", userID),
+		zap.String("upload_id", session.UploadID),
+		zap.Int64("size", session.Size),
+		zap.Int("total_chunks", session.TotalChunks),
+	)
+
+	response.SuccessWithData(c, dto.ChunkUploadInitResponse{
+		Response: response.Response{
+			StatusCode: errc.Success,
+			StatusMsg:  errc.GetMsg(errc.Success),
+		},
+		ChunkSession: *session,
+	})
+}
+
+// UploadChunk 上传一个分片
+// PUT /douyin/publish/upload/chunk/?upload_id=xxx&index=0
+// 请求体为分片的原始内容（application/octet-stream），重复上传同一分片会覆盖
+func (h *VideoHandler) UploadChunk(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetUint("user_id")
+
+	var req dto.ChunkUploadRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, errc.ErrInvalidParams, "参数错误: "+err.Error())
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, constant.ChunkUploadMaxChunkSize+1)
+	if err := h.chunkService.UploadChunk(ctx, userID, req.UploadID, *req.Index, body); err != nil {
+		respondUploadError(c, "handler.UploadChunk", err)
+		return
+	}
+
+	response.Success(c)
+}
+
+// GetChunkUploadStatus 查询分片上传进度（断点续传时用于确定需要重传的分片）
+// GET /douyin/publish/upload/status/?upload_id=xxx
+func (h *VideoHandler) GetChunkUploadStatus(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetUint("user_id")
+
+	var req dto.ChunkUploadIDRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, errc.ErrInvalidParams, "参数错误: "+err.Error())
+		return
+	}
+
+	status, err := h.chunkService.Status(ctx, userID, req.UploadID)
+	if err != nil {
+		respondUploadError(c, "handler.GetChunkUploadStatus", err)
+		return
+	}
+
+	response.SuccessWithData(c, dto.ChunkUploadStatusResponse{
+		Response: response.Response{
+			StatusCode: errc.Success,
+			StatusMsg:  errc.GetMsg(errc.Success),
+		},
+		ChunkStatus: *status,
+	})
+}
+
+// CompleteChunkUpload 合并分片并发布视频（与 /publish/action/ 相同，进入异步处理流程）
+// POST /douyin/publish/upload/complete/
+// 参数：token（必填），upload_id（必填）
+func (h *VideoHandler) CompleteChunkUpload(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetUint("user_id")
+
+	var req dto.ChunkUploadIDRequest
+	if err := c.ShouldBind(&req); err != nil {
+		response.Error(c, errc.ErrInvalidParams, "参数错误: "+err.Error())
+		return
+	}
+
+	session, tempFile, err := h.chunkService.Complete(ctx, userID, req.UploadID)
+	if err != nil {
+		respondUploadError(c, "handler.CompleteChunkUpload", err)
+		return
+	}
+
+	publishReq := &dto.VideoPublishRequest{Title: session.Title}
+	videoID, ok := h.submitUpload(c, userID, publishReq, filepath.Ext(session.FileName), tempFile)
+	if !ok {
+		// 视频未创建，保留分片，客户端可重新合并
+		h.chunkService.Release(ctx, req.UploadID)
+		return
+	}
+	h.chunkService.Finish(ctx, req.UploadID)
+
+	global.Logger.Info("handler.CompleteChunkUpload.success",
+		zap.Uint("user_id", userID),
+		zap.String("upload_id", req.UploadID),
+		zap.Uint("video_id", videoID),
+	)
+
+	response.SuccessWithData(c, dto.ChunkUploadCompleteResponse{
+		Response: response.Response{
+			StatusCode: errc.Success,
+			StatusMsg:  "视频发布成功，正在处理中",
+		},
+		VideoID: videoID,
+	})
+}
+
+// AbortChunkUpload 取消分片上传
+// POST /douyin/publish/upload/abort/
+// 参数：token（必填），upload_id（必填）
+func (h *VideoHandler) AbortChunkUpload(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetUint("user_id")
+
+	var req dto.ChunkUploadIDRequest
+	if err := c.ShouldBind(&req); err != nil {
+		response.Error(c, errc.ErrInvalidParams, "参数错误: "+err.Error())
+		return
+	}
+
+	if err := h.chunkService.Abort(ctx, userID, req.UploadID); err != nil {
+		respondUploadError(c, "handler.AbortChunkUpload", err)
+		return
+	}
+
+	response.Success(c)
+}
+
+// PresignUpload 获取直传对象存储的预签名上传表单（视频内容不经过 API 服务）
+// POST /douyin/publish/presign/
+// 参数：token（必填），file_name（必填），size（必填），content_type（必填），title（可选）
+func (h *VideoHandler) PresignUpload(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetUint("user_id")
+
+	var req dto.PresignUploadRequest
+	if err := c.ShouldBind(&req); err != nil {
+		response.Error(c, errc.ErrInvalidParams, "参数错误: "+err.Error())
+		return
+	}
+	if req.Title == "" {
+		req.Title = req.FileName
+	}
+
+	session, err := h.directService.Presign(ctx, userID, req.Title, req.FileName, req.ContentType, req.Size)
+	if err != nil {
+		respondUploadError(c, "handler.PresignUpload", err)
+		return
+	}
+
+	global.Logger.Info("handler.PresignUpload.success",
+		zap.Uint("user_id", userID),
+		zap.String("upload_id", session.UploadID),
+		zap.String("object_name", session.ObjectName),
+		zap.Int64("size", session.Size),
+	)
+
+	response.SuccessWithData(c, dto.PresignUploadResponse{
+		Response: response.Response{
+			StatusCode: errc.Success,
+			StatusMsg:  errc.GetMsg(errc.Success),
+		},
+		DirectUpload: *session,
+	})
+}
+
+// ConfirmUpload 确认直传完成：校验对象后创建视频并进入异步处理流程
+// POST /douyin/publish/presign/confirm/
+// 参数：token（必填），upload_id（必填）
+func (h *VideoHandler) ConfirmUpload(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetUint("user_id")
+
+	var req dto.ChunkUploadIDRequest
+	if err := c.ShouldBind(&req); err != nil {
+		response.Error(c, errc.ErrInvalidParams, "参数错误: "+err.Error())
+		return
+	}
+
+	session, err := h.directService.Confirm(ctx, userID, req.UploadID)
+	if err != nil {
+		respondUploadError(c, "handler.ConfirmUpload", err)
+		return
+	}
+
+	task := &upload.VideoUploadTask{
+		VideoName:    session.ObjectName,
+		ContentType:  session.ContentType,
+		SourceObject: session.ObjectName,
+	}
+	publishReq := &dto.VideoPublishRequest{Title: session.Title}
+	// 直传的对象不经过 API 服务，校验和由 worker 下载对象后计算
+	videoID, ok := h.enqueueUpload(c, userID, publishReq, task, "", session.Size)
+	if !ok {
+		// 视频未创建，对象仍归会话所有，客户端可重新确认
+		h.directService.Release(ctx, req.UploadID)
+		return
+	}
+	h.directService.Finish(ctx, req.UploadID)
+
+	global.Logger.Info("handler.ConfirmUpload.success",
+		zap.Uint("user_id", userID),
+		zap.String("upload_id", req.UploadID),
+		zap.Uint("video_id", videoID),
+	)
+
+	response.SuccessWithData(c, dto.ChunkUploadCompleteResponse{
+		Response: response.Response{
+			StatusCode: errc.Success,
+			StatusMsg:  "视频发布成功，正在处理中",
+		},
+		VideoID: videoID,
+	})
+}
+
+// respondUploadError 将分片上传和直传错误转换为错误码
+func respondUploadError(c *gin.Context, op string, err error) {
+	switch {
+	case errors.Is(err, upload.ErrUploadSessionNotFound):
+		response.ErrorWithCode(c, errc.ErrUploadNotFound)
+	case errors.Is(err, upload.ErrChunkInvalid):
+		response.Error(c, errc.ErrUploadChunkInvalid, err.Error())
+	case errors.Is(err, upload.ErrUploadIncomplete):
+		response.Error(c, errc.ErrUploadIncomplete, err.Error())
+	case errors.Is(err, upload.ErrUploadCompleting):
+		response.ErrorWithCode(c, errc.ErrUploadCompleting)
+	case errors.Is(err, storage.ErrObjectNotFound):
+		response.ErrorWithCode(c, errc.ErrUploadNotUploaded)
+	case errors.Is(err, upload.ErrFileTooLarge):
+		response.ErrorWithCode(c, errc.ErrVideoFileTooLarge)
+	case errors.Is(err, upload.ErrUnsupportedFileType):
+		response.ErrorWithCode(c, errc.ErrVideoFileType)
+	default:
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			response.Error(c, errc.ErrUploadChunkInvalid, "分片过大")
+			return
+		}
+		global.Logger.Error(op+".service_error", zap.Error(err))
+		response.Error(c, errc.ErrVideoUploadFailed, "分片上传失败")
+	}
+}
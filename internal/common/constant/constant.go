@@ -1,5 +1,7 @@
 package constant
 
+import "time"
+
 // Redis 键前缀
 const (
 	// RedisKeyUserPrefix 用户信息缓存键前缀
@@ -8,6 +10,154 @@ const (
 	RedisKeyVideoPrefix = "video:"
 	// RedisKeyUserVideosPrefix 用户视频列表缓存键前缀
 	RedisKeyUserVideosPrefix = "user:videos:"
+	// RedisKeyCounterVideoDelta 视频计数增量（Hash，field: {video_id}:{column}）
+	RedisKeyCounterVideoDelta = "counter:delta:video"
+	// RedisKeyCounterVideoFlushing 正在回写的视频计数增量
+	RedisKeyCounterVideoFlushing = "counter:flushing:video"
+	// RedisKeyCounterUserDelta 用户计数增量（Hash，field: {user_id}:{column}）
+	RedisKeyCounterUserDelta = "counter:delta:user"
+	// RedisKeyCounterUserFlushing 正在回写的用户计数增量
+	RedisKeyCounterUserFlushing = "counter:flushing:user"
+	// RedisKeyCounterLock 计数回写与对账互斥锁
+	RedisKeyCounterLock = "counter:lock"
+	// RedisKeyCounterAppliedPrefix 计数增量记录已写入 Redis 的标记（String，key: counter:applied:{delta_id}）
+	RedisKeyCounterAppliedPrefix = "counter:applied:"
+	// RedisKeyUploadSessionPrefix 分片上传会话键前缀（Hash）
+	RedisKeyUploadSessionPrefix = "upload:session:"
+	// RedisKeyUploadChunksSuffix 分片上传已接收分片位图键后缀
+	RedisKeyUploadChunksSuffix = ":chunks"
+	// RedisKeyDirectUploadPrefix 直传会话键前缀（Hash）
+	RedisKeyDirectUploadPrefix = "upload:direct:"
+	// RedisKeyOutboxConsumedPrefix 已处理的发件箱消息键前缀（消费方去重）
+	RedisKeyOutboxConsumedPrefix = "outbox:consumed:"
+	// RedisChannelChatPush 实时聊天推送频道（Pub/Sub，所有实例订阅后投递给本地连接）
+	RedisChannelChatPush = "chat:push"
+)
+
+// 缓存相关常量
+const (
+	// CacheUserTTL 用户信息缓存过期时间
+	CacheUserTTL = 30 * time.Minute
+	// CacheVideoTTL 视频信息缓存过期时间
+	CacheVideoTTL = 30 * time.Minute
+	// CacheUserVideosTTL 用户视频列表缓存过期时间
+	CacheUserVideosTTL = 10 * time.Minute
+	// CacheNullTTL 空值缓存过期时间（防止缓存穿透）
+	CacheNullTTL = 1 * time.Minute
+	// CacheTTLJitterRatio 过期时间随机抖动比例（防止缓存雪崩）
+	CacheTTLJitterRatio = 0.1
+	// CacheLoadTimeout 缓存未命中时共享回源查询的超时时间
+	CacheLoadTimeout = 5 * time.Second
+)
+
+// 计数器相关常量
+const (
+	// CounterDefaultFlushInterval 计数增量默认回写间隔
+	CounterDefaultFlushInterval = 5 * time.Second
+	// CounterFlushBatchSize 单个事务回写的对象数量
+	CounterFlushBatchSize = 200
+	// CounterFlushLogRetention 回写记录保留时间
+	CounterFlushLogRetention = 24 * time.Hour
+	// CounterDeltaDrainBatchSize 每批写入 Redis 的计数增量记录数量
+	CounterDeltaDrainBatchSize = 500
+	// CounterDeltaMarkerTTL 计数增量记录已写入 Redis 标记的过期时间（须远大于记录从写入 Redis 到删除的间隔）
+	CounterDeltaMarkerTTL = 24 * time.Hour
+	// CounterLockTTL 计数回写/对账锁的过期时间（防止持有者崩溃后死锁）
+	CounterLockTTL = 30 * time.Second
+	// CounterReconcileBatchSize 计数对账每批处理的对象数量
+	CounterReconcileBatchSize = 500
+	// CounterReconcileMaxReport 对账报告中保留的差异明细上限
+	CounterReconcileMaxReport = 100
+	// CounterReconcileGracePeriod 对账首轮发现差异后等待复查的最短时间（实际至少为两个回写周期）
+	CounterReconcileGracePeriod = 10 * time.Second
+)
+
+// 事务性发件箱（outbox）常量
+const (
+	// OutboxTopicVideoUpload 视频上传任务
+	OutboxTopicVideoUpload = "video.upload"
+	// OutboxTopicDomainEvent 领域事件（由 relay 投递到事件总线）
+	OutboxTopicDomainEvent = "domain.event"
+	// OutboxStatusPending 待投递
+	OutboxStatusPending = "pending"
+	// OutboxStatusInflight 已被 relay 认领、正在投递（租约到期前其他 relay 不会重复认领）
+	OutboxStatusInflight = "inflight"
+	// OutboxStatusSent 已投递
+	OutboxStatusSent = "sent"
+	// OutboxRelayInterval relay 轮询间隔（同一进程内写入的消息在事务提交后立即投递）
+	OutboxRelayInterval = time.Second
+	// OutboxRelayBatchSize relay 每批投递的消息数
+	OutboxRelayBatchSize = 100
+	// OutboxClaimLease relay 认领一批消息的租约时长（relay 崩溃时租约到期后由其他 relay 重新认领）
+	OutboxClaimLease = time.Minute
+	// OutboxRetryBaseDelay 投递失败后首次重试延迟（之后每次翻倍）
+	OutboxRetryBaseDelay = time.Second
+	// OutboxRetryMaxDelay 投递失败后的最大重试延迟
+	OutboxRetryMaxDelay = 5 * time.Minute
+	// OutboxSentRetention 已投递消息的保留时间
+	OutboxSentRetention = 7 * 24 * time.Hour
+	// OutboxCleanupInterval 清理已投递消息的间隔
+	OutboxCleanupInterval = time.Hour
+	// OutboxConsumedTTL 消费方去重记录的保留时间
+	OutboxConsumedTTL = 7 * 24 * time.Hour
+	// OutboxLastErrorMaxLength 投递失败原因的最大长度
+	OutboxLastErrorMaxLength = 512
+)
+
+// 站内通知常量
+const (
+	// NotificationTypeLike 点赞通知（同一视频未读期间聚合）
+	NotificationTypeLike = "like"
+	// NotificationTypeComment 评论通知
+	NotificationTypeComment = "comment"
+	// NotificationTypeReply 回复通知（通知被回复的用户）
+	NotificationTypeReply = "reply"
+	// NotificationTypeFollow 关注通知（未读期间聚合）
+	NotificationTypeFollow = "follow"
+	// NotificationEventGroup 通知中心订阅领域事件的订阅组
+	NotificationEventGroup = "notification"
+	// NotificationDedupPrefix 通知中心事件去重键前缀（与事件 ID 拼接）
+	NotificationDedupPrefix = "notification:"
+	// NotificationMaxActors 聚合通知保留的最近触发用户数
+	NotificationMaxActors = 3
+	// NotificationDefaultPageSize 通知列表默认每页数量
+	NotificationDefaultPageSize = 20
+)
+
+// 领域事件总线常量
+const (
+	// EventBusTypeRabbitMQ 使用 RabbitMQ topic 交换机
+	EventBusTypeRabbitMQ = "rabbitmq"
+	// EventBusTypeMemory 使用进程内事件总线（本地开发和测试，事件不持久化）
+	EventBusTypeMemory = "memory"
+	// EventDefaultExchange 默认事件交换机
+	EventDefaultExchange = "douyin.events"
+	// EventQueueFormat 订阅组队列名格式: {exchange}.{group}
+	EventQueueFormat = "%s.%s"
+	// EventPrefetch 订阅组消费 channel 的 prefetch 数量
+	EventPrefetch = 32
+	// EventMemoryCapacity 进程内事件总线每个订阅组的缓冲区大小
+	EventMemoryCapacity = 1024
+	// EventHandlerMaxAttempts 事件处理失败的最大尝试次数，超过后丢弃事件
+	EventHandlerMaxAttempts = 3
+	// EventHandlerRetryDelay 事件处理失败后的重试延迟（第 n 次重试等待 n 倍）
+	EventHandlerRetryDelay = time.Second
+)
+
+// 任务队列常量
+const (
+	// QueueTypeRabbitMQ 使用 RabbitMQ
+	QueueTypeRabbitMQ = "rabbitmq"
+	// QueueTypeMemory 使用进程内队列（本地开发和测试，任务不持久化）
+	QueueTypeMemory = "memory"
+	// QueueMemoryCapacity 进程内队列的容量，队列满时发布任务阻塞
+	QueueMemoryCapacity = 1024
+	// QueueReconnectMinDelay RabbitMQ 断线重连的初始间隔
+	QueueReconnectMinDelay = time.Second
+	// QueueReconnectMaxDelay RabbitMQ 断线重连的最大间隔
+	QueueReconnectMaxDelay = 30 * time.Second
+	// QueueDeadLetterReasonRejected 进程内队列死信原因：任务被拒绝（与 RabbitMQ x-death reason 一致）
+	QueueDeadLetterReasonRejected = "rejected"
 )
 
 // RabbitMQ 常量
@@ -16,6 +166,40 @@ const (
 	RabbitMQRoutingKeyVideo = "video"
 	// RabbitMQConsumerTag 消费者标签
 	RabbitMQConsumerTag = "tiny-douyin-video-worker"
+	// RabbitMQHeaderRetryCount 消息头：已重试次数
+	RabbitMQHeaderRetryCount = "x-retry-count"
+	// RabbitMQHeaderLastError 消息头：最近一次失败原因
+	RabbitMQHeaderLastError = "x-last-error"
+	// RabbitMQRetryQueueFormat 延迟重试队列名格式: {queue}.retry.{attempt}
+	RabbitMQRetryQueueFormat = "%s.retry.%d"
+	// RabbitMQDeadLetterExchangeSuffix 死信交换机后缀: {exchange}.dlx
+	RabbitMQDeadLetterExchangeSuffix = ".dlx"
+	// RabbitMQDeadLetterQueueSuffix 死信队列后缀: {queue}.dlq
+	RabbitMQDeadLetterQueueSuffix = ".dlq"
+	// RabbitMQDefaultMaxRetries 默认最大重试次数
+	RabbitMQDefaultMaxRetries = 5
+	// RabbitMQDefaultRetryBaseDelay 默认首次重试延迟（之后每次翻倍）
+	RabbitMQDefaultRetryBaseDelay = 5 * time.Second
+	// RabbitMQLastErrorMaxLength 消息头中失败原因的最大长度
+	RabbitMQLastErrorMaxLength = 512
+	// WorkerDefaultConcurrency 上传 worker 默认并发数
+	WorkerDefaultConcurrency = 2
+	// WorkerAbortGracePeriod 停机超时中断任务后等待任务退出的宽限期
+	WorkerAbortGracePeriod = 5 * time.Second
+)
+
+// 服务相关常量
+const (
+	// ServerDefaultShutdownTimeout 默认优雅停机超时
+	ServerDefaultShutdownTimeout = 30 * time.Second
+)
+
+// 管理接口常量
+const (
+	// AdminTokenHeader 管理接口鉴权请求头
+	AdminTokenHeader = "X-Admin-Token"
+	// AdminDeadLetterDefaultLimit 死信查看/重放默认数量
+	AdminDeadLetterDefaultLimit = 20
 )
 
 // MinIO 常量
@@ -24,6 +208,10 @@ const (
 	MinIOVideoPathFormat = "videos/%d/%s/%s"
 	// MinioCoverPathFormat 封面对象路径格式: covers/{user_id}/{date}/{uuid}.jpg
 	MinioCoverPathFormat = "covers/%d/%s/%s"
+	// MinIOMessageImagePathFormat 私信图片对象路径格式: messages/{user_id}/{date}/{uuid}{ext}
+	MinIOMessageImagePathFormat = "messages/%d/%s/%s"
+	// MinIOHLSPathFormat HLS 文件前缀格式: videos/{user_id}/{date}/{uuid}/hls
+	MinIOHLSPathFormat = "%s/hls"
 	// MinIODateFormat MinIO 存储路径中的日期格式
 	MinIODateFormat = "2006-01-02"
 	// MinioCoverExtension 封面文件扩展名
@@ -36,16 +224,117 @@ const (
 	TempUploadDir = "./tmp/uploads"
 	// MaxVideoSize 最大视频文件大小 (100MB)
 	MaxVideoSize = 100 * 1024 * 1024
+	// MaxUploadFormSize 上传请求中文件字段之前的普通字段总大小上限 (1MB)
+	MaxUploadFormSize = 1 * 1024 * 1024
+	// PublishFileField 视频发布请求中的文件字段名
+	PublishFileField = "data"
+	// ContextKeyUploadFile 流式读取的上传文件（*multipart.Part）在 gin.Context 中的键
+	ContextKeyUploadFile = "upload_file"
 )
 
-// 视频相关常量
+// 分片上传常量
 const (
-	// VideoStatusUploading 视频上传中
+	// ChunkUploadDirName 分片文件目录（位于 TempUploadDir 下）
+	ChunkUploadDirName = "chunks"
+	// ChunkUploadDefaultChunkSize 默认分片大小 (5MB)
+	ChunkUploadDefaultChunkSize = 5 * 1024 * 1024
+	// ChunkUploadMinChunkSize 最小分片大小 (256KB)
+	ChunkUploadMinChunkSize = 256 * 1024
+	// ChunkUploadMaxChunkSize 最大分片大小 (16MB)
+	ChunkUploadMaxChunkSize = 16 * 1024 * 1024
+	// ChunkUploadSessionTTL 会话有效期（每次上传分片后重新计算）
+	ChunkUploadSessionTTL = 24 * time.Hour
+	// ChunkUploadCleanupInterval 过期分片清理间隔
+	ChunkUploadCleanupInterval = 10 * time.Minute
+)
+
+// 对象存储常量
+const (
+	// StorageTypeMinIO 使用 MinIO 存储
+	StorageTypeMinIO = "minio"
+	// StorageTypeLocal 使用本地磁盘存储
+	StorageTypeLocal = "local"
+	// StorageLocalDefaultDir 本地存储默认目录
+	StorageLocalDefaultDir = "./data/storage"
+	// StorageLocalDefaultURLPrefix 本地存储默认访问地址前缀
+	StorageLocalDefaultURLPrefix = "http://localhost:8080/storage"
+	// StorageLocalDefaultRoute 本地存储默认静态文件路由
+	StorageLocalDefaultRoute = "/storage"
+	// StorageLocalUploadPath 本地存储预签名上传接口（相对静态文件路由）
+	StorageLocalUploadPath = "/upload"
+	// StorageUploadFileField 预签名上传表单中的文件字段名
+	StorageUploadFileField = "file"
+	// StorageUploadSecretPurpose 未配置 local_secret 时由 jwt.secret 派生签名密钥的用途标识
+	StorageUploadSecretPurpose = "storage-upload"
+)
+
+// 签名访问地址相关常量
+const (
+	// SignedURLDefaultTTL 签名地址默认有效期
+	SignedURLDefaultTTL = time.Hour
+	// SignedURLDefaultBaseURL 本服务默认对外地址
+	SignedURLDefaultBaseURL = "http://localhost:8080"
+	// SignedURLSecretPurpose 未配置 signed_url.secret 时由 jwt.secret 派生签名密钥的用途标识
+	SignedURLSecretPurpose = "url-signing"
+	// SignedURLStreamRoute HLS 播放路由: {route}/{token}/{key}
+	SignedURLStreamRoute = "/douyin/media"
+	// VideoURLTypePlay 原视频地址
+	VideoURLTypePlay = "play"
+	// VideoURLTypeHLS HLS 主播放列表地址
+	VideoURLTypeHLS = "hls"
+	// VideoURLTypeCover 封面地址
+	VideoURLTypeCover = "cover"
+)
+
+// 直传相关常量
+const (
+	// DirectUploadPresignExpiry 预签名上传表单有效期
+	DirectUploadPresignExpiry = 15 * time.Minute
+	// DirectUploadSessionTTL 直传会话有效期（需在此之前确认上传）
+	DirectUploadSessionTTL = 1 * time.Hour
+)
+
+// 视频处理常量
+const (
+	// MediaProcessorFFmpeg 使用 ffmpeg/ffprobe 处理视频
+	MediaProcessorFFmpeg = "ffmpeg"
+	// MediaProcessorFake 使用纯 Go 的模拟处理器（不依赖 ffmpeg）
+	MediaProcessorFake = "fake"
+	// MediaProcessTimeout 单个视频处理（探测、转码、截图）的超时时间
+	MediaProcessTimeout = 10 * time.Minute
+	// UploadTaskSettleTimeout 处理超时后调度重试或标记失败的超时时间
+	UploadTaskSettleTimeout = 10 * time.Second
+	// MediaDefaultCoverOffset 默认截取封面的时刻
+	MediaDefaultCoverOffset = 1 * time.Second
+	// MediaTranscodeExtension 转码输出的文件扩展名
+	MediaTranscodeExtension = ".mp4"
+	// MediaTranscodeContentType 转码输出的内容类型
+	MediaTranscodeContentType = "video/mp4"
+	// MediaHLSDirSuffix HLS 临时输出目录后缀
+	MediaHLSDirSuffix = ".hls"
+	// MediaCoverContentType 封面内容类型
+	MediaCoverContentType = "image/jpeg"
+)
+
+// HLSContentTypes HLS 文件扩展名对应的内容类型
+var HLSContentTypes = map[string]string{
+	".m3u8": "application/vnd.apple.mpegurl",
+	".ts":   "video/mp2t",
+	".m4s":  "video/iso.segment",
+}
+
+// 视频状态（状态流转：uploading → processing → ready / failed，任意状态 → deleted）
+const (
+	// VideoStatusUploading 视频记录已创建，等待 worker 处理
 	VideoStatusUploading = "uploading"
-	// VideoStatusReady 视频已就绪
+	// VideoStatusProcessing worker 正在处理（转码、截图、上传）
+	VideoStatusProcessing = "processing"
+	// VideoStatusReady 视频已就绪，对所有用户可见
 	VideoStatusReady = "ready"
-	// VideoStatusFailed 视频上传失败
+	// VideoStatusFailed 视频处理失败
 	VideoStatusFailed = "failed"
+	// VideoStatusDeleted 视频已删除
+	VideoStatusDeleted = "deleted"
 )
 
 // 日志相关常量
@@ -76,6 +365,8 @@ const (
 const (
 	// CommentMaxLength 评论内容最大长度
 	CommentMaxLength = 255
+	// CommentReplyDefaultPageSize 回复列表默认每页数量
+	CommentReplyDefaultPageSize = 20
 )
 
 // 关注操作类型
@@ -98,4 +389,65 @@ const (
 	MessageMaxLength = 255
 	// MessagePageSize 消息分页大小
 	MessagePageSize = 50
+	// ConversationDefaultPageSize 会话列表默认每页数量
+	ConversationDefaultPageSize = 20
+	// MessageImageFileField 图片消息请求中的文件字段名
+	MessageImageFileField = "data"
+	// MaxMessageImageSize 图片消息最大文件大小 (10MB)
+	MaxMessageImageSize = 10 * 1024 * 1024
+	// MessageDefaultRecallWindow 消息发送后默认可撤回的时限
+	MessageDefaultRecallWindow = 2 * time.Minute
+	// MessagePurgeInterval 过期消息清理间隔
+	MessagePurgeInterval = time.Hour
+	// MessagePurgeBatchSize 过期消息每批清理的数量
+	MessagePurgeBatchSize = 500
+	// MessageSyncSettleWindow 增量同步的安全窗口：消息 ID 在插入时分配、提交可能更晚，
+	// 发送时间在该窗口内的消息可能还有更小 ID 的消息未提交，同步游标不越过这些消息（需大于发送事务的最长耗时，含锁等待）
+	MessageSyncSettleWindow = time.Minute
+)
+
+// 消息类型
+const (
+	// MessageTypeText 文本消息
+	MessageTypeText = "text"
+	// MessageTypeImage 图片消息（content 为可选的说明文字）
+	MessageTypeImage = "image"
+	// MessageTypeVideo 分享视频（content 为可选的附言）
+	MessageTypeVideo = "video"
+	// MessageTypeUser 分享用户名片（content 为可选的附言）
+	MessageTypeUser = "user"
+	// MessageTypeReply 回复某条消息（content 为回复内容）
+	MessageTypeReply = "reply"
+)
+
+// 实时聊天（WebSocket）相关常量
+const (
+	// ChatEventGroup 实时推送订阅领域事件使用的订阅组
+	ChatEventGroup = "chat_push"
+	// ChatWriteWait 单次写入 WebSocket 的超时时间
+	ChatWriteWait = 10 * time.Second
+	// ChatPongWait 等待客户端心跳（pong 或任意消息）的超时时间，超时后断开连接
+	ChatPongWait = 60 * time.Second
+	// ChatPingInterval 服务端发送 ping 的间隔，需小于 ChatPongWait
+	ChatPingInterval = 25 * time.Second
+	// ChatMaxFrameSize 客户端上行帧的最大字节数
+	ChatMaxFrameSize = 4 * 1024
+	// ChatSendBufferSize 每个连接的待发送帧缓冲大小，缓冲区满时视为慢连接并断开
+	ChatSendBufferSize = 64
+)
+
+// 实时聊天帧类型
+const (
+	// ChatFrameConnected 连接建立（下行）
+	ChatFrameConnected = "connected"
+	// ChatFrameMessage 新消息（下行）
+	ChatFrameMessage = "message"
+	// ChatFramePing 应用层心跳（上行，浏览器无法主动发送 ping 控制帧）
+	ChatFramePing = "ping"
+	// ChatFramePong 应用层心跳响应（下行）
+	ChatFramePong = "pong"
+	// ChatFrameRead 已读回执（下行，推送给会话双方）
+	ChatFrameRead = "read"
+	// ChatFrameRecall 消息撤回（下行，推送给会话双方）
+	ChatFrameRecall = "recall"
 )
@@ -21,6 +21,15 @@ const (
 	ErrVideoUploadFailed  = 3002
 	ErrVideoFileInvalid   = 3003
 	ErrVideoProcessFailed = 3004
+	ErrVideoNotReady      = 3005
+	ErrVideoFileTooLarge  = 3006
+	ErrVideoFileType      = 3007
+	ErrUploadNotFound     = 3008
+	ErrUploadChunkInvalid = 3009
+	ErrUploadIncomplete   = 3010
+	ErrUploadCompleting   = 3011
+	ErrUploadNotUploaded  = 3012
+	ErrVideoPermission    = 3013
 
 	// Comment 4xxx
 	ErrCommentNotFound         = 4001
@@ -39,9 +48,11 @@ const (
 	ErrNotFriend        = 6004
 
 	// Message 7xxx
-	ErrMessageContentEmpty = 7001
-	ErrMessageTooLong      = 7002
-	ErrNotFriendToMessage  = 7003
+	ErrMessageContentEmpty  = 7001
+	ErrMessageTooLong       = 7002
+	ErrNotFriendToMessage   = 7003
+	ErrMessageImageTooLarge = 7004
+	ErrMessageImageType     = 7005
 
 	// System 9xxx
 	ErrInternalServer = 9001
@@ -63,6 +74,15 @@ var ErrMsg = map[int32]string{
 	ErrVideoUploadFailed:       "视频上传失败",
 	ErrVideoFileInvalid:        "视频文件无效",
 	ErrVideoProcessFailed:      "视频处理失败",
+	ErrVideoNotReady:           "视频尚未就绪",
+	ErrVideoFileTooLarge:       "视频文件过大",
+	ErrVideoFileType:           "不支持的视频格式",
+	ErrUploadNotFound:          "上传会话不存在或已过期",
+	ErrUploadChunkInvalid:      "分片无效",
+	ErrUploadIncomplete:        "分片未全部上传",
+	ErrUploadCompleting:        "上传正在完成中",
+	ErrUploadNotUploaded:       "文件尚未上传",
+	ErrVideoPermission:         "无权限删除视频",
 	ErrCommentNotFound:         "评论不存在",
 	ErrCommentPermissionDenied: "无权限删除评论",
 	ErrCommentTooLong:          "评论内容过长",
@@ -76,6 +96,8 @@ var ErrMsg = map[int32]string{
 	ErrMessageContentEmpty:     "消息内容不能为空",
 	ErrMessageTooLong:          "消息内容过长",
 	ErrNotFriendToMessage:      "非好友不能发送消息",
+	ErrMessageImageTooLarge:    "图片过大",
+	ErrMessageImageType:        "不支持的图片格式",
 	ErrInternalServer:          "服务器错误",
 	ErrDatabaseError:           "数据库错误",
 }
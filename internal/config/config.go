@@ -2,9 +2,14 @@ package config
 
 import (
 	"fmt"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
 )
 
 // Init loads configuration from config-{env}.yaml (default env=dev)
@@ -35,18 +40,27 @@ func Init() *AppConfig {
 
 // AppConfig 整合所有配置
 type AppConfig struct {
-	Server   Server         `mapstructure:"server"`
-	MySQL    MySQLConfig    `mapstructure:"mysql"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
-	Log      LogConfig      `mapstructure:"log"`
-	MinIO    MinIOConfig    `mapstructure:"minio"`
-	RabbitMQ RabbitMQConfig `mapstructure:"rabbitmq"`
+	Server    Server          `mapstructure:"server"`
+	MySQL     MySQLConfig     `mapstructure:"mysql"`
+	Redis     RedisConfig     `mapstructure:"redis"`
+	JWT       JWTConfig       `mapstructure:"jwt"`
+	Log       LogConfig       `mapstructure:"log"`
+	MinIO     MinIOConfig     `mapstructure:"minio"`
+	Storage   StorageConfig   `mapstructure:"storage"`
+	SignedURL SignedURLConfig `mapstructure:"signed_url"`
+	Queue     QueueConfig     `mapstructure:"queue"`
+	RabbitMQ  RabbitMQConfig  `mapstructure:"rabbitmq"`
+	Event     EventConfig     `mapstructure:"event"`
+	Counter   CounterConfig   `mapstructure:"counter"`
+	Message   MessageConfig   `mapstructure:"message"`
+	Media     MediaConfig     `mapstructure:"media"`
+	Admin     AdminConfig     `mapstructure:"admin"`
 }
 
 type Server struct {
-	Port int    `mapstructure:"port"`
-	Mode string `mapstructure:"mode"`
+	Port            int    `mapstructure:"port"`
+	Mode            string `mapstructure:"mode"`
+	ShutdownTimeout int    `mapstructure:"shutdown_timeout"` // 优雅停机超时（秒）
 }
 
 // MySQLConfig MySQL 配置
@@ -92,6 +106,41 @@ type MinIOConfig struct {
 	BucketName      string `mapstructure:"bucket_name"`       // 存储桶名称
 	Location        string `mapstructure:"location"`          // 存储桶位置（区域）
 	URLPrefix       string `mapstructure:"url_prefix"`        // 文件访问 URL 前缀
+	PublicEndpoint  string `mapstructure:"public_endpoint"`   // 客户端直传使用的地址（如 https://oss.example.com），为空时使用 endpoint
+}
+
+// StorageConfig 对象存储配置
+type StorageConfig struct {
+	Type           string `mapstructure:"type"`             // 存储类型: minio（默认）, local（本地磁盘，无需 MinIO）
+	LocalDir       string `mapstructure:"local_dir"`        // 本地存储目录
+	LocalURLPrefix string `mapstructure:"local_url_prefix"` // 本地存储文件的访问地址前缀（由服务以静态文件提供）
+	LocalSecret    string `mapstructure:"local_secret"`     // 本地存储预签名上传的签名密钥（默认由 jwt.secret 派生）
+}
+
+// LocalRoute 本地存储静态文件路由（local_url_prefix 的路径部分）
+func (c *StorageConfig) LocalRoute() string {
+	prefix := c.LocalURLPrefix
+	if prefix == "" {
+		prefix = constant.StorageLocalDefaultURLPrefix
+	}
+	u, err := url.Parse(prefix)
+	if err != nil || u.Path == "" || u.Path == "/" {
+		return constant.StorageLocalDefaultRoute
+	}
+	return strings.TrimSuffix(u.Path, "/")
+}
+
+// SignedURLConfig 视频和封面访问地址签名配置
+type SignedURLConfig struct {
+	Enabled bool   `mapstructure:"enabled"`  // 是否返回签名地址（开启后 MinIO 存储桶不再设置为公开读）
+	TTL     int    `mapstructure:"ttl"`      // 签名地址有效期（秒），默认 3600
+	Secret  string `mapstructure:"secret"`   // HLS 播放令牌的签名密钥（默认由 jwt.secret 派生）
+	BaseURL string `mapstructure:"base_url"` // 本服务对外地址，用于生成 HLS 播放地址，默认 http://localhost:8080
+}
+
+// QueueConfig 任务队列配置
+type QueueConfig struct {
+	Type string `mapstructure:"type"` // 队列类型: rabbitmq（默认）, memory（进程内队列，无需 RabbitMQ）
 }
 
 // RabbitMQConfig RabbitMQ 消息队列配置
@@ -103,4 +152,106 @@ type RabbitMQConfig struct {
 	VHost    string `mapstructure:"vhost"`    // 虚拟主机
 	Exchange string `mapstructure:"exchange"` // 交换机名称
 	Queue    string `mapstructure:"queue"`    // 队列名称
+
+	MaxRetries     int `mapstructure:"max_retries"`      // 最大重试次数，超过后转入死信队列
+	RetryBaseDelay int `mapstructure:"retry_base_delay"` // 首次重试延迟（秒），之后每次翻倍
+	Concurrency    int `mapstructure:"concurrency"`      // 上传 worker 并发数
+	Prefetch       int `mapstructure:"prefetch"`         // 消费 channel 的 basic.qos prefetch（默认等于并发数）
+}
+
+// WorkerConcurrency 上传 worker 并发数
+func (c *RabbitMQConfig) WorkerConcurrency() int {
+	if c.Concurrency <= 0 {
+		return constant.WorkerDefaultConcurrency
+	}
+	return c.Concurrency
+}
+
+// WorkerPrefetch 消费 channel 的 prefetch 数量
+func (c *RabbitMQConfig) WorkerPrefetch() int {
+	if c.Prefetch <= 0 {
+		return c.WorkerConcurrency()
+	}
+	return c.Prefetch
+}
+
+// RetryAttempts 最大重试次数
+func (c *RabbitMQConfig) RetryAttempts() int {
+	if c.MaxRetries <= 0 {
+		return constant.RabbitMQDefaultMaxRetries
+	}
+	return c.MaxRetries
+}
+
+// RetryQueue 第 attempt 次重试使用的延迟队列
+func (c *RabbitMQConfig) RetryQueue(attempt int) string {
+	return fmt.Sprintf(constant.RabbitMQRetryQueueFormat, c.Queue, attempt)
+}
+
+// RetryDelay 第 attempt 次重试的延迟（指数退避）
+func (c *RabbitMQConfig) RetryDelay(attempt int) time.Duration {
+	base := constant.RabbitMQDefaultRetryBaseDelay
+	if c.RetryBaseDelay > 0 {
+		base = time.Duration(c.RetryBaseDelay) * time.Second
+	}
+	return base << (attempt - 1)
+}
+
+// DeadLetterExchange 死信交换机
+func (c *RabbitMQConfig) DeadLetterExchange() string {
+	return c.Exchange + constant.RabbitMQDeadLetterExchangeSuffix
+}
+
+// DeadLetterQueue 死信队列
+func (c *RabbitMQConfig) DeadLetterQueue() string {
+	return c.Queue + constant.RabbitMQDeadLetterQueueSuffix
+}
+
+// EventConfig 领域事件总线配置（RabbitMQ 连接复用 rabbitmq 配置）
+type EventConfig struct {
+	Type     string `mapstructure:"type"`     // 总线类型: rabbitmq（默认）, memory（进程内总线，无需 RabbitMQ）
+	Exchange string `mapstructure:"exchange"` // topic 交换机名称，默认 douyin.events
+}
+
+// CounterConfig 计数器配置
+type CounterConfig struct {
+	FlushInterval     int  `mapstructure:"flush_interval"`     // 计数增量回写 MySQL 的间隔（秒）
+	ReconcileInterval int  `mapstructure:"reconcile_interval"` // 计数对账间隔（分钟），0 表示不在服务内定时对账
+	ReconcileRepair   bool `mapstructure:"reconcile_repair"`   // 定时对账发现差异时是否自动修复
+}
+
+// MessageConfig 私信配置
+type MessageConfig struct {
+	RecallWindow  int `mapstructure:"recall_window"`  // 消息发送后可撤回的时限（秒），默认 120
+	RetentionDays int `mapstructure:"retention_days"` // 消息保留天数，超过后由后台任务清理，0 表示永久保留
+}
+
+// RecallLimit 消息发送后可撤回的时限
+func (c *MessageConfig) RecallLimit() time.Duration {
+	if c.RecallWindow <= 0 {
+		return constant.MessageDefaultRecallWindow
+	}
+	return time.Duration(c.RecallWindow) * time.Second
+}
+
+// Retention 消息保留时长，0 表示永久保留
+func (c *MessageConfig) Retention() time.Duration {
+	if c.RetentionDays <= 0 {
+		return 0
+	}
+	return time.Duration(c.RetentionDays) * 24 * time.Hour
+}
+
+// MediaConfig 视频处理配置
+type MediaConfig struct {
+	Processor   string  `mapstructure:"processor"`    // 处理器: ffmpeg, fake
+	FFmpegPath  string  `mapstructure:"ffmpeg_path"`  // ffmpeg 可执行文件路径（默认从 PATH 查找）
+	FFprobePath string  `mapstructure:"ffprobe_path"` // ffprobe 可执行文件路径（默认从 PATH 查找）
+	CoverOffset float64 `mapstructure:"cover_offset"` // 截取封面的时刻（秒），超出视频时长时取中间帧
+	HLSEnabled  bool    `mapstructure:"hls_enabled"`  // 是否生成多码率 HLS，关闭时客户端直接播放原视频
+}
+
+// AdminConfig 管理接口配置
+type AdminConfig struct {
+	Token string `mapstructure:"token"` // 管理接口访问令牌（请求头 X-Admin-Token），为空时禁用管理接口
 }
@@ -0,0 +1,314 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+)
+
+// cacheNullValue 空值缓存占位符（记录不存在时写入，防止缓存穿透）
+const cacheNullValue = ""
+
+// cacheTTL 为过期时间增加随机抖动，避免大量 key 同时过期（缓存雪崩）
+func cacheTTL(base time.Duration) time.Duration {
+	jitter := int64(float64(base) * constant.CacheTTLJitterRatio)
+	if jitter <= 0 {
+		return base
+	}
+	return base + time.Duration(rand.Int64N(jitter))
+}
+
+// cacheKey 拼接缓存键
+func cacheKey(prefix string, id uint) string {
+	return prefix + strconv.FormatUint(uint64(id), 10)
+}
+
+// batchKey 生成批量加载的 singleflight 键（与 ID 顺序无关）
+func batchKey(prefix string, ids []uint) string {
+	sorted := make([]uint, len(ids))
+	copy(sorted, ids)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var b strings.Builder
+	b.WriteString(prefix)
+	b.WriteString("batch:")
+	for i, id := range sorted {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatUint(uint64(id), 10))
+	}
+	return b.String()
+}
+
+// uniqueIDs 去重并保持原有顺序
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// cacheGet 读取单个缓存
+// hit 表示缓存命中（包括空值缓存），命中空值缓存时 obj 为 nil
+// Redis 异常时按未命中处理，降级为回源查询
+func cacheGet[T any](ctx context.Context, rdb *redis.Client, key string) (obj *T, hit bool) {
+	val, err := rdb.Get(ctx, key).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			global.Logger.Warn("dao.cache.get_error",
+				zap.String("key", key),
+				zap.Error(err),
+			)
+		}
+		return nil, false
+	}
+
+	if val == cacheNullValue {
+		return nil, true
+	}
+
+	obj = new(T)
+	if err := json.Unmarshal([]byte(val), obj); err != nil {
+		global.Logger.Warn("dao.cache.unmarshal_error",
+			zap.String("key", key),
+			zap.Error(err),
+		)
+		return nil, false
+	}
+	return obj, true
+}
+
+// cacheMGet 批量读取缓存，返回命中的 key（值为 nil 表示命中空值缓存）
+func cacheMGet[T any](ctx context.Context, rdb *redis.Client, keys []string) map[string]*T {
+	hits := make(map[string]*T, len(keys))
+	if len(keys) == 0 {
+		return hits
+	}
+
+	vals, err := rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		global.Logger.Warn("dao.cache.mget_error",
+			zap.Int("key_count", len(keys)),
+			zap.Error(err),
+		)
+		return hits
+	}
+
+	for i, val := range vals {
+		str, ok := val.(string)
+		if !ok {
+			continue // 未命中
+		}
+		if str == cacheNullValue {
+			hits[keys[i]] = nil
+			continue
+		}
+		obj := new(T)
+		if err := json.Unmarshal([]byte(str), obj); err != nil {
+			global.Logger.Warn("dao.cache.unmarshal_error",
+				zap.String("key", keys[i]),
+				zap.Error(err),
+			)
+			continue
+		}
+		hits[keys[i]] = obj
+	}
+	return hits
+}
+
+// cacheMSet 批量写入缓存，值为 nil 时写入空值缓存
+func cacheMSet(ctx context.Context, rdb *redis.Client, items map[string]any, ttl time.Duration) {
+	if len(items) == 0 {
+		return
+	}
+
+	pipe := rdb.Pipeline()
+	for key, obj := range items {
+		if obj == nil {
+			pipe.Set(ctx, key, cacheNullValue, constant.CacheNullTTL)
+			continue
+		}
+		data, err := json.Marshal(obj)
+		if err != nil {
+			global.Logger.Warn("dao.cache.marshal_error",
+				zap.String("key", key),
+				zap.Error(err),
+			)
+			continue
+		}
+		pipe.Set(ctx, key, data, cacheTTL(ttl))
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		global.Logger.Warn("dao.cache.set_error",
+			zap.Int("key_count", len(items)),
+			zap.Error(err),
+		)
+	}
+}
+
+// cacheInvalidate 删除缓存；处于事务中时延迟到事务提交后执行
+func cacheInvalidate(ctx context.Context, rdb *redis.Client, keys ...string) {
+	AfterCommit(ctx, func() {
+		if err := rdb.Del(context.WithoutCancel(ctx), keys...).Err(); err != nil {
+			global.Logger.Warn("dao.cache.del_error",
+				zap.Strings("keys", keys),
+				zap.Error(err),
+			)
+		}
+	})
+}
+
+// cacheDo 通过 singleflight 合并并发回源请求
+// 共享的回源使用脱离调用方取消信号的 ctx（带独立超时），避免首个调用方取消导致所有等待者一起失败；
+// 每个调用方仍会在自己的 ctx 取消时提前返回
+func cacheDo(ctx context.Context, group *singleflight.Group, key string, fn func(ctx context.Context) (any, error)) (any, error) {
+	ch := group.DoChan(key, func() (any, error) {
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), constant.CacheLoadTimeout)
+		defer cancel()
+		return fn(loadCtx)
+	})
+
+	select {
+	case res := <-ch:
+		return res.Val, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// cacheLoadOne Cache-Aside 读取单个对象
+// 先查缓存，未命中时通过 singleflight 合并并发回源请求，并将结果（包括不存在）写回缓存
+func cacheLoadOne[T any](
+	ctx context.Context,
+	rdb *redis.Client,
+	group *singleflight.Group,
+	key string,
+	ttl time.Duration,
+	load func(ctx context.Context) (*T, error),
+) (*T, error) {
+	// 事务中直接回源，避免读到未提交数据后写入缓存
+	if inTx(ctx) {
+		return load(ctx)
+	}
+
+	if obj, hit := cacheGet[T](ctx, rdb, key); hit {
+		if obj == nil {
+			return nil, gorm.ErrRecordNotFound
+		}
+		return obj, nil
+	}
+
+	v, err := cacheDo(ctx, group, key, func(ctx context.Context) (any, error) {
+		obj, err := load(ctx)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				cacheMSet(ctx, rdb, map[string]any{key: nil}, ttl)
+			}
+			return nil, err
+		}
+		cacheMSet(ctx, rdb, map[string]any{key: obj}, ttl)
+		return obj, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// singleflight 的结果在调用方之间共享，返回副本避免互相修改
+	obj := *v.(*T)
+	return &obj, nil
+}
+
+// cacheLoadMany Cache-Aside 批量读取对象，按 ids 的顺序返回存在的对象
+func cacheLoadMany[T any](
+	ctx context.Context,
+	rdb *redis.Client,
+	group *singleflight.Group,
+	prefix string,
+	ids []uint,
+	ttl time.Duration,
+	idOf func(obj *T) uint,
+	load func(ctx context.Context, ids []uint) ([]*T, error),
+) ([]*T, error) {
+	if len(ids) == 0 {
+		return []*T{}, nil
+	}
+	if inTx(ctx) {
+		return load(ctx, ids)
+	}
+
+	ids = uniqueIDs(ids)
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = cacheKey(prefix, id)
+	}
+
+	// 1. 批量查缓存
+	hits := cacheMGet[T](ctx, rdb, keys)
+	objMap := make(map[uint]*T, len(ids))
+	missIDs := make([]uint, 0, len(ids))
+	for i, id := range ids {
+		obj, hit := hits[keys[i]]
+		if !hit {
+			missIDs = append(missIDs, id)
+			continue
+		}
+		if obj != nil {
+			objMap[id] = obj
+		}
+	}
+
+	// 2. 未命中的部分回源，并写回缓存（不存在的 ID 写入空值缓存）
+	if len(missIDs) > 0 {
+		v, err := cacheDo(ctx, group, batchKey(prefix, missIDs), func(ctx context.Context) (any, error) {
+			loaded, err := load(ctx, missIDs)
+			if err != nil {
+				return nil, err
+			}
+			items := make(map[string]any, len(missIDs))
+			for _, id := range missIDs {
+				items[cacheKey(prefix, id)] = nil
+			}
+			for _, obj := range loaded {
+				items[cacheKey(prefix, idOf(obj))] = obj
+			}
+			cacheMSet(ctx, rdb, items, ttl)
+			return loaded, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range v.([]*T) {
+			cp := *obj
+			objMap[idOf(obj)] = &cp
+		}
+	}
+
+	// 3. 按请求顺序组装结果
+	result := make([]*T, 0, len(objMap))
+	for _, id := range ids {
+		if obj, ok := objMap[id]; ok {
+			result = append(result, obj)
+		}
+	}
+	return result, nil
+}
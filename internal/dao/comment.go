@@ -7,6 +7,7 @@ import (
 	"github.com/wangn-tech/tiny-douyin/internal/model"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ICommentDAO 评论数据访问接口
@@ -15,10 +16,16 @@ type ICommentDAO interface {
 	CreateComment(ctx context.Context, comment *model.Comment) error
 	// DeleteComment 删除评论（软删除）
 	DeleteComment(ctx context.Context, commentID uint) error
-	// GetCommentByID 根据ID查询评论
+	// DeleteReplies 删除顶级评论下的所有回复（软删除），返回删除数量
+	DeleteReplies(ctx context.Context, rootID uint) (int64, error)
+	// GetCommentByID 根据ID查询评论，在事务中调用时锁定该评论
 	GetCommentByID(ctx context.Context, commentID uint) (*model.Comment, error)
-	// GetVideoComments 获取视频的所有评论（按时间倒序）
+	// GetVideoComments 获取视频的所有顶级评论（按时间倒序）
 	GetVideoComments(ctx context.Context, videoID uint) ([]*model.Comment, error)
+	// ListReplies 按 ID 正序分页查询顶级评论下的回复，cursor 为上一页最后一条的 ID（0 表示第一页）
+	ListReplies(ctx context.Context, rootID, cursor uint, limit int) ([]*model.Comment, error)
+	// IncrReplyCount 增减顶级评论的回复数
+	IncrReplyCount(ctx context.Context, rootID uint, delta int64) error
 	// GetCommentCount 获取视频的评论数
 	GetCommentCount(ctx context.Context, videoID uint) (int64, error)
 }
@@ -35,7 +42,7 @@ func NewCommentDAO(db *gorm.DB) ICommentDAO {
 
 // CreateComment 创建评论
 func (d *CommentDAO) CreateComment(ctx context.Context, comment *model.Comment) error {
-	err := d.db.WithContext(ctx).Create(comment).Error
+	err := getDB(ctx, d.db).Create(comment).Error
 	if err != nil {
 		global.Logger.Error("dao.CreateComment.db_error",
 			zap.Uint("user_id", comment.UserID),
@@ -56,7 +63,7 @@ func (d *CommentDAO) CreateComment(ctx context.Context, comment *model.Comment)
 
 // DeleteComment 删除评论（软删除）
 func (d *CommentDAO) DeleteComment(ctx context.Context, commentID uint) error {
-	result := d.db.WithContext(ctx).Delete(&model.Comment{}, commentID)
+	result := getDB(ctx, d.db).Delete(&model.Comment{}, commentID)
 
 	if result.Error != nil {
 		global.Logger.Error("dao.DeleteComment.db_error",
@@ -74,10 +81,30 @@ func (d *CommentDAO) DeleteComment(ctx context.Context, commentID uint) error {
 	return nil
 }
 
+// DeleteReplies 删除顶级评论下的所有回复（软删除）
+func (d *CommentDAO) DeleteReplies(ctx context.Context, rootID uint) (int64, error) {
+	result := getDB(ctx, d.db).Where("root_id = ?", rootID).Delete(&model.Comment{})
+
+	if result.Error != nil {
+		global.Logger.Error("dao.DeleteReplies.db_error",
+			zap.Uint("root_id", rootID),
+			zap.Error(result.Error),
+		)
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
 // GetCommentByID 根据ID查询评论
 func (d *CommentDAO) GetCommentByID(ctx context.Context, commentID uint) (*model.Comment, error) {
+	query := getDB(ctx, d.db)
+	if inTx(ctx) {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
 	var comment model.Comment
-	err := d.db.WithContext(ctx).First(&comment, commentID).Error
+	err := query.First(&comment, commentID).Error
 	if err != nil {
 		global.Logger.Error("dao.GetCommentByID.db_error",
 			zap.Uint("comment_id", commentID),
@@ -89,11 +116,11 @@ func (d *CommentDAO) GetCommentByID(ctx context.Context, commentID uint) (*model
 	return &comment, nil
 }
 
-// GetVideoComments 获取视频的所有评论（按时间倒序）
+// GetVideoComments 获取视频的所有顶级评论（按时间倒序）
 func (d *CommentDAO) GetVideoComments(ctx context.Context, videoID uint) ([]*model.Comment, error) {
 	var comments []*model.Comment
-	err := d.db.WithContext(ctx).
-		Where("video_id = ?", videoID).
+	err := getDB(ctx, d.db).
+		Where("video_id = ? AND root_id = ?", videoID, 0).
 		Order("created_at DESC").
 		Find(&comments).Error
 
@@ -113,10 +140,48 @@ func (d *CommentDAO) GetVideoComments(ctx context.Context, videoID uint) ([]*mod
 	return comments, nil
 }
 
+// ListReplies 按 ID 正序分页查询顶级评论下的回复
+func (d *CommentDAO) ListReplies(ctx context.Context, rootID, cursor uint, limit int) ([]*model.Comment, error) {
+	query := getDB(ctx, d.db).Where("root_id = ?", rootID)
+	if cursor > 0 {
+		query = query.Where("id > ?", cursor)
+	}
+
+	var comments []*model.Comment
+	if err := query.Order("id ASC").Limit(limit).Find(&comments).Error; err != nil {
+		global.Logger.Error("dao.ListReplies.db_error",
+			zap.Uint("root_id", rootID),
+			zap.Uint("cursor", cursor),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// IncrReplyCount 增减顶级评论的回复数
+func (d *CommentDAO) IncrReplyCount(ctx context.Context, rootID uint, delta int64) error {
+	err := getDB(ctx, d.db).Model(&model.Comment{}).
+		Where("id = ?", rootID).
+		UpdateColumn("reply_count", gorm.Expr("GREATEST(reply_count + ?, 0)", delta)).Error
+
+	if err != nil {
+		global.Logger.Error("dao.IncrReplyCount.db_error",
+			zap.Uint("root_id", rootID),
+			zap.Int64("delta", delta),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	return nil
+}
+
 // GetCommentCount 获取视频的评论数
 func (d *CommentDAO) GetCommentCount(ctx context.Context, videoID uint) (int64, error) {
 	var count int64
-	err := d.db.WithContext(ctx).
+	err := getDB(ctx, d.db).
 		Model(&model.Comment{}).
 		Where("video_id = ?", videoID).
 		Count(&count).Error
@@ -0,0 +1,242 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IConversationDAO 私信会话数据访问接口
+type IConversationDAO interface {
+	// RecordMessage 新消息写入后更新会话（不存在时创建）：刷新最新消息，发送方已读到该消息，接收方未读数加一
+	RecordMessage(ctx context.Context, message *model.Message) error
+	// GetConversation 获取两个用户之间的会话，不存在时返回 nil
+	// 在事务中调用时锁定该会话，避免并发标记已读时丢失更新
+	GetConversation(ctx context.Context, userID1, userID2 uint) (*model.Conversation, error)
+	// ListConversations 按最新消息倒序分页查询用户的会话，cursor 为上一页最后一条的 LastMessageID（0 表示第一页）
+	ListConversations(ctx context.Context, userID, cursor uint, limit int) ([]*model.Conversation, error)
+	// GetConversationsByPeers 批量获取用户与指定用户之间的会话（peerID -> 会话）
+	GetConversationsByPeers(ctx context.Context, userID uint, peerIDs []uint) (map[uint]*model.Conversation, error)
+	// UpdateReadState 更新用户在会话中的已读位置和未读数
+	UpdateReadState(ctx context.Context, conversation *model.Conversation, userID, lastReadID uint, unreadCount int) error
+	// SumUnread 统计用户所有会话的未读消息总数
+	SumUnread(ctx context.Context, userID uint) (int64, error)
+	// ClearConversation 清空用户一侧的会话：会话当前最新消息及之前的消息对该用户不可见，并标记全部已读
+	ClearConversation(ctx context.Context, conversation *model.Conversation, userID uint) error
+	// PurgeConversations 物理删除最新消息早于 before 的会话（其中的消息已全部过期清理）
+	PurgeConversations(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ConversationDAO 私信会话数据访问实现
+type ConversationDAO struct {
+	db *gorm.DB
+}
+
+// NewConversationDAO 创建 ConversationDAO 实例
+func NewConversationDAO(db *gorm.DB) IConversationDAO {
+	return &ConversationDAO{db: db}
+}
+
+// conversationPair 按 UserAID < UserBID 排列用户对
+func conversationPair(userID1, userID2 uint) (uint, uint) {
+	if userID1 < userID2 {
+		return userID1, userID2
+	}
+	return userID2, userID1
+}
+
+// conversationSide 返回用户在会话中对应的列前缀（a 或 b）
+func conversationSide(userAID, userID uint) string {
+	if userID == userAID {
+		return "a"
+	}
+	return "b"
+}
+
+// RecordMessage 新消息写入后更新会话
+// 并发发送时较早的消息可能较晚提交，最新消息只在消息 ID 更大时替换；
+// MySQL 按书写顺序执行赋值，依赖旧 last_message_id 的列需排在其前面
+func (d *ConversationDAO) RecordMessage(ctx context.Context, message *model.Message) error {
+	userAID, userBID := conversationPair(message.FromUserID, message.ToUserID)
+	sender := conversationSide(userAID, message.FromUserID)
+	recipient := conversationSide(userAID, message.ToUserID)
+
+	conversation := &model.Conversation{
+		UserAID:       userAID,
+		UserBID:       userBID,
+		LastMessageID: message.ID,
+		LastMessageAt: message.CreatedAt,
+	}
+	if sender == "a" {
+		conversation.ALastReadID, conversation.BUnreadCount = message.ID, 1
+	} else {
+		conversation.BLastReadID, conversation.AUnreadCount = message.ID, 1
+	}
+
+	err := getDB(ctx, d.db).Clauses(clause.OnConflict{
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "last_message_at"}, Value: gorm.Expr("IF(VALUES(last_message_id) > last_message_id, VALUES(last_message_at), last_message_at)")},
+			{Column: clause.Column{Name: sender + "_unread_count"}, Value: gorm.Expr("IF(VALUES(last_message_id) > last_message_id, 0, " + sender + "_unread_count)")},
+			{Column: clause.Column{Name: "last_message_id"}, Value: gorm.Expr("GREATEST(last_message_id, VALUES(last_message_id))")},
+			{Column: clause.Column{Name: sender + "_last_read_id"}, Value: gorm.Expr("GREATEST(" + sender + "_last_read_id, VALUES(" + sender + "_last_read_id))")},
+			{Column: clause.Column{Name: recipient + "_unread_count"}, Value: gorm.Expr(recipient + "_unread_count + 1")},
+			{Column: clause.Column{Name: "updated_at"}, Value: gorm.Expr("VALUES(updated_at)")},
+		},
+	}).Create(conversation).Error
+	if err != nil {
+		global.Logger.Error("dao.RecordConversationMessage.db_error",
+			zap.Uint("message_id", message.ID),
+			zap.Uint("from_user_id", message.FromUserID),
+			zap.Uint("to_user_id", message.ToUserID),
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
+
+// GetConversation 获取两个用户之间的会话
+func (d *ConversationDAO) GetConversation(ctx context.Context, userID1, userID2 uint) (*model.Conversation, error) {
+	userAID, userBID := conversationPair(userID1, userID2)
+	query := getDB(ctx, d.db).Where("user_a_id = ? AND user_b_id = ?", userAID, userBID)
+	if inTx(ctx) {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var conversation model.Conversation
+	if err := query.First(&conversation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		global.Logger.Error("dao.GetConversation.db_error",
+			zap.Uint("user_id_1", userID1),
+			zap.Uint("user_id_2", userID2),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// ListConversations 按最新消息倒序分页查询用户的会话
+func (d *ConversationDAO) ListConversations(ctx context.Context, userID, cursor uint, limit int) ([]*model.Conversation, error) {
+	query := getDB(ctx, d.db).Where("(user_a_id = ? OR user_b_id = ?)", userID, userID)
+	if cursor > 0 {
+		query = query.Where("last_message_id < ?", cursor)
+	}
+
+	var conversations []*model.Conversation
+	if err := query.Order("last_message_id DESC").Limit(limit).Find(&conversations).Error; err != nil {
+		global.Logger.Error("dao.ListConversations.db_error",
+			zap.Uint("user_id", userID),
+			zap.Uint("cursor", cursor),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	return conversations, nil
+}
+
+// GetConversationsByPeers 批量获取用户与指定用户之间的会话
+func (d *ConversationDAO) GetConversationsByPeers(ctx context.Context, userID uint, peerIDs []uint) (map[uint]*model.Conversation, error) {
+	result := make(map[uint]*model.Conversation, len(peerIDs))
+	if len(peerIDs) == 0 {
+		return result, nil
+	}
+
+	var conversations []*model.Conversation
+	err := getDB(ctx, d.db).
+		Where("(user_a_id = ? AND user_b_id IN ?) OR (user_b_id = ? AND user_a_id IN ?)", userID, peerIDs, userID, peerIDs).
+		Find(&conversations).Error
+	if err != nil {
+		global.Logger.Error("dao.GetConversationsByPeers.db_error",
+			zap.Uint("user_id", userID),
+			zap.Int("peer_count", len(peerIDs)),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	for _, conversation := range conversations {
+		result[conversation.PeerID(userID)] = conversation
+	}
+	return result, nil
+}
+
+// UpdateReadState 更新用户在会话中的已读位置和未读数
+func (d *ConversationDAO) UpdateReadState(ctx context.Context, conversation *model.Conversation, userID, lastReadID uint, unreadCount int) error {
+	side := conversationSide(conversation.UserAID, userID)
+	err := getDB(ctx, d.db).Model(&model.Conversation{}).
+		Where("id = ?", conversation.ID).
+		UpdateColumns(map[string]any{
+			side + "_last_read_id": lastReadID,
+			side + "_unread_count": unreadCount,
+		}).Error
+	if err != nil {
+		global.Logger.Error("dao.UpdateConversationReadState.db_error",
+			zap.Uint("conversation_id", conversation.ID),
+			zap.Uint("user_id", userID),
+			zap.Uint("last_read_id", lastReadID),
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
+
+// SumUnread 统计用户所有会话的未读消息总数
+func (d *ConversationDAO) SumUnread(ctx context.Context, userID uint) (int64, error) {
+	var total int64
+	err := getDB(ctx, d.db).Model(&model.Conversation{}).
+		Select("COALESCE(SUM(CASE WHEN user_a_id = ? THEN a_unread_count ELSE b_unread_count END), 0)", userID).
+		Where("user_a_id = ? OR user_b_id = ?", userID, userID).
+		Scan(&total).Error
+	if err != nil {
+		global.Logger.Error("dao.SumConversationUnread.db_error",
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		return 0, err
+	}
+	return total, nil
+}
+
+// ClearConversation 清空用户一侧的会话
+func (d *ConversationDAO) ClearConversation(ctx context.Context, conversation *model.Conversation, userID uint) error {
+	side := conversationSide(conversation.UserAID, userID)
+	err := getDB(ctx, d.db).Model(&model.Conversation{}).
+		Where("id = ?", conversation.ID).
+		UpdateColumns(map[string]any{
+			side + "_cleared_id":   conversation.LastMessageID,
+			side + "_last_read_id": gorm.Expr("GREATEST("+side+"_last_read_id, ?)", conversation.LastMessageID),
+			side + "_unread_count": 0,
+		}).Error
+	if err != nil {
+		global.Logger.Error("dao.ClearConversation.db_error",
+			zap.Uint("conversation_id", conversation.ID),
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
+
+// PurgeConversations 物理删除最新消息早于 before 的会话
+func (d *ConversationDAO) PurgeConversations(ctx context.Context, before time.Time) (int64, error) {
+	result := getDB(ctx, d.db).Where("last_message_at < ?", before).Delete(&model.Conversation{})
+	if result.Error != nil {
+		global.Logger.Error("dao.PurgeConversations.db_error",
+			zap.Time("before", before),
+			zap.Error(result.Error),
+		)
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,324 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ICounterDAO 计数增量、回写记录及计数对账数据访问接口
+type ICounterDAO interface {
+	// MarkFlushed 记录回写批次，返回 false 表示该批次已经回写过
+	MarkFlushed(ctx context.Context, batchKey string) (bool, error)
+	// CleanupFlushLogs 清理指定时间之前的回写记录
+	CleanupFlushLogs(ctx context.Context, before time.Time) error
+
+	// CreateCounterDelta 记录计数增量（需与业务变更在同一事务中调用）
+	CreateCounterDelta(ctx context.Context, delta *model.CounterDelta) error
+	// ListCounterDeltas 按 ID 顺序读取最早的一批计数增量记录
+	ListCounterDeltas(ctx context.Context, limit int) ([]*model.CounterDelta, error)
+	// ListCounterDeltasByObjectIDs 读取指定对象的计数增量记录
+	ListCounterDeltasByObjectIDs(ctx context.Context, target string, objectIDs []uint) ([]*model.CounterDelta, error)
+	// DeleteCounterDeltas 删除已写入 Redis 的计数增量记录
+	DeleteCounterDeltas(ctx context.Context, ids []uint) error
+
+	// ScanVideoCounts 按 ID 顺序分页读取视频的计数字段（id > afterID）
+	ScanVideoCounts(ctx context.Context, afterID uint, limit int) ([]*model.Video, error)
+	// ScanUserCounts 按 ID 顺序分页读取用户的计数字段（id > afterID）
+	ScanUserCounts(ctx context.Context, afterID uint, limit int) ([]*model.User, error)
+	// GetVideoCountsByIDs 读取指定视频的计数字段
+	GetVideoCountsByIDs(ctx context.Context, videoIDs []uint) ([]*model.Video, error)
+	// GetUserCountsByIDs 读取指定用户的计数字段
+	GetUserCountsByIDs(ctx context.Context, userIDs []uint) ([]*model.User, error)
+	// CountFavoritesByVideoIDs 统计视频的有效点赞数
+	CountFavoritesByVideoIDs(ctx context.Context, videoIDs []uint) (map[uint]int64, error)
+	// CountCommentsByVideoIDs 统计视频的有效评论数
+	CountCommentsByVideoIDs(ctx context.Context, videoIDs []uint) (map[uint]int64, error)
+	// CountFollowsByUserIDs 统计用户的有效关注数
+	CountFollowsByUserIDs(ctx context.Context, userIDs []uint) (map[uint]int64, error)
+	// CountFollowersByUserIDs 统计用户的有效粉丝数
+	CountFollowersByUserIDs(ctx context.Context, userIDs []uint) (map[uint]int64, error)
+}
+
+// CounterDAO 计数增量、回写记录及计数对账数据访问实现
+type CounterDAO struct {
+	db *gorm.DB
+}
+
+// NewCounterDAO 创建 CounterDAO 实例
+func NewCounterDAO(db *gorm.DB) ICounterDAO {
+	return &CounterDAO{db: db}
+}
+
+// MarkFlushed 记录回写批次（需与计数更新在同一事务中调用）
+func (d *CounterDAO) MarkFlushed(ctx context.Context, batchKey string) (bool, error) {
+	result := getDB(ctx, d.db).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.CounterFlushLog{BatchKey: batchKey})
+
+	if result.Error != nil {
+		global.Logger.Error("dao.MarkFlushed.db_error",
+			zap.String("batch_key", batchKey),
+			zap.Error(result.Error),
+		)
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// CleanupFlushLogs 清理指定时间之前的回写记录
+func (d *CounterDAO) CleanupFlushLogs(ctx context.Context, before time.Time) error {
+	result := getDB(ctx, d.db).
+		Where("created_at < ?", before).
+		Delete(&model.CounterFlushLog{})
+
+	if result.Error != nil {
+		global.Logger.Error("dao.CleanupFlushLogs.db_error",
+			zap.Time("before", before),
+			zap.Error(result.Error),
+		)
+		return result.Error
+	}
+
+	global.Logger.Info("dao.CleanupFlushLogs.success",
+		zap.Time("before", before),
+		zap.Int64("rows_affected", result.RowsAffected),
+	)
+
+	return nil
+}
+
+// CreateCounterDelta 记录计数增量（需与业务变更在同一事务中调用）
+func (d *CounterDAO) CreateCounterDelta(ctx context.Context, delta *model.CounterDelta) error {
+	if err := getDB(ctx, d.db).Create(delta).Error; err != nil {
+		global.Logger.Error("dao.CreateCounterDelta.db_error",
+			zap.String("target", delta.Target),
+			zap.Uint("object_id", delta.ObjectID),
+			zap.String("field", delta.Field),
+			zap.Int64("delta", delta.Delta),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// ListCounterDeltas 按 ID 顺序读取最早的一批计数增量记录
+func (d *CounterDAO) ListCounterDeltas(ctx context.Context, limit int) ([]*model.CounterDelta, error) {
+	var deltas []*model.CounterDelta
+	err := getDB(ctx, d.db).
+		Order("id ASC").
+		Limit(limit).
+		Find(&deltas).Error
+
+	if err != nil {
+		global.Logger.Error("dao.ListCounterDeltas.db_error",
+			zap.Int("limit", limit),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	return deltas, nil
+}
+
+// ListCounterDeltasByObjectIDs 读取指定对象的计数增量记录
+func (d *CounterDAO) ListCounterDeltasByObjectIDs(ctx context.Context, target string, objectIDs []uint) ([]*model.CounterDelta, error) {
+	if len(objectIDs) == 0 {
+		return []*model.CounterDelta{}, nil
+	}
+
+	var deltas []*model.CounterDelta
+	err := getDB(ctx, d.db).
+		Where("target = ? AND object_id IN ?", target, objectIDs).
+		Find(&deltas).Error
+
+	if err != nil {
+		global.Logger.Error("dao.ListCounterDeltasByObjectIDs.db_error",
+			zap.String("target", target),
+			zap.Int("id_count", len(objectIDs)),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	return deltas, nil
+}
+
+// DeleteCounterDeltas 删除已写入 Redis 的计数增量记录
+func (d *CounterDAO) DeleteCounterDeltas(ctx context.Context, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := getDB(ctx, d.db).Delete(&model.CounterDelta{}, ids).Error; err != nil {
+		global.Logger.Error("dao.DeleteCounterDeltas.db_error",
+			zap.Int("id_count", len(ids)),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// ScanVideoCounts 按 ID 顺序分页读取视频的计数字段（id > afterID）
+func (d *CounterDAO) ScanVideoCounts(ctx context.Context, afterID uint, limit int) ([]*model.Video, error) {
+	var videos []*model.Video
+	err := getDB(ctx, d.db).
+		Select("id", "favorite_count", "comment_count").
+		Where("id > ?", afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&videos).Error
+
+	if err != nil {
+		global.Logger.Error("dao.ScanVideoCounts.db_error",
+			zap.Uint("after_id", afterID),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	return videos, nil
+}
+
+// ScanUserCounts 按 ID 顺序分页读取用户的计数字段（id > afterID）
+func (d *CounterDAO) ScanUserCounts(ctx context.Context, afterID uint, limit int) ([]*model.User, error) {
+	var users []*model.User
+	err := getDB(ctx, d.db).
+		Select("id", "follow_count", "follower_count").
+		Where("id > ?", afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&users).Error
+
+	if err != nil {
+		global.Logger.Error("dao.ScanUserCounts.db_error",
+			zap.Uint("after_id", afterID),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// GetVideoCountsByIDs 读取指定视频的计数字段（按 ID 排序）
+func (d *CounterDAO) GetVideoCountsByIDs(ctx context.Context, videoIDs []uint) ([]*model.Video, error) {
+	var videos []*model.Video
+	if len(videoIDs) == 0 {
+		return videos, nil
+	}
+
+	err := getDB(ctx, d.db).
+		Select("id", "favorite_count", "comment_count").
+		Where("id IN ?", videoIDs).
+		Order("id ASC").
+		Find(&videos).Error
+
+	if err != nil {
+		global.Logger.Error("dao.GetVideoCountsByIDs.db_error",
+			zap.Int("id_count", len(videoIDs)),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	return videos, nil
+}
+
+// GetUserCountsByIDs 读取指定用户的计数字段（按 ID 排序）
+func (d *CounterDAO) GetUserCountsByIDs(ctx context.Context, userIDs []uint) ([]*model.User, error) {
+	var users []*model.User
+	if len(userIDs) == 0 {
+		return users, nil
+	}
+
+	err := getDB(ctx, d.db).
+		Select("id", "follow_count", "follower_count").
+		Where("id IN ?", userIDs).
+		Order("id ASC").
+		Find(&users).Error
+
+	if err != nil {
+		global.Logger.Error("dao.GetUserCountsByIDs.db_error",
+			zap.Int("id_count", len(userIDs)),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// CountFavoritesByVideoIDs 统计视频的有效点赞数（不含已取消的点赞）
+func (d *CounterDAO) CountFavoritesByVideoIDs(ctx context.Context, videoIDs []uint) (map[uint]int64, error) {
+	return d.countGroupBy(ctx, &model.Favorite{}, "video_id", videoIDs)
+}
+
+// CountCommentsByVideoIDs 统计视频的有效评论数（不含已删除的评论）
+func (d *CounterDAO) CountCommentsByVideoIDs(ctx context.Context, videoIDs []uint) (map[uint]int64, error) {
+	return d.countGroupBy(ctx, &model.Comment{}, "video_id", videoIDs)
+}
+
+// CountFollowsByUserIDs 统计用户的有效关注数（不含已取消的关注）
+func (d *CounterDAO) CountFollowsByUserIDs(ctx context.Context, userIDs []uint) (map[uint]int64, error) {
+	return d.countGroupBy(ctx, &model.Relation{}, "follower_id", userIDs)
+}
+
+// CountFollowersByUserIDs 统计用户的有效粉丝数（不含已取消的关注）
+func (d *CounterDAO) CountFollowersByUserIDs(ctx context.Context, userIDs []uint) (map[uint]int64, error) {
+	return d.countGroupBy(ctx, &model.Relation{}, "followee_id", userIDs)
+}
+
+// countGroupBy 按列分组统计记录数，GORM 会自动排除软删除的记录
+func (d *CounterDAO) countGroupBy(ctx context.Context, m any, column string, ids []uint) (map[uint]int64, error) {
+	result := make(map[uint]int64, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	var rows []struct {
+		ID    uint
+		Count int64
+	}
+	err := getDB(ctx, d.db).
+		Model(m).
+		Select(column+" AS id", "COUNT(*) AS count").
+		Where(column+" IN ?", ids).
+		Group(column).
+		Scan(&rows).Error
+
+	if err != nil {
+		global.Logger.Error("dao.countGroupBy.db_error",
+			zap.String("column", column),
+			zap.Int("id_count", len(ids)),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	for _, row := range rows {
+		result[row.ID] = row.Count
+	}
+	return result, nil
+}
+
+// buildCountUpdates 将计数增量转换为 UPDATE 表达式（结果不小于 0），忽略不在白名单中的列
+func buildCountUpdates(deltas map[string]int64, columns ...string) map[string]any {
+	updates := make(map[string]any, len(deltas))
+	for _, column := range columns {
+		if delta, ok := deltas[column]; ok && delta != 0 {
+			updates[column] = gorm.Expr("GREATEST("+column+" + ?, 0)", delta)
+		}
+	}
+	return updates
+}
@@ -3,21 +3,23 @@ package dao
 import (
 	"context"
 
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
 	"github.com/wangn-tech/tiny-douyin/internal/global"
 	"github.com/wangn-tech/tiny-douyin/internal/model"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // IFavoriteDAO 点赞数据访问接口
 type IFavoriteDAO interface {
-	// CreateFavorite 创建点赞记录
-	CreateFavorite(ctx context.Context, userID, videoID uint) error
-	// DeleteFavorite 删除点赞记录
-	DeleteFavorite(ctx context.Context, userID, videoID uint) error
+	// CreateFavorite 创建点赞记录（恢复已软删除的记录），返回点赞状态是否发生变化
+	CreateFavorite(ctx context.Context, userID, videoID uint) (bool, error)
+	// DeleteFavorite 删除点赞记录，返回点赞状态是否发生变化
+	DeleteFavorite(ctx context.Context, userID, videoID uint) (bool, error)
 	// IsFavorite 检查用户是否点赞了某个视频
 	IsFavorite(ctx context.Context, userID, videoID uint) (bool, error)
-	// GetUserFavoriteVideoIDs 获取用户点赞的所有视频ID列表
+	// GetUserFavoriteVideoIDs 获取用户点赞的所有视频ID列表（只包含已就绪的视频）
 	GetUserFavoriteVideoIDs(ctx context.Context, userID uint) ([]uint, error)
 	// GetFavoriteCount 获取视频的点赞数
 	GetFavoriteCount(ctx context.Context, videoID uint) (int64, error)
@@ -36,33 +38,49 @@ func NewFavoriteDAO(db *gorm.DB) IFavoriteDAO {
 }
 
 // CreateFavorite 创建点赞记录
-func (d *FavoriteDAO) CreateFavorite(ctx context.Context, userID, videoID uint) error {
+// 使用 INSERT ... ON DUPLICATE KEY UPDATE 保证幂等：
+//   - 记录不存在：插入新记录（rows_affected = 1）
+//   - 记录已软删除：恢复记录并刷新点赞时间（rows_affected = 2）
+//   - 记录已存在且未删除：不做修改（rows_affected = 0）
+//
+// 并发请求在 uk_user_video 唯一索引上串行化，只有真正改变点赞状态的请求返回 true
+func (d *FavoriteDAO) CreateFavorite(ctx context.Context, userID, videoID uint) (bool, error) {
 	favorite := &model.Favorite{
 		UserID:  userID,
 		VideoID: videoID,
 	}
 
-	err := d.db.WithContext(ctx).Create(favorite).Error
-	if err != nil {
+	result := getDB(ctx, d.db).
+		Clauses(clause.OnConflict{
+			// 注意赋值顺序：created_at 需要在 deleted_at 被置空之前判断
+			DoUpdates: []clause.Assignment{
+				{Column: clause.Column{Name: "created_at"}, Value: gorm.Expr("IF(deleted_at IS NULL, created_at, VALUES(created_at))")},
+				{Column: clause.Column{Name: "deleted_at"}, Value: nil},
+			},
+		}).
+		Create(favorite)
+	if result.Error != nil {
 		global.Logger.Error("dao.CreateFavorite.db_error",
 			zap.Uint("user_id", userID),
 			zap.Uint("video_id", videoID),
-			zap.Error(err),
+			zap.Error(result.Error),
 		)
-		return err
+		return false, result.Error
 	}
 
 	global.Logger.Info("dao.CreateFavorite.success",
 		zap.Uint("user_id", userID),
 		zap.Uint("video_id", videoID),
+		zap.Int64("rows_affected", result.RowsAffected),
 	)
 
-	return nil
+	return result.RowsAffected > 0, nil
 }
 
 // DeleteFavorite 删除点赞记录（软删除）
-func (d *FavoriteDAO) DeleteFavorite(ctx context.Context, userID, videoID uint) error {
-	result := d.db.WithContext(ctx).
+// 软删除自带 deleted_at IS NULL 条件，并发请求中只有一个能删除成功并返回 true
+func (d *FavoriteDAO) DeleteFavorite(ctx context.Context, userID, videoID uint) (bool, error) {
+	result := getDB(ctx, d.db).
 		Where("user_id = ? AND video_id = ?", userID, videoID).
 		Delete(&model.Favorite{})
 
@@ -72,7 +90,7 @@ func (d *FavoriteDAO) DeleteFavorite(ctx context.Context, userID, videoID uint)
 			zap.Uint("video_id", videoID),
 			zap.Error(result.Error),
 		)
-		return result.Error
+		return false, result.Error
 	}
 
 	global.Logger.Info("dao.DeleteFavorite.success",
@@ -81,13 +99,13 @@ func (d *FavoriteDAO) DeleteFavorite(ctx context.Context, userID, videoID uint)
 		zap.Int64("rows_affected", result.RowsAffected),
 	)
 
-	return nil
+	return result.RowsAffected > 0, nil
 }
 
 // IsFavorite 检查用户是否点赞了某个视频
 func (d *FavoriteDAO) IsFavorite(ctx context.Context, userID, videoID uint) (bool, error) {
 	var count int64
-	err := d.db.WithContext(ctx).
+	err := getDB(ctx, d.db).
 		Model(&model.Favorite{}).
 		Where("user_id = ? AND video_id = ?", userID, videoID).
 		Count(&count).Error
@@ -104,12 +122,13 @@ func (d *FavoriteDAO) IsFavorite(ctx context.Context, userID, videoID uint) (boo
 	return count > 0, nil
 }
 
-// GetUserFavoriteVideoIDs 获取用户点赞的所有视频ID列表
+// GetUserFavoriteVideoIDs 获取用户点赞的所有视频ID列表（只包含已就绪的视频）
 func (d *FavoriteDAO) GetUserFavoriteVideoIDs(ctx context.Context, userID uint) ([]uint, error) {
 	var favorites []model.Favorite
-	err := d.db.WithContext(ctx).
-		Where("user_id = ?", userID).
-		Order("created_at DESC").
+	err := getDB(ctx, d.db).
+		Joins("JOIN videos ON videos.id = favorites.video_id AND videos.status = ? AND videos.deleted_at IS NULL", constant.VideoStatusReady).
+		Where("favorites.user_id = ?", userID).
+		Order("favorites.created_at DESC").
 		Find(&favorites).Error
 
 	if err != nil {
@@ -136,7 +155,7 @@ func (d *FavoriteDAO) GetUserFavoriteVideoIDs(ctx context.Context, userID uint)
 // GetFavoriteCount 获取视频的点赞数
 func (d *FavoriteDAO) GetFavoriteCount(ctx context.Context, videoID uint) (int64, error) {
 	var count int64
-	err := d.db.WithContext(ctx).
+	err := getDB(ctx, d.db).
 		Model(&model.Favorite{}).
 		Where("video_id = ?", videoID).
 		Count(&count).Error
@@ -159,7 +178,7 @@ func (d *FavoriteDAO) BatchCheckFavorite(ctx context.Context, userID uint, video
 	}
 
 	var favorites []model.Favorite
-	err := d.db.WithContext(ctx).
+	err := getDB(ctx, d.db).
 		Where("user_id = ? AND video_id IN ?", userID, videoIDs).
 		Find(&favorites).Error
 
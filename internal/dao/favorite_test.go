@@ -0,0 +1,229 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"testing"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"go.uber.org/zap"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/model"
+)
+
+// 点赞并发测试需要 MySQL，未设置 TEST_MYSQL_DSN 时跳过，例如：
+//
+//	TEST_MYSQL_DSN='root:root@tcp(127.0.0.1:3306)/tiny_douyin_test?charset=utf8mb4&parseTime=True&loc=Local' go test ./internal/dao/
+
+const (
+	stormUsers      = 4  // 并发点赞的用户数
+	stormWorkers    = 8  // 每个用户的并发协程数
+	stormOpsPerWork = 25 // 每个协程的点赞/取消点赞次数
+)
+
+// openTestDB 连接测试数据库并迁移点赞相关的表
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("TEST_MYSQL_DSN not set, skipping MySQL integration test")
+	}
+	global.Logger = zap.NewNop()
+
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get sql.DB: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(stormUsers * stormWorkers)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	if err := db.AutoMigrate(&model.Video{}, &model.Favorite{}); err != nil {
+		t.Fatalf("failed to migrate test tables: %v", err)
+	}
+	return db
+}
+
+// createTestVideo 创建一个已就绪的测试视频，测试结束后删除视频及其点赞记录
+func createTestVideo(t *testing.T, db *gorm.DB) *model.Video {
+	t.Helper()
+
+	video := &model.Video{AuthorID: 1, PlayURL: "test.mp4", Status: constant.VideoStatusReady}
+	if err := db.Create(video).Error; err != nil {
+		t.Fatalf("failed to create test video: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Unscoped().Where("video_id = ?", video.ID).Delete(&model.Favorite{})
+		db.Unscoped().Delete(&model.Video{}, video.ID)
+	})
+	return video
+}
+
+// isLockConflict 判断是否为死锁或锁等待超时（事务整体回滚，客户端可重试）
+func isLockConflict(err error) bool {
+	var mysqlErr *mysqldriver.MySQLError
+	return errors.As(err, &mysqlErr) && (mysqlErr.Number == 1213 || mysqlErr.Number == 1205)
+}
+
+func TestFavoriteDAO_RestoresSoftDeletedRow(t *testing.T) {
+	db := openTestDB(t)
+	video := createTestVideo(t, db)
+	favoriteDAO := NewFavoriteDAO(db)
+	ctx := context.Background()
+	userID := video.ID*100 + 1
+
+	steps := []struct {
+		name    string
+		like    bool
+		changed bool
+		active  bool
+	}{
+		{"like", true, true, true},
+		{"like again", true, false, true},
+		{"unlike", false, true, false},
+		{"unlike again", false, false, false},
+		{"like restores soft-deleted row", true, true, true},
+	}
+
+	var rowID uint
+	for _, step := range steps {
+		var changed bool
+		var err error
+		if step.like {
+			changed, err = favoriteDAO.CreateFavorite(ctx, userID, video.ID)
+		} else {
+			changed, err = favoriteDAO.DeleteFavorite(ctx, userID, video.ID)
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if changed != step.changed {
+			t.Errorf("%s: changed = %v, want %v", step.name, changed, step.changed)
+		}
+
+		active, err := favoriteDAO.IsFavorite(ctx, userID, video.ID)
+		if err != nil {
+			t.Fatalf("%s: IsFavorite: %v", step.name, err)
+		}
+		if active != step.active {
+			t.Errorf("%s: IsFavorite = %v, want %v", step.name, active, step.active)
+		}
+
+		// 反复点赞/取消点赞始终复用同一行（不违反 uk_user_video）
+		var rows []model.Favorite
+		db.Unscoped().Where("user_id = ? AND video_id = ?", userID, video.ID).Find(&rows)
+		if len(rows) != 1 {
+			t.Fatalf("%s: got %d favorite rows, want 1", step.name, len(rows))
+		}
+		if rowID == 0 {
+			rowID = rows[0].ID
+		} else if rows[0].ID != rowID {
+			t.Errorf("%s: favorite row id = %d, want %d", step.name, rows[0].ID, rowID)
+		}
+	}
+}
+
+func TestFavoriteDAO_ConcurrentLikeUnlikeStorm(t *testing.T) {
+	db := openTestDB(t)
+	video := createTestVideo(t, db)
+	favoriteDAO := NewFavoriteDAO(db)
+	videoDAO := NewVideoDAO(db)
+	txManager := NewTxManager(db)
+
+	// 每个用户状态真正变化的次数：点赞 +1，取消点赞 -1
+	net := make([]int64, stormUsers)
+	var mu sync.Mutex
+	var conflicts int
+
+	var wg sync.WaitGroup
+	for u := range stormUsers {
+		userID := video.ID*100 + uint(u) + 1
+		for range stormWorkers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range stormOpsPerWork {
+					like := rand.IntN(2) == 0
+					var delta int64
+					err := txManager.Transaction(context.Background(), func(ctx context.Context) error {
+						var changed bool
+						var err error
+						if like {
+							changed, err = favoriteDAO.CreateFavorite(ctx, userID, video.ID)
+							delta = 1
+						} else {
+							changed, err = favoriteDAO.DeleteFavorite(ctx, userID, video.ID)
+							delta = -1
+						}
+						if err != nil || !changed {
+							delta = 0
+							return err
+						}
+						return videoDAO.AddCounts(ctx, video.ID, map[string]int64{"favorite_count": delta})
+					})
+
+					mu.Lock()
+					switch {
+					case err == nil:
+						net[u] += delta
+					case isLockConflict(err):
+						conflicts++
+					default:
+						t.Errorf("user %d: unexpected error: %v", userID, err)
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+	t.Logf("lock conflicts rolled back: %d", conflicts)
+
+	var active int64
+	for u := range stormUsers {
+		userID := video.ID*100 + uint(u) + 1
+
+		var rows int64
+		db.Unscoped().Model(&model.Favorite{}).Where("user_id = ? AND video_id = ?", userID, video.ID).Count(&rows)
+		if rows > 1 {
+			t.Errorf("user %d: got %d favorite rows, want at most 1", userID, rows)
+		}
+
+		isFavorite, err := favoriteDAO.IsFavorite(context.Background(), userID, video.ID)
+		if err != nil {
+			t.Fatalf("IsFavorite: %v", err)
+		}
+		want := int64(0)
+		if isFavorite {
+			want = 1
+			active++
+		}
+		// 只有真正改变状态的请求才计数：净变化必须与最终点赞状态一致
+		if net[u] != want {
+			t.Errorf("user %d: net state changes = %d, want %d", userID, net[u], want)
+		}
+	}
+
+	var got model.Video
+	if err := db.Select("favorite_count").First(&got, video.ID).Error; err != nil {
+		t.Fatalf("failed to load video: %v", err)
+	}
+	if got.FavoriteCount != active {
+		t.Errorf("favorite_count = %d, want %d active favorites", got.FavoriteCount, active)
+	}
+}
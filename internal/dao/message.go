@@ -2,23 +2,49 @@ package dao
 
 import (
 	"context"
+	"errors"
+	"sort"
 	"time"
 
-	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
 	"github.com/wangn-tech/tiny-douyin/internal/global"
 	"github.com/wangn-tech/tiny-douyin/internal/model"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // IMessageDAO 消息数据访问接口
 type IMessageDAO interface {
 	// CreateMessage 创建消息记录
 	CreateMessage(ctx context.Context, message *model.Message) error
-	// GetChatMessages 获取两个用户之间的聊天记录
-	GetChatMessages(ctx context.Context, userID1, userID2 uint, preMsgTime int64) ([]*model.Message, error)
-	// GetLatestMessage 获取两个用户之间的最新一条消息
-	GetLatestMessage(ctx context.Context, userID1, userID2 uint) (*model.Message, error)
+	// GetChatMessages 按消息 ID 游标获取 userID 可见的与 peerID 之间的聊天记录（不包含 userID 已删除的消息）
+	GetChatMessages(ctx context.Context, userID, peerID uint, query *MessageQuery) ([]*model.Message, error)
+	// SyncMessages 按 ID 正序获取 userID 所有会话中 ID 大于 afterID 的可见消息（不包含 userID 已删除的消息）
+	SyncMessages(ctx context.Context, userID, afterID uint, limit int) ([]*model.Message, error)
+	// GetMessageByID 根据 ID 获取消息，在事务中调用时锁定该消息
+	GetMessageByID(ctx context.Context, id uint) (*model.Message, error)
+	// GetMessagesByIDs 批量获取消息
+	GetMessagesByIDs(ctx context.Context, ids []uint) ([]*model.Message, error)
+	// CountMessagesAfter 统计 fromUserID 发给 toUserID 且 ID 大于 afterID 的消息数（计算未读数）
+	CountMessagesAfter(ctx context.Context, fromUserID, toUserID, afterID uint) (int64, error)
+	// RecallMessage 撤回消息：清空内容和附加数据并记录撤回时间
+	RecallMessage(ctx context.Context, id uint, recalledAt time.Time) error
+	// HideMessage 对用户隐藏消息（仅删除该用户一侧）
+	HideMessage(ctx context.Context, message *model.Message, userID uint) error
+	// ScanOldestMessages 按 ID 顺序获取最早的一批消息（包括已软删除的消息）
+	ScanOldestMessages(ctx context.Context, limit int) ([]*model.Message, error)
+	// PurgeMessages 物理删除消息
+	PurgeMessages(ctx context.Context, ids []uint) error
+}
+
+// MessageQuery 聊天记录查询条件，消息 ID 自增，作为分页和同步的游标
+type MessageQuery struct {
+	BeforeID   uint  // 只查询 ID 小于该值的消息（0 表示不限）
+	AfterID    uint  // 只查询 ID 大于该值的消息（0 表示不限）
+	ClearedID  uint  // 清空会话时的最新消息ID，不返回此前的消息
+	PreMsgTime int64 // 只查询发送时间早于该 Unix 时间戳（秒）的消息（兼容旧客户端，0 表示不限）
+	Ascending  bool  // 是否按 ID 正序返回（默认倒序，即最新的在前）
+	Limit      int   // 返回数量
 }
 
 // MessageDAO 消息数据访问实现
@@ -35,7 +61,7 @@ func NewMessageDAO(db *gorm.DB) IMessageDAO {
 
 // CreateMessage 创建消息记录
 func (d *MessageDAO) CreateMessage(ctx context.Context, message *model.Message) error {
-	err := d.db.WithContext(ctx).Create(message).Error
+	err := getDB(ctx, d.db).Create(message).Error
 
 	if err != nil {
 		global.Logger.Error("dao.CreateMessage.failed",
@@ -48,31 +74,199 @@ func (d *MessageDAO) CreateMessage(ctx context.Context, message *model.Message)
 	return err
 }
 
-// GetChatMessages 获取两个用户之间的聊天记录（双向查询）
-// preMsgTime: Unix时间戳（秒），查询比这个时间更早的消息（用于分页）
-func (d *MessageDAO) GetChatMessages(ctx context.Context, userID1, userID2 uint, preMsgTime int64) ([]*model.Message, error) {
+// GetChatMessages 按消息 ID 游标获取 userID 可见的与 peerID 之间的聊天记录（双向查询）
+// 自己发出的消息看发送者一侧的删除标记，收到的消息看接收者一侧的删除标记
+func (d *MessageDAO) GetChatMessages(ctx context.Context, userID, peerID uint, query *MessageQuery) ([]*model.Message, error) {
+	messages, err := findMessages(query,
+		getDB(ctx, d.db).Where("from_user_id = ? AND to_user_id = ? AND from_deleted = ?", userID, peerID, false),
+		getDB(ctx, d.db).Where("from_user_id = ? AND to_user_id = ? AND to_deleted = ?", peerID, userID, false),
+	)
+	if err != nil {
+		global.Logger.Error("dao.GetChatMessages.failed",
+			zap.Uint("user_id", userID),
+			zap.Uint("peer_id", peerID),
+			zap.Uint("before_id", query.BeforeID),
+			zap.Uint("after_id", query.AfterID),
+			zap.Int64("pre_msg_time", query.PreMsgTime),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// SyncMessages 按 ID 正序获取 userID 所有会话中 ID 大于 afterID 的可见消息
+func (d *MessageDAO) SyncMessages(ctx context.Context, userID, afterID uint, limit int) ([]*model.Message, error) {
+	query := &MessageQuery{AfterID: afterID, Ascending: true, Limit: limit}
+	messages, err := findMessages(query,
+		getDB(ctx, d.db).Where("from_user_id = ? AND from_deleted = ?", userID, false),
+		getDB(ctx, d.db).Where("to_user_id = ? AND to_deleted = ?", userID, false),
+	)
+	if err != nil {
+		global.Logger.Error("dao.SyncMessages.failed",
+			zap.Uint("user_id", userID),
+			zap.Uint("after_id", afterID),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// findMessages 分别查询发出和收到的消息，按 ID 合并后返回前 query.Limit 条
+// 每个方向都能沿索引按 ID 顺序扫描并在 Limit 处停止；用 OR 合并两个方向时需要取出两个范围的全部消息再排序
+func findMessages(query *MessageQuery, directions ...*gorm.DB) ([]*model.Message, error) {
+	order := "id DESC"
+	if query.Ascending {
+		order = "id ASC"
+	}
+
+	var merged []*model.Message
+	for _, db := range directions {
+		if query.BeforeID > 0 {
+			db = db.Where("id < ?", query.BeforeID)
+		}
+		if afterID := max(query.AfterID, query.ClearedID); afterID > 0 {
+			db = db.Where("id > ?", afterID)
+		}
+		if query.PreMsgTime > 0 {
+			db = db.Where("created_at < ?", time.Unix(query.PreMsgTime, 0))
+		}
+
+		var messages []*model.Message
+		if err := db.Order(order).Limit(query.Limit).Find(&messages).Error; err != nil {
+			return nil, err
+		}
+		merged = append(merged, messages...)
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		if query.Ascending {
+			return merged[i].ID < merged[j].ID
+		}
+		return merged[i].ID > merged[j].ID
+	})
+	if len(merged) > query.Limit {
+		merged = merged[:query.Limit]
+	}
+	return merged, nil
+}
+
+// GetMessageByID 根据 ID 获取消息
+func (d *MessageDAO) GetMessageByID(ctx context.Context, id uint) (*model.Message, error) {
+	query := getDB(ctx, d.db)
+	if inTx(ctx) {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var message model.Message
+	if err := query.First(&message, id).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			global.Logger.Error("dao.GetMessageByID.failed",
+				zap.Uint("message_id", id),
+				zap.Error(err),
+			)
+		}
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+// GetMessagesByIDs 批量获取消息
+func (d *MessageDAO) GetMessagesByIDs(ctx context.Context, ids []uint) ([]*model.Message, error) {
+	if len(ids) == 0 {
+		return []*model.Message{}, nil
+	}
+
 	var messages []*model.Message
+	if err := getDB(ctx, d.db).Where("id IN ?", ids).Find(&messages).Error; err != nil {
+		global.Logger.Error("dao.GetMessagesByIDs.failed",
+			zap.Int("count", len(ids)),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// CountMessagesAfter 统计 fromUserID 发给 toUserID 且 ID 大于 afterID 的消息数
+func (d *MessageDAO) CountMessagesAfter(ctx context.Context, fromUserID, toUserID, afterID uint) (int64, error) {
+	var count int64
+	err := getDB(ctx, d.db).Model(&model.Message{}).
+		Where("from_user_id = ? AND to_user_id = ? AND id > ?", fromUserID, toUserID, afterID).
+		Count(&count).Error
 
-	query := d.db.WithContext(ctx).
-		Where("(from_user_id = ? AND to_user_id = ?) OR (from_user_id = ? AND to_user_id = ?)",
-			userID1, userID2, userID2, userID1)
+	if err != nil {
+		global.Logger.Error("dao.CountMessagesAfter.failed",
+			zap.Uint("from_user_id", fromUserID),
+			zap.Uint("to_user_id", toUserID),
+			zap.Uint("after_id", afterID),
+			zap.Error(err),
+		)
+		return 0, err
+	}
+
+	return count, nil
+}
 
-	// 如果提供了 preMsgTime，查询比这个时间更早的消息
-	if preMsgTime > 0 {
-		preTime := time.Unix(preMsgTime, 0)
-		query = query.Where("created_at < ?", preTime)
+// RecallMessage 撤回消息
+func (d *MessageDAO) RecallMessage(ctx context.Context, id uint, recalledAt time.Time) error {
+	err := getDB(ctx, d.db).Model(&model.Message{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]any{
+			"content":     "",
+			"payload":     "",
+			"recalled_at": recalledAt,
+		}).Error
+
+	if err != nil {
+		global.Logger.Error("dao.RecallMessage.failed",
+			zap.Uint("message_id", id),
+			zap.Error(err),
+		)
 	}
 
-	err := query.
-		Order("created_at DESC").
-		Limit(constant.MessagePageSize).
+	return err
+}
+
+// HideMessage 对用户隐藏消息
+func (d *MessageDAO) HideMessage(ctx context.Context, message *model.Message, userID uint) error {
+	column := "to_deleted"
+	if userID == message.FromUserID {
+		column = "from_deleted"
+	}
+
+	err := getDB(ctx, d.db).Model(&model.Message{}).
+		Where("id = ?", message.ID).
+		UpdateColumn(column, true).Error
+
+	if err != nil {
+		global.Logger.Error("dao.HideMessage.failed",
+			zap.Uint("message_id", message.ID),
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+	}
+
+	return err
+}
+
+// ScanOldestMessages 按 ID 顺序获取最早的一批消息
+// 消息 ID 与发送时间同序，调用方按时间过滤即可，无需 created_at 索引
+func (d *MessageDAO) ScanOldestMessages(ctx context.Context, limit int) ([]*model.Message, error) {
+	var messages []*model.Message
+	err := getDB(ctx, d.db).Unscoped().
+		Order("id ASC").
+		Limit(limit).
 		Find(&messages).Error
 
 	if err != nil {
-		global.Logger.Error("dao.GetChatMessages.failed",
-			zap.Uint("user_id_1", userID1),
-			zap.Uint("user_id_2", userID2),
-			zap.Int64("pre_msg_time", preMsgTime),
+		global.Logger.Error("dao.ScanOldestMessages.failed",
+			zap.Int("limit", limit),
 			zap.Error(err),
 		)
 		return nil, err
@@ -81,27 +275,22 @@ func (d *MessageDAO) GetChatMessages(ctx context.Context, userID1, userID2 uint,
 	return messages, nil
 }
 
-// GetLatestMessage 获取两个用户之间的最新一条消息
-func (d *MessageDAO) GetLatestMessage(ctx context.Context, userID1, userID2 uint) (*model.Message, error) {
-	var message model.Message
+// PurgeMessages 物理删除消息
+func (d *MessageDAO) PurgeMessages(ctx context.Context, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
 
-	err := d.db.WithContext(ctx).
-		Where("(from_user_id = ? AND to_user_id = ?) OR (from_user_id = ? AND to_user_id = ?)",
-			userID1, userID2, userID2, userID1).
-		Order("created_at DESC").
-		First(&message).Error
+	err := getDB(ctx, d.db).Unscoped().
+		Where("id IN ?", ids).
+		Delete(&model.Message{}).Error
 
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, nil
-		}
-		global.Logger.Error("dao.GetLatestMessage.failed",
-			zap.Uint("user_id_1", userID1),
-			zap.Uint("user_id_2", userID2),
+		global.Logger.Error("dao.PurgeMessages.failed",
+			zap.Int("count", len(ids)),
 			zap.Error(err),
 		)
-		return nil, err
 	}
 
-	return &message, nil
+	return err
 }
@@ -0,0 +1,219 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// INotificationDAO 站内通知数据访问接口
+type INotificationDAO interface {
+	// CreateNotification 创建通知
+	CreateNotification(ctx context.Context, notification *model.Notification) error
+	// GetUnreadGroup 获取用户指定聚合键的未读通知，不存在时返回 nil
+	// 在事务中调用时锁定该通知，避免并发聚合丢失更新
+	GetUnreadGroup(ctx context.Context, userID uint, groupKey string) (*model.Notification, error)
+	// DeleteNotification 删除通知
+	DeleteNotification(ctx context.Context, id uint) error
+	// SetGroupID 设置通知的聚合组ID
+	SetGroupID(ctx context.Context, id, groupID uint) error
+	// AddGroupActor 记录聚合组的触发用户，返回是否为新用户（已记录过时返回 false）
+	AddGroupActor(ctx context.Context, groupID, actorID uint) (bool, error)
+	// ListNotifications 按 ID 倒序分页查询通知，cursor 为上一页最后一条的 ID（0 表示第一页），types 为空时不过滤类型
+	ListNotifications(ctx context.Context, userID, cursor uint, limit int, types []string) ([]*model.Notification, error)
+	// CountUnread 按类型统计未读通知数
+	CountUnread(ctx context.Context, userID uint) (map[string]int64, error)
+	// MarkRead 标记通知已读，id 为 0 时标记全部（types 为空时不过滤类型），返回更新的通知数
+	// 同时删除已读聚合组的触发用户记录，需在事务中调用
+	MarkRead(ctx context.Context, userID, id uint, types []string) (int64, error)
+}
+
+// NotificationDAO 站内通知数据访问实现
+type NotificationDAO struct {
+	db *gorm.DB
+}
+
+// NewNotificationDAO 创建 NotificationDAO 实例
+func NewNotificationDAO(db *gorm.DB) INotificationDAO {
+	return &NotificationDAO{db: db}
+}
+
+// CreateNotification 创建通知
+func (d *NotificationDAO) CreateNotification(ctx context.Context, notification *model.Notification) error {
+	if err := getDB(ctx, d.db).Create(notification).Error; err != nil {
+		global.Logger.Error("dao.CreateNotification.db_error",
+			zap.Uint("user_id", notification.UserID),
+			zap.String("type", notification.Type),
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
+
+// GetUnreadGroup 获取用户指定聚合键的未读通知
+func (d *NotificationDAO) GetUnreadGroup(ctx context.Context, userID uint, groupKey string) (*model.Notification, error) {
+	query := getDB(ctx, d.db).Where("user_id = ? AND group_key = ?", userID, groupKey)
+	if inTx(ctx) {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var notification model.Notification
+	err := query.First(&notification).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		global.Logger.Error("dao.GetUnreadNotificationGroup.db_error",
+			zap.Uint("user_id", userID),
+			zap.String("group_key", groupKey),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// DeleteNotification 删除通知
+func (d *NotificationDAO) DeleteNotification(ctx context.Context, id uint) error {
+	if err := getDB(ctx, d.db).Delete(&model.Notification{}, id).Error; err != nil {
+		global.Logger.Error("dao.DeleteNotification.db_error",
+			zap.Uint("id", id),
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
+
+// SetGroupID 设置通知的聚合组ID
+func (d *NotificationDAO) SetGroupID(ctx context.Context, id, groupID uint) error {
+	err := getDB(ctx, d.db).
+		Model(&model.Notification{}).
+		Where("id = ?", id).
+		Update("group_id", groupID).Error
+	if err != nil {
+		global.Logger.Error("dao.SetNotificationGroupID.db_error",
+			zap.Uint("id", id),
+			zap.Uint("group_id", groupID),
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
+
+// AddGroupActor 记录聚合组的触发用户（唯一键冲突时不插入）
+func (d *NotificationDAO) AddGroupActor(ctx context.Context, groupID, actorID uint) (bool, error) {
+	result := getDB(ctx, d.db).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.NotificationActor{GroupID: groupID, ActorID: actorID})
+	if result.Error != nil {
+		global.Logger.Error("dao.AddNotificationGroupActor.db_error",
+			zap.Uint("group_id", groupID),
+			zap.Uint("actor_id", actorID),
+			zap.Error(result.Error),
+		)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListNotifications 按 ID 倒序分页查询通知
+func (d *NotificationDAO) ListNotifications(ctx context.Context, userID, cursor uint, limit int, types []string) ([]*model.Notification, error) {
+	query := getDB(ctx, d.db).Where("user_id = ?", userID)
+	if cursor > 0 {
+		query = query.Where("id < ?", cursor)
+	}
+	if len(types) > 0 {
+		query = query.Where("type IN ?", types)
+	}
+
+	var notifications []*model.Notification
+	if err := query.Order("id DESC").Limit(limit).Find(&notifications).Error; err != nil {
+		global.Logger.Error("dao.ListNotifications.db_error",
+			zap.Uint("user_id", userID),
+			zap.Uint("cursor", cursor),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// CountUnread 按类型统计未读通知数
+func (d *NotificationDAO) CountUnread(ctx context.Context, userID uint) (map[string]int64, error) {
+	var rows []struct {
+		Type  string
+		Count int64
+	}
+	err := getDB(ctx, d.db).
+		Model(&model.Notification{}).
+		Select("type, COUNT(*) AS count").
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Group("type").
+		Scan(&rows).Error
+	if err != nil {
+		global.Logger.Error("dao.CountUnreadNotifications.db_error",
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Type] = row.Count
+	}
+	return counts, nil
+}
+
+// MarkRead 标记通知已读（同时清空聚合键，之后的同类操作生成新通知）
+func (d *NotificationDAO) MarkRead(ctx context.Context, userID, id uint, types []string) (int64, error) {
+	unread := func() *gorm.DB {
+		query := getDB(ctx, d.db).
+			Model(&model.Notification{}).
+			Where("user_id = ? AND is_read = ?", userID, false)
+		if id > 0 {
+			query = query.Where("id = ?", id)
+		}
+		if len(types) > 0 {
+			query = query.Where("type IN ?", types)
+		}
+		return query
+	}
+
+	// 已读的聚合组不再合并，删除其触发用户记录
+	err := getDB(ctx, d.db).
+		Where("group_id IN (?)", unread().Where("group_id > ?", 0).Select("group_id")).
+		Delete(&model.NotificationActor{}).Error
+	if err != nil {
+		global.Logger.Error("dao.DeleteNotificationGroupActors.db_error",
+			zap.Uint("user_id", userID),
+			zap.Uint("id", id),
+			zap.Error(err),
+		)
+		return 0, err
+	}
+
+	result := unread().Updates(map[string]any{
+		"is_read":   true,
+		"group_key": nil,
+		"read_at":   time.Now(),
+	})
+	if result.Error != nil {
+		global.Logger.Error("dao.MarkNotificationsRead.db_error",
+			zap.Uint("user_id", userID),
+			zap.Uint("id", id),
+			zap.Error(result.Error),
+		)
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,161 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IOutboxDAO 事务性发件箱数据访问接口
+type IOutboxDAO interface {
+	// CreateMessage 写入发件箱消息（需与业务变更在同一事务中调用）
+	CreateMessage(ctx context.Context, msg *model.OutboxMessage) error
+	// ListDueMessages 按写入顺序查询到期的待投递消息（包括租约已过期的投递中消息）
+	// 在事务中调用时锁定返回的行，并跳过其他实例已锁定的行
+	ListDueMessages(ctx context.Context, now time.Time, limit int) ([]*model.OutboxMessage, error)
+	// ClaimMessages 将消息标记为投递中，leaseUntil 之前不再被认领
+	ClaimMessages(ctx context.Context, ids []uint, leaseUntil time.Time) error
+	// MarkSent 标记消息已投递
+	MarkSent(ctx context.Context, ids []uint, sentAt time.Time) error
+	// MarkRetry 记录投递失败，nextAttemptAt 之后重试（消息已不在投递中时忽略）
+	MarkRetry(ctx context.Context, id uint, lastError string, nextAttemptAt time.Time) error
+	// CleanupSent 清理指定时间之前已投递的消息
+	CleanupSent(ctx context.Context, before time.Time) error
+}
+
+// OutboxDAO 事务性发件箱数据访问实现
+type OutboxDAO struct {
+	db *gorm.DB
+}
+
+// NewOutboxDAO 创建 OutboxDAO 实例
+func NewOutboxDAO(db *gorm.DB) IOutboxDAO {
+	return &OutboxDAO{db: db}
+}
+
+// CreateMessage 写入发件箱消息
+func (d *OutboxDAO) CreateMessage(ctx context.Context, msg *model.OutboxMessage) error {
+	if err := getDB(ctx, d.db).Create(msg).Error; err != nil {
+		global.Logger.Error("dao.CreateOutboxMessage.db_error",
+			zap.String("topic", msg.Topic),
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
+
+// ListDueMessages 按写入顺序查询到期的待投递消息
+// 投递中的消息 next_attempt_at 为租约到期时间，租约过期（认领它的 relay 已崩溃）后重新到期
+func (d *OutboxDAO) ListDueMessages(ctx context.Context, now time.Time, limit int) ([]*model.OutboxMessage, error) {
+	query := getDB(ctx, d.db).
+		Where("status IN ? AND next_attempt_at <= ?",
+			[]string{constant.OutboxStatusPending, constant.OutboxStatusInflight}, now).
+		Order("id ASC").
+		Limit(limit)
+	if inTx(ctx) {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+	}
+
+	var messages []*model.OutboxMessage
+	if err := query.Find(&messages).Error; err != nil {
+		global.Logger.Error("dao.ListDueOutboxMessages.db_error",
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	return messages, nil
+}
+
+// ClaimMessages 将消息标记为投递中
+func (d *OutboxDAO) ClaimMessages(ctx context.Context, ids []uint, leaseUntil time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	err := getDB(ctx, d.db).
+		Model(&model.OutboxMessage{}).
+		Where("id IN ?", ids).
+		Updates(map[string]any{
+			"status":          constant.OutboxStatusInflight,
+			"next_attempt_at": leaseUntil,
+		}).Error
+	if err != nil {
+		global.Logger.Error("dao.ClaimOutboxMessages.db_error",
+			zap.Int("count", len(ids)),
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
+
+// MarkSent 标记消息已投递
+func (d *OutboxDAO) MarkSent(ctx context.Context, ids []uint, sentAt time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	err := getDB(ctx, d.db).
+		Model(&model.OutboxMessage{}).
+		Where("id IN ?", ids).
+		Updates(map[string]any{
+			"status":  constant.OutboxStatusSent,
+			"sent_at": sentAt,
+		}).Error
+	if err != nil {
+		global.Logger.Error("dao.MarkOutboxSent.db_error",
+			zap.Int("count", len(ids)),
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
+
+// MarkRetry 记录投递失败
+// 只更新仍在投递中的消息：租约过期后消息可能已被其他 relay 重新认领并投递成功
+func (d *OutboxDAO) MarkRetry(ctx context.Context, id uint, lastError string, nextAttemptAt time.Time) error {
+	err := getDB(ctx, d.db).
+		Model(&model.OutboxMessage{}).
+		Where("id = ? AND status = ?", id, constant.OutboxStatusInflight).
+		Updates(map[string]any{
+			"status":          constant.OutboxStatusPending,
+			"attempts":        gorm.Expr("attempts + 1"),
+			"last_error":      lastError,
+			"next_attempt_at": nextAttemptAt,
+		}).Error
+	if err != nil {
+		global.Logger.Error("dao.MarkOutboxRetry.db_error",
+			zap.Uint("id", id),
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
+
+// CleanupSent 清理指定时间之前已投递的消息
+func (d *OutboxDAO) CleanupSent(ctx context.Context, before time.Time) error {
+	result := getDB(ctx, d.db).
+		Where("status = ? AND sent_at < ?", constant.OutboxStatusSent, before).
+		Delete(&model.OutboxMessage{})
+
+	if result.Error != nil {
+		global.Logger.Error("dao.CleanupOutboxSent.db_error",
+			zap.Time("before", before),
+			zap.Error(result.Error),
+		)
+		return result.Error
+	}
+
+	global.Logger.Info("dao.CleanupOutboxSent.success",
+		zap.Time("before", before),
+		zap.Int64("rows_affected", result.RowsAffected),
+	)
+	return nil
+}
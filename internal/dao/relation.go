@@ -39,7 +39,7 @@ func NewRelationDAO(db *gorm.DB) IRelationDAO {
 
 // CreateRelation 创建关注关系
 func (d *RelationDAO) CreateRelation(ctx context.Context, relation *model.Relation) error {
-	err := d.db.WithContext(ctx).Create(relation).Error
+	err := getDB(ctx, d.db).Create(relation).Error
 	if err != nil {
 		global.Logger.Error("dao.CreateRelation.db_error",
 			zap.Uint("follower_id", relation.FollowerID),
@@ -60,7 +60,7 @@ func (d *RelationDAO) CreateRelation(ctx context.Context, relation *model.Relati
 
 // DeleteRelation 删除关注关系（软删除）
 func (d *RelationDAO) DeleteRelation(ctx context.Context, followerID, followeeID uint) error {
-	result := d.db.WithContext(ctx).
+	result := getDB(ctx, d.db).
 		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
 		Delete(&model.Relation{})
 
@@ -85,7 +85,7 @@ func (d *RelationDAO) DeleteRelation(ctx context.Context, followerID, followeeID
 // IsFollowing 判断是否已关注
 func (d *RelationDAO) IsFollowing(ctx context.Context, followerID, followeeID uint) (bool, error) {
 	var count int64
-	err := d.db.WithContext(ctx).
+	err := getDB(ctx, d.db).
 		Model(&model.Relation{}).
 		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
 		Count(&count).Error
@@ -105,7 +105,7 @@ func (d *RelationDAO) IsFollowing(ctx context.Context, followerID, followeeID ui
 // GetFollowList 获取关注列表（某用户关注的所有用户ID）
 func (d *RelationDAO) GetFollowList(ctx context.Context, userID uint) ([]uint, error) {
 	var relations []*model.Relation
-	err := d.db.WithContext(ctx).
+	err := getDB(ctx, d.db).
 		Select("followee_id").
 		Where("follower_id = ?", userID).
 		Find(&relations).Error
@@ -134,7 +134,7 @@ func (d *RelationDAO) GetFollowList(ctx context.Context, userID uint) ([]uint, e
 // GetFollowerList 获取粉丝列表（关注某用户的所有用户ID）
 func (d *RelationDAO) GetFollowerList(ctx context.Context, userID uint) ([]uint, error) {
 	var relations []*model.Relation
-	err := d.db.WithContext(ctx).
+	err := getDB(ctx, d.db).
 		Select("follower_id").
 		Where("followee_id = ?", userID).
 		Find(&relations).Error
@@ -174,7 +174,7 @@ func (d *RelationDAO) GetFriendList(ctx context.Context, userID uint) ([]uint, e
 
 	// 查询这些人中谁也关注了当前用户（互相关注）
 	var relations []*model.Relation
-	err = d.db.WithContext(ctx).
+	err = getDB(ctx, d.db).
 		Select("follower_id").
 		Where("follower_id IN ? AND followee_id = ?", followList, userID).
 		Find(&relations).Error
@@ -207,7 +207,7 @@ func (d *RelationDAO) BatchCheckFollowing(ctx context.Context, followerID uint,
 	}
 
 	var relations []*model.Relation
-	err := d.db.WithContext(ctx).
+	err := getDB(ctx, d.db).
 		Select("followee_id").
 		Where("follower_id = ? AND followee_id IN ?", followerID, followeeIDs).
 		Find(&relations).Error
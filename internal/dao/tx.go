@@ -0,0 +1,78 @@
+package dao
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// txKey 事务在 context 中的键
+type txKey struct{}
+
+// txState 事务上下文：当前事务及提交后需要执行的回调
+type txState struct {
+	tx          *gorm.DB
+	afterCommit []func()
+}
+
+// ITxManager 事务管理接口（Unit of Work）
+type ITxManager interface {
+	// Transaction 在事务中执行 fn，fn 内通过 ctx 调用的 DAO 方法都会使用同一个事务
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// TxManager 基于 GORM 的事务管理实现
+type TxManager struct {
+	db *gorm.DB
+}
+
+// NewTxManager 创建 TxManager 实例
+func NewTxManager(db *gorm.DB) ITxManager {
+	return &TxManager{db: db}
+}
+
+// Transaction 在事务中执行 fn
+// 如果 ctx 中已存在事务，则直接复用（嵌套调用加入外层事务）
+func (m *TxManager) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*txState); ok {
+		return fn(ctx)
+	}
+
+	state := &txState{}
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		state.tx = tx
+		return fn(context.WithValue(ctx, txKey{}, state))
+	})
+	if err != nil {
+		return err
+	}
+
+	// 事务提交成功后再执行回调（如缓存失效），避免其他请求读到未提交的数据
+	for _, hook := range state.afterCommit {
+		hook()
+	}
+	return nil
+}
+
+// AfterCommit 注册事务提交后执行的回调；不在事务中时立即执行
+func AfterCommit(ctx context.Context, fn func()) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		state.afterCommit = append(state.afterCommit, fn)
+		return
+	}
+	fn()
+}
+
+// inTx 判断 ctx 是否处于事务中
+func inTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*txState)
+	return ok
+}
+
+// getDB 从 ctx 中获取数据库连接：存在事务时返回事务，否则返回默认连接
+func getDB(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
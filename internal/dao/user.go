@@ -16,14 +16,8 @@ type IUserDAO interface {
 	GetUserByID(ctx context.Context, id uint) (*model.User, error)
 	ExistsUsername(ctx context.Context, username string) (bool, error)
 	GetUsersByIDs(ctx context.Context, userIDs []uint) ([]*model.User, error)
-	// IncrementFollowCount 增加用户关注数
-	IncrementFollowCount(ctx context.Context, userID uint) error
-	// DecrementFollowCount 减少用户关注数
-	DecrementFollowCount(ctx context.Context, userID uint) error
-	// IncrementFollowerCount 增加用户粉丝数
-	IncrementFollowerCount(ctx context.Context, userID uint) error
-	// DecrementFollowerCount 减少用户粉丝数
-	DecrementFollowerCount(ctx context.Context, userID uint) error
+	// AddFollowCounts 按增量批量更新用户计数（follow_count、follower_count）
+	AddFollowCounts(ctx context.Context, userID uint, deltas map[string]int64) error
 }
 
 // UserDAO 用户数据访问实现
@@ -40,7 +34,7 @@ func NewUserDAO(db *gorm.DB) IUserDAO {
 
 // CreateUser 创建用户
 func (d *UserDAO) CreateUser(ctx context.Context, user *model.User) error {
-	err := d.db.WithContext(ctx).Create(user).Error
+	err := getDB(ctx, d.db).Create(user).Error
 
 	if err != nil {
 		global.Logger.Error("dao.CreateUser.failed",
@@ -55,7 +49,7 @@ func (d *UserDAO) CreateUser(ctx context.Context, user *model.User) error {
 // ExistsUsername 检查用户名是否存在（高性能查询，只查 ID）
 func (d *UserDAO) ExistsUsername(ctx context.Context, username string) (bool, error) {
 	var count int64
-	err := d.db.WithContext(ctx).
+	err := getDB(ctx, d.db).
 		Model(&model.User{}).
 		Where("username = ?", username).
 		Count(&count).Error
@@ -75,7 +69,7 @@ func (d *UserDAO) ExistsUsername(ctx context.Context, username string) (bool, er
 func (d *UserDAO) GetUserByUsername(ctx context.Context, username string) (*model.User, error) {
 	var user model.User
 
-	err := d.db.WithContext(ctx).
+	err := getDB(ctx, d.db).
 		Where("username = ?", username).
 		First(&user).Error
 
@@ -97,7 +91,7 @@ func (d *UserDAO) GetUserByUsername(ctx context.Context, username string) (*mode
 func (d *UserDAO) GetUserByID(ctx context.Context, id uint) (*model.User, error) {
 	var user model.User
 
-	err := d.db.WithContext(ctx).First(&user, id).Error
+	err := getDB(ctx, d.db).First(&user, id).Error
 
 	// 只记录真正的数据库错误
 	if err != nil && err != gorm.ErrRecordNotFound {
@@ -120,7 +114,7 @@ func (d *UserDAO) GetUsersByIDs(ctx context.Context, userIDs []uint) ([]*model.U
 	}
 
 	var users []*model.User
-	err := d.db.WithContext(ctx).
+	err := getDB(ctx, d.db).
 		Where("id IN ?", userIDs).
 		Find(&users).Error
 
@@ -139,90 +133,26 @@ func (d *UserDAO) GetUsersByIDs(ctx context.Context, userIDs []uint) ([]*model.U
 	return users, nil
 }
 
-// IncrementFollowCount 增加用户关注数
-func (d *UserDAO) IncrementFollowCount(ctx context.Context, userID uint) error {
-	err := d.db.WithContext(ctx).
-		Model(&model.User{}).
-		Where("id = ?", userID).
-		UpdateColumn("follow_count", gorm.Expr("follow_count + ?", 1)).Error
-
-	if err != nil {
-		global.Logger.Error("dao.IncrementFollowCount.db_error",
-			zap.Uint("user_id", userID),
-			zap.Error(err),
-		)
-		return err
+// AddFollowCounts 按增量批量更新用户计数（follow_count、follower_count），结果不小于 0
+func (d *UserDAO) AddFollowCounts(ctx context.Context, userID uint, deltas map[string]int64) error {
+	updates := buildCountUpdates(deltas, "follow_count", "follower_count")
+	if len(updates) == 0 {
+		return nil
 	}
 
-	global.Logger.Info("dao.IncrementFollowCount.success",
-		zap.Uint("user_id", userID),
-	)
-
-	return nil
-}
-
-// DecrementFollowCount 减少用户关注数
-func (d *UserDAO) DecrementFollowCount(ctx context.Context, userID uint) error {
-	err := d.db.WithContext(ctx).
-		Model(&model.User{}).
-		Where("id = ? AND follow_count > 0", userID).
-		UpdateColumn("follow_count", gorm.Expr("follow_count - ?", 1)).Error
-
-	if err != nil {
-		global.Logger.Error("dao.DecrementFollowCount.db_error",
-			zap.Uint("user_id", userID),
-			zap.Error(err),
-		)
-		return err
-	}
-
-	global.Logger.Info("dao.DecrementFollowCount.success",
-		zap.Uint("user_id", userID),
-	)
-
-	return nil
-}
-
-// IncrementFollowerCount 增加用户粉丝数
-func (d *UserDAO) IncrementFollowerCount(ctx context.Context, userID uint) error {
-	err := d.db.WithContext(ctx).
+	err := getDB(ctx, d.db).
 		Model(&model.User{}).
 		Where("id = ?", userID).
-		UpdateColumn("follower_count", gorm.Expr("follower_count + ?", 1)).Error
+		UpdateColumns(updates).Error
 
 	if err != nil {
-		global.Logger.Error("dao.IncrementFollowerCount.db_error",
+		global.Logger.Error("dao.AddFollowCounts.db_error",
 			zap.Uint("user_id", userID),
+			zap.Any("deltas", deltas),
 			zap.Error(err),
 		)
 		return err
 	}
 
-	global.Logger.Info("dao.IncrementFollowerCount.success",
-		zap.Uint("user_id", userID),
-	)
-
-	return nil
-}
-
-// DecrementFollowerCount 减少用户粉丝数
-func (d *UserDAO) DecrementFollowerCount(ctx context.Context, userID uint) error {
-	err := d.db.WithContext(ctx).
-		Model(&model.User{}).
-		Where("id = ? AND follower_count > 0", userID).
-		UpdateColumn("follower_count", gorm.Expr("follower_count - ?", 1)).Error
-
-	if err != nil {
-		global.Logger.Error("dao.DecrementFollowerCount.db_error",
-			zap.Uint("user_id", userID),
-			zap.Error(err),
-		)
-		return err
-	}
-
-	global.Logger.Info("dao.DecrementFollowerCount.success",
-		zap.Uint("user_id", userID),
-	)
-
 	return nil
 }
@@ -0,0 +1,68 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/model"
+)
+
+// UserCacheDAO 带 Redis 缓存的用户数据访问实现（Cache-Aside 装饰器）
+// 未覆盖的方法直接透传给底层 IUserDAO
+type UserCacheDAO struct {
+	IUserDAO
+	rdb   *redis.Client
+	group singleflight.Group
+}
+
+// NewUserCacheDAO 创建带缓存的 UserDAO 实例
+func NewUserCacheDAO(db *gorm.DB, rdb *redis.Client) IUserDAO {
+	return &UserCacheDAO{
+		IUserDAO: NewUserDAO(db),
+		rdb:      rdb,
+	}
+}
+
+// userKey 用户信息缓存键: user:{id}
+func userKey(id uint) string {
+	return cacheKey(constant.RedisKeyUserPrefix, id)
+}
+
+// CreateUser 创建用户（清除该 ID 可能存在的空值缓存）
+func (d *UserCacheDAO) CreateUser(ctx context.Context, user *model.User) error {
+	if err := d.IUserDAO.CreateUser(ctx, user); err != nil {
+		return err
+	}
+	cacheInvalidate(ctx, d.rdb, userKey(user.ID))
+	return nil
+}
+
+// GetUserByID 根据用户ID获取用户（优先读缓存）
+func (d *UserCacheDAO) GetUserByID(ctx context.Context, id uint) (*model.User, error) {
+	return cacheLoadOne(ctx, d.rdb, &d.group, userKey(id), constant.CacheUserTTL,
+		func(ctx context.Context) (*model.User, error) {
+			return d.IUserDAO.GetUserByID(ctx, id)
+		},
+	)
+}
+
+// GetUsersByIDs 批量查询用户信息（优先读缓存，仅未命中部分回源）
+func (d *UserCacheDAO) GetUsersByIDs(ctx context.Context, userIDs []uint) ([]*model.User, error) {
+	return cacheLoadMany(ctx, d.rdb, &d.group, constant.RedisKeyUserPrefix, userIDs, constant.CacheUserTTL,
+		func(user *model.User) uint { return user.ID },
+		d.IUserDAO.GetUsersByIDs,
+	)
+}
+
+// AddFollowCounts 按增量批量更新用户计数
+func (d *UserCacheDAO) AddFollowCounts(ctx context.Context, userID uint, deltas map[string]int64) error {
+	if err := d.IUserDAO.AddFollowCounts(ctx, userID, deltas); err != nil {
+		return err
+	}
+	cacheInvalidate(ctx, d.rdb, userKey(userID))
+	return nil
+}
@@ -3,6 +3,7 @@ package dao
 import (
 	"context"
 
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
 	"github.com/wangn-tech/tiny-douyin/internal/global"
 	"github.com/wangn-tech/tiny-douyin/internal/model"
 	"go.uber.org/zap"
@@ -15,22 +16,20 @@ type IVideoDAO interface {
 	CreateVideo(ctx context.Context, video *model.Video) error
 	// GetVideoByID 根据ID查询视频
 	GetVideoByID(ctx context.Context, id uint) (*model.Video, error)
-	// GetVideosByUserID 根据用户ID查询视频列表
-	GetVideosByUserID(ctx context.Context, userID uint) ([]*model.Video, error)
-	// GetVideoFeed 获取视频流（按时间倒序）
+	// GetVideosByUserID 根据用户ID查询视频列表（includePending 为 false 时只返回已就绪的视频）
+	GetVideosByUserID(ctx context.Context, userID uint, includePending bool) ([]*model.Video, error)
+	// GetVideoFeed 获取视频流（按时间倒序，只返回已就绪的视频）
 	GetVideoFeed(ctx context.Context, latestTime int64, limit int) ([]*model.Video, error)
 	// UpdateVideo 更新视频信息
 	UpdateVideo(ctx context.Context, video *model.Video) error
+	// UpdateVideoStatus 当视频处于 from 中的某个状态时更新为 to，返回是否更新成功
+	UpdateVideoStatus(ctx context.Context, videoID uint, to string, from ...string) (bool, error)
+	// UpdateVideoMedia 当视频处于 from 中的某个状态时写入处理结果（地址、媒体元数据、校验和）和 video.Status，返回是否更新成功
+	UpdateVideoMedia(ctx context.Context, video *model.Video, from ...string) (bool, error)
 	// GetVideosByIDs 批量查询视频（用于喜欢列表）
 	GetVideosByIDs(ctx context.Context, videoIDs []uint) ([]*model.Video, error)
-	// IncrementFavoriteCount 增加视频点赞数
-	IncrementFavoriteCount(ctx context.Context, videoID uint) error
-	// DecrementFavoriteCount 减少视频点赞数
-	DecrementFavoriteCount(ctx context.Context, videoID uint) error
-	// IncrementCommentCount 增加视频评论数
-	IncrementCommentCount(ctx context.Context, videoID uint) error
-	// DecrementCommentCount 减少视频评论数
-	DecrementCommentCount(ctx context.Context, videoID uint) error
+	// AddCounts 按增量批量更新视频计数（favorite_count、comment_count）
+	AddCounts(ctx context.Context, videoID uint, deltas map[string]int64) error
 }
 
 // VideoDAO 视频数据访问实现
@@ -45,7 +44,7 @@ func NewVideoDAO(db *gorm.DB) IVideoDAO {
 
 // CreateVideo 创建视频
 func (d *VideoDAO) CreateVideo(ctx context.Context, video *model.Video) error {
-	err := d.db.WithContext(ctx).Create(video).Error
+	err := getDB(ctx, d.db).Create(video).Error
 	if err != nil {
 		global.Logger.Error("dao.CreateVideo.db_error",
 			zap.Uint("author_id", video.AuthorID),
@@ -60,7 +59,7 @@ func (d *VideoDAO) CreateVideo(ctx context.Context, video *model.Video) error {
 // GetVideoByID 根据ID查询视频
 func (d *VideoDAO) GetVideoByID(ctx context.Context, id uint) (*model.Video, error) {
 	var video model.Video
-	err := d.db.WithContext(ctx).First(&video, id).Error
+	err := getDB(ctx, d.db).First(&video, id).Error
 	if err != nil {
 		global.Logger.Error("dao.GetVideoByID.db_error",
 			zap.Uint("video_id", id),
@@ -72,10 +71,17 @@ func (d *VideoDAO) GetVideoByID(ctx context.Context, id uint) (*model.Video, err
 }
 
 // GetVideosByUserID 根据用户ID查询视频列表
-func (d *VideoDAO) GetVideosByUserID(ctx context.Context, userID uint) ([]*model.Video, error) {
+// includePending 为 true 时返回除已删除外的所有视频（作者查看自己的视频），否则只返回已就绪的视频
+func (d *VideoDAO) GetVideosByUserID(ctx context.Context, userID uint, includePending bool) ([]*model.Video, error) {
+	query := getDB(ctx, d.db).Where("author_id = ?", userID)
+	if includePending {
+		query = query.Where("status <> ?", constant.VideoStatusDeleted)
+	} else {
+		query = query.Where("status = ?", constant.VideoStatusReady)
+	}
+
 	var videos []*model.Video
-	err := d.db.WithContext(ctx).
-		Where("author_id = ?", userID).
+	err := query.
 		Order("created_at DESC").
 		Find(&videos).Error
 	if err != nil {
@@ -93,7 +99,9 @@ func (d *VideoDAO) GetVideosByUserID(ctx context.Context, userID uint) ([]*model
 // limit: 限制返回数量
 func (d *VideoDAO) GetVideoFeed(ctx context.Context, latestTime int64, limit int) ([]*model.Video, error) {
 	var videos []*model.Video
-	query := d.db.WithContext(ctx).Order("created_at DESC")
+	query := getDB(ctx, d.db).
+		Where("status = ?", constant.VideoStatusReady).
+		Order("created_at DESC")
 
 	// 如果提供了 latestTime，则只返回比该时间更早的视频
 	if latestTime > 0 {
@@ -114,7 +122,7 @@ func (d *VideoDAO) GetVideoFeed(ctx context.Context, latestTime int64, limit int
 
 // UpdateVideo 更新视频信息
 func (d *VideoDAO) UpdateVideo(ctx context.Context, video *model.Video) error {
-	err := d.db.WithContext(ctx).Save(video).Error
+	err := getDB(ctx, d.db).Save(video).Error
 	if err != nil {
 		global.Logger.Error("dao.UpdateVideo.db_error",
 			zap.Uint("video_id", video.ID),
@@ -125,6 +133,64 @@ func (d *VideoDAO) UpdateVideo(ctx context.Context, video *model.Video) error {
 	return nil
 }
 
+// UpdateVideoStatus 条件更新视频状态（from 为空时不限制当前状态）
+func (d *VideoDAO) UpdateVideoStatus(ctx context.Context, videoID uint, to string, from ...string) (bool, error) {
+	query := getDB(ctx, d.db).
+		Model(&model.Video{}).
+		Where("id = ?", videoID)
+	if len(from) > 0 {
+		query = query.Where("status IN ?", from)
+	}
+
+	result := query.Update("status", to)
+	if result.Error != nil {
+		global.Logger.Error("dao.UpdateVideoStatus.db_error",
+			zap.Uint("video_id", videoID),
+			zap.String("to", to),
+			zap.Strings("from", from),
+			zap.Error(result.Error),
+		)
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// UpdateVideoMedia 条件写入视频处理结果
+// 只更新处理结果相关的列，不会覆盖并发修改的计数、标题等字段；视频已删除或已被标记失败时不更新
+func (d *VideoDAO) UpdateVideoMedia(ctx context.Context, video *model.Video, from ...string) (bool, error) {
+	query := getDB(ctx, d.db).
+		Model(&model.Video{}).
+		Where("id = ?", video.ID)
+	if len(from) > 0 {
+		query = query.Where("status IN ?", from)
+	}
+
+	result := query.Updates(map[string]any{
+		"play_url":    video.PlayURL,
+		"cover_url":   video.CoverURL,
+		"hls_url":     video.HLSURL,
+		"duration_ms": video.DurationMs,
+		"width":       video.Width,
+		"height":      video.Height,
+		"video_codec": video.VideoCodec,
+		"audio_codec": video.AudioCodec,
+		"checksum":    video.Checksum,
+		"status":      video.Status,
+	})
+	if result.Error != nil {
+		global.Logger.Error("dao.UpdateVideoMedia.db_error",
+			zap.Uint("video_id", video.ID),
+			zap.String("to", video.Status),
+			zap.Strings("from", from),
+			zap.Error(result.Error),
+		)
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
 // GetVideosByIDs 批量查询视频（用于喜欢列表）
 func (d *VideoDAO) GetVideosByIDs(ctx context.Context, videoIDs []uint) ([]*model.Video, error) {
 	if len(videoIDs) == 0 {
@@ -132,7 +198,7 @@ func (d *VideoDAO) GetVideosByIDs(ctx context.Context, videoIDs []uint) ([]*mode
 	}
 
 	var videos []*model.Video
-	err := d.db.WithContext(ctx).
+	err := getDB(ctx, d.db).
 		Where("id IN ?", videoIDs).
 		Find(&videos).Error
 
@@ -152,90 +218,26 @@ func (d *VideoDAO) GetVideosByIDs(ctx context.Context, videoIDs []uint) ([]*mode
 	return videos, nil
 }
 
-// IncrementFavoriteCount 增加视频点赞数
-func (d *VideoDAO) IncrementFavoriteCount(ctx context.Context, videoID uint) error {
-	err := d.db.WithContext(ctx).
-		Model(&model.Video{}).
-		Where("id = ?", videoID).
-		UpdateColumn("favorite_count", gorm.Expr("favorite_count + ?", 1)).Error
-
-	if err != nil {
-		global.Logger.Error("dao.IncrementFavoriteCount.db_error",
-			zap.Uint("video_id", videoID),
-			zap.Error(err),
-		)
-		return err
+// AddCounts 按增量批量更新视频计数（favorite_count、comment_count），结果不小于 0
+func (d *VideoDAO) AddCounts(ctx context.Context, videoID uint, deltas map[string]int64) error {
+	updates := buildCountUpdates(deltas, "favorite_count", "comment_count")
+	if len(updates) == 0 {
+		return nil
 	}
 
-	global.Logger.Info("dao.IncrementFavoriteCount.success",
-		zap.Uint("video_id", videoID),
-	)
-
-	return nil
-}
-
-// DecrementFavoriteCount 减少视频点赞数
-func (d *VideoDAO) DecrementFavoriteCount(ctx context.Context, videoID uint) error {
-	err := d.db.WithContext(ctx).
-		Model(&model.Video{}).
-		Where("id = ? AND favorite_count > 0", videoID).
-		UpdateColumn("favorite_count", gorm.Expr("favorite_count - ?", 1)).Error
-
-	if err != nil {
-		global.Logger.Error("dao.DecrementFavoriteCount.db_error",
-			zap.Uint("video_id", videoID),
-			zap.Error(err),
-		)
-		return err
-	}
-
-	global.Logger.Info("dao.DecrementFavoriteCount.success",
-		zap.Uint("video_id", videoID),
-	)
-
-	return nil
-}
-
-// IncrementCommentCount 增加视频评论数
-func (d *VideoDAO) IncrementCommentCount(ctx context.Context, videoID uint) error {
-	err := d.db.WithContext(ctx).
+	err := getDB(ctx, d.db).
 		Model(&model.Video{}).
 		Where("id = ?", videoID).
-		UpdateColumn("comment_count", gorm.Expr("comment_count + ?", 1)).Error
+		UpdateColumns(updates).Error
 
 	if err != nil {
-		global.Logger.Error("dao.IncrementCommentCount.db_error",
+		global.Logger.Error("dao.AddCounts.db_error",
 			zap.Uint("video_id", videoID),
+			zap.Any("deltas", deltas),
 			zap.Error(err),
 		)
 		return err
 	}
 
-	global.Logger.Info("dao.IncrementCommentCount.success",
-		zap.Uint("video_id", videoID),
-	)
-
-	return nil
-}
-
-// DecrementCommentCount 减少视频评论数
-func (d *VideoDAO) DecrementCommentCount(ctx context.Context, videoID uint) error {
-	err := d.db.WithContext(ctx).
-		Model(&model.Video{}).
-		Where("id = ? AND comment_count > 0", videoID).
-		UpdateColumn("comment_count", gorm.Expr("comment_count - ?", 1)).Error
-
-	if err != nil {
-		global.Logger.Error("dao.DecrementCommentCount.db_error",
-			zap.Uint("video_id", videoID),
-			zap.Error(err),
-		)
-		return err
-	}
-
-	global.Logger.Info("dao.DecrementCommentCount.success",
-		zap.Uint("video_id", videoID),
-	)
-
 	return nil
 }
@@ -0,0 +1,180 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/model"
+)
+
+// VideoCacheDAO 带 Redis 缓存的视频数据访问实现（Cache-Aside 装饰器）
+// 未覆盖的方法（如 GetVideoFeed）直接透传给底层 IVideoDAO
+type VideoCacheDAO struct {
+	IVideoDAO
+	rdb   *redis.Client
+	group singleflight.Group
+}
+
+// NewVideoCacheDAO 创建带缓存的 VideoDAO 实例
+func NewVideoCacheDAO(db *gorm.DB, rdb *redis.Client) IVideoDAO {
+	return &VideoCacheDAO{
+		IVideoDAO: NewVideoDAO(db),
+		rdb:       rdb,
+	}
+}
+
+// videoKey 视频信息缓存键: video:{id}
+func videoKey(id uint) string {
+	return cacheKey(constant.RedisKeyVideoPrefix, id)
+}
+
+// userVideosKey 用户视频列表缓存键: user:videos:{user_id}
+func userVideosKey(userID uint) string {
+	return cacheKey(constant.RedisKeyUserVideosPrefix, userID)
+}
+
+// CreateVideo 创建视频（清除作者的视频列表缓存）
+func (d *VideoCacheDAO) CreateVideo(ctx context.Context, video *model.Video) error {
+	if err := d.IVideoDAO.CreateVideo(ctx, video); err != nil {
+		return err
+	}
+	cacheInvalidate(ctx, d.rdb, videoKey(video.ID), userVideosKey(video.AuthorID))
+	return nil
+}
+
+// GetVideoByID 根据ID查询视频（优先读缓存）
+func (d *VideoCacheDAO) GetVideoByID(ctx context.Context, id uint) (*model.Video, error) {
+	return cacheLoadOne(ctx, d.rdb, &d.group, videoKey(id), constant.CacheVideoTTL,
+		func(ctx context.Context) (*model.Video, error) {
+			return d.IVideoDAO.GetVideoByID(ctx, id)
+		},
+	)
+}
+
+// GetVideosByIDs 批量查询视频（优先读缓存，仅未命中部分回源）
+func (d *VideoCacheDAO) GetVideosByIDs(ctx context.Context, videoIDs []uint) ([]*model.Video, error) {
+	return cacheLoadMany(ctx, d.rdb, &d.group, constant.RedisKeyVideoPrefix, videoIDs, constant.CacheVideoTTL,
+		func(video *model.Video) uint { return video.ID },
+		d.IVideoDAO.GetVideosByIDs,
+	)
+}
+
+// GetVideosByUserID 根据用户ID查询视频列表
+// 列表缓存只保存视频ID（包含未就绪的视频），视频详情通过 GetVideosByIDs 从视频缓存中获取，
+// 再按视频状态过滤，这样计数、状态等字段变化时只需失效单个视频的缓存
+func (d *VideoCacheDAO) GetVideosByUserID(ctx context.Context, userID uint, includePending bool) ([]*model.Video, error) {
+	if inTx(ctx) {
+		return d.IVideoDAO.GetVideosByUserID(ctx, userID, includePending)
+	}
+
+	key := userVideosKey(userID)
+	if ids, hit := cacheGet[[]uint](ctx, d.rdb, key); hit && ids != nil {
+		videos, err := d.GetVideosByIDs(ctx, *ids)
+		if err != nil {
+			return nil, err
+		}
+		return filterVideosByStatus(sortVideosByIDs(videos, *ids), includePending), nil
+	}
+
+	v, err := cacheDo(ctx, &d.group, key, func(ctx context.Context) (any, error) {
+		videos, err := d.IVideoDAO.GetVideosByUserID(ctx, userID, true)
+		if err != nil {
+			return nil, err
+		}
+
+		ids := make([]uint, 0, len(videos))
+		items := make(map[string]any, len(videos))
+		for _, video := range videos {
+			ids = append(ids, video.ID)
+			items[videoKey(video.ID)] = video
+		}
+		cacheMSet(ctx, d.rdb, items, constant.CacheVideoTTL)
+		cacheMSet(ctx, d.rdb, map[string]any{key: ids}, constant.CacheUserVideosTTL)
+		return videos, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	shared := v.([]*model.Video)
+	videos := make([]*model.Video, 0, len(shared))
+	for _, video := range shared {
+		cp := *video
+		videos = append(videos, &cp)
+	}
+	return filterVideosByStatus(videos, includePending), nil
+}
+
+// UpdateVideoStatus 更新视频状态（清除视频缓存）
+func (d *VideoCacheDAO) UpdateVideoStatus(ctx context.Context, videoID uint, to string, from ...string) (bool, error) {
+	updated, err := d.IVideoDAO.UpdateVideoStatus(ctx, videoID, to, from...)
+	if err != nil {
+		return false, err
+	}
+	if updated {
+		cacheInvalidate(ctx, d.rdb, videoKey(videoID))
+	}
+	return updated, nil
+}
+
+// UpdateVideoMedia 写入视频处理结果（清除视频缓存）
+func (d *VideoCacheDAO) UpdateVideoMedia(ctx context.Context, video *model.Video, from ...string) (bool, error) {
+	updated, err := d.IVideoDAO.UpdateVideoMedia(ctx, video, from...)
+	if err != nil {
+		return false, err
+	}
+	if updated {
+		cacheInvalidate(ctx, d.rdb, videoKey(video.ID))
+	}
+	return updated, nil
+}
+
+// sortVideosByIDs 按 ID 列表的顺序排列视频（批量查询不保证顺序）
+func sortVideosByIDs(videos []*model.Video, ids []uint) []*model.Video {
+	videoMap := make(map[uint]*model.Video, len(videos))
+	for _, video := range videos {
+		videoMap[video.ID] = video
+	}
+
+	sorted := make([]*model.Video, 0, len(videos))
+	for _, id := range ids {
+		if video, ok := videoMap[id]; ok {
+			sorted = append(sorted, video)
+		}
+	}
+	return sorted
+}
+
+// filterVideosByStatus 过滤视频：includePending 为 true 时排除已删除的视频，否则只保留已就绪的视频
+func filterVideosByStatus(videos []*model.Video, includePending bool) []*model.Video {
+	filtered := make([]*model.Video, 0, len(videos))
+	for _, video := range videos {
+		if includePending && video.Status != constant.VideoStatusDeleted ||
+			!includePending && video.Status == constant.VideoStatusReady {
+			filtered = append(filtered, video)
+		}
+	}
+	return filtered
+}
+
+// UpdateVideo 更新视频信息（清除视频及作者视频列表缓存）
+func (d *VideoCacheDAO) UpdateVideo(ctx context.Context, video *model.Video) error {
+	if err := d.IVideoDAO.UpdateVideo(ctx, video); err != nil {
+		return err
+	}
+	cacheInvalidate(ctx, d.rdb, videoKey(video.ID), userVideosKey(video.AuthorID))
+	return nil
+}
+
+// AddCounts 按增量批量更新视频计数
+func (d *VideoCacheDAO) AddCounts(ctx context.Context, videoID uint, deltas map[string]int64) error {
+	if err := d.IVideoDAO.AddCounts(ctx, videoID, deltas); err != nil {
+		return err
+	}
+	cacheInvalidate(ctx, d.rdb, videoKey(videoID))
+	return nil
+}
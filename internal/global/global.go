@@ -2,9 +2,11 @@ package global
 
 import (
 	"github.com/minio/minio-go/v7"
-	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/redis/go-redis/v9"
 	"github.com/wangn-tech/tiny-douyin/internal/config"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/chat"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/event"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/queue"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -15,6 +17,9 @@ var (
 	RedisClient *redis.Client     // Redis
 	Logger      *zap.Logger       // Zap 日志
 	MinIOClient *minio.Client     // MinIO 客户端
-	RabbitConn  *amqp.Connection  // RabbitMQ 连接
-	RabbitChan  *amqp.Channel     // RabbitMQ 频道
+	TaskQueue   queue.ITaskQueue  // 任务队列（RabbitMQ 或进程内队列）
+	EventBus    event.IBus        // 领域事件总线（RabbitMQ 或进程内总线）
+	ChatHub     chat.IHub         // 实时聊天连接中心（WebSocket）
+
+	OutboxNotifier interface{ Notify() } // 本进程的发件箱投递器（启动后设置，事务提交后唤醒投递）
 )
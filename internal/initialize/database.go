@@ -2,8 +2,10 @@ package initialize
 
 import (
 	"fmt"
+	"slices"
 	"time"
 
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
 	"github.com/wangn-tech/tiny-douyin/internal/global"
 	"github.com/wangn-tech/tiny-douyin/internal/model"
 	"gorm.io/driver/mysql"
@@ -42,6 +44,13 @@ func InitDB() *gorm.DB {
 
 // AutoMigrate 迁移所有表结构并创建必要索引（通过 GORM 标签）
 func AutoMigrate(db *gorm.DB) error {
+	backfillConversations := !db.Migrator().HasTable(&model.Conversation{})
+
+	// AutoMigrate 只创建缺失的索引，列发生变化的索引需要先删除
+	if err := dropStaleMessageIndexes(db); err != nil {
+		return err
+	}
+
 	if err := db.AutoMigrate(
 		&model.User{},
 		&model.Video{},
@@ -51,8 +60,51 @@ func AutoMigrate(db *gorm.DB) error {
 		&model.Message{},
 		&model.Tag{},
 		&model.VideoTag{},
+		&model.CounterFlushLog{},
+		&model.CounterDelta{},
+		&model.OutboxMessage{},
+		&model.Notification{},
+		&model.NotificationActor{},
+		&model.Conversation{},
 	); err != nil {
 		return err
 	}
+
+	// 历史数据：早期版本用占位 PlayURL 表示视频状态，迁移到 status 字段
+	if err := db.Model(&model.Video{}).
+		Where("status = ? AND play_url IN ?", constant.VideoStatusReady,
+			[]string{constant.VideoStatusUploading, constant.VideoStatusFailed}).
+		Update("status", gorm.Expr("play_url")).Error; err != nil {
+		return err
+	}
+
+	// 历史数据：会话表首次创建时按已有消息生成会话，历史消息视为双方已读
+	if backfillConversations {
+		return db.Exec(`INSERT INTO conversations
+			(user_a_id, user_b_id, last_message_id, last_message_at, a_last_read_id, b_last_read_id, created_at, updated_at)
+			SELECT LEAST(from_user_id, to_user_id), GREATEST(from_user_id, to_user_id),
+				MAX(id), MAX(created_at), MAX(id), MAX(id), MIN(created_at), NOW()
+			FROM messages WHERE deleted_at IS NULL
+			GROUP BY LEAST(from_user_id, to_user_id), GREATEST(from_user_id, to_user_id)`).Error
+	}
+	return nil
+}
+
+// dropStaleMessageIndexes 删除早期版本不包含 id 列的 idx_from_to（from_user_id, to_user_id），由 AutoMigrate 按新定义重建
+func dropStaleMessageIndexes(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&model.Message{}) {
+		return nil
+	}
+
+	indexes, err := migrator.GetIndexes(&model.Message{})
+	if err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		if index.Name() == "idx_from_to" && !slices.Contains(index.Columns(), "id") {
+			return migrator.DropIndex(&model.Message{}, index.Name())
+		}
+	}
 	return nil
 }
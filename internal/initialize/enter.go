@@ -1,8 +1,12 @@
 package initialize
 
 import (
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
 	"github.com/wangn-tech/tiny-douyin/internal/config"
 	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/chat"
 )
 
 func InitAll() {
@@ -21,9 +25,53 @@ func InitAll() {
 	// Redis
 	global.RedisClient = InitRedis()
 
-	// MinIO
-	global.MinIOClient = InitMinIO(&global.Config.MinIO)
+	// MinIO（使用本地存储时不需要）
+	if global.Config.Storage.Type != constant.StorageTypeLocal {
+		global.MinIOClient = InitMinIO(&global.Config.MinIO)
+	}
+
+	// 任务队列
+	global.TaskQueue = InitTaskQueue(global.Config)
+
+	// 领域事件总线
+	global.EventBus = InitEventBus(global.Config)
+
+	// 实时聊天连接中心（由 main 启动）
+	global.ChatHub = chat.NewHub(global.RedisClient, global.Logger)
+}
+
+// CloseAll 按与初始化相反的顺序释放资源（优雅停机时调用）
+func CloseAll() {
+	// 领域事件总线
+	if global.EventBus != nil {
+		if err := global.EventBus.Close(); err != nil {
+			global.Logger.Warn("Failed to close event bus", zap.Error(err))
+		}
+	}
+
+	// 任务队列
+	if global.TaskQueue != nil {
+		if err := global.TaskQueue.Close(); err != nil {
+			global.Logger.Warn("Failed to close task queue", zap.Error(err))
+		}
+	}
+
+	// Redis
+	if global.RedisClient != nil {
+		if err := global.RedisClient.Close(); err != nil {
+			global.Logger.Warn("Failed to close Redis client", zap.Error(err))
+		}
+	}
+
+	// 数据库
+	if global.DB != nil {
+		if sqlDB, err := global.DB.DB(); err == nil {
+			if err := sqlDB.Close(); err != nil {
+				global.Logger.Warn("Failed to close database", zap.Error(err))
+			}
+		}
+	}
 
-	// RabbitMQ
-	global.RabbitConn, global.RabbitChan = InitRabbitMQ(&global.Config.RabbitMQ)
+	global.Logger.Info("All resources released")
+	_ = global.Logger.Sync()
 }
@@ -0,0 +1,22 @@
+package initialize
+
+import (
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/config"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/event"
+)
+
+// InitEventBus 根据配置初始化领域事件总线（RabbitMQ topic 交换机或进程内总线）
+func InitEventBus(cfg *config.AppConfig) event.IBus {
+	if cfg.Event.Type == constant.EventBusTypeMemory {
+		global.Logger.Warn("Using in-memory event bus, undelivered events will be lost on exit")
+		return event.NewMemoryBus(global.Logger)
+	}
+
+	exchange := cfg.Event.Exchange
+	if exchange == "" {
+		exchange = constant.EventDefaultExchange
+	}
+	return event.NewRabbitMQBus(&cfg.RabbitMQ, exchange, global.Logger)
+}
@@ -38,7 +38,15 @@ func InitMinIO(cfg *config.MinIOConfig) *minio.Client {
 		global.Logger.Info("MinIO bucket created: " + cfg.BucketName)
 	}
 
-	// 设置存储桶策略为公开读
+	// 开启签名地址时存储桶保持私有（清除公开读策略），否则设置为公开读
+	if global.Config.SignedURL.Enabled {
+		if err := minioClient.SetBucketPolicy(ctx, cfg.BucketName, ""); err != nil {
+			global.Logger.Warn("Failed to remove MinIO bucket policy: " + err.Error())
+		}
+		global.Logger.Info("MinIO client initialized successfully (private bucket)")
+		return minioClient
+	}
+
 	policy := `{
 		"Version": "2012-10-17",
 		"Statement": [
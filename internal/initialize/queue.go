@@ -0,0 +1,17 @@
+package initialize
+
+import (
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/config"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/queue"
+)
+
+// InitTaskQueue 根据配置初始化任务队列（RabbitMQ 或进程内队列）
+func InitTaskQueue(cfg *config.AppConfig) queue.ITaskQueue {
+	if cfg.Queue.Type == constant.QueueTypeMemory {
+		global.Logger.Warn("Using in-memory task queue, pending tasks will be lost on exit")
+		return queue.NewMemoryQueue(cfg.RabbitMQ.RetryDelay)
+	}
+	return queue.NewRabbitMQQueue(&cfg.RabbitMQ, global.Logger)
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/common/errc"
+	"github.com/wangn-tech/tiny-douyin/internal/common/response"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+)
+
+// AdminAuth 管理接口鉴权中间件（校验 X-Admin-Token，未配置令牌时拒绝所有请求）
+func AdminAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		expected := global.Config.Admin.Token
+		token := c.GetHeader(constant.AdminTokenHeader)
+		if expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			response.ErrorWithCode(c, errc.ErrUnauthorized)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/common/errc"
+	"github.com/wangn-tech/tiny-douyin/internal/common/response"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+)
+
+// StreamMultipart 流式读取 multipart 请求体（替代 ParseMultipartForm，避免整个文件缓存在内存或临时文件中）
+// 只读取文件字段之前的普通字段并写入 Request.PostForm，后续中间件和 handler 可照常通过 c.PostForm 获取；
+// 文件字段不读取，以 *multipart.Part 的形式存入 context 由 handler 流式处理，因此客户端需要将文件字段放在最后。
+// 请求体大小限制为 maxFileSize + constant.MaxUploadFormSize
+func StreamMultipart(fileField string, maxFileSize int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := maxFileSize + constant.MaxUploadFormSize
+		if c.Request.ContentLength > limit {
+			response.ErrorWithCode(c, errc.ErrVideoFileTooLarge)
+			c.Abort()
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+
+		reader, err := c.Request.MultipartReader()
+		if err != nil {
+			// 不是 multipart 请求，交给 handler 处理
+			c.Next()
+			return
+		}
+
+		form := make(url.Values)
+		var formSize int64
+		for {
+			part, err := reader.NextPart()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				global.Logger.Warn("middleware.StreamMultipart.read_error", zap.Error(err))
+				response.Error(c, errc.ErrInvalidParams, "请求体格式错误")
+				c.Abort()
+				return
+			}
+
+			if part.FileName() != "" {
+				if part.FormName() == fileField {
+					c.Set(constant.ContextKeyUploadFile, part)
+					break
+				}
+				continue // 忽略其他文件字段
+			}
+
+			value, err := io.ReadAll(io.LimitReader(part, constant.MaxUploadFormSize-formSize+1))
+			formSize += int64(len(value))
+			if err != nil || formSize > constant.MaxUploadFormSize {
+				response.Error(c, errc.ErrInvalidParams, "表单字段过大")
+				c.Abort()
+				return
+			}
+			form.Add(part.FormName(), string(value))
+		}
+
+		// 已读取的字段作为表单数据，Request.Form 同时包含 query 参数（与 ParseForm 一致，表单字段在前）
+		c.Request.PostForm = form
+		c.Request.Form = make(url.Values)
+		for k, v := range form {
+			c.Request.Form[k] = append(c.Request.Form[k], v...)
+		}
+		for k, v := range c.Request.URL.Query() {
+			c.Request.Form[k] = append(c.Request.Form[k], v...)
+		}
+
+		c.Next()
+	}
+}
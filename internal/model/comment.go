@@ -2,11 +2,25 @@ package model
 
 import "gorm.io/gorm"
 
+// Comment 评论
+// 回复以两级展示：顶级评论（RootID 为 0）下平铺其所有回复，回复的回复通过 ParentID、ReplyToUserID 标明回复对象
 type Comment struct {
 	gorm.Model
-	VideoID uint   `gorm:"index;not null"`
-	UserID  uint   `gorm:"index;not null"`
-	Content string `gorm:"type:varchar(255);not null"`
+	VideoID       uint   `gorm:"index;not null"`
+	UserID        uint   `gorm:"index;not null"`
+	Content       string `gorm:"type:varchar(255);not null"`
+	ParentID      uint   `gorm:"not null;default:0"`       // 回复的评论ID（顶级评论为 0）
+	RootID        uint   `gorm:"not null;default:0;index"` // 所属顶级评论ID（顶级评论为 0）
+	ReplyToUserID uint   `gorm:"not null;default:0"`       // 被回复的用户ID（@提及，顶级评论为 0）
+	ReplyCount    int64  `gorm:"not null;default:0"`       // 未删除的回复数（仅顶级评论）
 }
 
 func (Comment) TableName() string { return "comments" }
+
+// ThreadID 返回评论所属的顶级评论ID（顶级评论返回自身ID）
+func (c *Comment) ThreadID() uint {
+	if c.RootID == 0 {
+		return c.ID
+	}
+	return c.RootID
+}
@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+// Conversation 私信会话，每对用户一条（UserAID < UserBID），记录最新消息和双方的已读位置
+// 消息 ID 自增，LastMessageID 同时作为会话列表的排序和分页依据
+type Conversation struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement"`
+	UserAID       uint      `gorm:"column:user_a_id;not null;uniqueIndex:uk_conversation_pair,priority:1;index:idx_user_a_last,priority:1"` // 较小的用户ID
+	UserBID       uint      `gorm:"column:user_b_id;not null;uniqueIndex:uk_conversation_pair,priority:2;index:idx_user_b_last,priority:1"` // 较大的用户ID
+	LastMessageID uint      `gorm:"not null;default:0;index:idx_user_a_last,priority:2;index:idx_user_b_last,priority:2"`                   // 最新消息ID
+	LastMessageAt time.Time // 最新消息时间
+	ALastReadID   uint      `gorm:"column:a_last_read_id;not null;default:0"` // A 已读到的消息ID
+	BLastReadID   uint      `gorm:"column:b_last_read_id;not null;default:0"` // B 已读到的消息ID
+	AUnreadCount  int       `gorm:"column:a_unread_count;not null;default:0"` // A 的未读消息数
+	BUnreadCount  int       `gorm:"column:b_unread_count;not null;default:0"` // B 的未读消息数
+	AClearedID    uint      `gorm:"column:a_cleared_id;not null;default:0"`   // A 清空会话时的最新消息ID，此前的消息对 A 不可见
+	BClearedID    uint      `gorm:"column:b_cleared_id;not null;default:0"`   // B 清空会话时的最新消息ID，此前的消息对 B 不可见
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (Conversation) TableName() string { return "conversations" }
+
+// PeerID 返回会话中对方的用户ID
+func (c *Conversation) PeerID(userID uint) uint {
+	if userID == c.UserAID {
+		return c.UserBID
+	}
+	return c.UserAID
+}
+
+// LastReadID 返回用户已读到的消息ID
+func (c *Conversation) LastReadID(userID uint) uint {
+	if userID == c.UserAID {
+		return c.ALastReadID
+	}
+	return c.BLastReadID
+}
+
+// UnreadCount 返回用户的未读消息数
+func (c *Conversation) UnreadCount(userID uint) int {
+	if userID == c.UserAID {
+		return c.AUnreadCount
+	}
+	return c.BUnreadCount
+}
+
+// ClearedID 返回用户清空会话时的最新消息ID（未清空过为 0）
+func (c *Conversation) ClearedID(userID uint) uint {
+	if userID == c.UserAID {
+		return c.AClearedID
+	}
+	return c.BClearedID
+}
+
+// MessageVisible 消息对用户是否可见（未被该用户删除且不在清空会话的位置之前）
+func (c *Conversation) MessageVisible(message *Message, userID uint) bool {
+	return message.ID > c.ClearedID(userID) && !message.DeletedFor(userID)
+}
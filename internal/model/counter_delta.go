@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// CounterDelta 计数增量记录
+// 与点赞、评论、关注等业务变更在同一事务中写入，保证计数增量不会因 Redis 异常或进程崩溃而丢失；
+// 由计数回写器写入 Redis（按记录 ID 幂等）后删除
+type CounterDelta struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	Target    string `gorm:"type:varchar(16);not null;index:idx_counter_delta_object,priority:1"` // 计数对象类型: video, user
+	ObjectID  uint   `gorm:"not null;index:idx_counter_delta_object,priority:2"`                  // 计数对象ID
+	Field     string `gorm:"type:varchar(32);not null"`                                           // 计数字段（数据库列名）
+	Delta     int64  `gorm:"not null"`                                                            // 增量
+	CreatedAt time.Time
+}
+
+func (CounterDelta) TableName() string { return "counter_deltas" }
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// CounterFlushLog 计数回写记录（保证 Redis 计数增量回写 MySQL 的幂等性）
+type CounterFlushLog struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	BatchKey  string    `gorm:"type:varchar(64);uniqueIndex;not null;comment:回写批次标识"` // 回写批次标识
+	CreatedAt time.Time `gorm:"index"`                                                // 回写时间
+}
+
+func (CounterFlushLog) TableName() string { return "counter_flush_logs" }
@@ -1,16 +1,86 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"gorm.io/gorm"
+)
 
 // Message 消息模型
+// 消息 ID 自增，作为聊天记录分页和同步的游标：两人之间的消息按方向走 idx_from_to（包含 id）顺序扫描，
+// 同步所有会话时走 idx_from、idx_to（InnoDB 二级索引隐含主键）
 type Message struct {
-	gorm.Model
-	FromUserID uint   `gorm:"index:idx_from_to;index:idx_from;not null;comment:发送者用户ID"`
-	ToUserID   uint   `gorm:"index:idx_from_to;index:idx_to;not null;comment:接收者用户ID"`
-	Content    string `gorm:"type:varchar(255);not null;comment:消息内容"`
+	ID          uint `gorm:"primaryKey;index:idx_from_to,priority:3"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	FromUserID  uint           `gorm:"index:idx_from_to,priority:1;index:idx_from;not null;comment:发送者用户ID"`
+	ToUserID    uint           `gorm:"index:idx_from_to,priority:2;index:idx_to;not null;comment:接收者用户ID"`
+	Type        string         `gorm:"type:varchar(16);not null;default:'text';comment:消息类型"`
+	Content     string         `gorm:"type:varchar(255);not null;comment:消息内容"`
+	Payload     string         `gorm:"type:text;comment:消息附加数据（JSON，见 MessagePayload）"`
+	FromDeleted bool           `gorm:"not null;default:false;comment:发送者是否已删除（仅对发送者隐藏）"`
+	ToDeleted   bool           `gorm:"not null;default:false;comment:接收者是否已删除（仅对接收者隐藏）"`
+	RecalledAt  *time.Time     `gorm:"comment:撤回时间（撤回后内容和附加数据被清空）"`
 }
 
 // TableName 指定表名
 func (Message) TableName() string {
 	return "messages"
 }
+
+// MessagePayload 非文本消息的附加数据，按消息类型填写对应字段
+type MessagePayload struct {
+	// 图片消息
+	ImageURL    string `json:"image_url,omitempty"`    // 图片公开访问地址（返回前签名）
+	ImageType   string `json:"image_type,omitempty"`   // 图片内容类型
+	ImageWidth  int    `json:"image_width,omitempty"`  // 图片宽度（无法识别时为 0）
+	ImageHeight int    `json:"image_height,omitempty"` // 图片高度（无法识别时为 0）
+	ImageSize   int64  `json:"image_size,omitempty"`   // 图片大小（字节）
+
+	VideoID   uint `json:"video_id,omitempty"`    // 分享的视频ID
+	UserID    uint `json:"user_id,omitempty"`     // 分享的用户ID
+	ReplyToID uint `json:"reply_to_id,omitempty"` // 回复的消息ID
+}
+
+// GetPayload 解析消息附加数据，文本消息或解析失败时返回空数据
+func (m *Message) GetPayload() *MessagePayload {
+	var payload MessagePayload
+	if m.Payload != "" {
+		_ = json.Unmarshal([]byte(m.Payload), &payload)
+	}
+	return &payload
+}
+
+// messagePreviews 非文本消息没有内容时的预览文字
+var messagePreviews = map[string]string{
+	constant.MessageTypeImage: "[图片]",
+	constant.MessageTypeVideo: "[视频]",
+	constant.MessageTypeUser:  "[名片]",
+}
+
+// Recalled 消息是否已撤回
+func (m *Message) Recalled() bool {
+	return m.RecalledAt != nil
+}
+
+// DeletedFor 消息是否已被用户删除
+func (m *Message) DeletedFor(userID uint) bool {
+	if userID == m.FromUserID {
+		return m.FromDeleted
+	}
+	return m.ToDeleted
+}
+
+// Preview 消息预览文字（会话列表、好友列表中展示）
+func (m *Message) Preview() string {
+	if m.Recalled() {
+		return "[消息已撤回]"
+	}
+	if m.Content != "" {
+		return m.Content
+	}
+	return messagePreviews[m.Type]
+}
@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// Notification 站内通知
+// 点赞、关注等可聚合的通知在未读期间合并为一条（GroupKey 相同），已读后 GroupKey 置空，之后的同类操作生成新通知
+type Notification struct {
+	ID         uint       `gorm:"primaryKey;autoIncrement;index:idx_user_notification,priority:2"`
+	UserID     uint       `gorm:"not null;index:idx_user_notification,priority:1;index:idx_user_read,priority:1;uniqueIndex:uk_user_group,priority:1"` // 接收者ID
+	Type       string     `gorm:"type:varchar(16);not null"`                                                                                           // 通知类型: like, comment, reply, follow
+	GroupKey   *string    `gorm:"type:varchar(64);uniqueIndex:uk_user_group,priority:2"`                                                               // 未读聚合键（如 like:{video_id}），不聚合或已读时为空
+	GroupID    uint       `gorm:"default:0;not null"`                                                                                                  // 聚合组ID（聚合中第一条通知的ID，合并后不变），不聚合时为 0
+	VideoID    uint       `gorm:"default:0;not null"`                                                                                                  // 相关视频ID（点赞、评论）
+	CommentID  uint       `gorm:"default:0;not null"`                                                                                                  // 相关评论ID（评论）
+	Content    string     `gorm:"type:varchar(255)"`                                                                                                   // 通知内容（评论内容）
+	ActorID    uint       `gorm:"not null"`                                                                                                            // 最近一次触发通知的用户ID
+	ActorIDs   string     `gorm:"type:varchar(255)"`                                                                                                   // 最近触发通知的用户ID（JSON 数组，最新的在前）
+	ActorCount int        `gorm:"default:1;not null"`                                                                                                  // 触发通知的用户数（按 NotificationActor 去重）
+	IsRead     bool       `gorm:"default:false;not null;index:idx_user_read,priority:2"`                                                               // 是否已读
+	ReadAt     *time.Time // 已读时间
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (Notification) TableName() string { return "notifications" }
+
+// NotificationActor 聚合通知的触发用户集合，未读期间按 (GroupID, ActorID) 去重计数，标记已读时删除
+type NotificationActor struct {
+	ID        uint `gorm:"primaryKey;autoIncrement"`
+	GroupID   uint `gorm:"not null;uniqueIndex:uk_group_actor,priority:1"` // 聚合组ID（Notification.GroupID）
+	ActorID   uint `gorm:"not null;uniqueIndex:uk_group_actor,priority:2"` // 触发通知的用户ID
+	CreatedAt time.Time
+}
+
+func (NotificationActor) TableName() string { return "notification_actors" }
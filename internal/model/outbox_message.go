@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// OutboxMessage 事务性发件箱消息
+// 与业务变更在同一事务中写入，由 outbox relay 投递到消息队列（至少一次），消费方按 MessageID 去重
+type OutboxMessage struct {
+	ID            uint       `gorm:"primaryKey;autoIncrement"`
+	MessageID     string     `gorm:"type:char(36);uniqueIndex;not null"`                                        // 消息唯一标识
+	Topic         string     `gorm:"type:varchar(64);not null"`                                                 // 消息主题
+	Payload       string     `gorm:"type:text;not null"`                                                        // 消息内容（JSON）
+	Status        string     `gorm:"type:varchar(16);not null;default:pending;index:idx_outbox_due,priority:1"` // 状态: pending, inflight, sent
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_outbox_due,priority:2"`                                  // 下次投递时间（inflight 时为租约到期时间）
+	Attempts      int        `gorm:"default:0;not null"`                                                        // 投递失败次数
+	LastError     string     `gorm:"type:varchar(512)"`                                                         // 最近一次投递失败原因
+	SentAt        *time.Time `gorm:"index"`                                                                     // 投递成功时间
+	CreatedAt     time.Time
+}
+
+func (OutboxMessage) TableName() string { return "outbox_messages" }
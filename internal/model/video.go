@@ -7,10 +7,27 @@ type Video struct {
 	AuthorID      uint   `gorm:"index;not null"`
 	PlayURL       string `gorm:"type:varchar(255);not null"`
 	CoverURL      string `gorm:"type:varchar(255)"`
+	Status        string `gorm:"type:varchar(16);not null;default:ready;index"` // 状态: uploading, processing, ready, failed, deleted
+	HLSURL        string `gorm:"column:hls_url;type:varchar(255)"`              // HLS 主播放列表地址，为空表示没有 HLS
 	Title         string `gorm:"type:varchar(128)"`
 	Description   string `gorm:"type:varchar(255)"`
 	FavoriteCount int64  `gorm:"default:0;not null"` // 点赞数
 	CommentCount  int64  `gorm:"default:0;not null"` // 评论数
+	DurationMs    int64  `gorm:"default:0;not null"` // 时长（毫秒）
+	Width         int    `gorm:"default:0;not null"` // 宽度（像素）
+	Height        int    `gorm:"default:0;not null"` // 高度（像素）
+	VideoCodec    string `gorm:"type:varchar(32)"`   // 视频编码
+	AudioCodec    string `gorm:"type:varchar(32)"`   // 音频编码
+	Checksum      string `gorm:"type:char(64)"`      // 原始上传文件的 SHA-256
+	FileSize      int64  `gorm:"default:0;not null"` // 原始上传文件大小（字节）
 }
 
 func (Video) TableName() string { return "videos" }
+
+// StreamURL 返回 HLS 主播放列表地址，未生成 HLS（转码关闭或失败）时回退到原视频地址
+func (v *Video) StreamURL() string {
+	if v.HLSURL != "" {
+		return v.HLSURL
+	}
+	return v.PlayURL
+}
@@ -0,0 +1,122 @@
+package chat
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+)
+
+// pongFrame 应用层心跳响应
+var pongFrame, _ = json.Marshal(&Frame{Type: constant.ChatFramePong})
+
+// client 单个 WebSocket 连接
+// 写操作只在 writePump 中进行；readPump 负责心跳超时检测和处理上行帧
+type client struct {
+	hub    *Hub
+	userID uint
+	conn   *websocket.Conn
+	send   chan []byte
+
+	closeOnce sync.Once
+	closeCode int
+	closing   chan struct{}
+}
+
+func newClient(hub *Hub, userID uint, conn *websocket.Conn) *client {
+	return &client{
+		hub:     hub,
+		userID:  userID,
+		conn:    conn,
+		send:    make(chan []byte, constant.ChatSendBufferSize),
+		closing: make(chan struct{}),
+	}
+}
+
+// enqueue 放入待发送缓冲，缓冲区已满说明客户端读取过慢，直接断开（重连后通过轮询补齐）
+func (c *client) enqueue(data []byte) {
+	select {
+	case c.send <- data:
+	case <-c.closing:
+	default:
+		c.hub.logger.Warn("Chat connection too slow, closing", zap.Uint("user_id", c.userID))
+		c.close(websocket.CloseTryAgainLater)
+	}
+}
+
+// close 通知写协程发送关闭帧并断开连接（可重复调用）
+func (c *client) close(code int) {
+	c.closeOnce.Do(func() {
+		c.closeCode = code
+		close(c.closing)
+	})
+}
+
+// writePump 发送缓冲中的帧并定时发送 ping，退出时关闭底层连接
+func (c *client) writePump() {
+	ticker := time.NewTicker(constant.ChatPingInterval)
+	defer func() {
+		ticker.Stop()
+		_ = c.conn.Close()
+		c.hub.unregister(c)
+		c.hub.wg.Done()
+	}()
+
+	for {
+		select {
+		case data := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(constant.ChatWriteWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				c.close(websocket.CloseAbnormalClosure)
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(constant.ChatWriteWait)); err != nil {
+				c.close(websocket.CloseAbnormalClosure)
+				return
+			}
+		case <-c.closing:
+			_ = c.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(c.closeCode, ""),
+				time.Now().Add(constant.ChatWriteWait),
+			)
+			return
+		}
+	}
+}
+
+// readPump 读取上行帧，超过 ChatPongWait 未收到 pong 或任何帧时断开
+func (c *client) readPump() {
+	defer func() {
+		c.close(websocket.CloseNormalClosure)
+		c.hub.wg.Done()
+	}()
+
+	extend := func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(constant.ChatPongWait))
+	}
+	c.conn.SetReadLimit(constant.ChatMaxFrameSize)
+	_ = extend("")
+	c.conn.SetPongHandler(extend)
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				c.hub.logger.Debug("Chat connection read error", zap.Uint("user_id", c.userID), zap.Error(err))
+			}
+			return
+		}
+		_ = extend("")
+
+		// 目前只处理应用层心跳，发送消息仍走 HTTP 接口
+		var frame Frame
+		if err := json.Unmarshal(data, &frame); err == nil && frame.Type == constant.ChatFramePing {
+			c.enqueue(pongFrame)
+		}
+	}
+}
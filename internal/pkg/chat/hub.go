@@ -0,0 +1,195 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+)
+
+// Hub 基于 Redis Pub/Sub 的实时聊天连接中心
+type Hub struct {
+	rdb    *redis.Client
+	logger *zap.Logger
+
+	mu      sync.RWMutex
+	clients map[uint]map[*client]struct{} // userID -> 本地连接（同一用户可多端在线）
+	started bool
+	stopped bool
+
+	wg   sync.WaitGroup // 连接读写 goroutine
+	done chan struct{}
+}
+
+// pushMessage 经 Redis 广播的推送消息
+type pushMessage struct {
+	UserIDs []uint          `json:"user_ids"`
+	Frame   json.RawMessage `json:"frame"`
+}
+
+// connectedData 连接建立帧数据，客户端随后以已收到的最新消息 ID 调用同步接口补齐断线期间的消息
+type connectedData struct {
+	UserID     uint  `json:"user_id"`
+	ServerTime int64 `json:"server_time"`
+}
+
+// NewHub 创建连接中心
+func NewHub(rdb *redis.Client, logger *zap.Logger) IHub {
+	return &Hub{
+		rdb:     rdb,
+		logger:  logger,
+		clients: make(map[uint]map[*client]struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start 订阅推送频道，ctx 取消时关闭所有连接并退出
+func (h *Hub) Start(ctx context.Context) error {
+	// 订阅与 ctx 解耦，由 run 在退出时主动关闭；断线后 go-redis 会自动重新订阅
+	ps := h.rdb.Subscribe(context.WithoutCancel(ctx), constant.RedisChannelChatPush)
+	if _, err := ps.Receive(ctx); err != nil {
+		_ = ps.Close()
+		return fmt.Errorf("failed to subscribe chat channel: %w", err)
+	}
+
+	h.mu.Lock()
+	h.started = true
+	h.mu.Unlock()
+
+	go h.run(ctx, ps)
+	h.logger.Info("Chat hub started", zap.String("channel", constant.RedisChannelChatPush))
+	return nil
+}
+
+// Wait 等待订阅和所有连接退出
+func (h *Hub) Wait(ctx context.Context) error {
+	select {
+	case <-h.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run 将收到的推送投递给本地连接
+func (h *Hub) run(ctx context.Context, ps *redis.PubSub) {
+	defer close(h.done)
+
+	ch := ps.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			_ = ps.Close()
+			h.closeAll()
+			h.wg.Wait()
+			h.logger.Info("Chat hub stopped")
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				ch = nil
+				continue
+			}
+			var push pushMessage
+			if err := json.Unmarshal([]byte(msg.Payload), &push); err != nil {
+				h.logger.Warn("Dropping malformed chat push", zap.Error(err))
+				continue
+			}
+			h.deliver(push.UserIDs, push.Frame)
+		}
+	}
+}
+
+// Serve 接管已升级的 WebSocket 连接
+func (h *Hub) Serve(userID uint, conn *websocket.Conn) error {
+	c := newClient(h, userID, conn)
+
+	h.mu.Lock()
+	if !h.started || h.stopped {
+		h.mu.Unlock()
+		return ErrHubStopped
+	}
+	if h.clients[userID] == nil {
+		h.clients[userID] = make(map[*client]struct{})
+	}
+	h.clients[userID][c] = struct{}{}
+	h.wg.Add(2)
+	h.mu.Unlock()
+
+	go c.writePump()
+	go c.readPump()
+
+	if data, err := json.Marshal(&Frame{
+		Type: constant.ChatFrameConnected,
+		Data: connectedData{UserID: userID, ServerTime: time.Now().Unix()},
+	}); err == nil {
+		c.enqueue(data)
+	}
+
+	h.logger.Debug("Chat connection opened", zap.Uint("user_id", userID))
+	return nil
+}
+
+// Push 经 Redis 广播推送帧，所有实例（包括当前实例）收到后投递给本地连接
+// 广播失败时退化为只投递给本地连接，其他实例上的连接由客户端轮询补齐
+func (h *Hub) Push(ctx context.Context, userIDs []uint, frame *Frame) error {
+	data, err := json.Marshal(frame)
+	if err != nil {
+		return fmt.Errorf("failed to marshal chat frame: %w", err)
+	}
+	payload, err := json.Marshal(&pushMessage{UserIDs: userIDs, Frame: data})
+	if err != nil {
+		return fmt.Errorf("failed to marshal chat push: %w", err)
+	}
+
+	if err := h.rdb.Publish(ctx, constant.RedisChannelChatPush, payload).Err(); err != nil {
+		h.logger.Warn("Failed to broadcast chat push, delivering to local connections only",
+			zap.Uints("user_ids", userIDs),
+			zap.Error(err),
+		)
+		h.deliver(userIDs, data)
+	}
+	return nil
+}
+
+// deliver 投递给本地连接
+func (h *Hub) deliver(userIDs []uint, data []byte) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, userID := range userIDs {
+		for c := range h.clients[userID] {
+			c.enqueue(data)
+		}
+	}
+}
+
+// unregister 移除连接（写协程退出时调用）
+func (h *Hub) unregister(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if conns, ok := h.clients[c.userID]; ok {
+		delete(conns, c)
+		if len(conns) == 0 {
+			delete(h.clients, c.userID)
+		}
+	}
+	h.logger.Debug("Chat connection closed", zap.Uint("user_id", c.userID))
+}
+
+// closeAll 停机时通知所有连接关闭，之后不再接受新连接
+func (h *Hub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stopped = true
+	for _, conns := range h.clients {
+		for c := range conns {
+			c.close(websocket.CloseGoingAway)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package chat
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gorilla/websocket"
+)
+
+// ErrHubStopped 连接中心未启动或已停止
+var ErrHubStopped = errors.New("chat hub stopped")
+
+// Frame WebSocket 收发的 JSON 帧
+type Frame struct {
+	Type string `json:"type"`           // 帧类型
+	Data any    `json:"data,omitempty"` // 帧数据
+}
+
+// IHub 实时聊天连接中心接口
+// 每个实例只持有本地连接，推送经 Redis Pub/Sub 广播到所有实例，由持有目标用户连接的实例投递
+type IHub interface {
+	// Start 订阅推送频道，ctx 取消时关闭所有连接并退出
+	Start(ctx context.Context) error
+	// Wait 等待订阅和所有连接退出
+	Wait(ctx context.Context) error
+	// Serve 接管已升级的 WebSocket 连接（立即返回，读写在后台进行）
+	Serve(userID uint, conn *websocket.Conn) error
+	// Push 向用户的所有在线连接推送帧（用户不在线时直接忽略，客户端通过轮询接口补齐）
+	Push(ctx context.Context, userIDs []uint, frame *Frame) error
+}
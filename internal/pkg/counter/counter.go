@@ -0,0 +1,159 @@
+package counter
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/dao"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/model"
+)
+
+// Counter 基于 Redis Hash 的计数器
+// 增量先作为 counter_deltas 记录与业务变更一起提交，事务提交后写入 Redis；
+// Redis 中按 {id}:{field} 累加在 counter:delta:* 中，回写时整体转移到 counter:flushing:*
+type Counter struct {
+	rdb        *redis.Client
+	counterDAO dao.ICounterDAO
+}
+
+// NewCounter 创建计数器
+func NewCounter(rdb *redis.Client, counterDAO dao.ICounterDAO) ICounter {
+	return &Counter{rdb: rdb, counterDAO: counterDAO}
+}
+
+// IncrVideo 增加视频计数增量
+func (c *Counter) IncrVideo(ctx context.Context, videoID uint, field string, delta int64) error {
+	return c.incr(ctx, targetVideo, videoID, field, delta)
+}
+
+// IncrUser 增加用户计数增量
+func (c *Counter) IncrUser(ctx context.Context, userID uint, field string, delta int64) error {
+	return c.incr(ctx, targetUser, userID, field, delta)
+}
+
+// incr 在当前事务中写入增量记录，事务提交后立即写入 Redis
+// 写 Redis 失败（或提交后进程崩溃）时增量记录仍在，由回写器补写
+func (c *Counter) incr(ctx context.Context, target string, id uint, field string, delta int64) error {
+	if delta == 0 {
+		return nil
+	}
+
+	row := &model.CounterDelta{Target: target, ObjectID: id, Field: field, Delta: delta}
+	if err := c.counterDAO.CreateCounterDelta(ctx, row); err != nil {
+		return err
+	}
+
+	dao.AfterCommit(ctx, func() {
+		if err := applyDeltas(context.WithoutCancel(ctx), c.rdb, []*model.CounterDelta{row}); err != nil {
+			global.Logger.Warn("counter.incr.redis_error",
+				zap.String("target", target),
+				zap.Uint("id", id),
+				zap.String("field", field),
+				zap.Int64("delta", delta),
+				zap.Error(err),
+			)
+		}
+	})
+
+	return nil
+}
+
+// PendingVideoDeltas 批量获取视频尚未回写的计数增量
+func (c *Counter) PendingVideoDeltas(ctx context.Context, videoIDs []uint) map[uint]Deltas {
+	return c.pending(ctx,
+		constant.RedisKeyCounterVideoDelta, constant.RedisKeyCounterVideoFlushing,
+		videoIDs, FieldFavoriteCount, FieldCommentCount,
+	)
+}
+
+// PendingUserDeltas 批量获取用户尚未回写的计数增量
+func (c *Counter) PendingUserDeltas(ctx context.Context, userIDs []uint) map[uint]Deltas {
+	return c.pending(ctx,
+		constant.RedisKeyCounterUserDelta, constant.RedisKeyCounterUserFlushing,
+		userIDs, FieldFollowCount, FieldFollowerCount,
+	)
+}
+
+// pending 汇总待回写和正在回写的增量；Redis 异常时返回空结果（降级为只读 MySQL 中的计数）
+// 已回写的分批会从 flushing 中移除，只有分批提交后、移除前的短暂窗口内会重复计入
+func (c *Counter) pending(ctx context.Context, deltaKey, flushingKey string, ids []uint, columns ...string) map[uint]Deltas {
+	result := make(map[uint]Deltas)
+	if len(ids) == 0 {
+		return result
+	}
+
+	fields := make([]string, 0, len(ids)*len(columns))
+	for _, id := range ids {
+		for _, column := range columns {
+			fields = append(fields, deltaField(id, column))
+		}
+	}
+
+	pipe := c.rdb.Pipeline()
+	deltaCmd := pipe.HMGet(ctx, deltaKey, fields...)
+	flushingCmd := pipe.HMGet(ctx, flushingKey, fields...)
+	if _, err := pipe.Exec(ctx); err != nil {
+		global.Logger.Warn("counter.pending.redis_error",
+			zap.String("key", deltaKey),
+			zap.Int("id_count", len(ids)),
+			zap.Error(err),
+		)
+		return result
+	}
+
+	for _, cmd := range []*redis.SliceCmd{deltaCmd, flushingCmd} {
+		for i, val := range cmd.Val() {
+			str, ok := val.(string)
+			if !ok {
+				continue
+			}
+			delta, err := strconv.ParseInt(str, 10, 64)
+			if err != nil || delta == 0 {
+				continue
+			}
+			id, column, ok := parseDeltaField(fields[i])
+			if !ok {
+				continue
+			}
+			if result[id] == nil {
+				result[id] = make(Deltas)
+			}
+			result[id][column] += delta
+		}
+	}
+
+	return result
+}
+
+// Apply 将增量合并到计数上（结果不小于 0）
+func (d Deltas) Apply(field string, count int64) int64 {
+	count += d[field]
+	if count < 0 {
+		return 0
+	}
+	return count
+}
+
+// deltaField 增量 Hash 的 field: {id}:{column}
+func deltaField(id uint, column string) string {
+	return strconv.FormatUint(uint64(id), 10) + ":" + column
+}
+
+// parseDeltaField 解析增量 Hash 的 field
+func parseDeltaField(field string) (uint, string, bool) {
+	idStr, column, ok := strings.Cut(field, ":")
+	if !ok {
+		return 0, "", false
+	}
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil || id == 0 {
+		return 0, "", false
+	}
+	return uint(id), column, true
+}
@@ -0,0 +1,92 @@
+package counter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+)
+
+// newTestRedis 启动内存 Redis（miniredis），脚本与真实 Redis 一致地执行
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	global.Logger = zap.NewNop()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return mr, rdb
+}
+
+func TestCounter_PendingVideoDeltas(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	c := &Counter{rdb: rdb}
+
+	mr.HSet(constant.RedisKeyCounterVideoDelta,
+		deltaField(1, FieldFavoriteCount), "3",
+		deltaField(1, FieldCommentCount), "-1",
+		deltaField(2, FieldFavoriteCount), "0",
+		deltaField(3, FieldFavoriteCount), "5",
+	)
+	mr.HSet(constant.RedisKeyCounterVideoFlushing,
+		batchField, "batch",
+		nextField, "1",
+		deltaField(1, FieldFavoriteCount), "2",
+		deltaField(2, FieldCommentCount), "4",
+	)
+
+	got := c.PendingVideoDeltas(context.Background(), []uint{1, 2, 4})
+
+	tests := []struct {
+		id    uint
+		field string
+		want  int64
+	}{
+		{1, FieldFavoriteCount, 5},
+		{1, FieldCommentCount, -1},
+		{2, FieldFavoriteCount, 0},
+		{2, FieldCommentCount, 4},
+		{4, FieldFavoriteCount, 0},
+	}
+	for _, tt := range tests {
+		if d := got[tt.id][tt.field]; d != tt.want {
+			t.Errorf("video %d %s = %d, want %d", tt.id, tt.field, d, tt.want)
+		}
+	}
+	if _, ok := got[3]; ok {
+		t.Errorf("video 3 was not requested but got %v", got[3])
+	}
+	if _, ok := got[4]; ok {
+		t.Errorf("video 4 has no deltas but got %v", got[4])
+	}
+}
+
+func TestCounter_PendingRedisDown(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	c := &Counter{rdb: rdb}
+	mr.HSet(constant.RedisKeyCounterUserDelta, deltaField(1, FieldFollowCount), "1")
+	mr.Close()
+
+	// Redis 异常时降级为空结果
+	if got := c.PendingUserDeltas(context.Background(), []uint{1}); len(got) != 0 {
+		t.Errorf("PendingUserDeltas() = %v, want empty", got)
+	}
+}
+
+func TestDeltas_Apply(t *testing.T) {
+	d := Deltas{FieldFavoriteCount: -5, FieldCommentCount: 2}
+	if got := d.Apply(FieldFavoriteCount, 3); got != 0 {
+		t.Errorf("Apply(favorite, 3) = %d, want 0", got)
+	}
+	if got := d.Apply(FieldCommentCount, 3); got != 5 {
+		t.Errorf("Apply(comment, 3) = %d, want 5", got)
+	}
+	if got := d.Apply(FieldFollowCount, 7); got != 7 {
+		t.Errorf("Apply(follow, 7) = %d, want 7", got)
+	}
+}
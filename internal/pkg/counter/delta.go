@@ -0,0 +1,138 @@
+package counter
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/dao"
+	"github.com/wangn-tech/tiny-douyin/internal/model"
+)
+
+// 计数对象类型（对应 model.CounterDelta.Target）
+const (
+	targetVideo = "video"
+	targetUser  = "user"
+)
+
+// deltaKeys 计数对象类型对应的增量 Hash
+var deltaKeys = map[string]string{
+	targetVideo: constant.RedisKeyCounterVideoDelta,
+	targetUser:  constant.RedisKeyCounterUserDelta,
+}
+
+// applyScript 将计数增量记录写入增量 Hash，按记录 ID 设置标记保证每条记录只写入一次
+// KEYS[1]: 增量 Hash，KEYS[2..n]: 各记录的标记
+// ARGV[1]: 标记过期时间（秒），ARGV[2k]、ARGV[2k+1]: 第 k 条记录的 field 和增量
+var applyScript = redis.NewScript(`
+local applied = 0
+for i = 2, #KEYS do
+	if redis.call('SET', KEYS[i], '1', 'NX', 'EX', ARGV[1]) then
+		redis.call('HINCRBY', KEYS[1], ARGV[2 * i - 2], ARGV[2 * i - 1])
+		applied = applied + 1
+	end
+end
+return applied
+`)
+
+// appliedKey 计数增量记录已写入 Redis 的标记
+func appliedKey(id uint) string {
+	return constant.RedisKeyCounterAppliedPrefix + strconv.FormatUint(uint64(id), 10)
+}
+
+// applyDeltas 将计数增量记录幂等地写入 Redis（同一条记录重复写入时跳过）
+func applyDeltas(ctx context.Context, rdb *redis.Client, deltas []*model.CounterDelta) error {
+	byTarget := make(map[string][]*model.CounterDelta)
+	for _, delta := range deltas {
+		byTarget[delta.Target] = append(byTarget[delta.Target], delta)
+	}
+
+	ttl := strconv.FormatInt(int64(constant.CounterDeltaMarkerTTL.Seconds()), 10)
+	for target, rows := range byTarget {
+		deltaKey, ok := deltaKeys[target]
+		if !ok {
+			return fmt.Errorf("unknown counter target %q", target)
+		}
+
+		keys := make([]string, 0, len(rows)+1)
+		args := make([]any, 0, len(rows)*2+1)
+		keys = append(keys, deltaKey)
+		args = append(args, ttl)
+		for _, row := range rows {
+			keys = append(keys, appliedKey(row.ID))
+			args = append(args, deltaField(row.ObjectID, row.Field), row.Delta)
+		}
+
+		if err := applyScript.Run(ctx, rdb, keys, args...).Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// drainDeltas 将已提交的计数增量记录分批写入 Redis 并删除，返回处理的记录数
+// 写入 Redis 后、删除记录前崩溃时，下次会重新处理这些记录，已写入的记录通过标记跳过
+func drainDeltas(ctx context.Context, rdb *redis.Client, counterDAO dao.ICounterDAO) (int, error) {
+	total := 0
+	for {
+		deltas, err := counterDAO.ListCounterDeltas(ctx, constant.CounterDeltaDrainBatchSize)
+		if err != nil {
+			return total, err
+		}
+		if len(deltas) == 0 {
+			return total, nil
+		}
+
+		if err := applyDeltas(ctx, rdb, deltas); err != nil {
+			return total, fmt.Errorf("failed to apply counter deltas: %w", err)
+		}
+
+		ids := make([]uint, 0, len(deltas))
+		for _, delta := range deltas {
+			ids = append(ids, delta.ID)
+		}
+		if err := counterDAO.DeleteCounterDeltas(ctx, ids); err != nil {
+			return total, err
+		}
+
+		total += len(deltas)
+		if len(deltas) < constant.CounterDeltaDrainBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// unappliedDeltas 汇总指定对象尚未写入 Redis 的计数增量记录（对账时与 Redis 中的增量合并）
+func unappliedDeltas(ctx context.Context, rdb *redis.Client, counterDAO dao.ICounterDAO, target string, ids []uint) (map[uint]Deltas, error) {
+	result := make(map[uint]Deltas)
+
+	deltas, err := counterDAO.ListCounterDeltasByObjectIDs(ctx, target, ids)
+	if err != nil || len(deltas) == 0 {
+		return result, err
+	}
+
+	pipe := rdb.Pipeline()
+	cmds := make([]*redis.IntCmd, len(deltas))
+	for i, delta := range deltas {
+		cmds[i] = pipe.Exists(ctx, appliedKey(delta.ID))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to check counter delta markers: %w", err)
+	}
+
+	for i, delta := range deltas {
+		if cmds[i].Val() > 0 {
+			continue // 已写入 Redis，包含在 Redis 增量中
+		}
+		if result[delta.ObjectID] == nil {
+			result[delta.ObjectID] = make(Deltas)
+		}
+		result[delta.ObjectID][delta.Field] += delta.Delta
+	}
+
+	return result, nil
+}
@@ -0,0 +1,118 @@
+package counter
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/dao"
+	"github.com/wangn-tech/tiny-douyin/internal/model"
+)
+
+// fakeCounterDAO 内存中的计数增量记录和回写记录
+type fakeCounterDAO struct {
+	dao.ICounterDAO
+	deltas  []*model.CounterDelta
+	flushed map[string]bool
+	staged  map[string]bool // 当前事务中写入的回写记录
+}
+
+func (d *fakeCounterDAO) ListCounterDeltas(ctx context.Context, limit int) ([]*model.CounterDelta, error) {
+	return d.deltas[:min(limit, len(d.deltas))], nil
+}
+
+func (d *fakeCounterDAO) DeleteCounterDeltas(ctx context.Context, ids []uint) error {
+	d.deltas = slices.DeleteFunc(d.deltas, func(delta *model.CounterDelta) bool {
+		return slices.Contains(ids, delta.ID)
+	})
+	return nil
+}
+
+func (d *fakeCounterDAO) MarkFlushed(ctx context.Context, batchKey string) (bool, error) {
+	if d.flushed[batchKey] || d.staged[batchKey] {
+		return false, nil
+	}
+	d.staged[batchKey] = true
+	return true, nil
+}
+
+func TestApplyDeltas_Idempotent(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	ctx := context.Background()
+
+	rows := []*model.CounterDelta{
+		{ID: 1, Target: targetVideo, ObjectID: 10, Field: FieldFavoriteCount, Delta: 1},
+		{ID: 2, Target: targetVideo, ObjectID: 10, Field: FieldFavoriteCount, Delta: 1},
+		{ID: 3, Target: targetVideo, ObjectID: 10, Field: FieldCommentCount, Delta: -1},
+		{ID: 4, Target: targetUser, ObjectID: 20, Field: FieldFollowerCount, Delta: 1},
+	}
+	if err := applyDeltas(ctx, rdb, rows); err != nil {
+		t.Fatalf("applyDeltas() error = %v", err)
+	}
+	// 重复写入（如提交后回调与补写同时执行）时跳过已写入的记录
+	retry := []*model.CounterDelta{
+		rows[1],
+		{ID: 5, Target: targetVideo, ObjectID: 10, Field: FieldFavoriteCount, Delta: 1},
+	}
+	if err := applyDeltas(ctx, rdb, retry); err != nil {
+		t.Fatalf("applyDeltas() error = %v", err)
+	}
+
+	tests := []struct {
+		key, field, want string
+	}{
+		{constant.RedisKeyCounterVideoDelta, deltaField(10, FieldFavoriteCount), "3"},
+		{constant.RedisKeyCounterVideoDelta, deltaField(10, FieldCommentCount), "-1"},
+		{constant.RedisKeyCounterUserDelta, deltaField(20, FieldFollowerCount), "1"},
+	}
+	for _, tt := range tests {
+		if got := mr.HGet(tt.key, tt.field); got != tt.want {
+			t.Errorf("%s %s = %q, want %q", tt.key, tt.field, got, tt.want)
+		}
+	}
+	if ttl := mr.TTL(appliedKey(1)); ttl != constant.CounterDeltaMarkerTTL {
+		t.Errorf("marker ttl = %v, want %v", ttl, constant.CounterDeltaMarkerTTL)
+	}
+}
+
+func TestApplyDeltas_UnknownTarget(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	rows := []*model.CounterDelta{{ID: 1, Target: "comment", ObjectID: 1, Field: FieldFavoriteCount, Delta: 1}}
+	if err := applyDeltas(context.Background(), rdb, rows); err == nil {
+		t.Fatal("applyDeltas() error = nil, want unknown target error")
+	}
+}
+
+func TestDrainDeltas(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	ctx := context.Background()
+
+	counterDAO := &fakeCounterDAO{}
+	total := constant.CounterDeltaDrainBatchSize + 3
+	for i := 1; i <= total; i++ {
+		counterDAO.deltas = append(counterDAO.deltas, &model.CounterDelta{
+			ID: uint(i), Target: targetVideo, ObjectID: 1, Field: FieldFavoriteCount, Delta: 1,
+		})
+	}
+	// 第一条记录在提交后已写入 Redis
+	if err := applyDeltas(ctx, rdb, counterDAO.deltas[:1]); err != nil {
+		t.Fatalf("applyDeltas() error = %v", err)
+	}
+
+	n, err := drainDeltas(ctx, rdb, counterDAO)
+	if err != nil {
+		t.Fatalf("drainDeltas() error = %v", err)
+	}
+	if n != total {
+		t.Errorf("drained %d, want %d", n, total)
+	}
+	if len(counterDAO.deltas) != 0 {
+		t.Errorf("%d delta rows left, want 0", len(counterDAO.deltas))
+	}
+	// 已写入的记录不重复累加
+	if got := mr.HGet(constant.RedisKeyCounterVideoDelta, deltaField(1, FieldFavoriteCount)); got != strconv.Itoa(total) {
+		t.Errorf("favorite delta = %q, want %d", got, total)
+	}
+}
@@ -0,0 +1,276 @@
+package counter
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/dao"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+)
+
+// 正在回写的 Hash 中的元数据 field
+const (
+	batchField = "_batch" // 批次 ID
+	nextField  = "_next"  // 下一个待回写分批的序号
+)
+
+// rotateScript 将增量 Hash 原子地转移为待回写 Hash，并写入批次 ID
+// 如果上一次回写未完成（flushing 仍存在），则继续回写上一批次
+var rotateScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[2]) == 1 then
+	return 1
+end
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('RENAME', KEYS[1], KEYS[2])
+redis.call('HSET', KEYS[2], '` + batchField + `', ARGV[1])
+return 1
+`)
+
+// flushTarget 回写目标
+type flushTarget struct {
+	name        string
+	deltaKey    string
+	flushingKey string
+	columns     []string
+	apply       func(ctx context.Context, id uint, deltas map[string]int64) error
+}
+
+// Flusher 计数回写器
+// 回写流程：
+//  0. 将尚未写入 Redis 的计数增量记录（counter_deltas）补写到 counter:delta:* 并删除
+//  1. 将 counter:delta:* 原子转移为 counter:flushing:*（新的增量继续写入 delta）
+//  2. 按 ID 排序分批，每批在一个事务中更新计数并写入回写记录（批次 ID + 序号），
+//     提交后从 flushing 中移除该分批并推进序号，flushing 中只保留尚未回写的增量
+//  3. 全部成功后删除 flushing
+//
+// 任一步骤失败（包括进程崩溃）后，下次回写会继续处理 flushing 中的同一批次：剩余增量按相同规则分批，
+// 提交后未能移除的分批通过回写记录跳过，保证每个增量恰好回写一次
+type Flusher struct {
+	rdb        *redis.Client
+	counterDAO dao.ICounterDAO
+	txManager  dao.ITxManager
+	interval   time.Duration
+	targets    []flushTarget
+	lastClean  time.Time
+	done       chan struct{} // 后台回写退出后关闭
+}
+
+// NewFlusher 创建计数回写器（通过依赖注入）
+func NewFlusher(
+	rdb *redis.Client,
+	videoDAO dao.IVideoDAO,
+	userDAO dao.IUserDAO,
+	counterDAO dao.ICounterDAO,
+	txManager dao.ITxManager,
+) IFlusher {
+	interval := time.Duration(global.Config.Counter.FlushInterval) * time.Second
+	if interval <= 0 {
+		interval = constant.CounterDefaultFlushInterval
+	}
+
+	return &Flusher{
+		rdb:        rdb,
+		counterDAO: counterDAO,
+		txManager:  txManager,
+		interval:   interval,
+		targets: []flushTarget{
+			{
+				name:        "video",
+				deltaKey:    constant.RedisKeyCounterVideoDelta,
+				flushingKey: constant.RedisKeyCounterVideoFlushing,
+				columns:     []string{FieldFavoriteCount, FieldCommentCount},
+				apply:       videoDAO.AddCounts,
+			},
+			{
+				name:        "user",
+				deltaKey:    constant.RedisKeyCounterUserDelta,
+				flushingKey: constant.RedisKeyCounterUserFlushing,
+				columns:     []string{FieldFollowCount, FieldFollowerCount},
+				apply:       userDAO.AddFollowCounts,
+			},
+		},
+	}
+}
+
+// Start 启动后台回写
+func (f *Flusher) Start(ctx context.Context) error {
+	global.Logger.Info("Counter flusher started",
+		zap.Duration("interval", f.interval))
+
+	f.done = make(chan struct{})
+	go func() {
+		defer close(f.done)
+		ticker := time.NewTicker(f.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				// 退出前回写剩余增量
+				f.Flush(context.WithoutCancel(ctx))
+				global.Logger.Info("Counter flusher stopped")
+				return
+			case <-ticker.C:
+				f.Flush(ctx)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Wait 等待后台回写退出
+func (f *Flusher) Wait(ctx context.Context) error {
+	return waitDone(ctx, f.done)
+}
+
+// Flush 立即执行一次回写（对账进行中时跳过本轮）
+func (f *Flusher) Flush(ctx context.Context) {
+	unlock, err := tryLock(ctx, f.rdb)
+	if err != nil {
+		global.Logger.Error("counter.Flush.lock_error", zap.Error(err))
+		return
+	}
+	if unlock == nil {
+		global.Logger.Info("counter.Flush.skipped_locked")
+		return
+	}
+	defer unlock()
+
+	// 补写事务提交后未能写入 Redis 的增量，补写失败时仍回写 Redis 中已有的增量
+	if n, err := drainDeltas(ctx, f.rdb, f.counterDAO); err != nil {
+		global.Logger.Error("counter.Flush.drain_failed", zap.Int("drained", n), zap.Error(err))
+	} else if n > 0 {
+		global.Logger.Info("counter.Flush.drained", zap.Int("count", n))
+	}
+
+	for _, target := range f.targets {
+		if err := f.flushTarget(ctx, target); err != nil {
+			global.Logger.Error("counter.Flush.failed",
+				zap.String("target", target.name),
+				zap.Error(err),
+			)
+		}
+	}
+
+	// 定期清理过期的回写记录
+	if time.Since(f.lastClean) > constant.CounterFlushLogRetention {
+		if err := f.counterDAO.CleanupFlushLogs(ctx, time.Now().Add(-constant.CounterFlushLogRetention)); err == nil {
+			f.lastClean = time.Now()
+		}
+	}
+}
+
+// flushTarget 回写单个目标（视频或用户）的计数增量
+func (f *Flusher) flushTarget(ctx context.Context, target flushTarget) error {
+	// 1. 转移增量
+	n, err := rotateScript.Run(ctx, f.rdb,
+		[]string{target.deltaKey, target.flushingKey},
+		uuid.New().String(),
+	).Int()
+	if err != nil {
+		return fmt.Errorf("failed to rotate counter deltas: %w", err)
+	}
+	if n == 0 {
+		return nil // 没有待回写的增量
+	}
+
+	entries, err := f.rdb.HGetAll(ctx, target.flushingKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to read counter deltas: %w", err)
+	}
+
+	batchID := entries[batchField]
+	if batchID == "" {
+		return fmt.Errorf("counter batch id missing in %s", target.flushingKey)
+	}
+
+	// 2. 解析增量并按 ID 排序（保证重试时分批结果一致）
+	deltaMap := make(map[uint]map[string]int64)
+	for field, value := range entries {
+		id, column, ok := parseDeltaField(field)
+		if !ok || !slices.Contains(target.columns, column) {
+			continue
+		}
+		delta, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || delta == 0 {
+			continue
+		}
+		if deltaMap[id] == nil {
+			deltaMap[id] = make(map[string]int64)
+		}
+		deltaMap[id][column] = delta
+	}
+
+	ids := make([]uint, 0, len(deltaMap))
+	for id := range deltaMap {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	// 3. 分批回写（上次回写中途失败时从未移除的第一个分批继续）
+	next, _ := strconv.Atoi(entries[nextField])
+	for start := 0; start < len(ids); start += constant.CounterFlushBatchSize {
+		end := min(start+constant.CounterFlushBatchSize, len(ids))
+		chunk := ids[start:end]
+		batchKey := fmt.Sprintf("%s:%d", batchID, next)
+
+		err := f.txManager.Transaction(ctx, func(ctx context.Context) error {
+			first, err := f.counterDAO.MarkFlushed(ctx, batchKey)
+			if err != nil {
+				return err
+			}
+			if !first {
+				return nil // 该分批已回写过
+			}
+			for _, id := range chunk {
+				if err := target.apply(ctx, id, deltaMap[id]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to flush counter batch %s: %w", batchKey, err)
+		}
+
+		// 已回写的增量不再计入待回写增量
+		fields := make([]string, 0, len(chunk)*len(target.columns))
+		for _, id := range chunk {
+			for _, column := range target.columns {
+				fields = append(fields, deltaField(id, column))
+			}
+		}
+		pipe := f.rdb.TxPipeline()
+		pipe.HDel(ctx, target.flushingKey, fields...)
+		pipe.HSet(ctx, target.flushingKey, nextField, next+1)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("failed to remove flushed counter batch %s: %w", batchKey, err)
+		}
+		next++
+	}
+
+	// 4. 全部回写成功，删除 flushing
+	if err := f.rdb.Del(ctx, target.flushingKey).Err(); err != nil {
+		return fmt.Errorf("failed to delete flushed counter deltas: %w", err)
+	}
+
+	global.Logger.Info("counter.Flush.success",
+		zap.String("target", target.name),
+		zap.String("batch_id", batchID),
+		zap.Int("count", len(ids)),
+	)
+
+	return nil
+}
@@ -0,0 +1,247 @@
+package counter
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"testing"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+)
+
+// fakeTxManager 模拟事务：fn 成功时提交暂存的回写记录和计数，失败时丢弃
+type fakeTxManager struct {
+	counterDAO *fakeCounterDAO
+	counts     map[uint]int64 // 已提交的计数
+	staged     map[uint]int64 // 当前事务中的计数
+}
+
+func (m *fakeTxManager) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.counterDAO.staged = make(map[string]bool)
+	m.staged = make(map[uint]int64)
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	maps.Copy(m.counterDAO.flushed, m.counterDAO.staged)
+	for id, delta := range m.staged {
+		m.counts[id] += delta
+	}
+	return nil
+}
+
+// newTestFlusher 创建只有视频点赞数一个目标的回写器，failOn 中的视频第一次回写时失败
+func newTestFlusher(t *testing.T, failOn ...uint) (*Flusher, *fakeTxManager, flushTarget) {
+	t.Helper()
+	_, rdb := newTestRedis(t)
+
+	counterDAO := &fakeCounterDAO{flushed: make(map[string]bool)}
+	txManager := &fakeTxManager{counterDAO: counterDAO, counts: make(map[uint]int64)}
+	failed := make(map[uint]bool)
+	target := flushTarget{
+		name:        "video",
+		deltaKey:    constant.RedisKeyCounterVideoDelta,
+		flushingKey: constant.RedisKeyCounterVideoFlushing,
+		columns:     []string{FieldFavoriteCount},
+		apply: func(ctx context.Context, id uint, deltas map[string]int64) error {
+			for _, failID := range failOn {
+				if id == failID && !failed[id] {
+					failed[id] = true
+					return errors.New("apply failed")
+				}
+			}
+			txManager.staged[id] += deltas[FieldFavoriteCount]
+			return nil
+		},
+	}
+	f := &Flusher{rdb: rdb, counterDAO: counterDAO, txManager: txManager, targets: []flushTarget{target}}
+	return f, txManager, target
+}
+
+// seedDeltas 为视频 1..n 各写入一个点赞增量
+func seedDeltas(t *testing.T, f *Flusher, key string, n int) {
+	t.Helper()
+	values := make([]any, 0, n*2)
+	for id := 1; id <= n; id++ {
+		values = append(values, deltaField(uint(id), FieldFavoriteCount), 1)
+	}
+	if err := f.rdb.HSet(context.Background(), key, values...).Err(); err != nil {
+		t.Fatalf("failed to seed deltas: %v", err)
+	}
+}
+
+// assertFlushedOnce 校验视频 1..n 的增量恰好回写一次，且 flushing 已删除
+func assertFlushedOnce(t *testing.T, f *Flusher, txManager *fakeTxManager, n int) {
+	t.Helper()
+	for id := 1; id <= n; id++ {
+		if got := txManager.counts[uint(id)]; got != 1 {
+			t.Fatalf("video %d flushed %d times, want 1", id, got)
+		}
+	}
+	if exists := f.rdb.Exists(context.Background(), constant.RedisKeyCounterVideoFlushing).Val(); exists != 0 {
+		t.Error("flushing key still exists after successful flush")
+	}
+}
+
+func TestRotateScript(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	ctx := context.Background()
+	keys := []string{constant.RedisKeyCounterVideoDelta, constant.RedisKeyCounterVideoFlushing}
+
+	// 没有增量
+	if n, err := rotateScript.Run(ctx, rdb, keys, "b1").Int(); err != nil || n != 0 {
+		t.Fatalf("rotate empty = %d, %v, want 0", n, err)
+	}
+
+	// 转移增量并写入批次 ID
+	mr.HSet(keys[0], deltaField(1, FieldFavoriteCount), "2")
+	if n, err := rotateScript.Run(ctx, rdb, keys, "b1").Int(); err != nil || n != 1 {
+		t.Fatalf("rotate = %d, %v, want 1", n, err)
+	}
+	if mr.Exists(keys[0]) {
+		t.Error("delta key still exists after rotate")
+	}
+	if got := mr.HGet(keys[1], batchField); got != "b1" {
+		t.Errorf("batch id = %q, want b1", got)
+	}
+
+	// 上一批次未完成时不转移新的增量，继续回写上一批次
+	mr.HSet(keys[0], deltaField(2, FieldFavoriteCount), "1")
+	if n, err := rotateScript.Run(ctx, rdb, keys, "b2").Int(); err != nil || n != 1 {
+		t.Fatalf("rotate = %d, %v, want 1", n, err)
+	}
+	if got := mr.HGet(keys[1], batchField); got != "b1" {
+		t.Errorf("batch id = %q, want b1", got)
+	}
+	if got := mr.HGet(keys[0], deltaField(2, FieldFavoriteCount)); got != "1" {
+		t.Errorf("new delta = %q, want 1", got)
+	}
+}
+
+func TestFlusher_FlushTarget(t *testing.T) {
+	tests := []struct {
+		name   string
+		ids    int
+		failOn []uint // 第一次回写失败的视频
+	}{
+		{name: "single batch", ids: 10},
+		{name: "multiple batches", ids: constant.CounterFlushBatchSize*2 + 5},
+		{name: "resume after first batch fails", ids: constant.CounterFlushBatchSize + 5, failOn: []uint{1}},
+		{name: "resume after middle batch fails", ids: constant.CounterFlushBatchSize*2 + 5, failOn: []uint{constant.CounterFlushBatchSize + 3}},
+		{name: "resume after last batch fails twice", ids: constant.CounterFlushBatchSize*2 + 5, failOn: []uint{constant.CounterFlushBatchSize*2 + 1, constant.CounterFlushBatchSize*2 + 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, txManager, target := newTestFlusher(t, tt.failOn...)
+			ctx := context.Background()
+			seedDeltas(t, f, target.deltaKey, tt.ids)
+
+			// 每次失败后下一轮从剩余增量继续
+			var err error
+			for range len(tt.failOn) + 1 {
+				if err = f.flushTarget(ctx, target); err == nil {
+					break
+				}
+				// 已回写的分批不再计入待回写增量
+				var pending, committed int64
+				for field := range f.rdb.HGetAll(ctx, target.flushingKey).Val() {
+					if _, _, ok := parseDeltaField(field); ok {
+						pending++
+					}
+				}
+				for _, count := range txManager.counts {
+					committed += count
+				}
+				if pending+committed != int64(tt.ids) {
+					t.Fatalf("pending %d + committed %d != %d", pending, committed, tt.ids)
+				}
+			}
+			if err != nil {
+				t.Fatalf("flushTarget() error = %v", err)
+			}
+			assertFlushedOnce(t, f, txManager, tt.ids)
+		})
+	}
+}
+
+func TestFlusher_FlushTargetSkipsLoggedBatch(t *testing.T) {
+	f, txManager, target := newTestFlusher(t)
+	ctx := context.Background()
+	n := constant.CounterFlushBatchSize + 5
+	seedDeltas(t, f, target.flushingKey, n)
+
+	// 模拟上次回写提交了第一个分批后、移除前崩溃：分批已记录但增量仍在 flushing 中
+	f.rdb.HSet(ctx, target.flushingKey, batchField, "b1")
+	f.counterDAO.(*fakeCounterDAO).flushed["b1:0"] = true
+	for id := 1; id <= constant.CounterFlushBatchSize; id++ {
+		txManager.counts[uint(id)] = 1
+	}
+
+	if err := f.flushTarget(ctx, target); err != nil {
+		t.Fatalf("flushTarget() error = %v", err)
+	}
+	assertFlushedOnce(t, f, txManager, n)
+	if !f.counterDAO.(*fakeCounterDAO).flushed["b1:1"] {
+		t.Errorf("batch b1:1 not logged, logs = %v", f.counterDAO.(*fakeCounterDAO).flushed)
+	}
+}
+
+func TestFlusher_FlushTargetContinuesFromNext(t *testing.T) {
+	f, txManager, target := newTestFlusher(t)
+	ctx := context.Background()
+	n := constant.CounterFlushBatchSize + 5
+
+	// 上次回写已移除前两个分批，剩余增量按新的序号继续
+	values := make([]any, 0)
+	for id := n - 4; id <= n; id++ {
+		values = append(values, deltaField(uint(id), FieldFavoriteCount), 1)
+	}
+	f.rdb.HSet(ctx, target.flushingKey, values...)
+	f.rdb.HSet(ctx, target.flushingKey, batchField, "b1", nextField, 2)
+
+	if err := f.flushTarget(ctx, target); err != nil {
+		t.Fatalf("flushTarget() error = %v", err)
+	}
+	logs := f.counterDAO.(*fakeCounterDAO).flushed
+	if !logs["b1:2"] || len(logs) != 1 {
+		t.Errorf("flush logs = %v, want only b1:2", logs)
+	}
+	for id := n - 4; id <= n; id++ {
+		if got := txManager.counts[uint(id)]; got != 1 {
+			t.Errorf("video %d flushed %d times, want 1", id, got)
+		}
+	}
+}
+
+func TestTryLock(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	ctx := context.Background()
+
+	unlock, err := tryLock(ctx, rdb)
+	if err != nil || unlock == nil {
+		t.Fatalf("tryLock() = %v, got lock %v, want lock", err, unlock != nil)
+	}
+	if ttl := mr.TTL(constant.RedisKeyCounterLock); ttl != constant.CounterLockTTL {
+		t.Errorf("lock ttl = %v, want %v", ttl, constant.CounterLockTTL)
+	}
+	if again, err := tryLock(ctx, rdb); err != nil || again != nil {
+		t.Fatalf("second tryLock() = %v, got lock %v, want no lock", err, again != nil)
+	}
+
+	// 锁过期后被其他实例获取，原持有者释放时不能删除别人的锁
+	mr.Set(constant.RedisKeyCounterLock, "other")
+	unlock()
+	if got, _ := mr.Get(constant.RedisKeyCounterLock); got != "other" {
+		t.Errorf("lock = %q after stale unlock, want other", got)
+	}
+
+	mr.Del(constant.RedisKeyCounterLock)
+	unlock, err = tryLock(ctx, rdb)
+	if err != nil || unlock == nil {
+		t.Fatalf("tryLock() = %v, got lock %v, want lock", err, unlock != nil)
+	}
+	unlock()
+	if mr.Exists(constant.RedisKeyCounterLock) {
+		t.Error("lock still exists after unlock")
+	}
+}
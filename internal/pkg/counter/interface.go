@@ -0,0 +1,72 @@
+package counter
+
+import "context"
+
+// 计数字段（对应数据库列名）
+const (
+	// FieldFavoriteCount 视频点赞数
+	FieldFavoriteCount = "favorite_count"
+	// FieldCommentCount 视频评论数
+	FieldCommentCount = "comment_count"
+	// FieldFollowCount 用户关注数
+	FieldFollowCount = "follow_count"
+	// FieldFollowerCount 用户粉丝数
+	FieldFollowerCount = "follower_count"
+)
+
+// Deltas 单个对象尚未回写的计数增量（field -> delta）
+type Deltas map[string]int64
+
+// ICounter 计数器接口
+// 计数增量随业务事务持久化后累加到 Redis，由 IFlusher 定期批量回写 MySQL
+type ICounter interface {
+	// IncrVideo 增加视频计数增量（增量记录随当前事务提交，提交后写入 Redis）
+	IncrVideo(ctx context.Context, videoID uint, field string, delta int64) error
+	// IncrUser 增加用户计数增量（增量记录随当前事务提交，提交后写入 Redis）
+	IncrUser(ctx context.Context, userID uint, field string, delta int64) error
+	// PendingVideoDeltas 批量获取 Redis 中视频尚未回写的计数增量（读路径合并展示）
+	PendingVideoDeltas(ctx context.Context, videoIDs []uint) map[uint]Deltas
+	// PendingUserDeltas 批量获取 Redis 中用户尚未回写的计数增量（读路径合并展示）
+	PendingUserDeltas(ctx context.Context, userIDs []uint) map[uint]Deltas
+}
+
+// IFlusher 计数回写器接口
+type IFlusher interface {
+	// Start 启动后台回写，ctx 取消时执行最后一次回写后退出
+	Start(ctx context.Context) error
+	// Wait 等待后台回写退出（包括最后一次回写）
+	Wait(ctx context.Context) error
+	// Flush 立即执行一次回写
+	Flush(ctx context.Context)
+}
+
+// Discrepancy 单个对象单个计数字段的差异
+type Discrepancy struct {
+	ID      uint   `json:"id"`
+	Field   string `json:"field"`
+	Stored  int64  `json:"stored"`  // MySQL 中的计数
+	Pending int64  `json:"pending"` // 尚未回写 MySQL 的增量
+	Actual  int64  `json:"actual"`  // 根据源表重新统计的计数
+}
+
+// ReconcileReport 单个对账目标（视频或用户）的对账结果
+type ReconcileReport struct {
+	Target        string        `json:"target"`
+	Scanned       int           `json:"scanned"`       // 检查的对象数
+	Mismatched    int           `json:"mismatched"`    // 复查确认存在差异的对象数
+	Transient     int           `json:"transient"`     // 首轮存在差异、复查时差异已消失或变化的对象数
+	Repaired      int           `json:"repaired"`      // 已修复的对象数
+	Skipped       bool          `json:"skipped"`       // 上次回写未完成，跳过了本次对账（下次回写完成后再对账）
+	Discrepancies []Discrepancy `json:"discrepancies"` // 差异明细（最多保留 CounterReconcileMaxReport 条）
+}
+
+// IReconciler 计数对账接口
+// 根据点赞、评论、关注关系表重新统计计数，与 MySQL 计数 + 尚未回写的增量比较
+type IReconciler interface {
+	// Reconcile 执行一次全量对账（首轮发现的差异间隔一段时间后复查确认），repair 为 true 时分批修复确认的差异
+	Reconcile(ctx context.Context, repair bool) ([]*ReconcileReport, error)
+	// Start 按配置的间隔在后台定时对账（间隔为 0 时不启动）
+	Start(ctx context.Context) error
+	// Wait 等待后台对账退出
+	Wait(ctx context.Context) error
+}
@@ -0,0 +1,45 @@
+package counter
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+)
+
+// unlockScript 仅释放自己持有的锁
+var unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// tryLock 尝试获取计数回写/对账互斥锁，返回释放函数；锁被占用时返回 nil
+// 回写和对账都会读取 MySQL 计数与 Redis 增量，互斥执行才能得到一致的快照
+func tryLock(ctx context.Context, rdb *redis.Client) (func(), error) {
+	token := uuid.New().String()
+	ok, err := rdb.SetNX(ctx, constant.RedisKeyCounterLock, token, constant.CounterLockTTL).Result()
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	return func() {
+		_ = unlockScript.Run(context.WithoutCancel(ctx), rdb, []string{constant.RedisKeyCounterLock}, token).Err()
+	}, nil
+}
+
+// waitDone 等待后台任务退出；未启动（done 为 nil）时直接返回
+func waitDone(ctx context.Context, done <-chan struct{}) error {
+	if done == nil {
+		return nil
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,468 @@
+package counter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/dao"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/model"
+)
+
+// lockRetryInterval 等待回写锁的重试间隔
+const lockRetryInterval = 100 * time.Millisecond
+
+// errFlushIncomplete 上次回写中途失败，flushing 中可能残留已提交但尚未移除的分批
+var errFlushIncomplete = errors.New("counter flush incomplete")
+
+// countRow 对账时读取的单个对象计数
+type countRow struct {
+	id     uint
+	stored Deltas
+}
+
+// countSource 计数字段及其源表统计方法
+type countSource struct {
+	field string
+	count func(ctx context.Context, ids []uint) (map[uint]int64, error)
+}
+
+// reconcileTarget 对账目标
+type reconcileTarget struct {
+	name        string
+	flushingKey string
+	scan        func(ctx context.Context, afterID uint, limit int) ([]countRow, error)
+	load        func(ctx context.Context, ids []uint) ([]countRow, error)
+	sources     []countSource
+	pending     func(ctx context.Context, ids []uint) (map[uint]Deltas, error)
+	apply       func(ctx context.Context, id uint, deltas map[string]int64) error
+}
+
+// fixKey 单个对象单个计数字段
+type fixKey struct {
+	id    uint
+	field string
+}
+
+// checkedRow 对账后的单个对象
+type checkedRow struct {
+	id            uint
+	discrepancies []Discrepancy
+}
+
+// fix 需要补偿到 MySQL 的增量
+func (d Discrepancy) fix() int64 {
+	return d.Actual - d.Stored - d.Pending
+}
+
+// Reconciler 计数对账器
+// 期望满足：源表统计值 = MySQL 计数 + 尚未回写的增量（Redis 中的增量及尚未写入 Redis 的增量记录）
+// 每批对账持有与回写器相同的锁，避免回写过程中读到不一致的快照；
+// 但写入方不持有该锁，刚提交的增量可能尚未写入 Redis 或正在写入，首轮发现的差异可能只是暂时的，
+// 因此间隔 grace 后复查，只有两次差异相同的字段才视为真实差异并修复；
+// 修复以增量方式写入（actual - stored - pending），不会覆盖并发产生的新增量；
+// 上次回写中途失败（持有锁时 flushing 仍存在）时待回写增量可能重复计入，跳过该目标的本次对账
+type Reconciler struct {
+	rdb       *redis.Client
+	txManager dao.ITxManager
+	interval  time.Duration
+	grace     time.Duration
+	repair    bool
+	targets   []reconcileTarget
+	done      chan struct{} // 后台对账退出后关闭
+}
+
+// NewReconciler 创建计数对账器（通过依赖注入）
+func NewReconciler(
+	rdb *redis.Client,
+	videoDAO dao.IVideoDAO,
+	userDAO dao.IUserDAO,
+	counterDAO dao.ICounterDAO,
+	txManager dao.ITxManager,
+	counter ICounter,
+) IReconciler {
+	// 复查间隔至少覆盖两个回写周期，使首轮时正在写入 Redis 或正在回写的增量在复查前落定
+	flushInterval := time.Duration(global.Config.Counter.FlushInterval) * time.Second
+	if flushInterval <= 0 {
+		flushInterval = constant.CounterDefaultFlushInterval
+	}
+
+	return &Reconciler{
+		rdb:       rdb,
+		txManager: txManager,
+		interval:  time.Duration(global.Config.Counter.ReconcileInterval) * time.Minute,
+		grace:     max(constant.CounterReconcileGracePeriod, 2*flushInterval),
+		repair:    global.Config.Counter.ReconcileRepair,
+		targets: []reconcileTarget{
+			{
+				name:        "video",
+				flushingKey: constant.RedisKeyCounterVideoFlushing,
+				scan: func(ctx context.Context, afterID uint, limit int) ([]countRow, error) {
+					videos, err := counterDAO.ScanVideoCounts(ctx, afterID, limit)
+					return videoCountRows(videos), err
+				},
+				load: func(ctx context.Context, ids []uint) ([]countRow, error) {
+					videos, err := counterDAO.GetVideoCountsByIDs(ctx, ids)
+					return videoCountRows(videos), err
+				},
+				sources: []countSource{
+					{field: FieldFavoriteCount, count: counterDAO.CountFavoritesByVideoIDs},
+					{field: FieldCommentCount, count: counterDAO.CountCommentsByVideoIDs},
+				},
+				pending: pendingDeltas(rdb, counterDAO, targetVideo, counter.PendingVideoDeltas),
+				apply:   videoDAO.AddCounts,
+			},
+			{
+				name:        "user",
+				flushingKey: constant.RedisKeyCounterUserFlushing,
+				scan: func(ctx context.Context, afterID uint, limit int) ([]countRow, error) {
+					users, err := counterDAO.ScanUserCounts(ctx, afterID, limit)
+					return userCountRows(users), err
+				},
+				load: func(ctx context.Context, ids []uint) ([]countRow, error) {
+					users, err := counterDAO.GetUserCountsByIDs(ctx, ids)
+					return userCountRows(users), err
+				},
+				sources: []countSource{
+					{field: FieldFollowCount, count: counterDAO.CountFollowsByUserIDs},
+					{field: FieldFollowerCount, count: counterDAO.CountFollowersByUserIDs},
+				},
+				pending: pendingDeltas(rdb, counterDAO, targetUser, counter.PendingUserDeltas),
+				apply:   userDAO.AddFollowCounts,
+			},
+		},
+	}
+}
+
+// pendingDeltas 汇总尚未回写 MySQL 的增量：尚未写入 Redis 的增量记录 + Redis 中的增量
+func pendingDeltas(
+	rdb *redis.Client,
+	counterDAO dao.ICounterDAO,
+	target string,
+	redisPending func(ctx context.Context, ids []uint) map[uint]Deltas,
+) func(ctx context.Context, ids []uint) (map[uint]Deltas, error) {
+	return func(ctx context.Context, ids []uint) (map[uint]Deltas, error) {
+		result, err := unappliedDeltas(ctx, rdb, counterDAO, target, ids)
+		if err != nil {
+			return nil, err
+		}
+		for id, deltas := range redisPending(ctx, ids) {
+			if result[id] == nil {
+				result[id] = make(Deltas)
+			}
+			for field, delta := range deltas {
+				result[id][field] += delta
+			}
+		}
+		return result, nil
+	}
+}
+
+// videoCountRows 提取视频的计数字段
+func videoCountRows(videos []*model.Video) []countRow {
+	rows := make([]countRow, 0, len(videos))
+	for _, video := range videos {
+		rows = append(rows, countRow{id: video.ID, stored: Deltas{
+			FieldFavoriteCount: video.FavoriteCount,
+			FieldCommentCount:  video.CommentCount,
+		}})
+	}
+	return rows
+}
+
+// userCountRows 提取用户的计数字段
+func userCountRows(users []*model.User) []countRow {
+	rows := make([]countRow, 0, len(users))
+	for _, user := range users {
+		rows = append(rows, countRow{id: user.ID, stored: Deltas{
+			FieldFollowCount:   user.FollowCount,
+			FieldFollowerCount: user.FollowerCount,
+		}})
+	}
+	return rows
+}
+
+// Start 启动后台定时对账
+func (r *Reconciler) Start(ctx context.Context) error {
+	if r.interval <= 0 {
+		global.Logger.Info("Counter reconciler disabled")
+		return nil
+	}
+
+	global.Logger.Info("Counter reconciler started",
+		zap.Duration("interval", r.interval),
+		zap.Bool("repair", r.repair))
+
+	r.done = make(chan struct{})
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				global.Logger.Info("Counter reconciler stopped")
+				return
+			case <-ticker.C:
+				if _, err := r.Reconcile(ctx, r.repair); err != nil {
+					global.Logger.Error("counter.Reconcile.failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Wait 等待后台对账退出（进行中的对账在 ctx 取消后中止）
+func (r *Reconciler) Wait(ctx context.Context) error {
+	return waitDone(ctx, r.done)
+}
+
+// Reconcile 执行一次全量对账
+func (r *Reconciler) Reconcile(ctx context.Context, repair bool) ([]*ReconcileReport, error) {
+	start := time.Now()
+	reports := make([]*ReconcileReport, 0, len(r.targets))
+
+	for _, target := range r.targets {
+		report, err := r.reconcileTarget(ctx, target, repair)
+		if errors.Is(err, errFlushIncomplete) {
+			global.Logger.Warn("counter.Reconcile.flush_incomplete",
+				zap.String("target", target.name),
+				zap.Int("scanned", report.Scanned),
+			)
+			report.Skipped = true
+			reports = append(reports, report)
+			continue
+		}
+		if err != nil {
+			return reports, fmt.Errorf("failed to reconcile %s counters: %w", target.name, err)
+		}
+		reports = append(reports, report)
+
+		global.Logger.Info("counter.Reconcile.success",
+			zap.String("target", target.name),
+			zap.Int("scanned", report.Scanned),
+			zap.Int("mismatched", report.Mismatched),
+			zap.Int("transient", report.Transient),
+			zap.Int("repaired", report.Repaired),
+			zap.Bool("repair", repair),
+			zap.Duration("duration", time.Since(start)),
+		)
+	}
+
+	return reports, nil
+}
+
+// reconcileTarget 对账单个目标：按 ID 顺序分批找出差异，等待 grace 后复查并修复
+func (r *Reconciler) reconcileTarget(ctx context.Context, target reconcileTarget, repair bool) (*ReconcileReport, error) {
+	report := &ReconcileReport{Target: target.name, Discrepancies: []Discrepancy{}}
+
+	// 1. 首轮：全量扫描，记录存在差异的字段及需要补偿的增量
+	suspects := make(map[fixKey]int64)
+	var afterID uint
+	for {
+		rows, err := r.lockedCheck(ctx, target, func(ctx context.Context) ([]countRow, error) {
+			return target.scan(ctx, afterID, constant.CounterReconcileBatchSize)
+		})
+		if err != nil {
+			return report, err
+		}
+		for _, row := range rows {
+			for _, d := range row.discrepancies {
+				suspects[fixKey{id: d.ID, field: d.Field}] = d.fix()
+			}
+		}
+
+		report.Scanned += len(rows)
+		if len(rows) < constant.CounterReconcileBatchSize {
+			break
+		}
+		afterID = rows[len(rows)-1].id
+	}
+	if len(suspects) == 0 {
+		return report, nil
+	}
+
+	// 2. 等待首轮时正在提交、写入 Redis 或回写的增量落定
+	select {
+	case <-ctx.Done():
+		return report, ctx.Err()
+	case <-time.After(r.grace):
+	}
+
+	// 3. 复查：只有两次差异相同的字段才是真实差异
+	ids := make([]uint, 0, len(suspects))
+	seen := make(map[uint]bool, len(suspects))
+	for key := range suspects {
+		if !seen[key.id] {
+			seen[key.id] = true
+			ids = append(ids, key.id)
+		}
+	}
+	slices.Sort(ids)
+
+	for start := 0; start < len(ids); start += constant.CounterReconcileBatchSize {
+		chunk := ids[start:min(start+constant.CounterReconcileBatchSize, len(ids))]
+		if err := r.confirmBatch(ctx, target, chunk, suspects, repair, report); err != nil {
+			return report, err
+		}
+	}
+
+	report.Transient = len(seen) - report.Mismatched
+	return report, nil
+}
+
+// confirmBatch 在锁内复查一批首轮存在差异的对象，修复两次差异相同的字段
+func (r *Reconciler) confirmBatch(ctx context.Context, target reconcileTarget, ids []uint, suspects map[fixKey]int64, repair bool, report *ReconcileReport) error {
+	unlock, err := r.waitLock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	rows, err := r.check(ctx, target, func(ctx context.Context) ([]countRow, error) {
+		return target.load(ctx, ids)
+	})
+	if err != nil {
+		return err
+	}
+
+	fixes := make(map[uint]map[string]int64)
+	for _, row := range rows {
+		for _, d := range row.discrepancies {
+			if prev, ok := suspects[fixKey{id: d.ID, field: d.Field}]; !ok || prev != d.fix() {
+				continue // 首轮没有差异或差异已变化，可能仍有增量在途，留到下次对账
+			}
+
+			global.Logger.Warn("counter.Reconcile.mismatch",
+				zap.String("target", target.name),
+				zap.Uint("id", d.ID),
+				zap.String("field", d.Field),
+				zap.Int64("stored", d.Stored),
+				zap.Int64("pending", d.Pending),
+				zap.Int64("actual", d.Actual),
+			)
+			if len(report.Discrepancies) < constant.CounterReconcileMaxReport {
+				report.Discrepancies = append(report.Discrepancies, d)
+			}
+			if fixes[d.ID] == nil {
+				fixes[d.ID] = make(map[string]int64)
+			}
+			fixes[d.ID][d.Field] = d.fix()
+		}
+	}
+
+	report.Mismatched += len(fixes)
+	if !repair || len(fixes) == 0 {
+		return nil
+	}
+
+	err = r.txManager.Transaction(ctx, func(ctx context.Context) error {
+		for id, deltas := range fixes {
+			if err := target.apply(ctx, id, deltas); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	report.Repaired += len(fixes)
+	return nil
+}
+
+// lockedCheck 在锁内读取一批对象并对账
+func (r *Reconciler) lockedCheck(ctx context.Context, target reconcileTarget, read func(ctx context.Context) ([]countRow, error)) ([]checkedRow, error) {
+	unlock, err := r.waitLock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return r.check(ctx, target, read)
+}
+
+// check 读取一批对象，与源表统计值及尚未回写的增量比较，返回每个对象的差异（调用方需持有锁）
+// 上次回写未完成时返回 errFlushIncomplete
+func (r *Reconciler) check(ctx context.Context, target reconcileTarget, read func(ctx context.Context) ([]countRow, error)) ([]checkedRow, error) {
+	flushing, err := r.rdb.Exists(ctx, target.flushingKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check counter flushing key: %w", err)
+	}
+	if flushing > 0 {
+		return nil, errFlushIncomplete
+	}
+
+	rows, err := read(ctx)
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.id)
+	}
+
+	actual := make(map[string]map[uint]int64, len(target.sources))
+	for _, source := range target.sources {
+		counts, err := source.count(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		actual[source.field] = counts
+	}
+	pending, err := target.pending(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	checked := make([]checkedRow, 0, len(rows))
+	for _, row := range rows {
+		c := checkedRow{id: row.id}
+		for _, source := range target.sources {
+			d := Discrepancy{
+				ID:      row.id,
+				Field:   source.field,
+				Stored:  row.stored[source.field],
+				Pending: pending[row.id][source.field],
+				Actual:  actual[source.field][row.id],
+			}
+			if d.fix() != 0 {
+				c.discrepancies = append(c.discrepancies, d)
+			}
+		}
+		checked = append(checked, c)
+	}
+
+	return checked, nil
+}
+
+// waitLock 等待获取回写/对账互斥锁
+func (r *Reconciler) waitLock(ctx context.Context) (func(), error) {
+	for {
+		unlock, err := tryLock(ctx, r.rdb)
+		if err != nil {
+			return nil, fmt.Errorf("failed to acquire counter lock: %w", err)
+		}
+		if unlock != nil {
+			return unlock, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package event
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+)
+
+// matches 判断事件类型是否在订阅范围内（types 为空时订阅全部事件）
+func matches(types []string, eventType string) bool {
+	return len(types) == 0 || slices.Contains(types, eventType)
+}
+
+// dispatch 调用处理函数，失败时按递增的延迟重试，超过最大次数后记录日志并丢弃事件
+// 只有 ctx 取消时返回错误（调用方应将事件退回，由其他实例或重启后继续处理）
+func dispatch(ctx context.Context, logger *zap.Logger, group string, handler Handler, env *Envelope) error {
+	for attempt := 1; ; attempt++ {
+		err := handler(ctx, env)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if attempt >= constant.EventHandlerMaxAttempts {
+			logger.Error("Event handler failed, dropping event",
+				zap.String("group", group),
+				zap.String("event_id", env.ID),
+				zap.String("event_type", env.Type),
+				zap.Int("attempts", attempt),
+				zap.Error(err))
+			return nil
+		}
+
+		logger.Warn("Event handler failed, retrying",
+			zap.String("group", group),
+			zap.String("event_id", env.ID),
+			zap.String("event_type", env.Type),
+			zap.Int("attempt", attempt),
+			zap.Error(err))
+		select {
+		case <-time.After(constant.EventHandlerRetryDelay * time.Duration(attempt)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package event
+
+// 领域事件类型
+const (
+	// TypeVideoLiked 视频被点赞
+	TypeVideoLiked = "video.liked"
+	// TypeVideoPublished 视频处理完成并发布
+	TypeVideoPublished = "video.published"
+	// TypeCommentPosted 发表评论
+	TypeCommentPosted = "comment.posted"
+	// TypeUserFollowed 关注用户
+	TypeUserFollowed = "user.followed"
+	// TypeMessageSent 发送私信
+	TypeMessageSent = "message.sent"
+)
+
+// VideoLiked 视频被点赞（取消点赞后再次点赞会再次发布）
+type VideoLiked struct {
+	UserID   uint `json:"user_id"`   // 点赞用户 ID
+	VideoID  uint `json:"video_id"`  // 视频 ID
+	AuthorID uint `json:"author_id"` // 视频作者 ID
+}
+
+// EventType 事件类型
+func (VideoLiked) EventType() string { return TypeVideoLiked }
+
+// VideoPublished 视频处理完成，开始出现在视频流中
+type VideoPublished struct {
+	VideoID  uint   `json:"video_id"`  // 视频 ID
+	AuthorID uint   `json:"author_id"` // 作者 ID
+	Title    string `json:"title"`     // 视频标题
+}
+
+// EventType 事件类型
+func (VideoPublished) EventType() string { return TypeVideoPublished }
+
+// CommentPosted 发表评论（包括回复）
+type CommentPosted struct {
+	CommentID     uint   `json:"comment_id"`                 // 评论 ID
+	VideoID       uint   `json:"video_id"`                   // 视频 ID
+	AuthorID      uint   `json:"author_id"`                  // 视频作者 ID
+	UserID        uint   `json:"user_id"`                    // 评论用户 ID
+	Content       string `json:"content"`                    // 评论内容
+	ParentID      uint   `json:"parent_id,omitempty"`        // 回复的评论 ID（顶级评论为 0）
+	ReplyToUserID uint   `json:"reply_to_user_id,omitempty"` // 被回复的用户 ID（顶级评论为 0）
+}
+
+// EventType 事件类型
+func (CommentPosted) EventType() string { return TypeCommentPosted }
+
+// UserFollowed 关注用户
+type UserFollowed struct {
+	FollowerID uint `json:"follower_id"` // 关注者 ID
+	FolloweeID uint `json:"followee_id"` // 被关注者 ID
+}
+
+// EventType 事件类型
+func (UserFollowed) EventType() string { return TypeUserFollowed }
+
+// MessageSent 发送私信
+type MessageSent struct {
+	MessageID  uint   `json:"message_id"`   // 消息 ID
+	FromUserID uint   `json:"from_user_id"` // 发送者 ID
+	ToUserID   uint   `json:"to_user_id"`   // 接收者 ID
+	Type       string `json:"type"`         // 消息类型
+	Content    string `json:"content"`      // 消息内容（非文本消息为可选的附言）
+}
+
+// EventType 事件类型
+func (MessageSent) EventType() string { return TypeMessageSent }
@@ -0,0 +1,74 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrClosed 事件总线已关闭
+	ErrClosed = errors.New("event bus closed")
+	// ErrNotConnected 与消息队列的连接已断开，正在重连
+	ErrNotConnected = errors.New("event bus not connected")
+	// ErrDuplicateGroup 同一订阅组重复订阅
+	ErrDuplicateGroup = errors.New("event subscription group already exists")
+)
+
+// Event 领域事件
+type Event interface {
+	// EventType 事件类型（同时作为 RabbitMQ routing key）
+	EventType() string
+}
+
+// Envelope 事件信封，总线上传输的统一格式
+type Envelope struct {
+	ID         string          `json:"id"`          // 事件 ID（发件箱消息 ID，重复投递时不变，供订阅方去重）
+	Type       string          `json:"type"`        // 事件类型
+	OccurredAt time.Time       `json:"occurred_at"` // 发生时间
+	Payload    json.RawMessage `json:"payload"`     // 事件内容（JSON）
+}
+
+// NewEnvelope 将领域事件封装为信封
+func NewEnvelope(evt Event) (*Envelope, error) {
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event %s: %w", evt.EventType(), err)
+	}
+	return &Envelope{
+		Type:       evt.EventType(),
+		OccurredAt: time.Now(),
+		Payload:    payload,
+	}, nil
+}
+
+// Decode 解析事件内容到具体的事件类型
+func (e *Envelope) Decode(evt Event) error {
+	if err := json.Unmarshal(e.Payload, evt); err != nil {
+		return fmt.Errorf("failed to decode event %s: %w", e.Type, err)
+	}
+	return nil
+}
+
+// Handler 事件处理函数，返回错误时按 EventHandlerMaxAttempts 重试
+type Handler func(ctx context.Context, env *Envelope) error
+
+// IPublisher 领域事件发布接口（业务服务使用）
+type IPublisher interface {
+	// Publish 发布领域事件；在事务中调用时与业务变更一起提交，事务提交后才会投递给订阅方
+	Publish(ctx context.Context, events ...Event) error
+}
+
+// IBus 事件总线接口
+// 发布方不直接调用，由 outbox relay 将已提交的事件投递到总线
+type IBus interface {
+	// Publish 将事件投递给订阅了该类型的所有订阅组
+	Publish(ctx context.Context, env *Envelope) error
+	// Subscribe 以 group 为订阅组订阅指定类型的事件（types 为空时订阅全部事件），ctx 取消后停止订阅
+	// 同一订阅组的多个实例竞争消费，不同订阅组各自收到一份
+	Subscribe(ctx context.Context, group string, types []string, handler Handler) error
+	// Close 关闭事件总线（优雅停机时在订阅方退出后调用）
+	Close() error
+}
@@ -0,0 +1,114 @@
+package event
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+)
+
+// MemoryBus 进程内事件总线（本地开发和测试，事件不持久化，进程退出时未处理的事件丢失）
+type MemoryBus struct {
+	logger *zap.Logger
+
+	mu     sync.RWMutex
+	subs   map[string]*memorySubscription // group -> 订阅
+	closed bool
+	done   chan struct{}
+}
+
+// memorySubscription 进程内订阅，事件先进入缓冲 channel，由订阅组的 goroutine 依次处理
+type memorySubscription struct {
+	types   []string
+	handler Handler
+	ch      chan *Envelope
+	stopped chan struct{} // 订阅停止后关闭
+}
+
+// NewMemoryBus 创建进程内事件总线
+func NewMemoryBus(logger *zap.Logger) IBus {
+	return &MemoryBus{
+		logger: logger,
+		subs:   make(map[string]*memorySubscription),
+		done:   make(chan struct{}),
+	}
+}
+
+// Publish 将事件放入各订阅组的缓冲区（缓冲区已满时阻塞直到 ctx 取消）
+func (b *MemoryBus) Publish(ctx context.Context, env *Envelope) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return ErrClosed
+	}
+
+	for _, sub := range b.subs {
+		if !matches(sub.types, env.Type) {
+			continue
+		}
+		select {
+		case sub.ch <- env:
+		case <-sub.stopped:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-b.done:
+			return ErrClosed
+		}
+	}
+	return nil
+}
+
+// Subscribe 订阅事件
+func (b *MemoryBus) Subscribe(ctx context.Context, group string, types []string, handler Handler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+	if _, ok := b.subs[group]; ok {
+		return ErrDuplicateGroup
+	}
+
+	sub := &memorySubscription{
+		types:   types,
+		handler: handler,
+		ch:      make(chan *Envelope, constant.EventMemoryCapacity),
+		stopped: make(chan struct{}),
+	}
+	b.subs[group] = sub
+
+	go func() {
+		defer func() {
+			close(sub.stopped)
+			b.mu.Lock()
+			delete(b.subs, group)
+			b.mu.Unlock()
+		}()
+		for {
+			select {
+			case env := <-sub.ch:
+				if err := dispatch(ctx, b.logger, group, handler, env); err != nil {
+					return
+				}
+			case <-ctx.Done():
+				return
+			case <-b.done:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Close 关闭事件总线，停止所有订阅
+func (b *MemoryBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.closed {
+		b.closed = true
+		close(b.done)
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/config"
+)
+
+// RabbitMQBus 基于 RabbitMQ topic 交换机的事件总线
+// 事件以类型作为 routing key 发布，每个订阅组对应一个持久化队列 {exchange}.{group}，按类型绑定；
+// 连接断开后在后台按指数退避自动重连，订阅在重连后自动恢复
+type RabbitMQBus struct {
+	exchange string
+	url      string
+	logger   *zap.Logger
+
+	mu          sync.RWMutex
+	conn        *amqp.Connection
+	pubCh       *amqp.Channel // 发布 channel
+	reconnected chan struct{} // 每次连接成功后关闭并替换，用于唤醒等待连接的订阅
+	closed      bool
+	done        chan struct{}
+}
+
+// NewRabbitMQBus 创建 RabbitMQ 事件总线
+// 首次连接失败时不会退出，而是在后台持续重连（期间发布事件返回 ErrNotConnected，由 outbox relay 重试）
+func NewRabbitMQBus(cfg *config.RabbitMQConfig, exchange string, logger *zap.Logger) IBus {
+	b := &RabbitMQBus{
+		exchange:    exchange,
+		logger:      logger,
+		url:         fmt.Sprintf("amqp://%s:%s@%s:%d%s", cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.VHost),
+		reconnected: make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+
+	if err := b.connect(); err != nil {
+		b.logger.Error("Failed to connect event bus to RabbitMQ, retrying in background", zap.Error(err))
+		go b.reconnect()
+	} else {
+		b.logger.Info("RabbitMQ event bus initialized successfully", zap.String("exchange", exchange))
+	}
+	return b
+}
+
+// Publish 以事件类型为 routing key 发布到 topic 交换机
+func (b *RabbitMQBus) Publish(ctx context.Context, env *Envelope) error {
+	b.mu.RLock()
+	ch := b.pubCh
+	b.mu.RUnlock()
+	if ch == nil {
+		return ErrNotConnected
+	}
+
+	body, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	return ch.PublishWithContext(ctx, b.exchange, env.Type, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		MessageId:    env.ID,
+		Type:         env.Type,
+		Body:         body,
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    env.OccurredAt,
+	})
+}
+
+// Subscribe 声明订阅组队列并开始消费，连接断开后等待重连并重新订阅
+func (b *RabbitMQBus) Subscribe(ctx context.Context, group string, types []string, handler Handler) error {
+	b.mu.RLock()
+	closed := b.closed
+	b.mu.RUnlock()
+	if closed {
+		return ErrClosed
+	}
+
+	go func() {
+		for {
+			conn, err := b.waitConnected(ctx)
+			if err != nil {
+				return
+			}
+			ch, msgs, err := b.subscribe(conn, group, types)
+			if err != nil {
+				b.logger.Error("Failed to subscribe events, retrying",
+					zap.String("group", group),
+					zap.Error(err))
+				select {
+				case <-time.After(constant.QueueReconnectMinDelay):
+					continue
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			resubscribe := b.consume(ctx, group, handler, msgs)
+			_ = ch.Close()
+			if !resubscribe {
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Close 关闭连接并停止重连
+func (b *RabbitMQBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	close(b.done)
+
+	if b.pubCh != nil {
+		_ = b.pubCh.Close()
+	}
+	if b.conn != nil {
+		return b.conn.Close()
+	}
+	return nil
+}
+
+// connect 建立连接、声明 topic 交换机，并在连接意外断开时触发重连
+func (b *RabbitMQBus) connect() error {
+	conn, err := amqp.Dial(b.url)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	closeCh := conn.NotifyClose(make(chan *amqp.Error, 1))
+
+	ch, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to open channel: %w", err)
+	}
+	if err := ch.ExchangeDeclare(
+		b.exchange, // 交换机名称
+		"topic",    // 交换机类型
+		true,       // 持久化
+		false,      // 自动删除
+		false,      // 内部使用
+		false,      // 不等待
+		nil,        // 额外参数
+	); err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to declare event exchange: %w", err)
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		_ = conn.Close()
+		return ErrClosed
+	}
+	b.conn, b.pubCh = conn, ch
+	close(b.reconnected)
+	b.reconnected = make(chan struct{})
+	b.mu.Unlock()
+
+	go func() {
+		if err, ok := <-closeCh; ok && err != nil {
+			b.logger.Error("RabbitMQ event bus connection lost, reconnecting", zap.Error(err))
+			b.mu.Lock()
+			b.pubCh = nil
+			b.mu.Unlock()
+			b.reconnect()
+		}
+	}()
+	return nil
+}
+
+// reconnect 按指数退避重连，直到成功或总线关闭
+func (b *RabbitMQBus) reconnect() {
+	delay := constant.QueueReconnectMinDelay
+	for {
+		select {
+		case <-time.After(delay):
+		case <-b.done:
+			return
+		}
+
+		err := b.connect()
+		if err == nil {
+			b.logger.Info("RabbitMQ event bus reconnected")
+			return
+		}
+		if errors.Is(err, ErrClosed) {
+			return
+		}
+		b.logger.Warn("Failed to reconnect event bus to RabbitMQ",
+			zap.Duration("next_delay", delay),
+			zap.Error(err))
+		delay = min(delay*2, constant.QueueReconnectMaxDelay)
+	}
+}
+
+// waitConnected 等待连接可用
+func (b *RabbitMQBus) waitConnected(ctx context.Context) (*amqp.Connection, error) {
+	for {
+		b.mu.RLock()
+		conn, reconnected, closed := b.conn, b.reconnected, b.closed
+		b.mu.RUnlock()
+		if closed {
+			return nil, ErrClosed
+		}
+		if conn != nil && !conn.IsClosed() {
+			return conn, nil
+		}
+
+		select {
+		case <-reconnected:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-b.done:
+			return nil, ErrClosed
+		}
+	}
+}
+
+// subscribe 声明订阅组队列、按事件类型绑定到交换机并开始消费
+func (b *RabbitMQBus) subscribe(conn *amqp.Connection, group string, types []string) (*amqp.Channel, <-chan amqp.Delivery, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open consumer channel: %w", err)
+	}
+
+	queue := fmt.Sprintf(constant.EventQueueFormat, b.exchange, group)
+	if _, err := ch.QueueDeclare(queue, true, false, false, false, nil); err != nil {
+		_ = ch.Close()
+		return nil, nil, fmt.Errorf("failed to declare event queue %s: %w", queue, err)
+	}
+
+	keys := types
+	if len(keys) == 0 {
+		keys = []string{"#"} // 订阅全部事件
+	}
+	for _, key := range keys {
+		if err := ch.QueueBind(queue, key, b.exchange, false, nil); err != nil {
+			_ = ch.Close()
+			return nil, nil, fmt.Errorf("failed to bind event queue %s to %s: %w", queue, key, err)
+		}
+	}
+
+	if err := ch.Qos(constant.EventPrefetch, 0, false); err != nil {
+		_ = ch.Close()
+		return nil, nil, fmt.Errorf("failed to set qos: %w", err)
+	}
+
+	msgs, err := ch.Consume(queue, "", false, false, false, false, nil)
+	if err != nil {
+		_ = ch.Close()
+		return nil, nil, fmt.Errorf("failed to register event consumer: %w", err)
+	}
+	return ch, msgs, nil
+}
+
+// consume 依次处理订阅组队列中的事件
+// 返回 false 表示 ctx 已取消（停止订阅），true 表示消费 channel 意外关闭（需要重新订阅）
+func (b *RabbitMQBus) consume(ctx context.Context, group string, handler Handler, msgs <-chan amqp.Delivery) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case msg, ok := <-msgs:
+			if !ok {
+				return true
+			}
+
+			var env Envelope
+			if err := json.Unmarshal(msg.Body, &env); err != nil {
+				b.logger.Error("Failed to unmarshal event, dropping",
+					zap.String("group", group),
+					zap.String("message_id", msg.MessageId),
+					zap.Error(err))
+				_ = msg.Nack(false, false)
+				continue
+			}
+
+			if err := dispatch(ctx, b.logger, group, handler, &env); err != nil {
+				// 停机时中断的事件退回队列，由其他实例或重启后继续处理
+				_ = msg.Nack(false, true)
+				return false
+			}
+			if err := msg.Ack(false); err != nil {
+				b.logger.Warn("Failed to acknowledge event",
+					zap.String("group", group),
+					zap.String("event_id", env.ID),
+					zap.Error(err))
+			}
+		}
+	}
+}
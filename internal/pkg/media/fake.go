@@ -0,0 +1,117 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FakeProcessor 纯 Go 实现的视频处理器（不依赖 ffmpeg，用于测试和本地开发）
+// 通过文件头识别容器格式，元数据使用预设值，封面为纯色 JPEG，转码仅复制文件
+type FakeProcessor struct {
+	// Metadata 预设的视频元数据（FormatName 由文件头识别）
+	Metadata Metadata
+}
+
+// NewFakeProcessor 创建纯 Go 视频处理器
+func NewFakeProcessor() IProcessor {
+	return &FakeProcessor{
+		Metadata: Metadata{
+			Duration:   10 * time.Second,
+			Width:      720,
+			Height:     1280,
+			VideoCodec: "h264",
+			AudioCodec: "aac",
+		},
+	}
+}
+
+// Probe 通过文件头识别容器格式，返回预设元数据
+func (p *FakeProcessor) Probe(ctx context.Context, path string) (*Metadata, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return nil, fmt.Errorf("%w: file too short", ErrInvalidMedia)
+	}
+
+	formatName := sniffFormat(header)
+	if formatName == "" {
+		return nil, fmt.Errorf("%w: unrecognized container", ErrInvalidMedia)
+	}
+
+	meta := p.Metadata
+	meta.FormatName = formatName
+	return &meta, nil
+}
+
+// Transcode 直接复制文件
+func (p *FakeProcessor) Transcode(ctx context.Context, src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("failed to transcode video: %w", err)
+	}
+	return os.WriteFile(dst, data, 0644)
+}
+
+// ExtractCover 生成与视频分辨率相同的纯色 JPEG
+func (p *FakeProcessor) ExtractCover(ctx context.Context, src, dst string, at time.Duration) error {
+	width, height := p.Metadata.Width, p.Metadata.Height
+	if width <= 0 || height <= 0 {
+		width, height = 320, 240
+	}
+
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = color.Gray{Y: 128}.Y
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		return fmt.Errorf("failed to extract cover: %w", err)
+	}
+	return os.WriteFile(dst, buf.Bytes(), 0644)
+}
+
+// TranscodeHLS 为每个档位生成只有一个分片（源文件副本）的播放列表
+func (p *FakeProcessor) TranscodeHLS(ctx context.Context, src, outDir string, meta *Metadata, renditions []Rendition) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("failed to transcode hls: %w", err)
+	}
+
+	seconds := meta.Duration.Seconds()
+	var master strings.Builder
+	master.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+	for _, r := range renditions {
+		dir := filepath.Join(outDir, r.Name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, "seg_000.ts"), data, 0644); err != nil {
+			return err
+		}
+		playlist := fmt.Sprintf("#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:%d\n#EXT-X-PLAYLIST-TYPE:VOD\n#EXTINF:%.3f,\nseg_000.ts\n#EXT-X-ENDLIST\n",
+			int(math.Ceil(seconds)), seconds)
+		if err := os.WriteFile(filepath.Join(dir, "index.m3u8"), []byte(playlist), 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(&master, "#EXT-X-STREAM-INF:BANDWIDTH=%d\n%s/index.m3u8\n",
+			(r.VideoBitrate+r.AudioBitrate)*1000, r.Name)
+	}
+
+	return os.WriteFile(filepath.Join(outDir, HLSMasterPlaylist), []byte(master.String()), 0644)
+}
@@ -0,0 +1,234 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// supportedFormats 允许上传的容器格式（ffprobe format_name 中的名称）
+var supportedFormats = []string{"mp4", "mov", "matroska", "webm", "avi", "flv", "mpegts"}
+
+// FFmpegProcessor 基于 ffmpeg/ffprobe 命令行的视频处理器
+type FFmpegProcessor struct {
+	ffmpegPath  string
+	ffprobePath string
+}
+
+// NewFFmpegProcessor 创建 ffmpeg 视频处理器
+func NewFFmpegProcessor(ffmpegPath, ffprobePath string) IProcessor {
+	if ffmpegPath == "" {
+		ffmpegPath = "ffmpeg"
+	}
+	if ffprobePath == "" {
+		ffprobePath = "ffprobe"
+	}
+	return &FFmpegProcessor{
+		ffmpegPath:  ffmpegPath,
+		ffprobePath: ffprobePath,
+	}
+}
+
+// probeOutput ffprobe -print_format json 的输出
+type probeOutput struct {
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+	} `json:"format"`
+	Streams []struct {
+		CodecType string `json:"codec_type"`
+		CodecName string `json:"codec_name"`
+		Width     int    `json:"width"`
+		Height    int    `json:"height"`
+	} `json:"streams"`
+}
+
+// Probe 使用 ffprobe 校验容器并读取视频元数据
+func (p *FFmpegProcessor) Probe(ctx context.Context, path string) (*Metadata, error) {
+	out, err := p.run(ctx, p.ffprobePath,
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		path,
+	)
+	if err != nil {
+		// 只有 ctx 未取消时的非零退出才表示 ffprobe 无法解析文件，超时或停机属于可重试的错误
+		var exitErr *exec.ExitError
+		if ctx.Err() == nil && errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMedia, err)
+		}
+		return nil, err
+	}
+
+	var probe probeOutput
+	if err := json.Unmarshal(out, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+
+	meta := &Metadata{FormatName: probe.Format.FormatName}
+	if !isSupportedFormat(meta.FormatName) {
+		return nil, fmt.Errorf("%w: unsupported container %q", ErrInvalidMedia, meta.FormatName)
+	}
+
+	seconds, _ := strconv.ParseFloat(probe.Format.Duration, 64)
+	meta.Duration = time.Duration(seconds * float64(time.Second))
+	if meta.Duration <= 0 {
+		return nil, fmt.Errorf("%w: unknown duration", ErrInvalidMedia)
+	}
+
+	for _, stream := range probe.Streams {
+		switch stream.CodecType {
+		case "video":
+			if meta.VideoCodec == "" {
+				meta.VideoCodec = stream.CodecName
+				meta.Width = stream.Width
+				meta.Height = stream.Height
+			}
+		case "audio":
+			if meta.AudioCodec == "" {
+				meta.AudioCodec = stream.CodecName
+			}
+		}
+	}
+	if meta.VideoCodec == "" {
+		return nil, fmt.Errorf("%w: no video stream", ErrInvalidMedia)
+	}
+
+	return meta, nil
+}
+
+// Transcode 使用 ffmpeg 转码为 H.264/AAC 的 MP4，并把 moov 移到文件头以支持边下边播
+func (p *FFmpegProcessor) Transcode(ctx context.Context, src, dst string) error {
+	_, err := p.run(ctx, p.ffmpegPath,
+		"-y", "-v", "error",
+		"-i", src,
+		"-vf", "scale=trunc(iw/2)*2:trunc(ih/2)*2", // libx264 要求 yuv420p 的宽高为偶数
+		"-c:v", "libx264", "-preset", "veryfast", "-crf", "23", "-pix_fmt", "yuv420p",
+		"-c:a", "aac", "-b:a", "128k",
+		"-movflags", "+faststart",
+		dst,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to transcode video: %w", err)
+	}
+	return nil
+}
+
+// ExtractCover 使用 ffmpeg 截取指定时刻的视频帧
+func (p *FFmpegProcessor) ExtractCover(ctx context.Context, src, dst string, at time.Duration) error {
+	_, err := p.run(ctx, p.ffmpegPath,
+		"-y", "-v", "error",
+		"-ss", strconv.FormatFloat(at.Seconds(), 'f', 3, 64),
+		"-i", src,
+		"-frames:v", "1",
+		"-q:v", "2",
+		dst,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to extract cover: %w", err)
+	}
+	return nil
+}
+
+// TranscodeHLS 使用 ffmpeg 一次解码、多路编码生成多码率 HLS
+func (p *FFmpegProcessor) TranscodeHLS(ctx context.Context, src, outDir string, meta *Metadata, renditions []Rendition) error {
+	if len(renditions) == 0 {
+		return fmt.Errorf("no hls renditions")
+	}
+	if _, err := p.run(ctx, p.ffmpegPath, hlsArgs(src, outDir, meta, renditions)...); err != nil {
+		return fmt.Errorf("failed to transcode hls: %w", err)
+	}
+	return nil
+}
+
+// hlsArgs 构建多码率 HLS 的 ffmpeg 参数：每个档位一路视频（有音轨时附带一路音频），按 var_stream_map 输出到 outDir/{name}/
+func hlsArgs(src, outDir string, meta *Metadata, renditions []Rendition) []string {
+	hasAudio := meta.AudioCodec != ""
+
+	// 视频流拆分为多路并分别缩放（横屏按高度、竖屏按宽度缩放到目标短边，另一边按 -2 等比取偶数）
+	var filter strings.Builder
+	fmt.Fprintf(&filter, "[0:v]split=%d", len(renditions))
+	for i := range renditions {
+		fmt.Fprintf(&filter, "[v%d]", i)
+	}
+	for i, r := range renditions {
+		scale := fmt.Sprintf("-2:%d", r.Height)
+		if meta.Width < meta.Height {
+			scale = fmt.Sprintf("%d:-2", r.Height)
+		}
+		fmt.Fprintf(&filter, ";[v%d]scale=%s[v%dout]", i, scale, i)
+	}
+
+	args := []string{"-y", "-v", "error", "-i", src, "-filter_complex", filter.String()}
+	streamMap := make([]string, 0, len(renditions))
+	for i, r := range renditions {
+		idx := strconv.Itoa(i)
+		args = append(args,
+			"-map", "[v"+idx+"out]",
+			"-c:v:"+idx, "libx264",
+			"-b:v:"+idx, strconv.Itoa(r.VideoBitrate)+"k",
+			"-maxrate:v:"+idx, strconv.Itoa(r.VideoBitrate*107/100)+"k",
+			"-bufsize:v:"+idx, strconv.Itoa(r.VideoBitrate*3/2)+"k",
+		)
+		entry := "v:" + idx
+		if hasAudio {
+			args = append(args,
+				"-map", "a:0",
+				"-c:a:"+idx, "aac",
+				"-b:a:"+idx, strconv.Itoa(r.AudioBitrate)+"k",
+			)
+			entry += ",a:" + idx
+		}
+		streamMap = append(streamMap, entry+",name:"+r.Name)
+	}
+
+	args = append(args,
+		"-preset", "veryfast", "-pix_fmt", "yuv420p",
+		"-g", "48", "-keyint_min", "48", "-sc_threshold", "0",
+		"-f", "hls",
+		"-hls_time", "4",
+		"-hls_playlist_type", "vod",
+		"-hls_segment_filename", filepath.Join(outDir, "%v", "seg_%03d.ts"),
+		"-master_pl_name", HLSMasterPlaylist,
+		"-var_stream_map", strings.Join(streamMap, " "),
+		filepath.Join(outDir, "%v", "index.m3u8"),
+	)
+	return args
+}
+
+// run 执行命令并返回标准输出，失败时附带标准错误输出
+func (p *FFmpegProcessor) run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		// 超时或停机时进程被终止，同样表现为 *exec.ExitError，需返回 ctx 的错误以便调用方重试
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, fmt.Errorf("%s: %w", name, ctxErr)
+		}
+		return nil, fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// isSupportedFormat 判断 format_name（逗号分隔的多个名称）是否包含支持的容器格式
+func isSupportedFormat(formatName string) bool {
+	for _, name := range strings.Split(formatName, ",") {
+		for _, supported := range supportedFormats {
+			if name == supported {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package media
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// argValue 返回参数 flag 之后的值
+func argValue(t *testing.T, args []string, flag string) string {
+	t.Helper()
+	i := slices.Index(args, flag)
+	if i < 0 || i+1 >= len(args) {
+		t.Fatalf("flag %s not found in %v", flag, args)
+	}
+	return args[i+1]
+}
+
+func TestHLSArgs(t *testing.T) {
+	renditions := []Rendition{
+		{Name: "360p", Height: 360, VideoBitrate: 800, AudioBitrate: 64},
+		{Name: "720p", Height: 720, VideoBitrate: 2800, AudioBitrate: 128},
+	}
+
+	tests := []struct {
+		name      string
+		meta      *Metadata
+		filter    string
+		streamMap string
+		audioMaps int
+	}{
+		{
+			name:      "landscape with audio",
+			meta:      &Metadata{Width: 1280, Height: 720, AudioCodec: "aac"},
+			filter:    "[0:v]split=2[v0][v1];[v0]scale=-2:360[v0out];[v1]scale=-2:720[v1out]",
+			streamMap: "v:0,a:0,name:360p v:1,a:1,name:720p",
+			audioMaps: 2,
+		},
+		{
+			name:      "portrait scales width",
+			meta:      &Metadata{Width: 720, Height: 1280, AudioCodec: "aac"},
+			filter:    "[0:v]split=2[v0][v1];[v0]scale=360:-2[v0out];[v1]scale=720:-2[v1out]",
+			streamMap: "v:0,a:0,name:360p v:1,a:1,name:720p",
+			audioMaps: 2,
+		},
+		{
+			name:      "no audio stream",
+			meta:      &Metadata{Width: 1280, Height: 720},
+			filter:    "[0:v]split=2[v0][v1];[v0]scale=-2:360[v0out];[v1]scale=-2:720[v1out]",
+			streamMap: "v:0,name:360p v:1,name:720p",
+			audioMaps: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := hlsArgs("in.mp4", "out", tt.meta, renditions)
+
+			if got := argValue(t, args, "-i"); got != "in.mp4" {
+				t.Errorf("input = %q, want in.mp4", got)
+			}
+			if got := argValue(t, args, "-filter_complex"); got != tt.filter {
+				t.Errorf("filter = %q, want %q", got, tt.filter)
+			}
+			if got := argValue(t, args, "-var_stream_map"); got != tt.streamMap {
+				t.Errorf("var_stream_map = %q, want %q", got, tt.streamMap)
+			}
+			if got := argValue(t, args, "-master_pl_name"); got != HLSMasterPlaylist {
+				t.Errorf("master playlist = %q, want %q", got, HLSMasterPlaylist)
+			}
+			if got := argValue(t, args, "-b:v:1"); got != "2800k" {
+				t.Errorf("video bitrate = %q, want 2800k", got)
+			}
+			if got := argValue(t, args, "-hls_segment_filename"); got != filepath.Join("out", "%v", "seg_%03d.ts") {
+				t.Errorf("segment filename = %q", got)
+			}
+			if got := args[len(args)-1]; got != filepath.Join("out", "%v", "index.m3u8") {
+				t.Errorf("output = %q", got)
+			}
+
+			audioMaps := 0
+			for i, arg := range args {
+				if arg == "-map" && args[i+1] == "a:0" {
+					audioMaps++
+				}
+			}
+			if audioMaps != tt.audioMaps {
+				t.Errorf("audio maps = %d, want %d", audioMaps, tt.audioMaps)
+			}
+		})
+	}
+}
+
+func TestFakeProcessor_TranscodeHLS(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "source.mp4")
+	if err := os.WriteFile(src, []byte("video"), 0644); err != nil {
+		t.Fatalf("failed to write source: %v", err)
+	}
+	outDir := filepath.Join(dir, "hls")
+	meta := &Metadata{Width: 1280, Height: 720, Duration: 2500 * time.Millisecond}
+
+	p := NewFakeProcessor()
+	if err := p.TranscodeHLS(context.Background(), src, outDir, meta, SelectRenditions(meta, DefaultLadder)); err != nil {
+		t.Fatalf("TranscodeHLS() error = %v", err)
+	}
+
+	master, err := os.ReadFile(filepath.Join(outDir, HLSMasterPlaylist))
+	if err != nil {
+		t.Fatalf("failed to read master playlist: %v", err)
+	}
+	for _, name := range []string{"360p", "540p", "720p"} {
+		if !strings.Contains(string(master), name+"/index.m3u8") {
+			t.Errorf("master playlist missing %s:\n%s", name, master)
+		}
+		playlist, err := os.ReadFile(filepath.Join(outDir, name, "index.m3u8"))
+		if err != nil {
+			t.Fatalf("failed to read %s playlist: %v", name, err)
+		}
+		if !strings.Contains(string(playlist), "#EXT-X-TARGETDURATION:3\n") || !strings.Contains(string(playlist), "#EXT-X-ENDLIST") {
+			t.Errorf("%s playlist = %q", name, playlist)
+		}
+	}
+	if strings.Contains(string(master), "1080p") {
+		t.Errorf("master playlist should not upscale to 1080p:\n%s", master)
+	}
+}
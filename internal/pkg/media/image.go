@@ -0,0 +1,39 @@
+package media
+
+import (
+	"image"
+	_ "image/gif"  // 注册 GIF 解码器（读取尺寸）
+	_ "image/jpeg" // 注册 JPEG 解码器（读取尺寸）
+	_ "image/png"  // 注册 PNG 解码器（读取尺寸）
+	"net/http"
+	"os"
+)
+
+// imageContentTypes 支持的图片 MIME 类型
+var imageContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// SniffImageContentType 根据文件头识别图片的 MIME 类型，不是支持的图片格式时返回 false
+func SniffImageContentType(header []byte) (string, bool) {
+	contentType := http.DetectContentType(header)
+	return contentType, imageContentTypes[contentType]
+}
+
+// ImageSize 读取图片尺寸，无法解码（如 WebP）时返回 0
+func ImageSize(path string) (int, int) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0
+	}
+	defer file.Close()
+
+	cfg, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return 0, 0
+	}
+	return cfg.Width, cfg.Height
+}
@@ -0,0 +1,103 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalidMedia 文件不是可处理的视频（容器不支持、没有视频流或文件损坏）
+// 属于永久性错误，重试无意义
+var ErrInvalidMedia = errors.New("invalid media file")
+
+// Metadata 视频元数据
+type Metadata struct {
+	FormatName string        // 容器格式（ffprobe 的 format_name，如 "mov,mp4,m4a,3gp,3g2,mj2"）
+	Duration   time.Duration // 时长
+	Width      int           // 宽度（像素）
+	Height     int           // 高度（像素）
+	VideoCodec string        // 视频编码（如 h264、hevc）
+	AudioCodec string        // 音频编码（如 aac），没有音频流时为空
+}
+
+// Rendition HLS 码率档位
+type Rendition struct {
+	Name         string // 档位名称，同时作为子目录名（如 720p）
+	Height       int    // 目标分辨率（短边像素，竖屏视频按宽度计算）
+	VideoBitrate int    // 视频码率（kbps）
+	AudioBitrate int    // 音频码率（kbps）
+}
+
+// DefaultLadder 默认 HLS 码率阶梯（从低到高）
+var DefaultLadder = []Rendition{
+	{Name: "360p", Height: 360, VideoBitrate: 800, AudioBitrate: 64},
+	{Name: "540p", Height: 540, VideoBitrate: 1500, AudioBitrate: 96},
+	{Name: "720p", Height: 720, VideoBitrate: 2800, AudioBitrate: 128},
+	{Name: "1080p", Height: 1080, VideoBitrate: 5000, AudioBitrate: 128},
+}
+
+// IProcessor 视频处理器接口
+type IProcessor interface {
+	// Probe 校验容器并读取视频元数据，文件无效时返回 ErrInvalidMedia
+	Probe(ctx context.Context, path string) (*Metadata, error)
+	// Transcode 将视频转码为 H.264/AAC 的 MP4（便于浏览器直接播放）
+	Transcode(ctx context.Context, src, dst string) error
+	// ExtractCover 截取 at 时刻的视频帧，保存为 JPEG 封面
+	ExtractCover(ctx context.Context, src, dst string, at time.Duration) error
+	// TranscodeHLS 生成多码率 HLS：outDir/master.m3u8 与 outDir/{rendition}/index.m3u8 及分片
+	TranscodeHLS(ctx context.Context, src, outDir string, meta *Metadata, renditions []Rendition) error
+}
+
+// HLSMasterPlaylist HLS 主播放列表文件名
+const HLSMasterPlaylist = "master.m3u8"
+
+// NeedsTranscode 判断视频是否需要转码为 H.264/AAC 的 MP4
+func NeedsTranscode(meta *Metadata) bool {
+	if meta.VideoCodec != "h264" {
+		return true
+	}
+	if meta.AudioCodec != "" && meta.AudioCodec != "aac" {
+		return true
+	}
+	return !strings.Contains(meta.FormatName, "mp4")
+}
+
+// CoverOffset 计算截取封面的时刻，超出视频时长时取视频中间帧
+func CoverOffset(meta *Metadata, want time.Duration) time.Duration {
+	if want < 0 || want >= meta.Duration {
+		return meta.Duration / 2
+	}
+	return want
+}
+
+// SelectRenditions 选择不高于源视频分辨率的档位（避免放大），源视频过小时只保留最低档
+// 目标短边向下取偶数（libx264 要求 yuv420p 的宽高为偶数，长边由 ffmpeg 按 -2 等比取偶数）
+func SelectRenditions(meta *Metadata, ladder []Rendition) []Rendition {
+	if len(ladder) == 0 {
+		return nil
+	}
+
+	short := min(meta.Width, meta.Height)
+	selected := make([]Rendition, 0, len(ladder))
+	for _, r := range ladder {
+		if r.Height <= short {
+			r.Height = evenDimension(r.Height)
+			selected = append(selected, r)
+		}
+	}
+	if len(selected) == 0 {
+		lowest := ladder[0]
+		if short > 0 {
+			lowest.Height = short
+		}
+		lowest.Height = evenDimension(lowest.Height)
+		selected = append(selected, lowest)
+	}
+	return selected
+}
+
+// evenDimension 将像素尺寸向下取偶数（最小为 2）
+func evenDimension(n int) int {
+	return max(n&^1, 2)
+}
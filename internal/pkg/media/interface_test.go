@@ -0,0 +1,99 @@
+package media
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSelectRenditions(t *testing.T) {
+	tests := []struct {
+		name    string
+		width   int
+		height  int
+		ladder  []Rendition
+		want    []string // 档位名称
+		heights []int    // 对应的目标短边
+	}{
+		{
+			name:  "landscape 1080p keeps all",
+			width: 1920, height: 1080,
+			ladder:  DefaultLadder,
+			want:    []string{"360p", "540p", "720p", "1080p"},
+			heights: []int{360, 540, 720, 1080},
+		},
+		{
+			name:  "landscape 720p drops higher",
+			width: 1280, height: 720,
+			ladder:  DefaultLadder,
+			want:    []string{"360p", "540p", "720p"},
+			heights: []int{360, 540, 720},
+		},
+		{
+			name:  "portrait uses width as short side",
+			width: 720, height: 1280,
+			ladder:  DefaultLadder,
+			want:    []string{"360p", "540p", "720p"},
+			heights: []int{360, 540, 720},
+		},
+		{
+			name:  "small source keeps lowest at source size",
+			width: 320, height: 240,
+			ladder:  DefaultLadder,
+			want:    []string{"360p"},
+			heights: []int{240},
+		},
+		{
+			name:  "odd source short side rounds down to even",
+			width: 641, height: 359,
+			ladder:  DefaultLadder,
+			want:    []string{"360p"},
+			heights: []int{358},
+		},
+		{
+			name:  "odd ladder height rounds down to even",
+			width: 1920, height: 1080,
+			ladder:  []Rendition{{Name: "odd", Height: 481}},
+			want:    []string{"odd"},
+			heights: []int{480},
+		},
+		{
+			name:  "unknown size keeps lowest",
+			width: 0, height: 0,
+			ladder:  DefaultLadder,
+			want:    []string{"360p"},
+			heights: []int{360},
+		},
+		{
+			name:  "empty ladder",
+			width: 1920, height: 1080,
+			ladder: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SelectRenditions(&Metadata{Width: tt.width, Height: tt.height}, tt.ladder)
+
+			var names []string
+			var heights []int
+			for _, r := range got {
+				names = append(names, r.Name)
+				heights = append(heights, r.Height)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("renditions = %v, want %v", names, tt.want)
+			}
+			if !slices.Equal(heights, tt.heights) {
+				t.Errorf("heights = %v, want %v", heights, tt.heights)
+			}
+		})
+	}
+}
+
+func TestSelectRenditions_DoesNotModifyLadder(t *testing.T) {
+	ladder := []Rendition{{Name: "360p", Height: 360}}
+	SelectRenditions(&Metadata{Width: 321, Height: 241}, ladder)
+	if ladder[0].Height != 360 {
+		t.Errorf("ladder height = %d, want 360", ladder[0].Height)
+	}
+}
@@ -0,0 +1,15 @@
+package media
+
+import (
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+)
+
+// NewProcessor 根据配置创建视频处理器（通过依赖注入）
+func NewProcessor() IProcessor {
+	cfg := global.Config.Media
+	if cfg.Processor == constant.MediaProcessorFake {
+		return NewFakeProcessor()
+	}
+	return NewFFmpegProcessor(cfg.FFmpegPath, cfg.FFprobePath)
+}
@@ -0,0 +1,67 @@
+package media
+
+import "bytes"
+
+// SniffLen 识别视频类型需要读取的文件头长度
+const SniffLen = 512
+
+// videoContentTypes 支持的视频 MIME 类型
+var videoContentTypes = map[string]bool{
+	"video/mp4":        true,
+	"video/quicktime":  true,
+	"video/webm":       true,
+	"video/x-matroska": true,
+	"video/x-msvideo":  true,
+	"video/x-flv":      true,
+	"video/mp2t":       true,
+}
+
+// IsVideoContentType 是否为支持的视频 MIME 类型
+func IsVideoContentType(contentType string) bool {
+	return videoContentTypes[contentType]
+}
+
+// SniffContentType 根据文件头识别视频的 MIME 类型，不是支持的视频容器时返回 false
+func SniffContentType(header []byte) (string, bool) {
+	switch sniffFormat(header) {
+	case "mov,mp4,m4a,3gp,3g2,mj2":
+		if bytes.Equal(header[8:12], []byte("qt  ")) {
+			return "video/quicktime", true
+		}
+		return "video/mp4", true
+	case "matroska,webm":
+		if bytes.Contains(header, []byte("webm")) {
+			return "video/webm", true
+		}
+		return "video/x-matroska", true
+	case "avi":
+		return "video/x-msvideo", true
+	case "flv":
+		return "video/x-flv", true
+	case "mpegts":
+		return "video/mp2t", true
+	default:
+		return "", false
+	}
+}
+
+// sniffFormat 根据文件头识别容器格式，返回与 ffprobe format_name 一致的名称
+func sniffFormat(header []byte) string {
+	if len(header) < 12 {
+		return ""
+	}
+	switch {
+	case bytes.Equal(header[4:8], []byte("ftyp")):
+		return "mov,mp4,m4a,3gp,3g2,mj2"
+	case bytes.Equal(header[0:4], []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		return "matroska,webm"
+	case bytes.Equal(header[0:4], []byte("RIFF")) && bytes.Equal(header[8:12], []byte("AVI ")):
+		return "avi"
+	case bytes.Equal(header[0:3], []byte("FLV")):
+		return "flv"
+	case header[0] == 0x47 && (len(header) <= 188 || header[188] == 0x47): // TS 包长 188 字节
+		return "mpegts"
+	default:
+		return ""
+	}
+}
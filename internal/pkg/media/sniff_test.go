@@ -0,0 +1,50 @@
+package media
+
+import (
+	"bytes"
+	"testing"
+)
+
+// header 构造指定前缀、补齐到 SniffLen 的文件头
+func header(prefix ...[]byte) []byte {
+	h := bytes.Join(prefix, nil)
+	return append(h, make([]byte, SniffLen-len(h))...)
+}
+
+func TestSniffContentType(t *testing.T) {
+	ts := header([]byte{0x47})
+	ts[188] = 0x47
+
+	tests := []struct {
+		name   string
+		header []byte
+		want   string
+		ok     bool
+	}{
+		{"mp4", header([]byte{0, 0, 0, 0x20}, []byte("ftypisom")), "video/mp4", true},
+		{"quicktime", header([]byte{0, 0, 0, 0x14}, []byte("ftypqt  ")), "video/quicktime", true},
+		{"webm", header([]byte{0x1A, 0x45, 0xDF, 0xA3}, []byte("\x42\x82\x84webm")), "video/webm", true},
+		{"matroska", header([]byte{0x1A, 0x45, 0xDF, 0xA3}, []byte("\x42\x82\x88matroska")), "video/x-matroska", true},
+		{"avi", header([]byte("RIFF\x00\x00\x00\x00AVI LIST")), "video/x-msvideo", true},
+		{"flv", header([]byte("FLV\x01\x05\x00\x00\x00\x09")), "video/x-flv", true},
+		{"mpegts", ts, "video/mp2t", true},
+		{"mpegts without second sync byte", header([]byte{0x47}), "", false},
+		{"wav is not video", header([]byte("RIFF\x00\x00\x00\x00WAVEfmt ")), "", false},
+		{"jpeg", header([]byte{0xFF, 0xD8, 0xFF, 0xE0}), "", false},
+		{"text", header([]byte("hello, world")), "", false},
+		{"too short", []byte("ftyp"), "", false},
+		{"empty", nil, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := SniffContentType(tt.header)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("SniffContentType() = %q, %v, want %q, %v", got, ok, tt.want, tt.ok)
+			}
+			if ok && !IsVideoContentType(got) {
+				t.Errorf("IsVideoContentType(%q) = false", got)
+			}
+		})
+	}
+}
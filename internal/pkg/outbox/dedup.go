@@ -0,0 +1,50 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+)
+
+// Deduplicator 基于 Redis 的消费方去重
+type Deduplicator struct {
+	rdb *redis.Client
+}
+
+// NewDeduplicator 创建消费方去重器（通过依赖注入）
+func NewDeduplicator(rdb *redis.Client) IDeduplicator {
+	return &Deduplicator{rdb: rdb}
+}
+
+// Seen 判断消息是否已处理过
+func (d *Deduplicator) Seen(ctx context.Context, messageID string) bool {
+	if messageID == "" {
+		return false
+	}
+	n, err := d.rdb.Exists(ctx, constant.RedisKeyOutboxConsumedPrefix+messageID).Result()
+	if err != nil {
+		global.Logger.Warn("outbox.Seen.redis_error",
+			zap.String("message_id", messageID),
+			zap.Error(err),
+		)
+		return false
+	}
+	return n > 0
+}
+
+// MarkDone 记录消息已处理
+func (d *Deduplicator) MarkDone(ctx context.Context, messageID string) {
+	if messageID == "" {
+		return
+	}
+	if err := d.rdb.Set(ctx, constant.RedisKeyOutboxConsumedPrefix+messageID, 1, constant.OutboxConsumedTTL).Err(); err != nil {
+		global.Logger.Warn("outbox.MarkDone.redis_error",
+			zap.String("message_id", messageID),
+			zap.Error(err),
+		)
+	}
+}
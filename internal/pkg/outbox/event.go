@@ -0,0 +1,46 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/event"
+)
+
+// EventPublisher 经发件箱发布领域事件：事件与业务变更在同一事务中写入，提交后由 relay 投递到事件总线
+type EventPublisher struct {
+	outbox IOutbox
+}
+
+// NewEventPublisher 创建领域事件发布器（通过依赖注入）
+func NewEventPublisher(outbox IOutbox) event.IPublisher {
+	return &EventPublisher{outbox: outbox}
+}
+
+// Publish 发布领域事件
+func (p *EventPublisher) Publish(ctx context.Context, events ...event.Event) error {
+	for _, evt := range events {
+		env, err := event.NewEnvelope(evt)
+		if err != nil {
+			return err
+		}
+		if err := p.outbox.Add(ctx, constant.OutboxTopicDomainEvent, env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// busPublisher 将发件箱中的领域事件投递到事件总线，事件 ID 使用发件箱消息 ID
+func busPublisher(bus event.IBus) Publisher {
+	return func(ctx context.Context, messageID string, payload []byte) error {
+		var env event.Envelope
+		if err := json.Unmarshal(payload, &env); err != nil {
+			return fmt.Errorf("failed to unmarshal event envelope: %w", err)
+		}
+		env.ID = messageID
+		return bus.Publish(ctx, &env)
+	}
+}
@@ -0,0 +1,39 @@
+package outbox
+
+import "context"
+
+// IOutbox 事务性发件箱接口
+type IOutbox interface {
+	// Add 将消息写入发件箱，必须与业务变更在同一事务中调用（ctx 来自 ITxManager.Transaction）
+	// 事务提交后由 IRelay 投递；事务回滚时消息随之丢弃
+	Add(ctx context.Context, topic string, payload any) error
+}
+
+// IRelay 发件箱投递器接口
+// 按写入顺序认领待投递消息并发布到下游，发布成功后标记为已投递；
+// 进程在发布后、标记前崩溃时消息在认领租约到期后被再次投递（至少一次），消费方需按消息 ID 去重
+type IRelay interface {
+	Notifier
+	// Start 启动后台投递，ctx 取消时投递完当前批次后退出
+	Start(ctx context.Context) error
+	// Wait 等待后台投递退出
+	Wait(ctx context.Context) error
+	// Relay 立即投递一批到期的消息，返回成功投递的消息数
+	Relay(ctx context.Context) (int, error)
+}
+
+// Notifier 唤醒 relay 立即投递，不必等到下一次轮询
+type Notifier interface {
+	Notify()
+}
+
+// IDeduplicator 消费方去重接口
+type IDeduplicator interface {
+	// Seen 判断消息是否已处理过（查询失败时视为未处理，由业务自身的幂等性兜底）
+	Seen(ctx context.Context, messageID string) bool
+	// MarkDone 记录消息已处理
+	MarkDone(ctx context.Context, messageID string)
+}
+
+// Publisher 将一条发件箱消息发布到下游
+type Publisher func(ctx context.Context, messageID string, payload []byte) error
@@ -0,0 +1,50 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/dao"
+	"github.com/wangn-tech/tiny-douyin/internal/model"
+)
+
+// Outbox 基于数据库的事务性发件箱
+type Outbox struct {
+	outboxDAO dao.IOutboxDAO
+	notifier  Notifier // 本进程的 relay，为 nil 时依赖 relay 轮询
+}
+
+// NewOutbox 创建事务性发件箱（通过依赖注入）
+func NewOutbox(outboxDAO dao.IOutboxDAO, notifier Notifier) IOutbox {
+	return &Outbox{outboxDAO: outboxDAO, notifier: notifier}
+}
+
+// Add 将消息写入发件箱
+func (o *Outbox) Add(ctx context.Context, topic string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox payload: %w", err)
+	}
+
+	msg := &model.OutboxMessage{
+		MessageID:     uuid.New().String(),
+		Topic:         topic,
+		Payload:       string(body),
+		Status:        constant.OutboxStatusPending,
+		NextAttemptAt: time.Now(),
+	}
+	if err := o.outboxDAO.CreateMessage(ctx, msg); err != nil {
+		return err
+	}
+
+	// 事务提交后唤醒本进程的 relay 立即投递
+	if o.notifier != nil {
+		dao.AfterCommit(ctx, o.notifier.Notify)
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/dao"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/model"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/event"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/queue"
+)
+
+// Relay 发件箱投递器
+// 每批先在短事务中认领到期的消息（SKIP LOCKED，多实例部署时互不重复）并标记为投递中，
+// 提交后在事务外按主题发布，再标记为已投递；发布失败的消息按指数退避延迟重试
+type Relay struct {
+	outboxDAO  dao.IOutboxDAO
+	txManager  dao.ITxManager
+	publishers map[string]Publisher
+	lastClean  time.Time
+	wakeup     chan struct{} // 本进程写入消息的事务提交后唤醒投递，不必等到下一次轮询
+	done       chan struct{} // 后台投递退出后关闭
+}
+
+// NewRelay 创建发件箱投递器（通过依赖注入）
+func NewRelay(outboxDAO dao.IOutboxDAO, txManager dao.ITxManager, taskQueue queue.ITaskQueue, eventBus event.IBus) IRelay {
+	return &Relay{
+		outboxDAO: outboxDAO,
+		txManager: txManager,
+		publishers: map[string]Publisher{
+			constant.OutboxTopicVideoUpload: taskQueue.Publish,
+			constant.OutboxTopicDomainEvent: busPublisher(eventBus),
+		},
+		wakeup: make(chan struct{}, 1),
+	}
+}
+
+// Start 启动后台投递
+func (r *Relay) Start(ctx context.Context) error {
+	global.Logger.Info("Outbox relay started",
+		zap.Duration("interval", constant.OutboxRelayInterval))
+
+	r.done = make(chan struct{})
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(constant.OutboxRelayInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				// 退出前投递已提交的消息
+				r.drain(context.WithoutCancel(ctx))
+				global.Logger.Info("Outbox relay stopped")
+				return
+			case <-ticker.C:
+			case <-r.wakeup:
+			}
+			r.drain(ctx)
+			r.cleanup(ctx)
+		}
+	}()
+
+	return nil
+}
+
+// Notify 唤醒后台投递（已有未处理的唤醒信号时忽略）
+func (r *Relay) Notify() {
+	select {
+	case r.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// Wait 等待后台投递退出
+func (r *Relay) Wait(ctx context.Context) error {
+	if r.done == nil {
+		return nil
+	}
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// drain 连续投递直到没有满批的到期消息
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := r.Relay(ctx)
+		if err != nil {
+			global.Logger.Error("outbox.Relay.failed", zap.Error(err))
+			return
+		}
+		if n < constant.OutboxRelayBatchSize {
+			return
+		}
+	}
+}
+
+// Relay 投递一批到期的消息
+// 返回本批处理的消息数（包括发布失败、等待重试的消息），用于判断是否还有积压
+func (r *Relay) Relay(ctx context.Context) (int, error) {
+	messages, leaseUntil, err := r.claim(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim outbox messages: %w", err)
+	}
+
+	sentIDs := make([]uint, 0, len(messages))
+	for _, msg := range messages {
+		// 租约已过期：剩余消息可能已被其他 relay 重新认领，留给下一次认领
+		if time.Now().After(leaseUntil) {
+			global.Logger.Warn("outbox.Relay.lease_expired",
+				zap.Int("remaining", len(messages)-len(sentIDs)))
+			break
+		}
+		if err := r.publish(ctx, msg); err != nil {
+			delay := retryDelay(msg.Attempts + 1)
+			global.Logger.Warn("outbox.Relay.publish_error",
+				zap.String("message_id", msg.MessageID),
+				zap.String("topic", msg.Topic),
+				zap.Int("attempts", msg.Attempts+1),
+				zap.Duration("retry_in", delay),
+				zap.Error(err),
+			)
+			lastError := err.Error()
+			if len(lastError) > constant.OutboxLastErrorMaxLength {
+				lastError = lastError[:constant.OutboxLastErrorMaxLength]
+			}
+			// 记录失败出错时消息仍在投递中，租约到期后重新认领
+			_ = r.outboxDAO.MarkRetry(ctx, msg.ID, lastError, time.Now().Add(delay))
+			continue
+		}
+		sentIDs = append(sentIDs, msg.ID)
+	}
+
+	// 标记失败时已发布的消息在租约到期后被再次投递（至少一次，消费方去重）
+	if err := r.outboxDAO.MarkSent(ctx, sentIDs, time.Now()); err != nil {
+		return 0, fmt.Errorf("failed to mark outbox messages sent: %w", err)
+	}
+	return len(messages), nil
+}
+
+// claim 在一个短事务中认领一批到期的消息并提交，返回消息和租约到期时间
+// 发布在事务外进行，不会在等待下游时长时间持有行锁
+func (r *Relay) claim(ctx context.Context) ([]*model.OutboxMessage, time.Time, error) {
+	var messages []*model.OutboxMessage
+	leaseUntil := time.Now().Add(constant.OutboxClaimLease)
+	err := r.txManager.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		messages, err = r.outboxDAO.ListDueMessages(ctx, time.Now(), constant.OutboxRelayBatchSize)
+		if err != nil || len(messages) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(messages))
+		for i, msg := range messages {
+			ids[i] = msg.ID
+		}
+		return r.outboxDAO.ClaimMessages(ctx, ids, leaseUntil)
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return messages, leaseUntil, nil
+}
+
+// publish 按主题发布一条消息
+func (r *Relay) publish(ctx context.Context, msg *model.OutboxMessage) error {
+	publisher, ok := r.publishers[msg.Topic]
+	if !ok {
+		return fmt.Errorf("no publisher for outbox topic %q", msg.Topic)
+	}
+	return publisher(ctx, msg.MessageID, []byte(msg.Payload))
+}
+
+// cleanup 定期清理过期的已投递消息
+func (r *Relay) cleanup(ctx context.Context) {
+	if time.Since(r.lastClean) < constant.OutboxCleanupInterval {
+		return
+	}
+	if err := r.outboxDAO.CleanupSent(ctx, time.Now().Add(-constant.OutboxSentRetention)); err == nil {
+		r.lastClean = time.Now()
+	}
+}
+
+// retryDelay 第 attempt 次发布失败后的重试延迟（指数退避，不超过 OutboxRetryMaxDelay）
+func retryDelay(attempt int) time.Duration {
+	delay := constant.OutboxRetryBaseDelay
+	for i := 1; i < attempt && delay < constant.OutboxRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, constant.OutboxRetryMaxDelay)
+}
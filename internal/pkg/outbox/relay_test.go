@@ -0,0 +1,264 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/model"
+)
+
+// fakeOutboxDAO 内存中的发件箱，查询条件与 OutboxDAO 一致
+type fakeOutboxDAO struct {
+	messages []*model.OutboxMessage
+}
+
+func (d *fakeOutboxDAO) CreateMessage(ctx context.Context, msg *model.OutboxMessage) error {
+	msg.ID = uint(len(d.messages) + 1)
+	d.messages = append(d.messages, msg)
+	return nil
+}
+
+func (d *fakeOutboxDAO) ListDueMessages(ctx context.Context, now time.Time, limit int) ([]*model.OutboxMessage, error) {
+	var due []*model.OutboxMessage
+	for _, msg := range d.messages {
+		if (msg.Status == constant.OutboxStatusPending || msg.Status == constant.OutboxStatusInflight) &&
+			!msg.NextAttemptAt.After(now) && len(due) < limit {
+			copied := *msg
+			due = append(due, &copied)
+		}
+	}
+	return due, nil
+}
+
+func (d *fakeOutboxDAO) ClaimMessages(ctx context.Context, ids []uint, leaseUntil time.Time) error {
+	for _, id := range ids {
+		d.get(id).Status = constant.OutboxStatusInflight
+		d.get(id).NextAttemptAt = leaseUntil
+	}
+	return nil
+}
+
+func (d *fakeOutboxDAO) MarkSent(ctx context.Context, ids []uint, sentAt time.Time) error {
+	for _, id := range ids {
+		d.get(id).Status = constant.OutboxStatusSent
+		d.get(id).SentAt = &sentAt
+	}
+	return nil
+}
+
+func (d *fakeOutboxDAO) MarkRetry(ctx context.Context, id uint, lastError string, nextAttemptAt time.Time) error {
+	msg := d.get(id)
+	if msg.Status != constant.OutboxStatusInflight {
+		return nil
+	}
+	msg.Status = constant.OutboxStatusPending
+	msg.Attempts++
+	msg.LastError = lastError
+	msg.NextAttemptAt = nextAttemptAt
+	return nil
+}
+
+func (d *fakeOutboxDAO) CleanupSent(ctx context.Context, before time.Time) error {
+	return nil
+}
+
+func (d *fakeOutboxDAO) get(id uint) *model.OutboxMessage {
+	return d.messages[id-1]
+}
+
+// fakeTxManager 直接执行 fn
+type fakeTxManager struct {
+	calls int
+}
+
+func (m *fakeTxManager) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.calls++
+	return fn(ctx)
+}
+
+// newTestRelay 创建投递器，publish 作为 video.upload 主题的发布方法
+func newTestRelay(publish Publisher) (*Relay, *fakeOutboxDAO) {
+	global.Logger = zap.NewNop()
+	outboxDAO := &fakeOutboxDAO{}
+	return &Relay{
+		outboxDAO:  outboxDAO,
+		txManager:  &fakeTxManager{},
+		publishers: map[string]Publisher{constant.OutboxTopicVideoUpload: publish},
+	}, outboxDAO
+}
+
+// addMessage 写入一条指定状态和下次投递时间的消息
+func addMessage(d *fakeOutboxDAO, topic, status string, nextAttemptAt time.Time) *model.OutboxMessage {
+	msg := &model.OutboxMessage{
+		MessageID:     fmt.Sprintf("msg-%d", len(d.messages)+1),
+		Topic:         topic,
+		Payload:       "{}",
+		Status:        status,
+		NextAttemptAt: nextAttemptAt,
+	}
+	_ = d.CreateMessage(context.Background(), msg)
+	return msg
+}
+
+func TestRelay_Relay(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name       string
+		status     string
+		next       time.Time
+		wantStatus string
+		wantSent   bool
+	}{
+		{"pending due", constant.OutboxStatusPending, now.Add(-time.Second), constant.OutboxStatusSent, true},
+		{"pending not due", constant.OutboxStatusPending, now.Add(time.Minute), constant.OutboxStatusPending, false},
+		{"inflight within lease", constant.OutboxStatusInflight, now.Add(time.Minute), constant.OutboxStatusInflight, false},
+		{"inflight lease expired", constant.OutboxStatusInflight, now.Add(-time.Second), constant.OutboxStatusSent, true},
+		{"already sent", constant.OutboxStatusSent, now.Add(-time.Second), constant.OutboxStatusSent, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var published []string
+			r, outboxDAO := newTestRelay(func(ctx context.Context, messageID string, payload []byte) error {
+				published = append(published, messageID)
+				return nil
+			})
+			msg := addMessage(outboxDAO, constant.OutboxTopicVideoUpload, tt.status, tt.next)
+
+			n, err := r.Relay(context.Background())
+			if err != nil {
+				t.Fatalf("Relay() error = %v", err)
+			}
+			if wantN := map[bool]int{true: 1}[tt.wantSent]; n != wantN {
+				t.Errorf("Relay() = %d, want %d", n, wantN)
+			}
+			if got := len(published) == 1; got != tt.wantSent {
+				t.Errorf("published = %v, want sent %v", published, tt.wantSent)
+			}
+			if msg.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", msg.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRelay_ClaimsBeforePublish(t *testing.T) {
+	var outboxDAO *fakeOutboxDAO
+	var r *Relay
+	r, outboxDAO = newTestRelay(func(ctx context.Context, messageID string, payload []byte) error {
+		// 发布时认领已提交：消息为投递中，租约到期前其他 relay 不会认领
+		msg := outboxDAO.get(1)
+		if msg.Status != constant.OutboxStatusInflight {
+			t.Errorf("status during publish = %s, want inflight", msg.Status)
+		}
+		if lease := time.Until(msg.NextAttemptAt); lease <= 0 || lease > constant.OutboxClaimLease {
+			t.Errorf("lease during publish = %v, want (0, %v]", lease, constant.OutboxClaimLease)
+		}
+		due, _ := outboxDAO.ListDueMessages(ctx, time.Now(), constant.OutboxRelayBatchSize)
+		if len(due) != 0 {
+			t.Errorf("claimed message listed as due by another relay")
+		}
+		return nil
+	})
+	addMessage(outboxDAO, constant.OutboxTopicVideoUpload, constant.OutboxStatusPending, time.Now())
+
+	if _, err := r.Relay(context.Background()); err != nil {
+		t.Fatalf("Relay() error = %v", err)
+	}
+	if r.txManager.(*fakeTxManager).calls != 1 {
+		t.Errorf("claim transactions = %d, want 1", r.txManager.(*fakeTxManager).calls)
+	}
+}
+
+func TestRelay_PublishFailure(t *testing.T) {
+	r, outboxDAO := newTestRelay(func(ctx context.Context, messageID string, payload []byte) error {
+		if messageID == "fail" {
+			return errors.New(strings.Repeat("x", constant.OutboxLastErrorMaxLength+10))
+		}
+		return nil
+	})
+	ok := addMessage(outboxDAO, constant.OutboxTopicVideoUpload, constant.OutboxStatusPending, time.Now())
+	failed := addMessage(outboxDAO, constant.OutboxTopicVideoUpload, constant.OutboxStatusPending, time.Now())
+	failed.MessageID = "fail"
+	unknown := addMessage(outboxDAO, "unknown.topic", constant.OutboxStatusPending, time.Now())
+
+	start := time.Now()
+	n, err := r.Relay(context.Background())
+	if err != nil {
+		t.Fatalf("Relay() error = %v", err)
+	}
+	if n != 3 {
+		t.Errorf("Relay() = %d, want 3", n)
+	}
+	if ok.Status != constant.OutboxStatusSent || ok.SentAt == nil {
+		t.Errorf("ok message status = %s, want sent", ok.Status)
+	}
+
+	for _, msg := range []*model.OutboxMessage{failed, unknown} {
+		if msg.Status != constant.OutboxStatusPending || msg.Attempts != 1 {
+			t.Errorf("%s: status = %s attempts = %d, want pending 1", msg.MessageID, msg.Status, msg.Attempts)
+		}
+		if delay := msg.NextAttemptAt.Sub(start); delay < constant.OutboxRetryBaseDelay || delay > constant.OutboxRetryBaseDelay+time.Second {
+			t.Errorf("%s: retry in %v, want about %v", msg.MessageID, delay, constant.OutboxRetryBaseDelay)
+		}
+	}
+	if len(failed.LastError) != constant.OutboxLastErrorMaxLength {
+		t.Errorf("last error length = %d, want %d", len(failed.LastError), constant.OutboxLastErrorMaxLength)
+	}
+	if !strings.Contains(unknown.LastError, "unknown.topic") {
+		t.Errorf("last error = %q, want unknown topic", unknown.LastError)
+	}
+
+	// 重试时间未到，下一轮不再投递
+	if n, err := r.Relay(context.Background()); err != nil || n != 0 {
+		t.Errorf("second Relay() = %d, %v, want 0", n, err)
+	}
+}
+
+func TestRelay_PublishOrderAndBatchSize(t *testing.T) {
+	var published []string
+	r, outboxDAO := newTestRelay(func(ctx context.Context, messageID string, payload []byte) error {
+		published = append(published, messageID)
+		return nil
+	})
+	total := constant.OutboxRelayBatchSize + 5
+	var want []string
+	for range total {
+		msg := addMessage(outboxDAO, constant.OutboxTopicVideoUpload, constant.OutboxStatusPending, time.Now())
+		want = append(want, msg.MessageID)
+	}
+
+	// drain 连续投递直到没有满批的到期消息
+	r.drain(context.Background())
+	if !slices.Equal(published, want) {
+		t.Errorf("published %d messages out of order or incomplete, want %d", len(published), total)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, constant.OutboxRetryBaseDelay},
+		{2, 2 * constant.OutboxRetryBaseDelay},
+		{3, 4 * constant.OutboxRetryBaseDelay},
+		{9, 256 * constant.OutboxRetryBaseDelay},
+		{10, constant.OutboxRetryMaxDelay},
+		{100, constant.OutboxRetryMaxDelay},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempt); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
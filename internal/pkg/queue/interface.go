@@ -0,0 +1,76 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrClosed 队列已关闭
+	ErrClosed = errors.New("task queue closed")
+	// ErrNotConnected 与消息队列的连接已断开，正在重连
+	ErrNotConnected = errors.New("task queue not connected")
+)
+
+// Message 队列中的任务消息
+type Message struct {
+	ID         string    // 消息 ID（重试和死信重放时保持不变，供消费方去重）
+	Body       []byte    // 消息体（JSON）
+	RetryCount int       // 已重试次数
+	LastError  string    // 最近一次失败原因
+	Reason     string    // 转入死信的原因（仅死信消息）
+	Timestamp  time.Time // 最近一次投递时间
+}
+
+// Delivery 消费到的任务，处理完成后必须调用 Ack、Requeue、Retry、Reject 之一
+type Delivery struct {
+	Message
+	acker acknowledger
+}
+
+// acknowledger 由各实现提供的消息确认操作
+type acknowledger interface {
+	ack(d *Delivery) error
+	requeue(d *Delivery) error
+	retry(ctx context.Context, d *Delivery, cause error) error
+	reject(d *Delivery) error
+}
+
+// Ack 确认任务处理完成
+func (d *Delivery) Ack() error { return d.acker.ack(d) }
+
+// Requeue 立即退回队列，不计入重试次数（如停机时被中断的任务）
+func (d *Delivery) Requeue() error { return d.acker.requeue(d) }
+
+// Retry 延迟重试：第 RetryCount+1 次重试按指数退避延迟后重新投递
+func (d *Delivery) Retry(ctx context.Context, cause error) error { return d.acker.retry(ctx, d, cause) }
+
+// Reject 拒绝任务，转入死信队列
+func (d *Delivery) Reject() error { return d.acker.reject(d) }
+
+// DeadLetterAction 死信消息的处理方式
+type DeadLetterAction int
+
+const (
+	// DeadLetterKeep 保留在死信队列中
+	DeadLetterKeep DeadLetterAction = iota
+	// DeadLetterDrop 从死信队列中删除
+	DeadLetterDrop
+	// DeadLetterReplay 重新投递到业务队列（清空重试次数）
+	DeadLetterReplay
+)
+
+// ITaskQueue 任务队列接口
+type ITaskQueue interface {
+	// Publish 发布任务，id 为消息 ID（可为空）
+	Publish(ctx context.Context, id string, body []byte) error
+	// Consume 开始消费，最多 prefetch 个任务未确认；ctx 取消后停止消费并关闭返回的 channel，
+	// 已取出的任务仍可确认
+	Consume(ctx context.Context, prefetch int) (<-chan *Delivery, error)
+	// ScanDeadLetters 依次取出死信队列头部最多 limit 条消息交给 fn 决定处理方式，返回重放的消息数
+	// fn 返回错误时停止扫描，未处理的消息保留在死信队列中
+	ScanDeadLetters(ctx context.Context, limit int, fn func(ctx context.Context, msg *Message) (DeadLetterAction, error)) (int, error)
+	// Close 关闭队列（优雅停机时在 worker 退出后调用）
+	Close() error
+}
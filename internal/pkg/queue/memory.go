@@ -0,0 +1,168 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+)
+
+// MemoryQueue 进程内任务队列（基于 channel，用于本地开发和测试，无需 RabbitMQ）
+// 任务只保存在内存中，进程退出后未处理的任务和死信会丢失
+type MemoryQueue struct {
+	ready      chan *Message
+	retryDelay func(attempt int) time.Duration
+
+	mu          sync.Mutex
+	deadLetters []*Message
+	closed      bool
+	done        chan struct{}
+}
+
+// NewMemoryQueue 创建进程内任务队列，retryDelay 为第 attempt 次重试的延迟
+func NewMemoryQueue(retryDelay func(attempt int) time.Duration) ITaskQueue {
+	return &MemoryQueue{
+		ready:      make(chan *Message, constant.QueueMemoryCapacity),
+		retryDelay: retryDelay,
+		done:       make(chan struct{}),
+	}
+}
+
+// Publish 发布任务（队列已满时阻塞直到 ctx 取消）
+func (q *MemoryQueue) Publish(ctx context.Context, id string, body []byte) error {
+	return q.enqueue(ctx, &Message{ID: id, Body: body, Timestamp: time.Now()})
+}
+
+// Consume 开始消费（任务直接交给处理方，prefetch 不起作用）
+func (q *MemoryQueue) Consume(ctx context.Context, prefetch int) (<-chan *Delivery, error) {
+	out := make(chan *Delivery)
+	go func() {
+		defer close(out)
+		for {
+			var msg *Message
+			select {
+			case msg = <-q.ready:
+			case <-ctx.Done():
+				return
+			case <-q.done:
+				return
+			}
+
+			select {
+			case out <- &Delivery{Message: *msg, acker: q}:
+			case <-ctx.Done():
+				q.requeueAsync(msg)
+				return
+			case <-q.done:
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// ScanDeadLetters 依次处理死信队列头部最多 limit 条消息
+func (q *MemoryQueue) ScanDeadLetters(ctx context.Context, limit int, fn func(ctx context.Context, msg *Message) (DeadLetterAction, error)) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	replayed := 0
+	kept := make([]*Message, 0, len(q.deadLetters))
+	var scanErr error
+	for i, msg := range q.deadLetters {
+		if i >= limit || scanErr != nil {
+			kept = append(kept, msg)
+			continue
+		}
+
+		action, err := fn(ctx, msg)
+		if err != nil {
+			scanErr = err
+			kept = append(kept, msg)
+			continue
+		}
+		switch action {
+		case DeadLetterReplay:
+			q.requeueAsync(&Message{ID: msg.ID, Body: msg.Body, Timestamp: time.Now()})
+			replayed++
+		case DeadLetterDrop:
+		default:
+			kept = append(kept, msg)
+		}
+	}
+	q.deadLetters = kept
+	return replayed, scanErr
+}
+
+// Close 关闭队列，停止所有消费
+func (q *MemoryQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.closed {
+		q.closed = true
+		close(q.done)
+	}
+	return nil
+}
+
+// enqueue 放入待处理队列
+func (q *MemoryQueue) enqueue(ctx context.Context, msg *Message) error {
+	select {
+	case <-q.done:
+		return ErrClosed
+	default:
+	}
+
+	select {
+	case q.ready <- msg:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-q.done:
+		return ErrClosed
+	}
+}
+
+// requeueAsync 放回待处理队列，不阻塞调用方
+func (q *MemoryQueue) requeueAsync(msg *Message) {
+	go func() {
+		_ = q.enqueue(context.Background(), msg)
+	}()
+}
+
+func (q *MemoryQueue) ack(d *Delivery) error { return nil }
+
+func (q *MemoryQueue) requeue(d *Delivery) error {
+	msg := d.Message
+	q.requeueAsync(&msg)
+	return nil
+}
+
+func (q *MemoryQueue) retry(ctx context.Context, d *Delivery, cause error) error {
+	msg := d.Message
+	msg.RetryCount++
+	msg.LastError = truncate(cause.Error(), constant.RabbitMQLastErrorMaxLength)
+	msg.Timestamp = time.Now()
+	time.AfterFunc(q.retryDelay(msg.RetryCount), func() {
+		_ = q.enqueue(context.Background(), &msg)
+	})
+	return nil
+}
+
+func (q *MemoryQueue) reject(d *Delivery) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	msg := d.Message
+	msg.Reason = constant.QueueDeadLetterReasonRejected
+	q.deadLetters = append(q.deadLetters, &msg)
+	return nil
+}
+
+// truncate 截断字符串到最多 n 个字节
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,504 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/config"
+)
+
+// RabbitMQQueue 基于 RabbitMQ 的任务队列
+// 连接断开后在后台按指数退避自动重连并重新声明交换机和队列，消费者在重连后自动重新订阅；
+// 断开期间未确认的任务由 RabbitMQ 重新投递
+type RabbitMQQueue struct {
+	cfg    *config.RabbitMQConfig
+	url    string
+	logger *zap.Logger
+
+	mu          sync.RWMutex
+	conn        *amqp.Connection
+	pubCh       *amqp.Channel // 发布 channel
+	reconnected chan struct{} // 每次连接成功后关闭并替换，用于唤醒等待连接的消费者
+	closed      bool
+	done        chan struct{}
+}
+
+// NewRabbitMQQueue 创建 RabbitMQ 任务队列
+// 首次连接失败时不会退出，而是在后台持续重连（期间发布任务返回 ErrNotConnected）
+// global 依赖本包，因此日志由调用方传入
+func NewRabbitMQQueue(cfg *config.RabbitMQConfig, logger *zap.Logger) ITaskQueue {
+	q := &RabbitMQQueue{
+		cfg:         cfg,
+		logger:      logger,
+		url:         fmt.Sprintf("amqp://%s:%s@%s:%d%s", cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.VHost),
+		reconnected: make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+
+	if err := q.connect(); err != nil {
+		q.logger.Error("Failed to connect to RabbitMQ, retrying in background", zap.Error(err))
+		go q.reconnect()
+	} else {
+		q.logger.Info("RabbitMQ connection and channel initialized successfully")
+	}
+	return q
+}
+
+// Publish 发布任务到业务交换机
+func (q *RabbitMQQueue) Publish(ctx context.Context, id string, body []byte) error {
+	q.mu.RLock()
+	ch := q.pubCh
+	q.mu.RUnlock()
+	if ch == nil {
+		return ErrNotConnected
+	}
+
+	return ch.PublishWithContext(ctx, q.cfg.Exchange, constant.RabbitMQRoutingKeyVideo, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		MessageId:    id,
+		Body:         body,
+		DeliveryMode: amqp.Persistent, // 持久化消息
+		Timestamp:    time.Now(),
+	})
+}
+
+// Consume 开始消费业务队列，连接断开后等待重连并重新订阅
+func (q *RabbitMQQueue) Consume(ctx context.Context, prefetch int) (<-chan *Delivery, error) {
+	out := make(chan *Delivery)
+	go func() {
+		defer close(out)
+		for {
+			conn, err := q.waitConnected(ctx)
+			if err != nil {
+				return
+			}
+			ch, msgs, err := q.subscribe(conn, prefetch)
+			if err != nil {
+				q.logger.Warn("Failed to subscribe upload queue, waiting for reconnect", zap.Error(err))
+				select {
+				case <-time.After(constant.QueueReconnectMinDelay):
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+			if !q.forward(ctx, ch, msgs, out) {
+				return
+			}
+			q.logger.Warn("Upload consumer channel closed, resubscribing")
+		}
+	}()
+	return out, nil
+}
+
+// ScanDeadLetters 依次处理死信队列头部最多 limit 条消息
+// 使用独立的 channel：保留的消息不确认，在 channel 关闭时自动退回死信队列
+func (q *RabbitMQQueue) ScanDeadLetters(ctx context.Context, limit int, fn func(ctx context.Context, msg *Message) (DeadLetterAction, error)) (int, error) {
+	q.mu.RLock()
+	conn := q.conn
+	q.mu.RUnlock()
+	if conn == nil || conn.IsClosed() {
+		return 0, ErrNotConnected
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to open channel: %w", err)
+	}
+	defer ch.Close()
+
+	replayed := 0
+	for scanned := 0; scanned < limit; scanned++ {
+		delivery, ok, err := ch.Get(q.cfg.DeadLetterQueue(), false)
+		if err != nil {
+			return replayed, fmt.Errorf("failed to get dead letter: %w", err)
+		}
+		if !ok {
+			break
+		}
+
+		action, err := fn(ctx, deadLetterMessage(delivery))
+		if err != nil {
+			return replayed, err
+		}
+		switch action {
+		case DeadLetterReplay:
+			// 重新发布时不带重试次数等消息头
+			err = ch.PublishWithContext(ctx, q.cfg.Exchange, constant.RabbitMQRoutingKeyVideo, false, false, amqp.Publishing{
+				ContentType:  delivery.ContentType,
+				MessageId:    delivery.MessageId,
+				Body:         delivery.Body,
+				DeliveryMode: amqp.Persistent,
+				Timestamp:    time.Now(),
+			})
+			if err != nil {
+				return replayed, fmt.Errorf("failed to republish dead letter: %w", err)
+			}
+			if err := ch.Ack(delivery.DeliveryTag, false); err != nil {
+				return replayed, err
+			}
+			replayed++
+		case DeadLetterDrop:
+			if err := ch.Ack(delivery.DeliveryTag, false); err != nil {
+				return replayed, err
+			}
+		}
+	}
+	return replayed, nil
+}
+
+// Close 关闭连接并停止重连
+func (q *RabbitMQQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	close(q.done)
+
+	if q.pubCh != nil {
+		_ = q.pubCh.Close()
+	}
+	if q.conn != nil {
+		return q.conn.Close()
+	}
+	return nil
+}
+
+// connect 建立连接、声明交换机和队列，并在连接意外断开时触发重连
+func (q *RabbitMQQueue) connect() error {
+	conn, err := amqp.Dial(q.url)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	// 主动关闭时 NotifyClose 的 channel 直接关闭（不发送错误），不触发重连
+	closeCh := conn.NotifyClose(make(chan *amqp.Error, 1))
+
+	ch, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to open channel: %w", err)
+	}
+	if err := declareTopology(ch, q.cfg); err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		_ = conn.Close()
+		return ErrClosed
+	}
+	q.conn, q.pubCh = conn, ch
+	close(q.reconnected)
+	q.reconnected = make(chan struct{})
+	q.mu.Unlock()
+
+	go func() {
+		if err, ok := <-closeCh; ok && err != nil {
+			q.logger.Error("RabbitMQ connection lost, reconnecting", zap.Error(err))
+			q.mu.Lock()
+			q.pubCh = nil
+			q.mu.Unlock()
+			q.reconnect()
+		}
+	}()
+	return nil
+}
+
+// reconnect 按指数退避重连，直到成功或队列关闭
+func (q *RabbitMQQueue) reconnect() {
+	delay := constant.QueueReconnectMinDelay
+	for {
+		select {
+		case <-time.After(delay):
+		case <-q.done:
+			return
+		}
+
+		err := q.connect()
+		if err == nil {
+			q.logger.Info("RabbitMQ reconnected")
+			return
+		}
+		if errors.Is(err, ErrClosed) {
+			return
+		}
+		q.logger.Warn("Failed to reconnect to RabbitMQ",
+			zap.Duration("next_delay", delay),
+			zap.Error(err))
+		delay = min(delay*2, constant.QueueReconnectMaxDelay)
+	}
+}
+
+// waitConnected 等待连接可用
+func (q *RabbitMQQueue) waitConnected(ctx context.Context) (*amqp.Connection, error) {
+	for {
+		q.mu.RLock()
+		conn, reconnected, closed := q.conn, q.reconnected, q.closed
+		q.mu.RUnlock()
+		if closed {
+			return nil, ErrClosed
+		}
+		if conn != nil && !conn.IsClosed() {
+			return conn, nil
+		}
+
+		select {
+		case <-reconnected:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-q.done:
+			return nil, ErrClosed
+		}
+	}
+}
+
+// subscribe 打开消费 channel 并订阅业务队列
+func (q *RabbitMQQueue) subscribe(conn *amqp.Connection, prefetch int) (*amqp.Channel, <-chan amqp.Delivery, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open consumer channel: %w", err)
+	}
+
+	// 限制未确认的消息数，避免单个实例囤积任务
+	if err := ch.Qos(prefetch, 0, false); err != nil {
+		_ = ch.Close()
+		return nil, nil, fmt.Errorf("failed to set qos: %w", err)
+	}
+
+	msgs, err := ch.Consume(
+		q.cfg.Queue,                  // 队列名称
+		constant.RabbitMQConsumerTag, // 消费者标签
+		false,                        // 自动确认
+		false,                        // 独占
+		false,                        // 不等待
+		false,                        // 额外参数
+		nil,
+	)
+	if err != nil {
+		_ = ch.Close()
+		return nil, nil, fmt.Errorf("failed to register consumer: %w", err)
+	}
+	return ch, msgs, nil
+}
+
+// forward 将 RabbitMQ 投递的消息转交给处理方
+// 返回 false 表示 ctx 已取消（停止消费），true 表示消费 channel 意外关闭（需要重新订阅）
+// 停止消费后消费 channel 保持打开，以便处理中的任务确认，由 Close 关闭；已预取但未处理的消息在关闭时退回队列
+func (q *RabbitMQQueue) forward(ctx context.Context, ch *amqp.Channel, msgs <-chan amqp.Delivery, out chan<- *Delivery) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			if err := ch.Cancel(constant.RabbitMQConsumerTag, false); err != nil {
+				q.logger.Warn("Failed to cancel upload consumer", zap.Error(err))
+			}
+			return false
+		case msg, ok := <-msgs:
+			if !ok {
+				return true
+			}
+			d := &Delivery{
+				Message: Message{
+					ID:         msg.MessageId,
+					Body:       msg.Body,
+					RetryCount: retryCount(msg.Headers),
+					LastError:  lastError(msg.Headers),
+					Timestamp:  msg.Timestamp,
+				},
+				acker: &rabbitAcker{queue: q, msg: msg},
+			}
+			select {
+			case out <- d:
+			case <-ctx.Done():
+				_ = msg.Nack(false, true)
+				if err := ch.Cancel(constant.RabbitMQConsumerTag, false); err != nil {
+					q.logger.Warn("Failed to cancel upload consumer", zap.Error(err))
+				}
+				return false
+			}
+		}
+	}
+}
+
+// rabbitAcker RabbitMQ 消息确认
+type rabbitAcker struct {
+	queue *RabbitMQQueue
+	msg   amqp.Delivery
+}
+
+func (a *rabbitAcker) ack(d *Delivery) error { return a.msg.Ack(false) }
+
+func (a *rabbitAcker) requeue(d *Delivery) error { return a.msg.Nack(false, true) }
+
+// reject 拒绝且不重新入队，经 x-dead-letter-exchange 转入死信队列
+func (a *rabbitAcker) reject(d *Delivery) error { return a.msg.Nack(false, false) }
+
+// retry 通过默认交换机投递到第 attempt 次重试的延迟队列，过期后回到业务队列
+func (a *rabbitAcker) retry(ctx context.Context, d *Delivery, cause error) error {
+	a.queue.mu.RLock()
+	ch := a.queue.pubCh
+	a.queue.mu.RUnlock()
+	if ch == nil {
+		return ErrNotConnected
+	}
+
+	attempt := d.RetryCount + 1
+	headers := amqp.Table{}
+	for k, v := range a.msg.Headers {
+		headers[k] = v
+	}
+	headers[constant.RabbitMQHeaderRetryCount] = int32(attempt)
+	headers[constant.RabbitMQHeaderLastError] = truncate(cause.Error(), constant.RabbitMQLastErrorMaxLength)
+
+	retryQueue := a.queue.cfg.RetryQueue(attempt)
+	err := ch.PublishWithContext(ctx, "", retryQueue, false, false, amqp.Publishing{
+		ContentType:  a.msg.ContentType,
+		MessageId:    a.msg.MessageId,
+		Body:         a.msg.Body,
+		Headers:      headers,
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", retryQueue, err)
+	}
+	return a.msg.Ack(false)
+}
+
+// declareTopology 声明业务交换机、业务队列、延迟重试队列和死信队列
+func declareTopology(ch *amqp.Channel, cfg *config.RabbitMQConfig) error {
+	// 声明交换机
+	err := ch.ExchangeDeclare(
+		cfg.Exchange, // 交换机名称
+		"direct",     // 交换机类型
+		true,         // 持久化
+		false,        // 自动删除
+		false,        // 内部使用
+		false,        // 不等待
+		nil,          // 额外参数
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare exchange: %w", err)
+	}
+
+	// 声明死信交换机和死信队列（超过重试次数或无法解析的消息）
+	if err := declareDeadLetter(ch, cfg); err != nil {
+		return fmt.Errorf("failed to declare dead letter queue: %w", err)
+	}
+
+	// 声明队列（被拒绝且不重新入队的消息转入死信交换机）
+	_, err = ch.QueueDeclare(
+		cfg.Queue, // 队列名称
+		true,      // 持久化
+		false,     // 自动删除
+		false,     // 独占
+		false,     // 不等待
+		amqp.Table{
+			"x-dead-letter-exchange":    cfg.DeadLetterExchange(),
+			"x-dead-letter-routing-key": constant.RabbitMQRoutingKeyVideo,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare queue: %w", err)
+	}
+
+	// 声明延迟重试队列
+	if err := declareRetryQueues(ch, cfg); err != nil {
+		return fmt.Errorf("failed to declare retry queues: %w", err)
+	}
+
+	// 绑定队列到交换机
+	err = ch.QueueBind(
+		cfg.Queue,                        // 队列名称
+		constant.RabbitMQRoutingKeyVideo, // routing key
+		cfg.Exchange,                     // 交换机名称
+		false,                            // 不等待
+		nil,                              // 额外参数
+	)
+	if err != nil {
+		return fmt.Errorf("failed to bind queue: %w", err)
+	}
+	return nil
+}
+
+// declareDeadLetter 声明死信交换机和死信队列
+func declareDeadLetter(ch *amqp.Channel, cfg *config.RabbitMQConfig) error {
+	if err := ch.ExchangeDeclare(cfg.DeadLetterExchange(), "direct", true, false, false, false, nil); err != nil {
+		return err
+	}
+	if _, err := ch.QueueDeclare(cfg.DeadLetterQueue(), true, false, false, false, nil); err != nil {
+		return err
+	}
+	return ch.QueueBind(cfg.DeadLetterQueue(), constant.RabbitMQRoutingKeyVideo, cfg.DeadLetterExchange(), false, nil)
+}
+
+// declareRetryQueues 为每次重试声明一个延迟队列
+// 消息在延迟队列中过期后经 x-dead-letter-exchange 回到业务交换机，
+// 每个队列的 TTL 固定，避免单队列按消息设置过期时间导致的队头阻塞
+func declareRetryQueues(ch *amqp.Channel, cfg *config.RabbitMQConfig) error {
+	for attempt := 1; attempt <= cfg.RetryAttempts(); attempt++ {
+		_, err := ch.QueueDeclare(
+			cfg.RetryQueue(attempt), // 队列名称
+			true,                    // 持久化
+			false,                   // 自动删除
+			false,                   // 独占
+			false,                   // 不等待
+			amqp.Table{
+				"x-message-ttl":             cfg.RetryDelay(attempt).Milliseconds(),
+				"x-dead-letter-exchange":    cfg.Exchange,
+				"x-dead-letter-routing-key": constant.RabbitMQRoutingKeyVideo,
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("declare %s: %w", cfg.RetryQueue(attempt), err)
+		}
+	}
+	return nil
+}
+
+// deadLetterMessage 解析死信消息
+func deadLetterMessage(msg amqp.Delivery) *Message {
+	m := &Message{
+		ID:         msg.MessageId,
+		Body:       msg.Body,
+		RetryCount: retryCount(msg.Headers),
+		LastError:  lastError(msg.Headers),
+		Timestamp:  msg.Timestamp,
+	}
+	if deaths, ok := msg.Headers["x-death"].([]any); ok && len(deaths) > 0 {
+		if death, ok := deaths[0].(amqp.Table); ok {
+			m.Reason, _ = death["reason"].(string)
+		}
+	}
+	return m
+}
+
+// retryCount 从消息头中读取已重试次数
+func retryCount(headers amqp.Table) int {
+	switch v := headers[constant.RabbitMQHeaderRetryCount].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	default:
+		return 0
+	}
+}
+
+// lastError 从消息头中读取最近一次失败原因
+func lastError(headers amqp.Table) string {
+	s, _ := headers[constant.RabbitMQHeaderLastError].(string)
+	return s
+}
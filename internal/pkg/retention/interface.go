@@ -0,0 +1,13 @@
+package retention
+
+import "context"
+
+// IMessagePurger 过期私信清理接口
+type IMessagePurger interface {
+	// Start 启动后台定期清理（未配置保留天数时不启动）
+	Start(ctx context.Context) error
+	// Wait 等待后台清理退出
+	Wait(ctx context.Context) error
+	// Purge 立即清理一次，返回删除的消息数量
+	Purge(ctx context.Context) (int, error)
+}
@@ -0,0 +1,160 @@
+package retention
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/dao"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/model"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/upload"
+)
+
+// MessagePurger 按保留天数物理删除过期私信及其图片，并删除消息已全部过期的会话
+// 多个实例同时清理时删除操作是幂等的，无需加锁
+type MessagePurger struct {
+	messageDAO dao.IMessageDAO
+	convDAO    dao.IConversationDAO
+	uploadSvc  upload.IUploadService
+	retention  time.Duration
+	interval   time.Duration
+	done       chan struct{} // 后台清理退出后关闭
+}
+
+// NewMessagePurger 创建过期私信清理器（通过依赖注入）
+func NewMessagePurger(
+	messageDAO dao.IMessageDAO,
+	convDAO dao.IConversationDAO,
+	uploadSvc upload.IUploadService,
+) IMessagePurger {
+	return &MessagePurger{
+		messageDAO: messageDAO,
+		convDAO:    convDAO,
+		uploadSvc:  uploadSvc,
+		retention:  global.Config.Message.Retention(),
+		interval:   constant.MessagePurgeInterval,
+	}
+}
+
+// Start 启动后台定期清理
+func (p *MessagePurger) Start(ctx context.Context) error {
+	if p.retention <= 0 {
+		global.Logger.Info("Message purger disabled")
+		return nil
+	}
+
+	global.Logger.Info("Message purger started",
+		zap.Duration("retention", p.retention),
+		zap.Duration("interval", p.interval))
+
+	p.done = make(chan struct{})
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := p.Purge(ctx); err != nil && ctx.Err() == nil {
+				global.Logger.Error("retention.PurgeMessages.failed", zap.Error(err))
+			}
+
+			select {
+			case <-ctx.Done():
+				global.Logger.Info("Message purger stopped")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Wait 等待后台清理退出
+func (p *MessagePurger) Wait(ctx context.Context) error {
+	if p.done == nil {
+		return nil
+	}
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Purge 删除发送时间早于保留期限的消息，返回删除数量
+// 消息 ID 与发送时间同序，每批取最早的消息，遇到未过期的消息即可停止
+func (p *MessagePurger) Purge(ctx context.Context) (int, error) {
+	if p.retention <= 0 {
+		return 0, nil
+	}
+	cutoff := time.Now().Add(-p.retention)
+
+	purged := 0
+	for ctx.Err() == nil {
+		messages, err := p.messageDAO.ScanOldestMessages(ctx, constant.MessagePurgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		expired := make([]*model.Message, 0, len(messages))
+		for _, message := range messages {
+			if !message.CreatedAt.Before(cutoff) {
+				break
+			}
+			expired = append(expired, message)
+		}
+		if len(expired) == 0 {
+			break
+		}
+
+		ids := make([]uint, 0, len(expired))
+		for _, message := range expired {
+			ids = append(ids, message.ID)
+		}
+		if err := p.messageDAO.PurgeMessages(ctx, ids); err != nil {
+			return purged, err
+		}
+		p.removeImages(ctx, expired)
+		purged += len(expired)
+
+		if len(expired) < len(messages) || len(messages) < constant.MessagePurgeBatchSize {
+			break
+		}
+	}
+
+	conversations, err := p.convDAO.PurgeConversations(ctx, cutoff)
+	if err != nil {
+		return purged, err
+	}
+
+	if purged > 0 || conversations > 0 {
+		global.Logger.Info("Expired messages purged",
+			zap.Int("messages", purged),
+			zap.Int64("conversations", conversations),
+			zap.Time("before", cutoff))
+	}
+	return purged, nil
+}
+
+// removeImages 删除图片消息的图片，失败时只记录日志（消息已删除，图片不再被引用）
+func (p *MessagePurger) removeImages(ctx context.Context, messages []*model.Message) {
+	for _, message := range messages {
+		if message.Type != constant.MessageTypeImage {
+			continue
+		}
+		imageURL := message.GetPayload().ImageURL
+		if imageURL == "" {
+			continue
+		}
+		if err := p.uploadSvc.RemoveObjectByURL(ctx, imageURL); err != nil {
+			global.Logger.Warn("Failed to remove expired message image",
+				zap.Uint("message_id", message.ID),
+				zap.Error(err))
+		}
+	}
+}
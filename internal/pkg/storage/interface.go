@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"time"
+)
+
+var (
+	// ErrObjectNotFound 对象不存在
+	ErrObjectNotFound = errors.New("object not found")
+	// ErrInvalidKey 对象名不合法（为空、绝对路径或包含 ..）
+	ErrInvalidKey = errors.New("invalid object key")
+	// ErrInvalidPolicy 预签名上传表单无效、已过期或与上传的文件不符
+	ErrInvalidPolicy = errors.New("invalid upload policy")
+	// ErrInvalidSignature 访问地址的签名无效或已过期
+	ErrInvalidSignature = errors.New("invalid url signature")
+)
+
+// ObjectInfo 对象信息
+type ObjectInfo struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	ContentType  string    `json:"content_type"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// PresignedPost 预签名 POST 上传表单
+// 客户端以 multipart/form-data 向 URL 提交 FormData 中的所有字段，最后附加 file 字段
+type PresignedPost struct {
+	URL       string            `json:"url"`
+	FormData  map[string]string `json:"form_data"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// IObjectStore 对象存储接口
+// 对象名使用 / 分隔的相对路径（如 videos/1/2024-01-01/{uuid}.mp4）
+type IObjectStore interface {
+	// Put 上传对象，size 未知时传 -1
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// PutFile 上传本地文件
+	PutFile(ctx context.Context, key, path, contentType string) error
+	// Get 读取对象，length 为 -1 时读取到末尾；对象不存在时返回 ErrObjectNotFound
+	Get(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	// GetFile 下载对象到本地文件
+	GetFile(ctx context.Context, key, path string) error
+	// Stat 获取对象信息，对象不存在时返回 ErrObjectNotFound
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// Delete 删除对象（对象不存在时不报错）
+	Delete(ctx context.Context, key string) error
+	// List 列出前缀下的所有对象
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	// PresignPost 生成预签名 POST 上传表单，限定对象名、内容类型和文件大小
+	PresignPost(ctx context.Context, key, contentType string, size int64, expiry time.Duration) (*PresignedPost, error)
+	// PresignGet 生成限时有效的下载地址
+	PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error)
+	// URL 对象的公开访问地址
+	URL(key string) string
+	// Key 从公开访问地址解析对象名，不是本存储的地址时返回 false
+	Key(rawURL string) (string, bool)
+}
+
+// IURLVerifier 由服务自身提供对象访问的存储（本地存储），校验 PresignGet 生成的地址
+type IURLVerifier interface {
+	// VerifyURL 校验访问地址中的签名参数，无效或已过期时返回 ErrInvalidSignature
+	VerifyURL(key string, query url.Values) error
+	// Path 对象对应的本地文件路径
+	Path(key string) (string, error)
+}
+
+// IPostReceiver 由服务自身接收预签名 POST 上传的存储（本地存储）
+type IPostReceiver interface {
+	// ReceivePost 校验表单字段中的签名后保存上传的文件
+	ReceivePost(ctx context.Context, form url.Values, r io.Reader) error
+}
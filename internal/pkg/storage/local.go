@@ -0,0 +1,350 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 本地存储预签名表单字段和下载地址参数
+const (
+	localFieldPolicy    = "policy"
+	localFieldSignature = "signature"
+	localFieldExpires   = "expires"
+)
+
+// localPolicy 本地存储预签名上传策略
+type localPolicy struct {
+	Key         string `json:"key"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Expires     int64  `json:"expires"` // Unix 时间戳（秒）
+}
+
+// LocalStore 基于本地磁盘的对象存储（用于本地开发和 CI，无需 MinIO）
+// 对象保存为 dir 下的同名文件，由服务以静态文件方式提供访问；
+// 预签名上传表单为 HMAC 签名的策略，由服务的上传接口（uploadURL）校验后保存
+type LocalStore struct {
+	dir       string
+	urlPrefix string
+	uploadURL string
+	secret    []byte
+}
+
+// NewLocalStore 创建本地对象存储
+func NewLocalStore(dir, urlPrefix, uploadURL, secret string) IObjectStore {
+	return &LocalStore{
+		dir:       dir,
+		urlPrefix: strings.TrimSuffix(urlPrefix, "/"),
+		uploadURL: uploadURL,
+		secret:    []byte(secret),
+	}
+}
+
+// Put 上传对象（先写入临时文件再重命名，读取方不会看到不完整的对象）
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	dst, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create object directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create object file: %w", err)
+	}
+	n, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && size >= 0 && n != size {
+		err = fmt.Errorf("object size %d, expected %d", n, size)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), dst)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to put object %s: %w", key, err)
+	}
+	return nil
+}
+
+// PutFile 上传本地文件
+func (s *LocalStore) PutFile(ctx context.Context, key, path, contentType string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to put object %s: %w", key, err)
+	}
+	defer f.Close()
+	return s.Put(ctx, key, f, -1, contentType)
+}
+
+// Get 读取对象
+func (s *LocalStore) Get(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, s.wrapError(key, err)
+	}
+	if offset > 0 {
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	if length < 0 {
+		return f, nil
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(f, length), f}, nil
+}
+
+// GetFile 下载对象到本地文件
+func (s *LocalStore) GetFile(ctx context.Context, key, path string) error {
+	src, err := s.Get(ctx, key, 0, -1)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return fmt.Errorf("failed to get object %s: %w", key, err)
+	}
+	return nil
+}
+
+// Stat 获取对象信息
+func (s *LocalStore) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, s.wrapError(key, err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	return &ObjectInfo{
+		Key:          key,
+		Size:         info.Size(),
+		ContentType:  mime.TypeByExtension(path.Ext(key)),
+		LastModified: info.ModTime(),
+	}, nil
+}
+
+// Delete 删除对象
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete object %s: %w", key, err)
+	}
+	return nil
+}
+
+// List 列出前缀下的所有对象
+func (s *LocalStore) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	err := filepath.WalkDir(s.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.dir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, ObjectInfo{
+			Key:          key,
+			Size:         info.Size(),
+			ContentType:  mime.TypeByExtension(path.Ext(key)),
+			LastModified: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects: %w", err)
+	}
+	return objects, nil
+}
+
+// PresignPost 生成预签名 POST 上传表单（HMAC-SHA256 签名的上传策略）
+func (s *LocalStore) PresignPost(ctx context.Context, key, contentType string, size int64, expiry time.Duration) (*PresignedPost, error) {
+	if _, err := s.path(key); err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(expiry)
+	data, err := json.Marshal(localPolicy{
+		Key:         key,
+		ContentType: contentType,
+		Size:        size,
+		Expires:     expiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	policy := base64.StdEncoding.EncodeToString(data)
+
+	return &PresignedPost{
+		URL: s.uploadURL,
+		FormData: map[string]string{
+			localFieldPolicy:    policy,
+			localFieldSignature: s.sign(policy),
+		},
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// ReceivePost 校验预签名上传策略后保存上传的文件
+func (s *LocalStore) ReceivePost(ctx context.Context, form url.Values, r io.Reader) error {
+	encoded := form.Get(localFieldPolicy)
+	signature, err := hex.DecodeString(form.Get(localFieldSignature))
+	if err != nil || encoded == "" {
+		return ErrInvalidPolicy
+	}
+	expected, _ := hex.DecodeString(s.sign(encoded))
+	if !hmac.Equal(signature, expected) {
+		return ErrInvalidPolicy
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalidPolicy
+	}
+	var policy localPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return ErrInvalidPolicy
+	}
+	if time.Now().Unix() > policy.Expires {
+		return fmt.Errorf("%w: policy expired", ErrInvalidPolicy)
+	}
+
+	// 多读 1 字节用于判断文件是否超过策略限定的大小
+	if err := s.Put(ctx, policy.Key, io.LimitReader(r, policy.Size+1), policy.Size, policy.ContentType); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPolicy, err)
+	}
+	return nil
+}
+
+// PresignGet 生成限时有效的下载地址（expires 和对象名的 HMAC-SHA256 签名作为查询参数）
+func (s *LocalStore) PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	query := url.Values{
+		localFieldExpires:   {expires},
+		localFieldSignature: {s.sign(key + "\n" + expires)},
+	}
+	return s.URL(key) + "?" + query.Encode(), nil
+}
+
+// VerifyURL 校验 PresignGet 生成的下载地址
+func (s *LocalStore) VerifyURL(key string, query url.Values) error {
+	expires, err := strconv.ParseInt(query.Get(localFieldExpires), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	signature, err := hex.DecodeString(query.Get(localFieldSignature))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	expected, _ := hex.DecodeString(s.sign(key + "\n" + query.Get(localFieldExpires)))
+	if !hmac.Equal(signature, expected) {
+		return ErrInvalidSignature
+	}
+	if time.Now().Unix() > expires {
+		return fmt.Errorf("%w: url expired", ErrInvalidSignature)
+	}
+	return nil
+}
+
+// URL 对象的公开访问地址
+func (s *LocalStore) URL(key string) string {
+	return fmt.Sprintf("%s/%s", s.urlPrefix, key)
+}
+
+// Key 从公开访问地址解析对象名
+func (s *LocalStore) Key(rawURL string) (string, bool) {
+	return trimURLPrefix(rawURL, s.urlPrefix)
+}
+
+// Path 对象对应的本地文件路径
+func (s *LocalStore) Path(key string) (string, error) {
+	return s.path(key)
+}
+
+// path 对象名对应的本地文件路径（对象名必须是不含 .. 的相对路径）
+func (s *LocalStore) path(key string) (string, error) {
+	if key == "" || !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// sign 计算上传策略或下载地址的签名
+func (s *LocalStore) sign(data string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(data))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// wrapError 将文件不存在转换为 ErrObjectNotFound
+func (s *LocalStore) wrapError(key string, err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	return fmt.Errorf("failed to access object %s: %w", key, err)
+}
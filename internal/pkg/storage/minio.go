@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// MinIOStore 基于 MinIO（S3 兼容）的对象存储
+type MinIOStore struct {
+	client         *minio.Client
+	signClient     *minio.Client
+	bucket         string
+	urlPrefix      string
+	publicEndpoint string
+}
+
+// NewMinIOStore 创建 MinIO 对象存储
+// publicEndpoint 为客户端直传使用的地址，为空时使用 client 的地址；
+// 预签名下载地址的签名包含主机名，因此由 signClient（指向 publicEndpoint）生成，为 nil 时使用 client
+func NewMinIOStore(client, signClient *minio.Client, bucket, urlPrefix, publicEndpoint string) IObjectStore {
+	if signClient == nil {
+		signClient = client
+	}
+	return &MinIOStore{
+		client:         client,
+		signClient:     signClient,
+		bucket:         bucket,
+		urlPrefix:      urlPrefix,
+		publicEndpoint: publicEndpoint,
+	}
+}
+
+// Put 上传对象
+func (s *MinIOStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if _, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	}); err != nil {
+		return fmt.Errorf("failed to put object %s: %w", key, err)
+	}
+	return nil
+}
+
+// PutFile 上传本地文件
+func (s *MinIOStore) PutFile(ctx context.Context, key, path, contentType string) error {
+	if _, err := s.client.FPutObject(ctx, s.bucket, key, path, minio.PutObjectOptions{
+		ContentType: contentType,
+	}); err != nil {
+		return fmt.Errorf("failed to put object %s: %w", key, err)
+	}
+	return nil
+}
+
+// Get 读取对象
+func (s *MinIOStore) Get(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	// GetObject 不会立即请求，先 Stat 以便返回 ErrObjectNotFound
+	if _, err := s.Stat(ctx, key); err != nil {
+		return nil, err
+	}
+
+	opts := minio.GetObjectOptions{}
+	if offset > 0 || length >= 0 {
+		end := int64(0) // 0 表示读取到末尾
+		if length >= 0 {
+			end = offset + length - 1
+		}
+		if err := opts.SetRange(offset, end); err != nil {
+			return nil, err
+		}
+	}
+
+	obj, err := s.client.GetObject(ctx, s.bucket, key, opts)
+	if err != nil {
+		return nil, s.wrapError(key, err)
+	}
+	return obj, nil
+}
+
+// GetFile 下载对象到本地文件
+func (s *MinIOStore) GetFile(ctx context.Context, key, path string) error {
+	if err := s.client.FGetObject(ctx, s.bucket, key, path, minio.GetObjectOptions{}); err != nil {
+		return s.wrapError(key, err)
+	}
+	return nil
+}
+
+// Stat 获取对象信息
+func (s *MinIOStore) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	info, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, s.wrapError(key, err)
+	}
+	return &ObjectInfo{
+		Key:          info.Key,
+		Size:         info.Size,
+		ContentType:  info.ContentType,
+		LastModified: info.LastModified,
+	}, nil
+}
+
+// Delete 删除对象
+func (s *MinIOStore) Delete(ctx context.Context, key string) error {
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to delete object %s: %w", key, err)
+	}
+	return nil
+}
+
+// List 列出前缀下的所有对象
+func (s *MinIOStore) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	for obj := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+	}) {
+		if obj.Err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", obj.Err)
+		}
+		objects = append(objects, ObjectInfo{
+			Key:          obj.Key,
+			Size:         obj.Size,
+			ContentType:  obj.ContentType,
+			LastModified: obj.LastModified,
+		})
+	}
+	return objects, nil
+}
+
+// PresignPost 生成预签名 POST 上传表单
+func (s *MinIOStore) PresignPost(ctx context.Context, key, contentType string, size int64, expiry time.Duration) (*PresignedPost, error) {
+	expiresAt := time.Now().Add(expiry)
+
+	policy := minio.NewPostPolicy()
+	if err := policy.SetBucket(s.bucket); err != nil {
+		return nil, err
+	}
+	if err := policy.SetKey(key); err != nil {
+		return nil, err
+	}
+	if err := policy.SetExpires(expiresAt); err != nil {
+		return nil, err
+	}
+	if err := policy.SetContentType(contentType); err != nil {
+		return nil, err
+	}
+	if err := policy.SetContentLengthRange(size, size); err != nil {
+		return nil, err
+	}
+
+	u, formData, err := s.client.PresignedPostPolicy(ctx, policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign upload: %w", err)
+	}
+
+	// POST policy 的签名与地址无关，可替换为客户端可访问的地址
+	if s.publicEndpoint != "" {
+		public, err := url.Parse(s.publicEndpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid minio public endpoint: %w", err)
+		}
+		u.Scheme, u.Host = public.Scheme, public.Host
+	}
+
+	return &PresignedPost{
+		URL:       u.String(),
+		FormData:  formData,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// PresignGet 生成限时有效的下载地址
+func (s *MinIOStore) PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	u, err := s.signClient.PresignedGetObject(ctx, s.bucket, key, expiry, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign object %s: %w", key, err)
+	}
+	return u.String(), nil
+}
+
+// URL 对象的公开访问地址
+func (s *MinIOStore) URL(key string) string {
+	return fmt.Sprintf("%s/%s", s.urlPrefix, key)
+}
+
+// Key 从公开访问地址解析对象名
+func (s *MinIOStore) Key(rawURL string) (string, bool) {
+	return trimURLPrefix(rawURL, s.urlPrefix)
+}
+
+// wrapError 将 NoSuchKey 转换为 ErrObjectNotFound
+func (s *MinIOStore) wrapError(key string, err error) error {
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	return fmt.Errorf("failed to access object %s: %w", key, err)
+}
@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+)
+
+// IURLSigner 生成视频和封面的限时访问地址
+type IURLSigner interface {
+	// Sign 将保存的公开访问地址转换为限时访问地址；未开启签名或不是本存储的地址时原样返回
+	Sign(ctx context.Context, rawURL string) string
+	// SignKey 生成对象的限时下载地址
+	SignKey(ctx context.Context, key string) (string, error)
+	// VerifyStream 校验 HLS 播放令牌有效且覆盖对象 key，否则返回 ErrInvalidSignature
+	VerifyStream(token, key string) error
+}
+
+// URLSigner 访问地址签名实现
+// 普通对象使用存储的预签名下载地址（MinIO 预签名 / 本地 HMAC 签名）；
+// HLS 播放列表中的子播放列表和分片使用相对地址，无法逐个签名，
+// 因此转换为本服务的播放地址 {streamURL}/{token}/{key}，令牌覆盖播放列表所在目录，
+// 相对地址解析后仍带有令牌，由播放接口校验后代理播放列表、重定向分片
+type URLSigner struct {
+	store     IObjectStore
+	enabled   bool
+	ttl       time.Duration
+	secret    []byte
+	streamURL string
+}
+
+// newURLSigner 创建访问地址签名器
+func newURLSigner(store IObjectStore, enabled bool, ttl time.Duration, secret, streamURL string) IURLSigner {
+	return &URLSigner{
+		store:     store,
+		enabled:   enabled,
+		ttl:       ttl,
+		secret:    []byte(secret),
+		streamURL: strings.TrimSuffix(streamURL, "/"),
+	}
+}
+
+// Sign 将保存的公开访问地址转换为限时访问地址
+func (s *URLSigner) Sign(ctx context.Context, rawURL string) string {
+	if !s.enabled || rawURL == "" {
+		return rawURL
+	}
+	key, ok := s.store.Key(rawURL)
+	if !ok {
+		return rawURL
+	}
+
+	if path.Ext(key) == ".m3u8" {
+		return fmt.Sprintf("%s/%s/%s", s.streamURL, s.streamToken(path.Dir(key)+"/"), key)
+	}
+
+	signed, err := s.SignKey(ctx, key)
+	if err != nil {
+		global.Logger.Warn("storage.Sign.presign_error",
+			zap.String("key", key),
+			zap.Error(err),
+		)
+		return rawURL
+	}
+	return signed
+}
+
+// SignKey 生成对象的限时下载地址
+func (s *URLSigner) SignKey(ctx context.Context, key string) (string, error) {
+	return s.store.PresignGet(ctx, key, s.ttl)
+}
+
+// VerifyStream 校验 HLS 播放令牌
+// 令牌格式: {expires}.{scope_len}.{signature}，scope 为 key 的前 scope_len 个字符（以 / 结尾的目录）
+func (s *URLSigner) VerifyStream(token, key string) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ErrInvalidSignature
+	}
+	expires, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	scopeLen, err := strconv.Atoi(parts[1])
+	if err != nil || scopeLen <= 0 || scopeLen > len(key) {
+		return ErrInvalidSignature
+	}
+	scope := key[:scopeLen]
+	if !strings.HasSuffix(scope, "/") || strings.Contains(key, "..") {
+		return ErrInvalidSignature
+	}
+
+	signature, err := hex.DecodeString(parts[2])
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	expected, _ := hex.DecodeString(s.sign(scope, parts[0]))
+	if !hmac.Equal(signature, expected) {
+		return ErrInvalidSignature
+	}
+	if time.Now().Unix() > expires {
+		return fmt.Errorf("%w: token expired", ErrInvalidSignature)
+	}
+	return nil
+}
+
+// streamToken 生成覆盖目录 scope 的 HLS 播放令牌
+func (s *URLSigner) streamToken(scope string) string {
+	expires := strconv.FormatInt(time.Now().Add(s.ttl).Unix(), 10)
+	return fmt.Sprintf("%s.%d.%s", expires, len(scope), s.sign(scope, expires))
+}
+
+// sign 计算令牌签名
+func (s *URLSigner) sign(scope, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(scope + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// trimURLPrefix 去掉公开访问地址前缀得到对象名
+func trimURLPrefix(rawURL, prefix string) (string, bool) {
+	key, ok := strings.CutPrefix(rawURL, strings.TrimSuffix(prefix, "/")+"/")
+	if !ok || key == "" {
+		return "", false
+	}
+	return key, true
+}
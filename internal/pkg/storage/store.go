@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+)
+
+// NewObjectStore 根据配置创建对象存储（通过依赖注入）
+func NewObjectStore() IObjectStore {
+	cfg := global.Config.Storage
+	if cfg.Type != constant.StorageTypeLocal {
+		minioCfg := global.Config.MinIO
+		return NewMinIOStore(global.MinIOClient, newMinIOSignClient(), minioCfg.BucketName, minioCfg.URLPrefix, minioCfg.PublicEndpoint)
+	}
+
+	dir := cfg.LocalDir
+	if dir == "" {
+		dir = constant.StorageLocalDefaultDir
+	}
+	urlPrefix := cfg.LocalURLPrefix
+	if urlPrefix == "" {
+		urlPrefix = constant.StorageLocalDefaultURLPrefix
+	}
+	secret := cfg.LocalSecret
+	if secret == "" {
+		secret = deriveSecret(constant.StorageUploadSecretPurpose)
+	}
+	return NewLocalStore(dir, urlPrefix, urlPrefix+constant.StorageLocalUploadPath, secret)
+}
+
+// NewURLSigner 根据配置创建访问地址签名器（通过依赖注入）
+func NewURLSigner(store IObjectStore) IURLSigner {
+	cfg := global.Config.SignedURL
+
+	ttl := time.Duration(cfg.TTL) * time.Second
+	if ttl <= 0 {
+		ttl = constant.SignedURLDefaultTTL
+	}
+	secret := cfg.Secret
+	if secret == "" {
+		secret = deriveSecret(constant.SignedURLSecretPurpose)
+	}
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = constant.SignedURLDefaultBaseURL
+	}
+	return newURLSigner(store, cfg.Enabled, ttl, secret, baseURL+constant.SignedURLStreamRoute)
+}
+
+// deriveSecret 未单独配置签名密钥时，由 jwt.secret 按用途派生：HMAC-SHA256(jwt.secret, purpose) 的十六进制
+// 各用途的密钥互不相同，签名地址或上传表单泄露的签名无法用于伪造登录令牌
+func deriveSecret(purpose string) string {
+	mac := hmac.New(sha256.New, []byte(global.Config.JWT.Secret))
+	mac.Write([]byte(purpose))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newMinIOSignClient 创建指向 public_endpoint 的 MinIO 客户端，仅用于生成预签名下载地址
+// 指定 Region 后生成签名不需要访问 MinIO；未配置 public_endpoint 时返回 nil
+func newMinIOSignClient() *minio.Client {
+	cfg := global.Config.MinIO
+	if cfg.PublicEndpoint == "" {
+		return nil
+	}
+	public, err := url.Parse(cfg.PublicEndpoint)
+	if err != nil || public.Host == "" {
+		global.Logger.Warn("storage.newMinIOSignClient.invalid_public_endpoint",
+			zap.String("public_endpoint", cfg.PublicEndpoint),
+		)
+		return nil
+	}
+
+	client, err := minio.New(public.Host, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
+		Secure: public.Scheme == "https",
+		Region: cfg.Location,
+	})
+	if err != nil {
+		global.Logger.Warn("storage.newMinIOSignClient.create_error", zap.Error(err))
+		return nil
+	}
+	return client
+}
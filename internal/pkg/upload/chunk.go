@@ -0,0 +1,409 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/media"
+)
+
+// 会话 Hash 中的 field
+const (
+	sessionFieldUserID     = "user_id"
+	sessionFieldTitle      = "title"
+	sessionFieldFileName   = "file_name"
+	sessionFieldSize       = "size"
+	sessionFieldChunkSize  = "chunk_size"
+	sessionFieldCreatedAt  = "created_at"
+	sessionFieldCompleting = "completing"
+)
+
+// ChunkUploadService 分片上传服务
+// 会话信息保存在 Redis Hash upload:session:{id} 中，已接收的分片记录在位图 upload:session:{id}:chunks 中，
+// 分片内容保存为临时目录下的 chunks/{id}/{index}；会话在最后一次上传分片后 ChunkUploadSessionTTL 过期，
+// 过期会话遗留的分片由 ChunkCleaner 清理
+type ChunkUploadService struct {
+	rdb           *redis.Client
+	uploadService IUploadService
+	chunkDir      string
+}
+
+// NewChunkUploadService 创建分片上传服务（通过依赖注入）
+func NewChunkUploadService(rdb *redis.Client, uploadService IUploadService) IChunkUploadService {
+	return &ChunkUploadService{
+		rdb:           rdb,
+		uploadService: uploadService,
+		chunkDir:      filepath.Join(constant.TempUploadDir, constant.ChunkUploadDirName),
+	}
+}
+
+// Init 创建分片上传会话
+func (s *ChunkUploadService) Init(ctx context.Context, userID uint, title, fileName string, size, chunkSize int64) (*ChunkSession, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("%w: invalid file size", ErrChunkInvalid)
+	}
+	if size > constant.MaxVideoSize {
+		return nil, ErrFileTooLarge
+	}
+	if chunkSize == 0 {
+		chunkSize = constant.ChunkUploadDefaultChunkSize
+	}
+	if chunkSize < constant.ChunkUploadMinChunkSize || chunkSize > constant.ChunkUploadMaxChunkSize {
+		return nil, fmt.Errorf("%w: chunk size must be between %d and %d", ErrChunkInvalid,
+			constant.ChunkUploadMinChunkSize, constant.ChunkUploadMaxChunkSize)
+	}
+
+	session := &ChunkSession{
+		UploadID:    uuid.New().String(),
+		UserID:      userID,
+		Title:       title,
+		FileName:    fileName,
+		Size:        size,
+		ChunkSize:   chunkSize,
+		TotalChunks: int((size + chunkSize - 1) / chunkSize),
+		ExpiresAt:   time.Now().Add(constant.ChunkUploadSessionTTL),
+	}
+
+	key := sessionKey(session.UploadID)
+	pipe := s.rdb.TxPipeline()
+	pipe.HSet(ctx, key,
+		sessionFieldUserID, userID,
+		sessionFieldTitle, title,
+		sessionFieldFileName, fileName,
+		sessionFieldSize, size,
+		sessionFieldChunkSize, chunkSize,
+		sessionFieldCreatedAt, time.Now().Unix(),
+	)
+	pipe.Expire(ctx, key, constant.ChunkUploadSessionTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to create upload session: %w", err)
+	}
+
+	return session, nil
+}
+
+// UploadChunk 保存一个分片（重复上传同一分片会覆盖）
+func (s *ChunkUploadService) UploadChunk(ctx context.Context, userID uint, uploadID string, index int, r io.Reader) error {
+	session, completing, err := s.getSession(ctx, userID, uploadID)
+	if err != nil {
+		return err
+	}
+	if completing {
+		return ErrUploadCompleting
+	}
+	if index < 0 || index >= session.TotalChunks {
+		return fmt.Errorf("%w: index %d out of range [0, %d)", ErrChunkInvalid, index, session.TotalChunks)
+	}
+
+	expected := session.chunkLength(index)
+	dir := filepath.Join(s.chunkDir, uploadID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create chunk directory: %w", err)
+	}
+
+	// 先写入 .part 文件，长度校验通过后再重命名，避免留下不完整的分片
+	path := filepath.Join(dir, strconv.Itoa(index))
+	partPath := path + ".part"
+	file, err := os.Create(partPath)
+	if err != nil {
+		return fmt.Errorf("failed to create chunk file: %w", err)
+	}
+	n, err := io.Copy(file, io.LimitReader(r, expected+1))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && n != expected {
+		err = fmt.Errorf("%w: chunk %d size %d, expected %d", ErrChunkInvalid, index, n, expected)
+	}
+	if err == nil && index == 0 {
+		err = sniffChunk(partPath)
+	}
+	if err != nil {
+		_ = os.Remove(partPath)
+		return err
+	}
+	if err := os.Rename(partPath, path); err != nil {
+		return fmt.Errorf("failed to save chunk file: %w", err)
+	}
+
+	// 记录分片并延长会话有效期
+	pipe := s.rdb.TxPipeline()
+	pipe.SetBit(ctx, chunksKey(uploadID), int64(index), 1)
+	pipe.Expire(ctx, chunksKey(uploadID), constant.ChunkUploadSessionTTL)
+	pipe.Expire(ctx, sessionKey(uploadID), constant.ChunkUploadSessionTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record chunk: %w", err)
+	}
+
+	return nil
+}
+
+// Status 查询已接收的分片
+func (s *ChunkUploadService) Status(ctx context.Context, userID uint, uploadID string) (*ChunkStatus, error) {
+	session, _, err := s.getSession(ctx, userID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	received, err := s.receivedChunks(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &ChunkStatus{
+		ChunkSession: *session,
+		Received:     make([]ChunkRange, 0),
+	}
+	for index := 0; index < session.TotalChunks; index++ {
+		if !received[index] {
+			continue
+		}
+		status.ReceivedChunks++
+		status.ReceivedBytes += session.chunkLength(index)
+		if last := len(status.Received) - 1; last >= 0 && status.Received[last].End == index-1 {
+			status.Received[last].End = index
+		} else {
+			status.Received = append(status.Received, ChunkRange{Start: index, End: index})
+		}
+	}
+
+	return status, nil
+}
+
+// Complete 合并所有分片为一个临时文件（识别文件类型、计算校验和）
+// 合并成功后会话保持合并中，调用方创建视频后调用 Finish 删除会话和分片，失败时调用 Release 允许客户端重新合并
+func (s *ChunkUploadService) Complete(ctx context.Context, userID uint, uploadID string) (*ChunkSession, *TempFile, error) {
+	session, _, err := s.getSession(ctx, userID, uploadID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 标记为合并中，防止重复合并或合并时继续上传分片
+	if err := lockSession(ctx, s.rdb, sessionKey(uploadID), sessionFieldCompleting); err != nil {
+		return nil, nil, err
+	}
+
+	received, err := s.receivedChunks(ctx, session)
+	if err != nil {
+		s.Release(ctx, uploadID)
+		return nil, nil, err
+	}
+	for index := 0; index < session.TotalChunks; index++ {
+		if !received[index] {
+			s.Release(ctx, uploadID)
+			return nil, nil, fmt.Errorf("%w: chunk %d missing", ErrUploadIncomplete, index)
+		}
+	}
+
+	reader := &chunkReader{dir: filepath.Join(s.chunkDir, uploadID), total: session.TotalChunks}
+	tempFile, err := s.uploadService.SaveTempStream(reader, filepath.Ext(session.FileName), constant.MaxVideoSize)
+	reader.Close()
+	if err != nil {
+		if errors.Is(err, ErrUnsupportedFileType) || errors.Is(err, ErrFileTooLarge) {
+			// 文件本身无效，重试无意义，直接删除会话
+			s.Finish(ctx, uploadID)
+		} else {
+			s.Release(ctx, uploadID)
+		}
+		return nil, nil, err
+	}
+	if tempFile.Size != session.Size {
+		s.uploadService.CleanupTempFile(tempFile.Path)
+		s.Release(ctx, uploadID)
+		return nil, nil, fmt.Errorf("%w: assembled size %d, expected %d", ErrUploadIncomplete, tempFile.Size, session.Size)
+	}
+
+	return session, tempFile, nil
+}
+
+// Finish 视频创建成功后删除会话和分片文件
+func (s *ChunkUploadService) Finish(ctx context.Context, uploadID string) {
+	s.removeSession(ctx, uploadID)
+}
+
+// Release 取消合并中标记，保留已上传的分片，允许客户端重新合并
+func (s *ChunkUploadService) Release(ctx context.Context, uploadID string) {
+	if err := s.rdb.HDel(context.WithoutCancel(ctx), sessionKey(uploadID), sessionFieldCompleting).Err(); err != nil {
+		global.Logger.Warn("Failed to release upload session",
+			zap.String("upload_id", uploadID),
+			zap.Error(err))
+	}
+}
+
+// Abort 取消上传并删除已上传的分片
+func (s *ChunkUploadService) Abort(ctx context.Context, userID uint, uploadID string) error {
+	_, completing, err := s.getSession(ctx, userID, uploadID)
+	if err != nil {
+		return err
+	}
+	if completing {
+		return ErrUploadCompleting
+	}
+	s.removeSession(ctx, uploadID)
+	return nil
+}
+
+// getSession 读取会话，会话不存在或不属于该用户时返回 ErrUploadSessionNotFound
+func (s *ChunkUploadService) getSession(ctx context.Context, userID uint, uploadID string) (*ChunkSession, bool, error) {
+	// upload_id 会拼接到文件路径中，必须是合法的 UUID
+	if uuid.Validate(uploadID) != nil {
+		return nil, false, ErrUploadSessionNotFound
+	}
+
+	key := sessionKey(uploadID)
+	pipe := s.rdb.Pipeline()
+	fieldsCmd := pipe.HGetAll(ctx, key)
+	ttlCmd := pipe.TTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, false, fmt.Errorf("failed to get upload session: %w", err)
+	}
+
+	fields := fieldsCmd.Val()
+	if len(fields) == 0 {
+		return nil, false, ErrUploadSessionNotFound
+	}
+	owner, _ := strconv.ParseUint(fields[sessionFieldUserID], 10, 64)
+	if uint(owner) != userID {
+		return nil, false, ErrUploadSessionNotFound
+	}
+
+	size, _ := strconv.ParseInt(fields[sessionFieldSize], 10, 64)
+	chunkSize, _ := strconv.ParseInt(fields[sessionFieldChunkSize], 10, 64)
+	if size <= 0 || chunkSize <= 0 {
+		return nil, false, ErrUploadSessionNotFound
+	}
+
+	session := &ChunkSession{
+		UploadID:    uploadID,
+		UserID:      userID,
+		Title:       fields[sessionFieldTitle],
+		FileName:    fields[sessionFieldFileName],
+		Size:        size,
+		ChunkSize:   chunkSize,
+		TotalChunks: int((size + chunkSize - 1) / chunkSize),
+		ExpiresAt:   time.Now().Add(ttlCmd.Val()),
+	}
+	return session, fields[sessionFieldCompleting] != "", nil
+}
+
+// receivedChunks 读取已接收分片的位图
+func (s *ChunkUploadService) receivedChunks(ctx context.Context, session *ChunkSession) ([]bool, error) {
+	bitmap, err := s.rdb.Get(ctx, chunksKey(session.UploadID)).Bytes()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to get received chunks: %w", err)
+	}
+
+	received := make([]bool, session.TotalChunks)
+	for index := range received {
+		if b := index / 8; b < len(bitmap) {
+			received[index] = bitmap[b]&(0x80>>(index%8)) != 0
+		}
+	}
+	return received, nil
+}
+
+// removeSession 删除会话和分片文件
+func (s *ChunkUploadService) removeSession(ctx context.Context, uploadID string) {
+	if err := s.rdb.Del(context.WithoutCancel(ctx), sessionKey(uploadID), chunksKey(uploadID)).Err(); err != nil {
+		global.Logger.Warn("Failed to delete upload session",
+			zap.String("upload_id", uploadID),
+			zap.Error(err))
+	}
+	if err := os.RemoveAll(filepath.Join(s.chunkDir, uploadID)); err != nil {
+		global.Logger.Warn("Failed to cleanup upload chunks",
+			zap.String("upload_id", uploadID),
+			zap.Error(err))
+	}
+}
+
+// chunkLength 第 index 个分片的长度（最后一个分片可能不足 ChunkSize）
+func (s *ChunkSession) chunkLength(index int) int64 {
+	if index == s.TotalChunks-1 {
+		return s.Size - int64(index)*s.ChunkSize
+	}
+	return s.ChunkSize
+}
+
+// sniffChunk 校验第一个分片是否为支持的视频格式
+func sniffChunk(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	header := make([]byte, media.SniffLen)
+	n, err := io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+	if _, ok := media.SniffContentType(header[:n]); !ok {
+		return ErrUnsupportedFileType
+	}
+	return nil
+}
+
+// chunkReader 按顺序读取所有分片文件（每次只打开一个文件）
+type chunkReader struct {
+	dir   string
+	total int
+	next  int
+	cur   *os.File
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.cur == nil {
+			if r.next >= r.total {
+				return 0, io.EOF
+			}
+			f, err := os.Open(filepath.Join(r.dir, strconv.Itoa(r.next)))
+			if err != nil {
+				return 0, err
+			}
+			r.cur = f
+			r.next++
+		}
+
+		n, err := r.cur.Read(p)
+		if errors.Is(err, io.EOF) {
+			_ = r.cur.Close()
+			r.cur = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+// Close 关闭当前打开的分片文件
+func (r *chunkReader) Close() {
+	if r.cur != nil {
+		_ = r.cur.Close()
+		r.cur = nil
+	}
+}
+
+// sessionKey 会话 Hash 键
+func sessionKey(uploadID string) string {
+	return constant.RedisKeyUploadSessionPrefix + uploadID
+}
+
+// chunksKey 已接收分片位图键
+func chunksKey(uploadID string) string {
+	return constant.RedisKeyUploadSessionPrefix + uploadID + constant.RedisKeyUploadChunksSuffix
+}
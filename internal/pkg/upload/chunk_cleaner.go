@@ -0,0 +1,118 @@
+package upload
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+)
+
+// ChunkCleaner 清理过期分片上传会话遗留的分片文件
+// 会话过期由 Redis TTL 处理，这里只删除会话已不存在的分片目录
+type ChunkCleaner struct {
+	rdb      *redis.Client
+	chunkDir string
+	interval time.Duration
+	done     chan struct{} // 后台清理退出后关闭
+}
+
+// NewChunkCleaner 创建分片清理器（通过依赖注入）
+func NewChunkCleaner(rdb *redis.Client) IChunkCleaner {
+	return &ChunkCleaner{
+		rdb:      rdb,
+		chunkDir: filepath.Join(constant.TempUploadDir, constant.ChunkUploadDirName),
+		interval: constant.ChunkUploadCleanupInterval,
+	}
+}
+
+// Start 启动后台清理
+func (c *ChunkCleaner) Start(ctx context.Context) error {
+	global.Logger.Info("Chunk upload cleaner started",
+		zap.Duration("interval", c.interval))
+
+	c.done = make(chan struct{})
+	go func() {
+		defer close(c.done)
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				global.Logger.Info("Chunk upload cleaner stopped")
+				return
+			case <-ticker.C:
+				c.Cleanup(ctx)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Wait 等待后台清理退出
+func (c *ChunkCleaner) Wait(ctx context.Context) error {
+	if c.done == nil {
+		return nil
+	}
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Cleanup 删除会话已过期的分片目录，返回删除数量
+func (c *ChunkCleaner) Cleanup(ctx context.Context) int {
+	entries, err := os.ReadDir(c.chunkDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			global.Logger.Error("Failed to read chunk directory", zap.Error(err))
+		}
+		return 0
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		uploadID := entry.Name()
+		if !entry.IsDir() || uuid.Validate(uploadID) != nil {
+			continue
+		}
+
+		// 刚创建的目录可能还没来得及写入会话，跳过
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < c.interval {
+			continue
+		}
+
+		exists, err := c.rdb.Exists(ctx, sessionKey(uploadID)).Result()
+		if err != nil {
+			global.Logger.Error("Failed to check upload session", zap.Error(err))
+			return removed
+		}
+		if exists > 0 {
+			continue
+		}
+
+		if err := os.RemoveAll(filepath.Join(c.chunkDir, uploadID)); err != nil {
+			global.Logger.Warn("Failed to remove expired upload chunks",
+				zap.String("upload_id", uploadID),
+				zap.Error(err))
+			continue
+		}
+		removed++
+	}
+
+	if removed > 0 {
+		global.Logger.Info("Expired upload chunks removed", zap.Int("count", removed))
+	}
+	return removed
+}
@@ -0,0 +1,102 @@
+package upload
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/dao"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/queue"
+)
+
+// DeadLetterService 死信队列管理
+type DeadLetterService struct {
+	videoDAO  dao.IVideoDAO
+	taskQueue queue.ITaskQueue
+}
+
+// NewDeadLetterService 创建死信队列管理服务（通过依赖注入）
+func NewDeadLetterService(videoDAO dao.IVideoDAO, taskQueue queue.ITaskQueue) IDeadLetterService {
+	return &DeadLetterService{
+		videoDAO:  videoDAO,
+		taskQueue: taskQueue,
+	}
+}
+
+// List 查看死信队列头部的消息
+func (s *DeadLetterService) List(ctx context.Context, limit int) ([]*DeadLetter, error) {
+	letters := make([]*DeadLetter, 0, limit)
+	_, err := s.taskQueue.ScanDeadLetters(ctx, limit, func(ctx context.Context, msg *queue.Message) (queue.DeadLetterAction, error) {
+		letters = append(letters, parseDeadLetter(msg))
+		return queue.DeadLetterKeep, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return letters, nil
+}
+
+// Replay 重放死信队列中的任务
+// 重放前将视频状态重置为 uploading，并清空重试次数
+func (s *DeadLetterService) Replay(ctx context.Context, limit int, videoIDs []uint) (int, error) {
+	replayed, err := s.taskQueue.ScanDeadLetters(ctx, limit, func(ctx context.Context, msg *queue.Message) (queue.DeadLetterAction, error) {
+		// 无法解析或不在重放范围内的消息保留在死信队列中
+		var task VideoUploadTask
+		if err := json.Unmarshal(msg.Body, &task); err != nil {
+			return queue.DeadLetterKeep, nil
+		}
+		if len(videoIDs) > 0 && !slices.Contains(videoIDs, task.VideoID) {
+			return queue.DeadLetterKeep, nil
+		}
+		return s.replayAction(ctx, &task)
+	})
+	if err != nil {
+		return replayed, err
+	}
+
+	global.Logger.Info("Dead letters replayed",
+		zap.Int("replayed", replayed),
+		zap.Int("limit", limit),
+		zap.Any("video_ids", videoIDs))
+
+	return replayed, nil
+}
+
+// replayAction 重置视频状态，决定单个任务是否重放
+func (s *DeadLetterService) replayAction(ctx context.Context, task *VideoUploadTask) (queue.DeadLetterAction, error) {
+	reset, err := s.videoDAO.UpdateVideoStatus(ctx, task.VideoID, constant.VideoStatusUploading,
+		constant.VideoStatusFailed, constant.VideoStatusProcessing)
+	if err != nil {
+		return queue.DeadLetterKeep, fmt.Errorf("failed to reset video %d status: %w", task.VideoID, err)
+	}
+	if !reset {
+		// 视频已就绪、已删除或不存在，任务无需重放，直接丢弃
+		global.Logger.Warn("Drop dead letter, video not replayable",
+			zap.Uint("video_id", task.VideoID))
+		return queue.DeadLetterDrop, nil
+	}
+	return queue.DeadLetterReplay, nil
+}
+
+// parseDeadLetter 解析死信消息
+func parseDeadLetter(msg *queue.Message) *DeadLetter {
+	letter := &DeadLetter{
+		RetryCount: msg.RetryCount,
+		LastError:  msg.LastError,
+		Reason:     msg.Reason,
+		Timestamp:  msg.Timestamp,
+	}
+
+	var task VideoUploadTask
+	if err := json.Unmarshal(msg.Body, &task); err != nil {
+		letter.Raw = string(msg.Body)
+	} else {
+		letter.Task = &task
+	}
+	return letter
+}
@@ -0,0 +1,178 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/media"
+)
+
+// 直传会话 Hash 中的 field
+const (
+	directFieldUserID      = "user_id"
+	directFieldObjectName  = "object_name"
+	directFieldTitle       = "title"
+	directFieldFileName    = "file_name"
+	directFieldSize        = "size"
+	directFieldContentType = "content_type"
+	directFieldConfirming  = "confirming"
+)
+
+// DirectUploadService 客户端直传对象存储 服务
+// 会话保存在 Redis Hash upload:direct:{id} 中，记录预签名时限定的对象名、大小和内容类型，
+// 确认时据此校验客户端实际上传的对象
+type DirectUploadService struct {
+	rdb           *redis.Client
+	uploadService IUploadService
+}
+
+// NewDirectUploadService 创建直传服务（通过依赖注入）
+func NewDirectUploadService(rdb *redis.Client, uploadService IUploadService) IDirectUploadService {
+	return &DirectUploadService{
+		rdb:           rdb,
+		uploadService: uploadService,
+	}
+}
+
+// Presign 创建直传会话并生成预签名上传表单
+func (s *DirectUploadService) Presign(ctx context.Context, userID uint, title, fileName, contentType string, size int64) (*DirectUpload, error) {
+	if size <= 0 || size > constant.MaxVideoSize {
+		return nil, ErrFileTooLarge
+	}
+	if !media.IsVideoContentType(contentType) {
+		return nil, ErrUnsupportedFileType
+	}
+
+	session := &DirectUpload{
+		UploadID:    uuid.New().String(),
+		UserID:      userID,
+		ObjectName:  s.uploadService.GenerateObjectName(userID, strings.ToLower(filepath.Ext(fileName))),
+		Title:       title,
+		FileName:    fileName,
+		Size:        size,
+		ContentType: contentType,
+	}
+
+	post, err := s.uploadService.PresignVideoUpload(ctx, session.ObjectName, contentType, size)
+	if err != nil {
+		return nil, err
+	}
+	session.Post = post
+
+	key := directKey(session.UploadID)
+	pipe := s.rdb.TxPipeline()
+	pipe.HSet(ctx, key,
+		directFieldUserID, userID,
+		directFieldObjectName, session.ObjectName,
+		directFieldTitle, title,
+		directFieldFileName, fileName,
+		directFieldSize, size,
+		directFieldContentType, contentType,
+	)
+	pipe.Expire(ctx, key, constant.DirectUploadSessionTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to create direct upload session: %w", err)
+	}
+
+	return session, nil
+}
+
+// Confirm 校验客户端上传的对象
+// 校验通过后会话保持确认中，调用方创建视频后调用 Finish 结束会话，失败时调用 Release 允许客户端重新确认
+func (s *DirectUploadService) Confirm(ctx context.Context, userID uint, uploadID string) (*DirectUpload, error) {
+	session, err := s.getUpload(ctx, userID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 标记为确认中，防止重复确认导致同一视频被处理两次
+	if err := lockSession(ctx, s.rdb, directKey(uploadID), directFieldConfirming); err != nil {
+		return nil, err
+	}
+
+	size, contentType, err := s.uploadService.InspectObject(ctx, session.ObjectName)
+	if err == nil && size != session.Size {
+		err = fmt.Errorf("%w: object size %d, expected %d", ErrUploadIncomplete, size, session.Size)
+	}
+	if err != nil {
+		if errors.Is(err, ErrUnsupportedFileType) {
+			// 文件本身无效，删除对象和会话
+			if err := s.uploadService.RemoveObject(ctx, session.ObjectName); err != nil {
+				global.Logger.Warn("Failed to remove invalid direct upload object",
+					zap.String("object_name", session.ObjectName),
+					zap.Error(err))
+			}
+			s.Finish(ctx, uploadID)
+		} else {
+			// 客户端可能还未上传完成，允许稍后再次确认
+			s.Release(ctx, uploadID)
+		}
+		return nil, err
+	}
+
+	session.ContentType = contentType
+	return session, nil
+}
+
+// Finish 视频创建成功后删除会话（对象由上传任务接管）
+func (s *DirectUploadService) Finish(ctx context.Context, uploadID string) {
+	if err := s.rdb.Del(context.WithoutCancel(ctx), directKey(uploadID)).Err(); err != nil {
+		global.Logger.Warn("Failed to delete direct upload session",
+			zap.String("upload_id", uploadID),
+			zap.Error(err))
+	}
+}
+
+// Release 取消确认中标记，允许客户端重新确认
+func (s *DirectUploadService) Release(ctx context.Context, uploadID string) {
+	if err := s.rdb.HDel(context.WithoutCancel(ctx), directKey(uploadID), directFieldConfirming).Err(); err != nil {
+		global.Logger.Warn("Failed to release direct upload session",
+			zap.String("upload_id", uploadID),
+			zap.Error(err))
+	}
+}
+
+// getUpload 读取会话，会话不存在或不属于该用户时返回 ErrUploadSessionNotFound
+func (s *DirectUploadService) getUpload(ctx context.Context, userID uint, uploadID string) (*DirectUpload, error) {
+	if uuid.Validate(uploadID) != nil {
+		return nil, ErrUploadSessionNotFound
+	}
+
+	fields, err := s.rdb.HGetAll(ctx, directKey(uploadID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get direct upload session: %w", err)
+	}
+	if len(fields) == 0 {
+		return nil, ErrUploadSessionNotFound
+	}
+	owner, _ := strconv.ParseUint(fields[directFieldUserID], 10, 64)
+	if uint(owner) != userID {
+		return nil, ErrUploadSessionNotFound
+	}
+	size, _ := strconv.ParseInt(fields[directFieldSize], 10, 64)
+
+	return &DirectUpload{
+		UploadID:    uploadID,
+		UserID:      userID,
+		ObjectName:  fields[directFieldObjectName],
+		Title:       fields[directFieldTitle],
+		FileName:    fields[directFieldFileName],
+		Size:        size,
+		ContentType: fields[directFieldContentType],
+	}, nil
+}
+
+// directKey 直传会话键
+func directKey(uploadID string) string {
+	return constant.RedisKeyDirectUploadPrefix + uploadID
+}
@@ -1,25 +1,179 @@
 package upload
 
-import "context"
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/storage"
+)
+
+var (
+	// ErrFileTooLarge 上传的文件超过大小限制
+	ErrFileTooLarge = errors.New("file too large")
+	// ErrUnsupportedFileType 上传的文件不是支持的视频（图片）格式
+	ErrUnsupportedFileType = errors.New("unsupported file type")
+	// ErrUploadSessionNotFound 分片上传会话不存在、已过期或不属于当前用户
+	ErrUploadSessionNotFound = errors.New("upload session not found")
+	// ErrChunkInvalid 分片参数无效（序号越界、长度不符等）
+	ErrChunkInvalid = errors.New("invalid chunk")
+	// ErrUploadIncomplete 还有分片未上传
+	ErrUploadIncomplete = errors.New("upload incomplete")
+	// ErrUploadCompleting 会话正在合并分片或确认直传
+	ErrUploadCompleting = errors.New("upload is completing")
+)
+
+// TempFile 已保存到临时目录的上传文件
+type TempFile struct {
+	Path        string // 本地文件路径
+	Size        int64  // 文件大小（字节）
+	Checksum    string // SHA-256（十六进制）
+	ContentType string // 根据文件头识别的内容类型
+}
 
 // IUploadService 上传服务接口
 type IUploadService interface {
-	// SaveTempFile 保存上传的文件到临时目录
-	SaveTempFile(fileData []byte, ext string) (string, error)
-	// PublishUploadTask 发布上传任务到消息队列
-	PublishUploadTask(ctx context.Context, task *VideoUploadTask) error
-	// UploadToMinIO 上传文件到 MinIO
-	UploadToMinIO(ctx context.Context, filePath, objectName, contentType string) (string, error)
+	// SaveTempStream 将上传的文件流式写入临时目录，同时识别文件类型并计算校验和
+	// 超过 maxSize 时返回 ErrFileTooLarge，不是视频文件时返回 ErrUnsupportedFileType
+	SaveTempStream(r io.Reader, ext string, maxSize int64) (*TempFile, error)
+	// SaveTempImage 与 SaveTempStream 相同，但只接受图片文件（JPEG、PNG、GIF、WebP）
+	SaveTempImage(r io.Reader, ext string, maxSize int64) (*TempFile, error)
+	// UploadFile 上传文件到对象存储，返回访问 URL
+	UploadFile(ctx context.Context, filePath, objectName, contentType string) (string, error)
+	// UploadDir 将目录下的所有文件按相对路径上传到对象存储的 prefix 下，返回 prefix 对应的 URL
+	UploadDir(ctx context.Context, dir, prefix string) (string, error)
+	// PresignVideoUpload 生成直传视频的预签名 POST 表单（限定对象名、内容类型和大小）
+	PresignVideoUpload(ctx context.Context, objectName, contentType string, size int64) (*storage.PresignedPost, error)
+	// InspectObject 检查对象存储中的视频对象，返回大小和根据文件头识别的内容类型
+	InspectObject(ctx context.Context, objectName string) (int64, string, error)
+	// DownloadObject 将对象下载到临时目录，返回本地文件路径
+	DownloadObject(ctx context.Context, objectName string) (string, error)
+	// RemoveObject 删除对象存储中的对象
+	RemoveObject(ctx context.Context, objectName string) error
+	// RemoveObjectByURL 删除访问 URL 对应的对象（不是本存储的地址时忽略）
+	RemoveObjectByURL(ctx context.Context, rawURL string) error
+	// ObjectURL 对象的访问 URL
+	ObjectURL(objectName string) string
 	// CleanupTempFile 清理临时文件
 	CleanupTempFile(filePath string)
-	// GenerateObjectName 生成 MinIO 中的对象名称
+	// CleanupTempDir 清理临时目录
+	CleanupTempDir(dir string)
+	// GenerateObjectName 生成对象存储中的对象名称
 	GenerateObjectName(userID uint, ext string) string
 	// GenerateCoverObjectName 生成封面对象名称
 	GenerateCoverObjectName(userID uint) string
+	// GenerateMessageImageObjectName 生成私信图片对象名称
+	GenerateMessageImageObjectName(userID uint, ext string) string
+	// GenerateHLSPrefix 根据视频对象名称生成 HLS 文件前缀
+	GenerateHLSPrefix(videoName string) string
+}
+
+// ChunkSession 分片上传会话
+type ChunkSession struct {
+	UploadID    string    `json:"upload_id"`
+	UserID      uint      `json:"-"`
+	Title       string    `json:"title"`
+	FileName    string    `json:"file_name"`
+	Size        int64     `json:"size"`         // 文件总大小（字节）
+	ChunkSize   int64     `json:"chunk_size"`   // 分片大小（最后一个分片可能更小）
+	TotalChunks int       `json:"total_chunks"` // 分片数量，序号从 0 开始
+	ExpiresAt   time.Time `json:"expires_at"`   // 会话过期时间（每次上传分片后延长）
+}
+
+// ChunkRange 连续的已接收分片序号区间 [Start, End]
+type ChunkRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// ChunkStatus 分片上传进度
+type ChunkStatus struct {
+	ChunkSession
+	Received       []ChunkRange `json:"received"`        // 已接收的分片区间
+	ReceivedChunks int          `json:"received_chunks"` // 已接收的分片数量
+	ReceivedBytes  int64        `json:"received_bytes"`  // 已接收的字节数
+}
+
+// IChunkUploadService 分片上传（断点续传）服务接口
+// 流程：Init 创建会话 → UploadChunk 上传各分片（可乱序、可重传） → Status 查询已接收分片 → Complete 合并 → 创建视频后 Finish（失败时 Release）
+type IChunkUploadService interface {
+	// Init 创建上传会话，chunkSize 为 0 时使用默认分片大小
+	Init(ctx context.Context, userID uint, title, fileName string, size, chunkSize int64) (*ChunkSession, error)
+	// UploadChunk 上传第 index 个分片
+	UploadChunk(ctx context.Context, userID uint, uploadID string, index int, r io.Reader) error
+	// Status 查询已接收的分片
+	Status(ctx context.Context, userID uint, uploadID string) (*ChunkStatus, error)
+	// Complete 合并分片为临时文件，返回会话信息和合并后的文件
+	// 会话保持合并中，调用方处理完成后必须调用 Finish 或 Release
+	Complete(ctx context.Context, userID uint, uploadID string) (*ChunkSession, *TempFile, error)
+	// Finish 视频创建成功后删除会话和分片
+	Finish(ctx context.Context, uploadID string)
+	// Release 视频创建失败时取消合并中标记，保留分片，允许客户端重新合并
+	Release(ctx context.Context, uploadID string)
+	// Abort 取消上传并删除已上传的分片
+	Abort(ctx context.Context, userID uint, uploadID string) error
+}
+
+// IChunkCleaner 过期分片清理接口
+type IChunkCleaner interface {
+	// Start 启动后台定期清理
+	Start(ctx context.Context) error
+	// Wait 等待后台清理退出
+	Wait(ctx context.Context) error
+	// Cleanup 立即清理一次，返回删除的会话数量
+	Cleanup(ctx context.Context) int
+}
+
+// DirectUpload 直传会话
+type DirectUpload struct {
+	UploadID    string                 `json:"upload_id"`
+	UserID      uint                   `json:"-"`
+	ObjectName  string                 `json:"object_name"` // 对象名（videos/{user_id}/{date}/{uuid}{ext}）
+	Title       string                 `json:"title"`
+	FileName    string                 `json:"file_name"`
+	Size        int64                  `json:"size"`           // 文件大小（字节），上传的文件必须与之一致
+	ContentType string                 `json:"content_type"`   // 内容类型，上传时必须与之一致
+	Post        *storage.PresignedPost `json:"post,omitempty"` // 预签名上传表单（仅创建时返回）
+}
+
+// IDirectUploadService 客户端直传对象存储服务接口
+// 流程：Presign 获取预签名表单 → 客户端直接上传到对象存储 → Confirm 校验对象 → 创建视频后 Finish（失败时 Release）
+type IDirectUploadService interface {
+	// Presign 创建直传会话并生成预签名上传表单
+	Presign(ctx context.Context, userID uint, title, fileName, contentType string, size int64) (*DirectUpload, error)
+	// Confirm 校验对象已上传且大小、类型与会话一致，返回会话（ContentType 为识别出的类型）
+	// 会话保持确认中，调用方处理完成后必须调用 Finish 或 Release
+	Confirm(ctx context.Context, userID uint, uploadID string) (*DirectUpload, error)
+	// Finish 视频创建成功后结束会话
+	Finish(ctx context.Context, uploadID string)
+	// Release 视频创建失败时取消确认中标记，允许客户端重新确认
+	Release(ctx context.Context, uploadID string)
 }
 
 // IUploadWorker 上传任务工作器接口
 type IUploadWorker interface {
-	// Start 启动工作器
+	// Start 启动工作器，ctx 取消后停止消费新任务
 	Start(ctx context.Context) error
+	// Wait 等待处理中的任务完成，ctx 超时后中断剩余任务
+	Wait(ctx context.Context) error
+}
+
+// DeadLetter 死信队列中的上传任务
+type DeadLetter struct {
+	Task       *VideoUploadTask `json:"task,omitempty"`     // 上传任务（消息无法解析时为空）
+	Raw        string           `json:"raw,omitempty"`      // 无法解析的原始消息体
+	RetryCount int              `json:"retry_count"`        // 已重试次数
+	LastError  string           `json:"last_error"`         // 最近一次失败原因
+	Reason     string           `json:"reason"`             // 转入死信的原因（x-death reason）
+	Timestamp  time.Time        `json:"timestamp,omitzero"` // 最近一次投递时间
+}
+
+// IDeadLetterService 死信队列管理接口
+type IDeadLetterService interface {
+	// List 查看死信队列头部最多 limit 条消息（不会移除消息）
+	List(ctx context.Context, limit int) ([]*DeadLetter, error)
+	// Replay 从死信队列头部取出最多 limit 条消息重新投递到业务队列，
+	// videoIDs 不为空时只重放这些视频的任务（其余消息保留在死信队列中），返回重放数量
+	Replay(ctx context.Context, limit int, videoIDs []uint) (int, error)
 }
@@ -1,20 +1,28 @@
 package upload
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/minio/minio-go/v7"
-	amqp "github.com/rabbitmq/amqp091-go"
 	"go.uber.org/zap"
 
 	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
 	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/media"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/storage"
 )
 
 // VideoUploadTask 视频上传任务
@@ -22,108 +30,237 @@ type VideoUploadTask struct {
 	VideoID     uint   `json:"video_id"`     // 视频 ID
 	VideoPath   string `json:"video_path"`   // 本地视频文件路径
 	CoverPath   string `json:"cover_path"`   // 本地封面文件路径（可选）
-	VideoName   string `json:"video_name"`   // MinIO 中的视频文件名
-	CoverName   string `json:"cover_name"`   // MinIO 中的封面文件名
+	VideoName   string `json:"video_name"`   // 对象存储中的视频文件名
+	CoverName   string `json:"cover_name"`   // 对象存储中的封面文件名
 	ContentType string `json:"content_type"` // 视频内容类型
 	UserID      uint   `json:"user_id"`      // 上传用户 ID
 	Title       string `json:"title"`        // 视频标题
 	Description string `json:"description"`  // 视频描述
+
+	// SourceObject 客户端直传到对象存储的原始视频对象名，此时 VideoPath 为空，由 worker 下载后处理
+	SourceObject string `json:"source_object,omitempty"`
 }
 
 // UploadService 上传服务
 type UploadService struct {
-	minioClient *minio.Client
-	rabbitChan  *amqp.Channel
-	bucketName  string
-	urlPrefix   string
-	exchange    string
-	routingKey  string
+	store storage.IObjectStore
 }
 
-// NewUploadService 创建上传服务
-func NewUploadService() IUploadService {
+// NewUploadService 创建上传服务（通过依赖注入）
+func NewUploadService(store storage.IObjectStore) IUploadService {
 	return &UploadService{
-		minioClient: global.MinIOClient,
-		rabbitChan:  global.RabbitChan,
-		bucketName:  global.Config.MinIO.BucketName,
-		urlPrefix:   global.Config.MinIO.URLPrefix,
-		exchange:    global.Config.RabbitMQ.Exchange,
-		routingKey:  constant.RabbitMQRoutingKeyVideo,
+		store: store,
 	}
 }
 
-// SaveTempFile 保存上传的文件到临时目录
-func (s *UploadService) SaveTempFile(fileData []byte, ext string) (string, error) {
+// SaveTempStream 将上传的视频文件流式写入临时目录
+func (s *UploadService) SaveTempStream(r io.Reader, ext string, maxSize int64) (*TempFile, error) {
+	return s.saveTemp(r, ext, maxSize, media.SniffContentType)
+}
+
+// SaveTempImage 将上传的图片文件流式写入临时目录
+func (s *UploadService) SaveTempImage(r io.Reader, ext string, maxSize int64) (*TempFile, error) {
+	return s.saveTemp(r, ext, maxSize, media.SniffImageContentType)
+}
+
+// saveTemp 将上传的文件流式写入临时目录
+// 先读取文件头识别类型，再边写入边计算 SHA-256，文件内容不会整体读入内存
+func (s *UploadService) saveTemp(r io.Reader, ext string, maxSize int64, sniff func([]byte) (string, bool)) (*TempFile, error) {
+	// 识别文件类型
+	header := make([]byte, media.SniffLen)
+	n, err := io.ReadFull(r, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read file header: %w", err)
+	}
+	header = header[:n]
+	contentType, ok := sniff(header)
+	if !ok {
+		return nil, ErrUnsupportedFileType
+	}
+
 	// 创建临时目录
 	tempDir := constant.TempUploadDir
 	if err := os.MkdirAll(tempDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create temp directory: %w", err)
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
 	}
 
 	// 生成唯一文件名
-	filename := uuid.New().String() + ext
-	filepath := filepath.Join(tempDir, filename)
+	path := filepath.Join(tempDir, uuid.New().String()+ext)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
 
-	// 写入文件
-	if err := os.WriteFile(filepath, fileData, 0644); err != nil {
-		return "", fmt.Errorf("failed to write temp file: %w", err)
+	// 写入文件（多读 1 字节用于判断是否超过大小限制）
+	hash := sha256.New()
+	src := io.MultiReader(bytes.NewReader(header), r)
+	size, err := io.Copy(io.MultiWriter(file, hash), io.LimitReader(src, maxSize+1))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && size > maxSize {
+		err = ErrFileTooLarge
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		if errors.Is(err, ErrFileTooLarge) {
+			return nil, err
+		}
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, ErrFileTooLarge
+		}
+		return nil, fmt.Errorf("failed to write temp file: %w", err)
 	}
 
-	return filepath, nil
+	return &TempFile{
+		Path:        path,
+		Size:        size,
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		ContentType: contentType,
+	}, nil
 }
 
-// PublishUploadTask 发布上传任务到消息队列
-func (s *UploadService) PublishUploadTask(ctx context.Context, task *VideoUploadTask) error {
-	// 序列化任务
-	body, err := json.Marshal(task)
+// fileChecksum 计算文件的 SHA-256（十六进制）
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
 	if err != nil {
-		return fmt.Errorf("failed to marshal task: %w", err)
-	}
-
-	// 发布消息
-	err = s.rabbitChan.PublishWithContext(
-		ctx,
-		s.exchange,   // 交换机
-		s.routingKey, // routing key
-		false,        // mandatory
-		false,        // immediate
-		amqp.Publishing{
-			ContentType:  "application/json",
-			Body:         body,
-			DeliveryMode: amqp.Persistent, // 持久化消息
-			Timestamp:    time.Now(),
-		},
-	)
-	if err != nil {
-		return fmt.Errorf("failed to publish message: %w", err)
+		return "", err
 	}
+	defer f.Close()
 
-	global.Logger.Info("Video upload task published to queue",
-		zap.Uint("video_id", task.VideoID),
-		zap.Uint("user_id", task.UserID))
-
-	return nil
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
-// UploadToMinIO 上传文件到 MinIO
-func (s *UploadService) UploadToMinIO(ctx context.Context, filePath, objectName, contentType string) (string, error) {
-	// 上传文件
-	_, err := s.minioClient.FPutObject(ctx, s.bucketName, objectName, filePath, minio.PutObjectOptions{
-		ContentType: contentType,
-	})
-	if err != nil {
-		return "", fmt.Errorf("failed to upload to MinIO: %w", err)
+// UploadFile 上传文件到对象存储
+func (s *UploadService) UploadFile(ctx context.Context, filePath, objectName, contentType string) (string, error) {
+	if err := s.store.PutFile(ctx, objectName, filePath, contentType); err != nil {
+		return "", err
 	}
 
 	// 返回文件 URL
-	url := fmt.Sprintf("%s/%s", s.urlPrefix, objectName)
-	global.Logger.Info("File uploaded to MinIO",
+	url := s.store.URL(objectName)
+	global.Logger.Info("File uploaded to object storage",
 		zap.String("object_name", objectName),
 		zap.String("url", url))
 
 	return url, nil
 }
 
+// UploadDir 将目录下的所有文件按相对路径上传到对象存储
+func (s *UploadService) UploadDir(ctx context.Context, dir, prefix string) (string, error) {
+	count := 0
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		objectName := prefix + "/" + filepath.ToSlash(rel)
+
+		contentType := mime.TypeByExtension(filepath.Ext(path))
+		if ct, ok := constant.HLSContentTypes[filepath.Ext(path)]; ok {
+			contentType = ct
+		}
+
+		if err := s.store.PutFile(ctx, objectName, path, contentType); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	url := s.store.URL(prefix)
+	global.Logger.Info("Directory uploaded to object storage",
+		zap.String("prefix", prefix),
+		zap.Int("file_count", count))
+
+	return url, nil
+}
+
+// PresignVideoUpload 生成直传视频的预签名 POST 表单，限定对象名、内容类型和文件大小
+func (s *UploadService) PresignVideoUpload(ctx context.Context, objectName, contentType string, size int64) (*storage.PresignedPost, error) {
+	return s.store.PresignPost(ctx, objectName, contentType, size, constant.DirectUploadPresignExpiry)
+}
+
+// InspectObject 检查对象存储中的视频对象，返回对象大小和根据文件头识别的内容类型
+// 对象不存在时返回 storage.ErrObjectNotFound，不是视频文件时返回 ErrUnsupportedFileType
+func (s *UploadService) InspectObject(ctx context.Context, objectName string) (int64, string, error) {
+	info, err := s.store.Stat(ctx, objectName)
+	if err != nil {
+		return 0, "", err
+	}
+
+	// 只读取文件头识别类型
+	obj, err := s.store.Get(ctx, objectName, 0, media.SniffLen)
+	if err != nil {
+		return 0, "", err
+	}
+	defer obj.Close()
+
+	header, err := io.ReadAll(obj)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to read object header: %w", err)
+	}
+	contentType, ok := media.SniffContentType(header)
+	if !ok {
+		return info.Size, "", ErrUnsupportedFileType
+	}
+
+	return info.Size, contentType, nil
+}
+
+// DownloadObject 将对象下载到临时目录，返回本地文件路径
+func (s *UploadService) DownloadObject(ctx context.Context, objectName string) (string, error) {
+	if err := os.MkdirAll(constant.TempUploadDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+
+	path := filepath.Join(constant.TempUploadDir, uuid.New().String()+filepath.Ext(objectName))
+	if err := s.store.GetFile(ctx, objectName, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// RemoveObject 删除对象存储中的对象
+func (s *UploadService) RemoveObject(ctx context.Context, objectName string) error {
+	return s.store.Delete(ctx, objectName)
+}
+
+// RemoveObjectByURL 删除访问 URL 对应的对象
+func (s *UploadService) RemoveObjectByURL(ctx context.Context, rawURL string) error {
+	key, ok := s.store.Key(rawURL)
+	if !ok {
+		return nil
+	}
+	return s.store.Delete(ctx, key)
+}
+
+// ObjectURL 对象的访问 URL
+func (s *UploadService) ObjectURL(objectName string) string {
+	return s.store.URL(objectName)
+}
+
+// CleanupTempDir 清理临时目录
+func (s *UploadService) CleanupTempDir(dir string) {
+	if err := os.RemoveAll(dir); err != nil {
+		global.Logger.Warn("Failed to cleanup temp dir",
+			zap.String("dir", dir),
+			zap.String("error", err.Error()))
+	}
+}
+
 // CleanupTempFile 清理临时文件
 func (s *UploadService) CleanupTempFile(filePath string) {
 	if err := os.Remove(filePath); err != nil {
@@ -133,7 +270,7 @@ func (s *UploadService) CleanupTempFile(filePath string) {
 	}
 }
 
-// GenerateObjectName 生成 MinIO 中的对象名称
+// GenerateObjectName 生成对象存储中的对象名称
 func (s *UploadService) GenerateObjectName(userID uint, ext string) string {
 	// 格式: videos/{user_id}/{date}/{uuid}.{ext}
 	date := time.Now().Format(constant.MinIODateFormat)
@@ -141,6 +278,20 @@ func (s *UploadService) GenerateObjectName(userID uint, ext string) string {
 	return fmt.Sprintf(constant.MinIOVideoPathFormat, userID, date, filename)
 }
 
+// GenerateMessageImageObjectName 生成私信图片对象名称
+func (s *UploadService) GenerateMessageImageObjectName(userID uint, ext string) string {
+	// 格式: messages/{user_id}/{date}/{uuid}{ext}
+	date := time.Now().Format(constant.MinIODateFormat)
+	filename := uuid.New().String() + ext
+	return fmt.Sprintf(constant.MinIOMessageImagePathFormat, userID, date, filename)
+}
+
+// GenerateHLSPrefix 根据视频对象名称生成 HLS 文件前缀
+func (s *UploadService) GenerateHLSPrefix(videoName string) string {
+	// 格式: videos/{user_id}/{date}/{uuid}/hls（与原视频 videos/{user_id}/{date}/{uuid}{ext} 相邻）
+	return fmt.Sprintf(constant.MinIOHLSPathFormat, strings.TrimSuffix(videoName, filepath.Ext(videoName)))
+}
+
 // GenerateCoverObjectName 生成封面对象名称
 func (s *UploadService) GenerateCoverObjectName(userID uint) string {
 	// 格式: covers/{user_id}/{date}/{uuid}.jpg
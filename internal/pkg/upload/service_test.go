@@ -0,0 +1,102 @@
+package upload
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+)
+
+// mp4File 构造以 ftyp 开头、总长为 size 的 MP4 文件内容
+func mp4File(size int) []byte {
+	data := make([]byte, size)
+	copy(data, "\x00\x00\x00\x20ftypisom")
+	return data
+}
+
+func TestSaveTempStream(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		maxSize int64
+		wantErr error
+	}{
+		{name: "under limit", data: mp4File(4096), maxSize: 8192},
+		{name: "exactly at limit", data: mp4File(4096), maxSize: 4096},
+		{name: "smaller than sniff length", data: mp4File(100), maxSize: 4096},
+		{name: "over limit", data: mp4File(4097), maxSize: 4096, wantErr: ErrFileTooLarge},
+		{name: "over limit within header", data: mp4File(300), maxSize: 200, wantErr: ErrFileTooLarge},
+		{name: "not a video", data: []byte("<html><body>not a video</body></html>"), maxSize: 4096, wantErr: ErrUnsupportedFileType},
+		{name: "empty", data: nil, maxSize: 4096, wantErr: ErrUnsupportedFileType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			s := &UploadService{}
+
+			file, err := s.SaveTempStream(bytes.NewReader(tt.data), ".mp4", tt.maxSize)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("SaveTempStream() error = %v, want %v", err, tt.wantErr)
+				}
+				// 失败时不留下临时文件
+				if entries, _ := os.ReadDir(constant.TempUploadDir); len(entries) != 0 {
+					t.Errorf("temp dir has %d files after failure, want 0", len(entries))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SaveTempStream() error = %v", err)
+			}
+
+			saved, err := os.ReadFile(file.Path)
+			if err != nil {
+				t.Fatalf("failed to read temp file: %v", err)
+			}
+			if !bytes.Equal(saved, tt.data) {
+				t.Errorf("saved %d bytes, want original %d bytes", len(saved), len(tt.data))
+			}
+			sum := sha256.Sum256(tt.data)
+			if file.Checksum != hex.EncodeToString(sum[:]) {
+				t.Errorf("checksum = %s, want %s", file.Checksum, hex.EncodeToString(sum[:]))
+			}
+			if file.Size != int64(len(tt.data)) {
+				t.Errorf("size = %d, want %d", file.Size, len(tt.data))
+			}
+			if file.ContentType != "video/mp4" {
+				t.Errorf("content type = %q, want video/mp4", file.ContentType)
+			}
+		})
+	}
+}
+
+func TestSaveTempStream_MaxBytesReader(t *testing.T) {
+	t.Chdir(t.TempDir())
+	s := &UploadService{}
+
+	// 请求体超过 http.MaxBytesReader 限制时同样返回 ErrFileTooLarge
+	body := http.MaxBytesReader(httptest.NewRecorder(), io.NopCloser(bytes.NewReader(mp4File(4096))), 1024)
+	if _, err := s.SaveTempStream(body, ".mp4", 8192); !errors.Is(err, ErrFileTooLarge) {
+		t.Fatalf("SaveTempStream() error = %v, want ErrFileTooLarge", err)
+	}
+	if entries, _ := os.ReadDir(constant.TempUploadDir); len(entries) != 0 {
+		t.Errorf("temp dir has %d files after failure, want 0", len(entries))
+	}
+}
+
+func TestSaveTempImage_RejectsVideo(t *testing.T) {
+	t.Chdir(t.TempDir())
+	s := &UploadService{}
+
+	if _, err := s.SaveTempImage(bytes.NewReader(mp4File(1024)), ".jpg", 4096); !errors.Is(err, ErrUnsupportedFileType) {
+		t.Fatalf("SaveTempImage() error = %v, want ErrUnsupportedFileType", err)
+	}
+}
@@ -0,0 +1,33 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// lockScript 会话仍存在时设置标记字段，返回 1 表示设置成功、0 表示已被标记、-1 表示会话不存在
+// 会话可能在读取后过期，直接 HSETNX 会创建一个没有过期时间的新 Hash
+var lockScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return -1
+end
+return redis.call('HSETNX', KEYS[1], ARGV[1], 1)
+`)
+
+// lockSession 标记会话正在合并或确认，会话已被标记时返回 ErrUploadCompleting，已过期时返回 ErrUploadSessionNotFound
+func lockSession(ctx context.Context, rdb *redis.Client, key, field string) error {
+	res, err := lockScript.Run(ctx, rdb, []string{key}, field).Int()
+	if err != nil {
+		return fmt.Errorf("failed to lock upload session: %w", err)
+	}
+	switch res {
+	case -1:
+		return ErrUploadSessionNotFound
+	case 0:
+		return ErrUploadCompleting
+	default:
+		return nil
+	}
+}
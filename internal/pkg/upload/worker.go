@@ -3,81 +3,160 @@ package upload
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
-	amqp "github.com/rabbitmq/amqp091-go"
 	"go.uber.org/zap"
 
 	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/config"
 	"github.com/wangn-tech/tiny-douyin/internal/dao"
 	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/event"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/media"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/outbox"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/queue"
 )
 
 // Worker 上传任务工作器
+// 从任务队列消费，按 prefetch 限制未确认任务数，由 concurrency 个 goroutine 并发处理
 type Worker struct {
 	uploadService IUploadService
 	videoDAO      dao.IVideoDAO
-	queueName     string
+	txManager     dao.ITxManager
+	events        event.IPublisher
+	processor     media.IProcessor
+	taskQueue     queue.ITaskQueue
+	dedup         outbox.IDeduplicator
+	rabbitCfg     *config.RabbitMQConfig
+	maxRetries    int
+	concurrency   int
+	prefetch      int
+	coverOffset   time.Duration
+	hlsEnabled    bool
+
+	wg        sync.WaitGroup     // 处理中的 goroutine
+	procCtx   context.Context    // 任务处理 context，停止超时后取消以中断处理中的任务
+	abortProc context.CancelFunc // 取消 procCtx
 }
 
 // NewWorker 创建工作器（通过依赖注入）
-func NewWorker(uploadService IUploadService, videoDAO dao.IVideoDAO) IUploadWorker {
+func NewWorker(
+	uploadService IUploadService,
+	videoDAO dao.IVideoDAO,
+	txManager dao.ITxManager,
+	events event.IPublisher,
+	processor media.IProcessor,
+	taskQueue queue.ITaskQueue,
+	dedup outbox.IDeduplicator,
+) IUploadWorker {
+	coverOffset := constant.MediaDefaultCoverOffset
+	if offset := global.Config.Media.CoverOffset; offset > 0 {
+		coverOffset = time.Duration(offset * float64(time.Second))
+	}
+
+	cfg := &global.Config.RabbitMQ
+	procCtx, abortProc := context.WithCancel(context.Background())
+
 	return &Worker{
 		uploadService: uploadService,
 		videoDAO:      videoDAO,
-		queueName:     global.Config.RabbitMQ.Queue,
+		txManager:     txManager,
+		events:        events,
+		processor:     processor,
+		taskQueue:     taskQueue,
+		dedup:         dedup,
+		rabbitCfg:     cfg,
+		maxRetries:    cfg.RetryAttempts(),
+		concurrency:   cfg.WorkerConcurrency(),
+		prefetch:      cfg.WorkerPrefetch(),
+		coverOffset:   coverOffset,
+		hlsEnabled:    global.Config.Media.HLSEnabled,
+		procCtx:       procCtx,
+		abortProc:     abortProc,
 	}
 }
 
-// Start 启动工作器
+// Start 启动工作器，ctx 取消后停止消费，处理中的任务继续执行直至完成
 func (w *Worker) Start(ctx context.Context) error {
-	// 获取消息通道
-	msgs, err := global.RabbitChan.Consume(
-		w.queueName,                  // 队列名称
-		constant.RabbitMQConsumerTag, // 消费者标签
-		false,                        // 自动确认
-		false,                        // 独占
-		false,                        // 不等待
-		false,                        // 额外参数
-		nil,
-	)
+	msgs, err := w.taskQueue.Consume(ctx, w.prefetch)
 	if err != nil {
-		return fmt.Errorf("failed to register consumer: %w", err)
+		return fmt.Errorf("failed to consume task queue: %w", err)
 	}
 
-	global.Logger.Info("Upload worker started, waiting for messages...")
+	global.Logger.Info("Upload worker started, waiting for messages...",
+		zap.Int("concurrency", w.concurrency),
+		zap.Int("prefetch", w.prefetch))
 
-	// 处理消息
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				global.Logger.Info("Upload worker stopped")
-				return
-			case msg, ok := <-msgs:
-				if !ok {
-					global.Logger.Warn("Message channel closed")
-					return
-				}
+	// 并发处理任务：ctx 取消后 msgs 关闭，各 goroutine 处理完手上的任务后退出
+	for i := 0; i < w.concurrency; i++ {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			for msg := range msgs {
 				w.processMessage(msg)
 			}
-		}
+		}()
+	}
+
+	go func() {
+		<-ctx.Done()
+		global.Logger.Info("Upload worker stopped consuming, draining in-flight tasks")
 	}()
 
 	return nil
 }
 
+// Wait 等待处理中的任务完成（任务队列在之后由 initialize.CloseAll 关闭）
+// ctx 超时后中断处理中的任务（消息退回队列），并在短暂宽限期后返回
+func (w *Worker) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		global.Logger.Warn("Upload worker drain timeout, aborting in-flight tasks")
+		w.abortProc()
+		select {
+		case <-done:
+		case <-time.After(constant.WorkerAbortGracePeriod):
+		}
+		err = ctx.Err()
+	}
+
+	w.abortProc()
+	global.Logger.Info("Upload worker stopped")
+	return err
+}
+
 // processMessage 处理单条消息
-func (w *Worker) processMessage(msg amqp.Delivery) {
-	ctx := context.Background()
+func (w *Worker) processMessage(msg *queue.Delivery) {
+	ctx, cancel := context.WithTimeout(w.procCtx, constant.MediaProcessTimeout)
+	defer cancel()
+
+	// 发件箱至少投递一次，已处理过的消息直接确认
+	if w.dedup.Seen(ctx, msg.ID) {
+		global.Logger.Info("Skip duplicate upload task",
+			zap.String("message_id", msg.ID))
+		_ = msg.Ack()
+		return
+	}
 
 	// 解析任务
 	var task VideoUploadTask
 	if err := json.Unmarshal(msg.Body, &task); err != nil {
 		global.Logger.Error("Failed to unmarshal task",
 			zap.Error(err))
-		_ = msg.Nack(false, false) // 拒绝消息，不重新入队
+		_ = msg.Reject() // 无法解析的消息直接转入死信队列
 		return
 	}
 
@@ -85,69 +164,358 @@ func (w *Worker) processMessage(msg amqp.Delivery) {
 		zap.Uint("video_id", task.VideoID),
 		zap.String("video_path", task.VideoPath))
 
-	// 上传视频到 MinIO
-	videoURL, err := w.uploadService.UploadToMinIO(ctx, task.VideoPath, task.VideoName, task.ContentType)
+	// 进入处理中状态（消息重投时视频可能已处于 processing）
+	started, err := w.videoDAO.UpdateVideoStatus(ctx, task.VideoID, constant.VideoStatusProcessing,
+		constant.VideoStatusUploading, constant.VideoStatusProcessing)
 	if err != nil {
-		global.Logger.Error("Failed to upload video to MinIO",
+		global.Logger.Error("Failed to update video status",
 			zap.Uint("video_id", task.VideoID),
 			zap.Error(err))
-		_ = msg.Nack(false, true) // 拒绝消息，重新入队
+		w.retryLater(ctx, msg, task.VideoID, err)
+		return
+	}
+	if !started {
+		// 视频已就绪、失败、删除或不存在，重复消息直接确认
+		global.Logger.Warn("Skip upload task, video not pending",
+			zap.Uint("video_id", task.VideoID))
+		w.cleanupTask(&task)
+		w.ack(ctx, msg)
 		return
 	}
 
-	// 上传封面到 MinIO（如果存在）
-	var coverURL string
-	if task.CoverPath != "" {
-		coverURL, err = w.uploadService.UploadToMinIO(ctx, task.CoverPath, task.CoverName, "image/jpeg")
+	// 客户端直传的视频先下载到临时目录（每次处理重新下载，不随消息保留）
+	// 直传的内容不经过 API 服务，原始文件的校验和在这里计算
+	var sourceChecksum string
+	if task.SourceObject != "" {
+		path, err := w.uploadService.DownloadObject(ctx, task.SourceObject)
 		if err != nil {
-			global.Logger.Error("Failed to upload cover to MinIO",
+			global.Logger.Error("Failed to download source object",
 				zap.Uint("video_id", task.VideoID),
+				zap.String("source_object", task.SourceObject),
 				zap.Error(err))
-			// 封面上传失败不影响视频，继续处理
+			w.retryLater(ctx, msg, task.VideoID, err)
+			return
+		}
+		task.VideoPath = path
+		defer w.uploadService.CleanupTempFile(path)
+
+		if sourceChecksum, err = fileChecksum(path); err != nil {
+			global.Logger.Error("Failed to checksum source object",
+				zap.Uint("video_id", task.VideoID),
+				zap.String("source_object", task.SourceObject),
+				zap.Error(err))
+			w.retryLater(ctx, msg, task.VideoID, err)
+			return
+		}
+	}
+
+	// 处理视频：校验容器、读取元数据、按需转码、截取封面
+	result, err := w.processVideo(ctx, &task)
+	defer result.cleanup(w.uploadService)
+	if err != nil {
+		if errors.Is(err, media.ErrInvalidMedia) {
+			// 文件无效，重试和重放都无意义：标记视频失败并确认消息
+			global.Logger.Warn("Invalid video file",
+				zap.Uint("video_id", task.VideoID),
+				zap.Error(err))
+			w.markFailed(ctx, task.VideoID)
+			w.cleanupTask(&task)
+			w.removeSourceObject(ctx, &task)
+			w.ack(ctx, msg)
+			return
 		}
+		global.Logger.Error("Failed to process video",
+			zap.Uint("video_id", task.VideoID),
+			zap.Error(err))
+		w.retryLater(ctx, msg, task.VideoID, err)
+		return
 	}
 
-	// 如果没有封面，生成默认封面 URL
-	if coverURL == "" {
-		coverURL = videoURL + "?x-oss-process=video/snapshot,t_1000,f_jpg" // 1秒处截图
+	// 上传视频到对象存储（直传且未转码的视频已在对象存储中，无需重复上传）
+	var videoURL string
+	if task.SourceObject != "" && result.videoPath == task.VideoPath && result.videoName == task.SourceObject {
+		videoURL = w.uploadService.ObjectURL(result.videoName)
+	} else {
+		videoURL, err = w.uploadService.UploadFile(ctx, result.videoPath, result.videoName, result.contentType)
+	}
+	if err != nil {
+		global.Logger.Error("Failed to upload video to object storage",
+			zap.Uint("video_id", task.VideoID),
+			zap.Error(err))
+		w.retryLater(ctx, msg, task.VideoID, err)
+		return
+	}
+
+	// 上传 HLS 到对象存储（与原视频相邻的 {uuid}/hls/ 前缀下）
+	var hlsURL string
+	if result.hlsDir != "" {
+		prefixURL, err := w.uploadService.UploadDir(ctx, result.hlsDir, w.uploadService.GenerateHLSPrefix(result.videoName))
+		if err != nil {
+			global.Logger.Error("Failed to upload hls to object storage",
+				zap.Uint("video_id", task.VideoID),
+				zap.Error(err))
+			w.retryLater(ctx, msg, task.VideoID, err)
+			return
+		}
+		hlsURL = prefixURL + "/" + media.HLSMasterPlaylist
 	}
 
-	// 更新数据库中的视频 URL
+	// 上传封面到对象存储（用户上传的封面或截取的视频帧）
+	var coverURL string
+	if result.coverPath != "" {
+		coverURL, err = w.uploadService.UploadFile(ctx, result.coverPath, task.CoverName, constant.MediaCoverContentType)
+		if err != nil {
+			global.Logger.Error("Failed to upload cover to object storage",
+				zap.Uint("video_id", task.VideoID),
+				zap.Error(err))
+			// 封面上传失败不影响视频，继续处理
+		}
+	}
+
+	// 更新数据库中的视频 URL 和元数据
 	video, err := w.videoDAO.GetVideoByID(ctx, task.VideoID)
 	if err != nil {
 		global.Logger.Error("Failed to get video from database",
 			zap.Uint("video_id", task.VideoID),
 			zap.Error(err))
-		_ = msg.Nack(false, true) // 拒绝消息，重新入队
+		w.retryLater(ctx, msg, task.VideoID, err)
 		return
 	}
 
 	video.PlayURL = videoURL
 	video.CoverURL = coverURL
-	video.UpdatedAt = time.Now()
+	video.HLSURL = hlsURL
+	video.DurationMs = result.meta.Duration.Milliseconds()
+	video.Width = result.meta.Width
+	video.Height = result.meta.Height
+	video.VideoCodec = result.meta.VideoCodec
+	video.AudioCodec = result.meta.AudioCodec
+	video.Status = constant.VideoStatusReady
+	if video.Checksum == "" {
+		video.Checksum = sourceChecksum
+	}
 
-	if err := w.videoDAO.UpdateVideo(ctx, video); err != nil {
+	// 视频仍处于 processing 时写入处理结果并发布视频发布事件（同一事务）
+	// 处理期间视频可能已被删除或被其他投递标记失败，此时不覆盖状态、不发布事件
+	var published bool
+	err = w.txManager.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		published, err = w.videoDAO.UpdateVideoMedia(ctx, video, constant.VideoStatusProcessing)
+		if err != nil || !published {
+			return err
+		}
+		return w.events.Publish(ctx, event.VideoPublished{
+			VideoID:  video.ID,
+			AuthorID: video.AuthorID,
+			Title:    video.Title,
+		})
+	})
+	if err != nil {
 		global.Logger.Error("Failed to update video in database",
 			zap.Uint("video_id", task.VideoID),
 			zap.Error(err))
-		_ = msg.Nack(false, true) // 拒绝消息，重新入队
+		w.retryLater(ctx, msg, task.VideoID, err)
 		return
 	}
 
-	// 清理临时文件
-	w.uploadService.CleanupTempFile(task.VideoPath)
-	if task.CoverPath != "" {
-		w.uploadService.CleanupTempFile(task.CoverPath)
+	// 清理临时文件（直传的原始视频转码为其他对象名后不再需要）
+	w.cleanupTask(&task)
+	if result.videoName != task.SourceObject {
+		w.removeSourceObject(ctx, &task)
 	}
 
 	// 确认消息
-	if err := msg.Ack(false); err != nil {
-		global.Logger.Error("Failed to acknowledge message",
-			zap.Error(err))
+	w.ack(ctx, msg)
+
+	if !published {
+		global.Logger.Warn("Upload task finished but video no longer processing, skip publishing",
+			zap.Uint("video_id", task.VideoID))
+		return
 	}
 
 	global.Logger.Info("Upload task completed successfully",
 		zap.Uint("video_id", task.VideoID),
 		zap.String("video_url", videoURL),
-		zap.String("cover_url", coverURL))
+		zap.String("cover_url", coverURL),
+		zap.String("hls_url", hlsURL),
+		zap.Duration("duration", result.meta.Duration),
+		zap.Int("width", result.meta.Width),
+		zap.Int("height", result.meta.Height),
+		zap.String("video_codec", result.meta.VideoCodec))
+}
+
+// ack 确认消息并记录已处理，重复投递的同一消息不再处理
+func (w *Worker) ack(ctx context.Context, msg *queue.Delivery) {
+	if err := msg.Ack(); err != nil {
+		global.Logger.Error("Failed to acknowledge message",
+			zap.Error(err))
+		return
+	}
+	w.dedup.MarkDone(ctx, msg.ID)
+}
+
+// retryLater 将处理失败的任务延迟重试（指数退避）
+// 超过最大重试次数后标记视频失败，并拒绝任务使其转入死信队列；
+// 此时保留临时文件，以便通过管理接口重放
+func (w *Worker) retryLater(ctx context.Context, msg *queue.Delivery, videoID uint, cause error) {
+	// 停机时被中断的任务不计入重试次数，直接退回业务队列
+	if w.procCtx.Err() != nil {
+		global.Logger.Warn("Upload task aborted by shutdown, requeue",
+			zap.Uint("video_id", videoID))
+		_ = msg.Requeue()
+		return
+	}
+
+	// 处理超时时 ctx 已过期，调度重试和标记失败需要使用新的 ctx
+	if ctx.Err() != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.WithoutCancel(ctx), constant.UploadTaskSettleTimeout)
+		defer cancel()
+	}
+
+	attempt := msg.RetryCount + 1
+	if attempt > w.maxRetries {
+		global.Logger.Error("Upload task exceeded max retries, moving to dead letter queue",
+			zap.Uint("video_id", videoID),
+			zap.Int("max_retries", w.maxRetries),
+			zap.Error(cause))
+		w.markFailed(ctx, videoID)
+		_ = msg.Reject()
+		return
+	}
+
+	if err := msg.Retry(ctx, cause); err != nil {
+		global.Logger.Error("Failed to schedule upload task retry",
+			zap.Uint("video_id", videoID),
+			zap.Int("attempt", attempt),
+			zap.Error(err))
+		_ = msg.Requeue() // 无法投递到重试队列时退回业务队列
+		return
+	}
+
+	global.Logger.Warn("Upload task scheduled for retry",
+		zap.Uint("video_id", videoID),
+		zap.Int("attempt", attempt),
+		zap.Duration("delay", w.rabbitCfg.RetryDelay(attempt)),
+		zap.Error(cause))
+}
+
+// processResult 视频处理结果
+type processResult struct {
+	meta        *media.Metadata
+	videoPath   string   // 待上传的视频文件（原文件或转码后的文件）
+	videoName   string   // 对象存储中的视频文件名（转码后扩展名为 .mp4）
+	contentType string   // 视频内容类型
+	coverPath   string   // 待上传的封面文件，为空表示没有封面
+	hlsDir      string   // HLS 输出目录，为空表示没有生成 HLS
+	tempFiles   []string // 处理过程中生成的临时文件
+}
+
+// cleanup 清理处理过程中生成的临时文件
+func (r *processResult) cleanup(uploadService IUploadService) {
+	for _, path := range r.tempFiles {
+		uploadService.CleanupTempFile(path)
+	}
+	if r.hlsDir != "" {
+		uploadService.CleanupTempDir(r.hlsDir)
+	}
+}
+
+// processVideo 校验视频、按需转码并准备封面
+func (w *Worker) processVideo(ctx context.Context, task *VideoUploadTask) (*processResult, error) {
+	result := &processResult{
+		videoPath:   task.VideoPath,
+		videoName:   task.VideoName,
+		contentType: task.ContentType,
+		coverPath:   task.CoverPath,
+	}
+
+	// 1. 校验容器并读取元数据
+	meta, err := w.processor.Probe(ctx, task.VideoPath)
+	if err != nil {
+		return result, err
+	}
+	result.meta = meta
+
+	// 2. 非 H.264/AAC 的 MP4 统一转码，保证各端可直接播放
+	if media.NeedsTranscode(meta) {
+		dst := strings.TrimSuffix(task.VideoPath, filepath.Ext(task.VideoPath)) + ".transcoded" + constant.MediaTranscodeExtension
+		result.tempFiles = append(result.tempFiles, dst)
+		if err := w.processor.Transcode(ctx, task.VideoPath, dst); err != nil {
+			return result, err
+		}
+
+		// 重新读取转码后的元数据
+		if meta, err = w.processor.Probe(ctx, dst); err != nil {
+			return result, err
+		}
+		result.meta = meta
+		result.videoPath = dst
+		result.videoName = strings.TrimSuffix(task.VideoName, filepath.Ext(task.VideoName)) + constant.MediaTranscodeExtension
+		result.contentType = constant.MediaTranscodeContentType
+	}
+
+	// 3. 没有上传封面时截取视频帧作为封面
+	if result.coverPath == "" {
+		dst := strings.TrimSuffix(task.VideoPath, filepath.Ext(task.VideoPath)) + constant.MinioCoverExtension
+		at := media.CoverOffset(meta, w.coverOffset)
+		if err := w.processor.ExtractCover(ctx, result.videoPath, dst, at); err != nil {
+			// 截图失败不影响视频，继续处理（没有封面）
+			global.Logger.Warn("Failed to extract cover",
+				zap.Uint("video_id", task.VideoID),
+				zap.Error(err))
+		} else {
+			result.tempFiles = append(result.tempFiles, dst)
+			result.coverPath = dst
+		}
+	}
+
+	// 4. 生成多码率 HLS（失败时降级为只提供原视频）
+	if w.hlsEnabled {
+		dir := strings.TrimSuffix(task.VideoPath, filepath.Ext(task.VideoPath)) + constant.MediaHLSDirSuffix
+		result.hlsDir = dir
+		renditions := media.SelectRenditions(meta, media.DefaultLadder)
+		if err := w.processor.TranscodeHLS(ctx, result.videoPath, dir, meta, renditions); err != nil {
+			global.Logger.Warn("Failed to transcode hls, fallback to original video",
+				zap.Uint("video_id", task.VideoID),
+				zap.Error(err))
+			w.uploadService.CleanupTempDir(dir)
+			result.hlsDir = ""
+		}
+	}
+
+	return result, nil
+}
+
+// markFailed 将视频标记为处理失败
+func (w *Worker) markFailed(ctx context.Context, videoID uint) {
+	if _, err := w.videoDAO.UpdateVideoStatus(ctx, videoID, constant.VideoStatusFailed,
+		constant.VideoStatusUploading, constant.VideoStatusProcessing); err != nil {
+		global.Logger.Error("Failed to update video status",
+			zap.Uint("video_id", videoID),
+			zap.Error(err))
+	}
+}
+
+// cleanupTask 清理任务的上传临时文件（直传视频下载的临时文件由处理流程自行清理）
+func (w *Worker) cleanupTask(task *VideoUploadTask) {
+	if task.SourceObject == "" {
+		w.uploadService.CleanupTempFile(task.VideoPath)
+	}
+	if task.CoverPath != "" {
+		w.uploadService.CleanupTempFile(task.CoverPath)
+	}
+}
+
+// removeSourceObject 删除客户端直传的原始视频对象
+func (w *Worker) removeSourceObject(ctx context.Context, task *VideoUploadTask) {
+	if task.SourceObject == "" {
+		return
+	}
+	if err := w.uploadService.RemoveObject(ctx, task.SourceObject); err != nil {
+		global.Logger.Warn("Failed to remove source object",
+			zap.Uint("video_id", task.VideoID),
+			zap.String("source_object", task.SourceObject),
+			zap.Error(err))
+	}
 }
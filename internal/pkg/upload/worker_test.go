@@ -0,0 +1,365 @@
+package upload
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/config"
+	"github.com/wangn-tech/tiny-douyin/internal/dao"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/model"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/event"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/media"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/queue"
+)
+
+const testStoreURL = "http://store/"
+
+// fakeVideoDAO 内存中的单个视频，条件更新与 VideoDAO 一致
+type fakeVideoDAO struct {
+	dao.IVideoDAO
+	video *model.Video
+}
+
+func (d *fakeVideoDAO) GetVideoByID(ctx context.Context, id uint) (*model.Video, error) {
+	copied := *d.video
+	return &copied, nil
+}
+
+func (d *fakeVideoDAO) UpdateVideoStatus(ctx context.Context, videoID uint, to string, from ...string) (bool, error) {
+	if !slices.Contains(from, d.video.Status) {
+		return false, nil
+	}
+	d.video.Status = to
+	return true, nil
+}
+
+func (d *fakeVideoDAO) UpdateVideoMedia(ctx context.Context, video *model.Video, from ...string) (bool, error) {
+	if !slices.Contains(from, d.video.Status) {
+		return false, nil
+	}
+	copied := *video
+	d.video = &copied
+	return true, nil
+}
+
+// fakeUploadService 记录上传到对象存储的对象，临时文件直接删除
+type fakeUploadService struct {
+	IUploadService
+	objects    []string
+	failUpload bool // 上传视频失败
+}
+
+func (s *fakeUploadService) UploadFile(ctx context.Context, filePath, objectName, contentType string) (string, error) {
+	if s.failUpload && contentType != constant.MediaCoverContentType {
+		return "", errors.New("storage unavailable")
+	}
+	if _, err := os.Stat(filePath); err != nil {
+		return "", err
+	}
+	s.objects = append(s.objects, objectName)
+	return testStoreURL + objectName, nil
+}
+
+func (s *fakeUploadService) UploadDir(ctx context.Context, dir, prefix string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, media.HLSMasterPlaylist)); err != nil {
+		return "", err
+	}
+	s.objects = append(s.objects, prefix+"/"+media.HLSMasterPlaylist)
+	return testStoreURL + prefix, nil
+}
+
+func (s *fakeUploadService) GenerateHLSPrefix(videoName string) string {
+	return strings.TrimSuffix(videoName, filepath.Ext(videoName)) + "/hls"
+}
+
+func (s *fakeUploadService) CleanupTempFile(filePath string) { _ = os.Remove(filePath) }
+func (s *fakeUploadService) CleanupTempDir(dir string)       { _ = os.RemoveAll(dir) }
+
+// fakeTxManager 直接执行 fn
+type fakeTxManager struct{}
+
+func (fakeTxManager) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// fakePublisher 记录发布的领域事件
+type fakePublisher struct {
+	events []event.Event
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, events ...event.Event) error {
+	p.events = append(p.events, events...)
+	return nil
+}
+
+// fakeDedup 内存中的已处理消息
+type fakeDedup map[string]bool
+
+func (d fakeDedup) Seen(ctx context.Context, messageID string) bool { return d[messageID] }
+func (d fakeDedup) MarkDone(ctx context.Context, messageID string)  { d[messageID] = true }
+
+// workerTestEnv 使用模拟处理器和进程内队列的工作器
+type workerTestEnv struct {
+	worker        *Worker
+	videoDAO      *fakeVideoDAO
+	uploadService *fakeUploadService
+	events        *fakePublisher
+	dedup         fakeDedup
+	taskQueue     queue.ITaskQueue
+	msgs          <-chan *queue.Delivery
+}
+
+func newWorkerTestEnv(t *testing.T, status string, maxRetries int) *workerTestEnv {
+	t.Helper()
+	global.Logger = zap.NewNop()
+
+	video := &model.Video{AuthorID: 1, Title: "test", Status: status}
+	video.ID = 1
+	taskQueue := queue.NewMemoryQueue(func(attempt int) time.Duration { return 0 })
+	ctx, cancel := context.WithCancel(context.Background())
+	msgs, err := taskQueue.Consume(ctx, 1)
+	if err != nil {
+		t.Fatalf("Consume() error = %v", err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		_ = taskQueue.Close()
+	})
+
+	env := &workerTestEnv{
+		videoDAO:      &fakeVideoDAO{video: video},
+		uploadService: &fakeUploadService{},
+		events:        &fakePublisher{},
+		dedup:         fakeDedup{},
+		taskQueue:     taskQueue,
+		msgs:          msgs,
+	}
+	env.worker = &Worker{
+		uploadService: env.uploadService,
+		videoDAO:      env.videoDAO,
+		txManager:     fakeTxManager{},
+		events:        env.events,
+		processor:     media.NewFakeProcessor(),
+		taskQueue:     taskQueue,
+		dedup:         env.dedup,
+		rabbitCfg:     &config.RabbitMQConfig{},
+		maxRetries:    maxRetries,
+		coverOffset:   constant.MediaDefaultCoverOffset,
+		hlsEnabled:    true,
+		procCtx:       context.Background(),
+		abortProc:     func() {},
+	}
+	return env
+}
+
+// publish 发布任务并取出投递
+func (e *workerTestEnv) publish(t *testing.T, id string, task *VideoUploadTask) *queue.Delivery {
+	t.Helper()
+	body, err := json.Marshal(task)
+	if err != nil {
+		t.Fatalf("failed to marshal task: %v", err)
+	}
+	if err := e.taskQueue.Publish(context.Background(), id, body); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	msg := e.next(time.Second)
+	if msg == nil {
+		t.Fatal("task not delivered")
+	}
+	return msg
+}
+
+// next 在 timeout 内取出下一条投递，没有时返回 nil
+func (e *workerTestEnv) next(timeout time.Duration) *queue.Delivery {
+	select {
+	case msg := <-e.msgs:
+		return msg
+	case <-time.After(timeout):
+		return nil
+	}
+}
+
+// deadLetters 死信队列中的消息数
+func (e *workerTestEnv) deadLetters(t *testing.T) int {
+	t.Helper()
+	n := 0
+	_, err := e.taskQueue.ScanDeadLetters(context.Background(), 10, func(ctx context.Context, msg *queue.Message) (queue.DeadLetterAction, error) {
+		n++
+		return queue.DeadLetterKeep, nil
+	})
+	if err != nil {
+		t.Fatalf("ScanDeadLetters() error = %v", err)
+	}
+	return n
+}
+
+// writeTempVideo 在临时目录写入以 header 开头的视频文件
+func writeTempVideo(t *testing.T, name string, header []byte) string {
+	t.Helper()
+	data := make([]byte, 1024)
+	copy(data, header)
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write video: %v", err)
+	}
+	return path
+}
+
+var (
+	mp4Header  = []byte("\x00\x00\x00\x20ftypisom")
+	webmHeader = []byte{0x1A, 0x45, 0xDF, 0xA3, 0, 0, 0, 0, 0, 0, 0, 0}
+	textHeader = []byte("not a video file")
+)
+
+func TestWorker_ProcessMessage(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		header      []byte
+		status      string // 处理前的视频状态
+		failUpload  bool
+		maxRetries  int
+		wantStatus  string
+		wantPlayURL string
+		wantAck     bool // 确认消息并记录已处理
+		wantRetry   bool // 延迟重试
+		wantDead    bool // 转入死信队列
+		wantKept    bool // 保留临时文件
+	}{
+		{
+			name: "mp4 uploaded as is", file: "a.mp4", header: mp4Header,
+			status: constant.VideoStatusUploading, maxRetries: 3,
+			wantStatus: constant.VideoStatusReady, wantPlayURL: testStoreURL + "videos/a.mp4", wantAck: true,
+		},
+		{
+			name: "webm transcoded to mp4", file: "a.webm", header: webmHeader,
+			status: constant.VideoStatusUploading, maxRetries: 3,
+			wantStatus: constant.VideoStatusReady, wantPlayURL: testStoreURL + "videos/a.mp4", wantAck: true,
+		},
+		{
+			name: "redelivered while processing", file: "a.mp4", header: mp4Header,
+			status: constant.VideoStatusProcessing, maxRetries: 3,
+			wantStatus: constant.VideoStatusReady, wantPlayURL: testStoreURL + "videos/a.mp4", wantAck: true,
+		},
+		{
+			name: "invalid media", file: "a.mp4", header: textHeader,
+			status: constant.VideoStatusUploading, maxRetries: 3,
+			wantStatus: constant.VideoStatusFailed, wantAck: true,
+		},
+		{
+			name: "video no longer pending", file: "a.mp4", header: mp4Header,
+			status: constant.VideoStatusDeleted, maxRetries: 3,
+			wantStatus: constant.VideoStatusDeleted, wantAck: true,
+		},
+		{
+			name: "upload failure retried", file: "a.mp4", header: mp4Header,
+			status: constant.VideoStatusUploading, failUpload: true, maxRetries: 3,
+			wantStatus: constant.VideoStatusProcessing, wantRetry: true, wantKept: true,
+		},
+		{
+			name: "upload failure after max retries", file: "a.mp4", header: mp4Header,
+			status: constant.VideoStatusUploading, failUpload: true, maxRetries: 0,
+			wantStatus: constant.VideoStatusFailed, wantDead: true, wantKept: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newWorkerTestEnv(t, tt.status, tt.maxRetries)
+			env.uploadService.failUpload = tt.failUpload
+			path := writeTempVideo(t, tt.file, tt.header)
+			task := &VideoUploadTask{
+				VideoID:   1,
+				VideoPath: path,
+				VideoName: "videos/" + tt.file,
+				CoverName: "covers/a.jpg",
+				UserID:    1,
+			}
+
+			env.worker.processMessage(env.publish(t, "msg-1", task))
+
+			video := env.videoDAO.video
+			if video.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", video.Status, tt.wantStatus)
+			}
+			if video.PlayURL != tt.wantPlayURL {
+				t.Errorf("play url = %q, want %q", video.PlayURL, tt.wantPlayURL)
+			}
+			if env.dedup["msg-1"] != tt.wantAck {
+				t.Errorf("message marked done = %v, want %v", env.dedup["msg-1"], tt.wantAck)
+			}
+			if got := env.deadLetters(t) == 1; got != tt.wantDead {
+				t.Errorf("dead lettered = %v, want %v", got, tt.wantDead)
+			}
+			if tt.wantRetry {
+				retry := env.next(time.Second)
+				if retry == nil || retry.RetryCount != 1 || retry.ID != "msg-1" {
+					t.Fatalf("retry delivery = %+v, want msg-1 with retry count 1", retry)
+				}
+			} else if msg := env.next(50 * time.Millisecond); msg != nil {
+				t.Errorf("unexpected redelivery %+v", msg.Message)
+			}
+
+			// 失败重试或转入死信时保留上传的临时文件以便重放，生成的文件始终清理
+			entries, _ := os.ReadDir(filepath.Dir(path))
+			var left []string
+			for _, entry := range entries {
+				left = append(left, entry.Name())
+			}
+			wantLeft := []string(nil)
+			if tt.wantKept {
+				wantLeft = []string{tt.file}
+			}
+			if !slices.Equal(left, wantLeft) {
+				t.Errorf("temp files = %v, want %v", left, wantLeft)
+			}
+
+			wantPublished := tt.wantStatus == constant.VideoStatusReady
+			if got := len(env.events.events) == 1; got != wantPublished {
+				t.Errorf("published events = %v, want published %v", env.events.events, wantPublished)
+			}
+			if !wantPublished {
+				return
+			}
+			if video.CoverURL != testStoreURL+task.CoverName {
+				t.Errorf("cover url = %q, want extracted cover", video.CoverURL)
+			}
+			if video.HLSURL != testStoreURL+"videos/a/hls/"+media.HLSMasterPlaylist {
+				t.Errorf("hls url = %q, want master playlist", video.HLSURL)
+			}
+			if video.DurationMs != 10000 || video.Width != 720 || video.Height != 1280 || video.VideoCodec != "h264" {
+				t.Errorf("metadata = %dms %dx%d %s, want fake processor metadata",
+					video.DurationMs, video.Width, video.Height, video.VideoCodec)
+			}
+		})
+	}
+}
+
+func TestWorker_ProcessMessageSkipsDuplicate(t *testing.T) {
+	env := newWorkerTestEnv(t, constant.VideoStatusUploading, 3)
+	env.dedup["msg-1"] = true
+	path := writeTempVideo(t, "a.mp4", mp4Header)
+
+	env.worker.processMessage(env.publish(t, "msg-1", &VideoUploadTask{VideoID: 1, VideoPath: path, VideoName: "videos/a.mp4"}))
+
+	if env.videoDAO.video.Status != constant.VideoStatusUploading {
+		t.Errorf("status = %s, want unchanged", env.videoDAO.video.Status)
+	}
+	if len(env.uploadService.objects) != 0 {
+		t.Errorf("uploaded %v for duplicate message, want nothing", env.uploadService.objects)
+	}
+	if msg := env.next(50 * time.Millisecond); msg != nil {
+		t.Errorf("unexpected redelivery %+v", msg.Message)
+	}
+}
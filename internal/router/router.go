@@ -2,6 +2,8 @@ package router
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
 	"github.com/wangn-tech/tiny-douyin/internal/middleware"
 	"github.com/wangn-tech/tiny-douyin/internal/wire"
 )
@@ -20,6 +22,21 @@ func Init(r *gin.Engine) {
 		})
 	})
 
+	// HLS 播放（播放令牌鉴权，开启签名地址时 hls_url 指向该接口）
+	storageHandler := wire.InitStorageHandler()
+	r.GET(constant.SignedURLStreamRoute+"/:token/*key", storageHandler.Stream)
+
+	// 本地对象存储：文件访问和预签名上传（使用 MinIO 时由 MinIO 提供）
+	if storageCfg := global.Config.Storage; storageCfg.Type == constant.StorageTypeLocal {
+		route := storageCfg.LocalRoute()
+		r.GET(route+"/*filepath", storageHandler.Get)
+		r.HEAD(route+"/*filepath", storageHandler.Get)
+		r.POST(route+constant.StorageLocalUploadPath,
+			middleware.StreamMultipart(constant.StorageUploadFileField, constant.MaxVideoSize),
+			storageHandler.Upload,
+		)
+	}
+
 	// Swagger 路由占位（后续集成）
 	// r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
@@ -41,12 +58,34 @@ func Init(r *gin.Engine) {
 	// 视频流（可选登录，传 token 可获取点赞状态）
 	apiRouter.GET("/feed", middleware.JWTAuthOptional(), videoHandler.GetVideoFeed)
 
+	// 视频访问地址重定向（地址过期后客户端可通过该接口获取新的签名地址）
+	// 需要登录：否则该地址相当于永久有效的公开链接，签名地址的有效期失去意义
+	apiRouter.GET("/video/url", middleware.JWTAuth(), videoHandler.RedirectVideoURL)
+
 	// 视频发布（需要登录）
+	// 上传请求先流式读取文件之前的表单字段（token 可能在表单中），再校验登录状态
+	apiRouter.POST("/publish/action",
+		middleware.StreamMultipart(constant.PublishFileField, constant.MaxVideoSize),
+		middleware.JWTAuth(),
+		videoHandler.PublishVideo,
+	)
 	publishRouter := apiRouter.Group("/publish")
 	publishRouter.Use(middleware.JWTAuth())
 	{
-		publishRouter.POST("/action", videoHandler.PublishVideo)
 		publishRouter.GET("/list", videoHandler.GetVideoList)
+		publishRouter.GET("/status", videoHandler.GetVideoStatus)
+		publishRouter.POST("/delete", videoHandler.DeleteVideo)
+
+		// 分片上传（断点续传）
+		publishRouter.POST("/upload/init", videoHandler.InitChunkUpload)
+		publishRouter.PUT("/upload/chunk", videoHandler.UploadChunk)
+		publishRouter.GET("/upload/status", videoHandler.GetChunkUploadStatus)
+		publishRouter.POST("/upload/complete", videoHandler.CompleteChunkUpload)
+		publishRouter.POST("/upload/abort", videoHandler.AbortChunkUpload)
+
+		// 直传对象存储（预签名表单）
+		publishRouter.POST("/presign", videoHandler.PresignUpload)
+		publishRouter.POST("/presign/confirm", videoHandler.ConfirmUpload)
 	}
 
 	// 点赞路由
@@ -69,6 +108,7 @@ func Init(r *gin.Engine) {
 	{
 		commentRouter.POST("/action/", commentHandler.CommentAction)
 		commentRouter.GET("/list/", commentHandler.GetCommentList)
+		commentRouter.GET("/replies/", commentHandler.GetCommentReplies)
 	}
 
 	// 关注路由
@@ -84,15 +124,51 @@ func Init(r *gin.Engine) {
 		relationRouter.GET("/friend/list/", relationHandler.GetFriendList)
 	}
 
+	// 管理路由（X-Admin-Token 鉴权）
+	adminHandler := wire.InitAdminHandler()
+	adminRouter := apiRouter.Group("/admin")
+	adminRouter.Use(middleware.AdminAuth())
+	{
+		adminRouter.GET("/upload/dead-letters/", adminHandler.ListDeadLetters)
+		adminRouter.POST("/upload/dead-letters/replay/", adminHandler.ReplayDeadLetters)
+	}
+
 	// 消息路由
 	messageHandler := wire.InitMessageHandler()
 
+	// 发送消息（需要登录）
+	// 图片消息以 multipart 提交，先流式读取文件之前的表单字段（token 可能在表单中），再校验登录状态
+	apiRouter.POST("/message/action/",
+		middleware.StreamMultipart(constant.MessageImageFileField, constant.MaxMessageImageSize),
+		middleware.JWTAuth(),
+		messageHandler.SendMessage,
+	)
+
 	// 消息操作（需要登录）
 	messageRouter := apiRouter.Group("/message")
 	messageRouter.Use(middleware.JWTAuth())
 	{
-		messageRouter.POST("/action/", messageHandler.SendMessage)
 		messageRouter.GET("/chat/", messageHandler.GetChatMessages)
+		messageRouter.GET("/sync/", messageHandler.SyncMessages)
+		messageRouter.GET("/conversations/", messageHandler.GetConversationList)
+		messageRouter.POST("/read/", messageHandler.MarkRead)
+		messageRouter.POST("/recall/", messageHandler.RecallMessage)
+		messageRouter.POST("/delete/", messageHandler.DeleteMessage)
+		messageRouter.POST("/clear/", messageHandler.ClearConversation)
+		// 实时消息（WebSocket，浏览器无法设置请求头，token 通过查询参数传递）
+		messageRouter.GET("/ws/", messageHandler.Connect)
+	}
+
+	// 通知路由
+	notificationHandler := wire.InitNotificationHandler()
+
+	// 站内通知（需要登录）
+	notificationRouter := apiRouter.Group("/notification")
+	notificationRouter.Use(middleware.JWTAuth())
+	{
+		notificationRouter.GET("/list/", notificationHandler.GetNotificationList)
+		notificationRouter.GET("/unread/", notificationHandler.GetUnreadCount)
+		notificationRouter.POST("/read/", notificationHandler.MarkRead)
 	}
 
 }
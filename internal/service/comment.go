@@ -10,6 +10,8 @@ import (
 	"github.com/wangn-tech/tiny-douyin/internal/dao"
 	"github.com/wangn-tech/tiny-douyin/internal/global"
 	"github.com/wangn-tech/tiny-douyin/internal/model"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/counter"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/event"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -18,8 +20,10 @@ import (
 type ICommentService interface {
 	// CommentAction 评论操作（发布/删除）
 	CommentAction(ctx context.Context, userID uint, req *dto.CommentActionRequest) (*dto.Comment, error)
-	// GetCommentList 获取视频评论列表
+	// GetCommentList 获取视频顶级评论列表
 	GetCommentList(ctx context.Context, videoID uint) ([]*dto.Comment, error)
+	// GetCommentReplies 分页获取顶级评论下的回复
+	GetCommentReplies(ctx context.Context, req *dto.CommentReplyListRequest) (*dto.CommentReplyListResponse, error)
 }
 
 // CommentService 评论服务实现
@@ -27,7 +31,9 @@ type CommentService struct {
 	commentDAO dao.ICommentDAO
 	videoDAO   dao.IVideoDAO
 	userDAO    dao.IUserDAO
-	db         *gorm.DB
+	txManager  dao.ITxManager
+	counter    counter.ICounter
+	events     event.IPublisher
 }
 
 // NewCommentService 创建 CommentService 实例
@@ -35,13 +41,17 @@ func NewCommentService(
 	commentDAO dao.ICommentDAO,
 	videoDAO dao.IVideoDAO,
 	userDAO dao.IUserDAO,
-	db *gorm.DB,
+	txManager dao.ITxManager,
+	counter counter.ICounter,
+	events event.IPublisher,
 ) ICommentService {
 	return &CommentService{
 		commentDAO: commentDAO,
 		videoDAO:   videoDAO,
 		userDAO:    userDAO,
-		db:         db,
+		txManager:  txManager,
+		counter:    counter,
+		events:     events,
 	}
 }
 
@@ -62,6 +72,13 @@ func (s *CommentService) CommentAction(ctx context.Context, userID uint, req *dt
 		)
 		return nil, fmt.Errorf("查询视频失败")
 	}
+	if video.Status != constant.VideoStatusReady {
+		global.Logger.Warn("service.CommentAction.video_not_ready",
+			zap.Uint("video_id", req.VideoID),
+			zap.String("status", video.Status),
+		)
+		return nil, fmt.Errorf("视频尚未就绪")
+	}
 
 	switch req.ActionType {
 	case constant.CommentActionPublish:
@@ -76,7 +93,8 @@ func (s *CommentService) CommentAction(ctx context.Context, userID uint, req *dt
 	}
 }
 
-// publishComment 发布评论
+// publishComment 发布评论，指定 ReplyToID 时发布回复
+// 回复归入被回复评论所在的顶级评论下，并 @ 被回复评论的作者
 func (s *CommentService) publishComment(ctx context.Context, userID uint, req *dto.CommentActionRequest, video *model.Video) (*dto.Comment, error) {
 	// 验证评论内容
 	if req.CommentText == "" {
@@ -101,22 +119,68 @@ func (s *CommentService) publishComment(ctx context.Context, userID uint, req *d
 		Content: req.CommentText,
 	}
 
-	// 使用事务：创建评论 + 增加视频评论数
-	err := s.db.Transaction(func(tx *gorm.DB) error {
+	// 回复：验证被回复的评论属于该视频
+	if req.ReplyToID > 0 {
+		parent, err := s.commentDAO.GetCommentByID(ctx, req.ReplyToID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("回复的评论不存在")
+			}
+			return nil, fmt.Errorf("查询评论失败")
+		}
+		if parent.VideoID != req.VideoID {
+			global.Logger.Warn("service.publishComment.reply_video_mismatch",
+				zap.Uint("reply_to_id", req.ReplyToID),
+				zap.Uint("parent_video_id", parent.VideoID),
+				zap.Uint("request_video_id", req.VideoID),
+			)
+			return nil, fmt.Errorf("回复的评论不属于该视频")
+		}
+		comment.ParentID = parent.ID
+		comment.RootID = parent.ThreadID()
+		comment.ReplyToUserID = parent.UserID
+	}
+
+	// 使用事务：创建评论 + 增加顶级评论回复数 + 增加视频评论数 + 发布评论事件
+	err := s.txManager.Transaction(ctx, func(ctx context.Context) error {
+		// 锁定顶级评论，与删除顶级评论互斥，避免回复写入已删除的评论下
+		if comment.RootID > 0 {
+			if _, err := s.commentDAO.GetCommentByID(ctx, comment.RootID); err != nil {
+				return err
+			}
+		}
+
 		// 创建评论
 		if err := s.commentDAO.CreateComment(ctx, comment); err != nil {
 			return err
 		}
 
-		// 增加视频评论数
-		if err := s.videoDAO.IncrementCommentCount(ctx, req.VideoID); err != nil {
+		if comment.RootID > 0 {
+			if err := s.commentDAO.IncrReplyCount(ctx, comment.RootID, 1); err != nil {
+				return err
+			}
+		}
+
+		// 增加视频评论数（增量记录随事务提交）
+		if err := s.counter.IncrVideo(ctx, req.VideoID, counter.FieldCommentCount, 1); err != nil {
 			return err
 		}
 
-		return nil
+		return s.events.Publish(ctx, event.CommentPosted{
+			CommentID:     comment.ID,
+			VideoID:       req.VideoID,
+			AuthorID:      video.AuthorID,
+			UserID:        userID,
+			Content:       comment.Content,
+			ParentID:      comment.ParentID,
+			ReplyToUserID: comment.ReplyToUserID,
+		})
 	})
 
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("回复的评论不存在")
+		}
 		global.Logger.Error("service.publishComment.transaction_error",
 			zap.Uint("user_id", userID),
 			zap.Uint("video_id", req.VideoID),
@@ -125,9 +189,9 @@ func (s *CommentService) publishComment(ctx context.Context, userID uint, req *d
 		return nil, fmt.Errorf("发布评论失败")
 	}
 
-	// 查询用户信息
-	user, err := s.userDAO.GetUserByID(ctx, userID)
-	if err != nil {
+	// 查询评论用户和被回复用户的信息
+	userMap, err := s.getCommentUsers(ctx, []*model.Comment{comment})
+	if err != nil || userMap[userID] == nil {
 		global.Logger.Error("service.publishComment.get_user_error",
 			zap.Uint("user_id", userID),
 			zap.Error(err),
@@ -136,7 +200,7 @@ func (s *CommentService) publishComment(ctx context.Context, userID uint, req *d
 	}
 
 	// 构建返回的 DTO
-	commentDTO := s.buildCommentDTO(comment, user)
+	commentDTO := s.buildCommentDTO(comment, userMap)
 
 	global.Logger.Info("service.publishComment.success",
 		zap.Uint("comment_id", comment.ID),
@@ -148,6 +212,7 @@ func (s *CommentService) publishComment(ctx context.Context, userID uint, req *d
 }
 
 // deleteComment 删除评论
+// 删除顶级评论时同时删除其下所有回复，视频评论数按实际删除的数量减少
 func (s *CommentService) deleteComment(ctx context.Context, userID uint, req *dto.CommentActionRequest, video *model.Video) (*dto.Comment, error) {
 	// 验证 CommentID
 	if req.CommentID == 0 {
@@ -193,22 +258,43 @@ func (s *CommentService) deleteComment(ctx context.Context, userID uint, req *dt
 		return nil, fmt.Errorf("无权限删除该评论")
 	}
 
-	// 使用事务：删除评论 + 减少视频评论数
-	err = s.db.Transaction(func(tx *gorm.DB) error {
+	// 使用事务：删除评论（及其回复）+ 更新顶级评论回复数 + 减少视频评论数
+	var removed int64
+	err = s.txManager.Transaction(ctx, func(ctx context.Context) error {
+		// 先锁定顶级评论再锁定回复，与发布回复、并发删除互斥；已被并发删除时返回 ErrRecordNotFound
+		if _, err := s.commentDAO.GetCommentByID(ctx, comment.ThreadID()); err != nil {
+			return err
+		}
+		if comment.RootID > 0 {
+			if _, err := s.commentDAO.GetCommentByID(ctx, comment.ID); err != nil {
+				return err
+			}
+		}
+
 		// 删除评论
 		if err := s.commentDAO.DeleteComment(ctx, req.CommentID); err != nil {
 			return err
 		}
-
-		// 减少视频评论数
-		if err := s.videoDAO.DecrementCommentCount(ctx, req.VideoID); err != nil {
+		removed = 1
+
+		if comment.RootID == 0 {
+			replies, err := s.commentDAO.DeleteReplies(ctx, comment.ID)
+			if err != nil {
+				return err
+			}
+			removed += replies
+		} else if err := s.commentDAO.IncrReplyCount(ctx, comment.RootID, -1); err != nil {
 			return err
 		}
 
-		return nil
+		// 减少视频评论数（增量记录随事务提交）
+		return s.counter.IncrVideo(ctx, req.VideoID, counter.FieldCommentCount, -removed)
 	})
 
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("评论不存在")
+		}
 		global.Logger.Error("service.deleteComment.transaction_error",
 			zap.Uint("user_id", userID),
 			zap.Uint("comment_id", req.CommentID),
@@ -221,15 +307,16 @@ func (s *CommentService) deleteComment(ctx context.Context, userID uint, req *dt
 		zap.Uint("comment_id", req.CommentID),
 		zap.Uint("user_id", userID),
 		zap.Uint("video_id", req.VideoID),
+		zap.Int64("removed", removed),
 	)
 
 	return nil, nil
 }
 
-// GetCommentList 获取视频评论列表
+// GetCommentList 获取视频顶级评论列表
 func (s *CommentService) GetCommentList(ctx context.Context, videoID uint) ([]*dto.Comment, error) {
 	// 验证视频是否存在
-	_, err := s.videoDAO.GetVideoByID(ctx, videoID)
+	video, err := s.videoDAO.GetVideoByID(ctx, videoID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			global.Logger.Warn("service.GetCommentList.video_not_found",
@@ -243,6 +330,9 @@ func (s *CommentService) GetCommentList(ctx context.Context, videoID uint) ([]*d
 		)
 		return nil, fmt.Errorf("查询视频失败")
 	}
+	if video.Status != constant.VideoStatusReady {
+		return nil, fmt.Errorf("视频尚未就绪")
+	}
 
 	// 获取评论列表
 	comments, err := s.commentDAO.GetVideoComments(ctx, videoID)
@@ -259,14 +349,8 @@ func (s *CommentService) GetCommentList(ctx context.Context, videoID uint) ([]*d
 		return []*dto.Comment{}, nil
 	}
 
-	// 收集所有评论的用户ID
-	userIDs := make([]uint, 0, len(comments))
-	for _, comment := range comments {
-		userIDs = append(userIDs, comment.UserID)
-	}
-
 	// 批量查询用户信息
-	users, err := s.userDAO.GetUsersByIDs(ctx, userIDs)
+	userMap, err := s.getCommentUsers(ctx, comments)
 	if err != nil {
 		global.Logger.Error("service.GetCommentList.get_users_error",
 			zap.Uint("video_id", videoID),
@@ -275,45 +359,150 @@ func (s *CommentService) GetCommentList(ctx context.Context, videoID uint) ([]*d
 		return nil, fmt.Errorf("查询用户信息失败")
 	}
 
-	// 构建用户信息映射
-	userMap := make(map[uint]*model.User)
+	// 构建评论 DTO 列表
+	commentDTOs := s.buildCommentDTOs(comments, userMap)
+
+	global.Logger.Info("service.GetCommentList.success",
+		zap.Uint("video_id", videoID),
+		zap.Int("count", len(commentDTOs)),
+	)
+
+	return commentDTOs, nil
+}
+
+// GetCommentReplies 分页获取顶级评论下的回复（按发布时间正序）
+func (s *CommentService) GetCommentReplies(ctx context.Context, req *dto.CommentReplyListRequest) (*dto.CommentReplyListResponse, error) {
+	root, err := s.commentDAO.GetCommentByID(ctx, req.CommentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("评论不存在")
+		}
+		return nil, fmt.Errorf("查询评论失败")
+	}
+	if root.RootID > 0 {
+		return nil, fmt.Errorf("只能查询顶级评论的回复")
+	}
+
+	// 与评论列表一致：视频未就绪（处理中、已删除等）时不返回其评论
+	video, err := s.videoDAO.GetVideoByID(ctx, root.VideoID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			global.Logger.Warn("service.GetCommentReplies.video_not_found",
+				zap.Uint("comment_id", root.ID),
+				zap.Uint("video_id", root.VideoID),
+			)
+			return nil, fmt.Errorf("视频不存在")
+		}
+		global.Logger.Error("service.GetCommentReplies.get_video_error",
+			zap.Uint("video_id", root.VideoID),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("查询视频失败")
+	}
+	if video.Status != constant.VideoStatusReady {
+		return nil, fmt.Errorf("视频尚未就绪")
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = constant.CommentReplyDefaultPageSize
+	}
+
+	// 多查一条用于判断是否还有下一页
+	replies, err := s.commentDAO.ListReplies(ctx, root.ID, req.Cursor, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("查询回复列表失败")
+	}
+	hasMore := len(replies) > limit
+	if hasMore {
+		replies = replies[:limit]
+	}
+
+	userMap, err := s.getCommentUsers(ctx, replies)
+	if err != nil {
+		global.Logger.Error("service.GetCommentReplies.get_users_error",
+			zap.Uint("comment_id", req.CommentID),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("查询用户信息失败")
+	}
+
+	resp := &dto.CommentReplyListResponse{
+		StatusCode: 0,
+		StatusMsg:  "success",
+		ReplyList:  s.buildCommentDTOs(replies, userMap),
+		HasMore:    hasMore,
+	}
+	if len(replies) > 0 {
+		resp.NextCursor = replies[len(replies)-1].ID
+	}
+	return resp, nil
+}
+
+// getCommentUsers 批量查询评论用户和被回复用户（userID -> 用户）
+func (s *CommentService) getCommentUsers(ctx context.Context, comments []*model.Comment) (map[uint]*model.User, error) {
+	userIDs := make([]uint, 0, len(comments))
+	for _, comment := range comments {
+		userIDs = append(userIDs, comment.UserID)
+		if comment.ReplyToUserID > 0 {
+			userIDs = append(userIDs, comment.ReplyToUserID)
+		}
+	}
+
+	users, err := s.userDAO.GetUsersByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	userMap := make(map[uint]*model.User, len(users))
 	for _, user := range users {
 		userMap[user.ID] = user
 	}
+	return userMap, nil
+}
 
-	// 构建评论 DTO 列表
+// buildCommentDTOs 构建评论 DTO 列表，跳过用户不存在的评论
+func (s *CommentService) buildCommentDTOs(comments []*model.Comment, userMap map[uint]*model.User) []*dto.Comment {
 	commentDTOs := make([]*dto.Comment, 0, len(comments))
 	for _, comment := range comments {
-		user := userMap[comment.UserID]
-		if user == nil {
-			global.Logger.Warn("service.GetCommentList.user_not_found",
+		if userMap[comment.UserID] == nil {
+			global.Logger.Warn("service.buildCommentDTOs.user_not_found",
 				zap.Uint("user_id", comment.UserID),
 			)
 			continue
 		}
-		commentDTO := s.buildCommentDTO(comment, user)
-		commentDTOs = append(commentDTOs, commentDTO)
+		commentDTOs = append(commentDTOs, s.buildCommentDTO(comment, userMap))
 	}
-
-	global.Logger.Info("service.GetCommentList.success",
-		zap.Uint("video_id", videoID),
-		zap.Int("count", len(commentDTOs)),
-	)
-
-	return commentDTOs, nil
+	return commentDTOs
 }
 
-// buildCommentDTO 构建评论 DTO
-func (s *CommentService) buildCommentDTO(comment *model.Comment, user *model.User) *dto.Comment {
-	return &dto.Comment{
-		ID: comment.ID,
-		User: &dto.UserInfo{
-			ID:        user.ID,
-			Username:  user.Username,
-			Avatar:    user.Avatar,
-			Signature: user.Signature,
-		},
+// buildCommentDTO 构建评论 DTO（评论用户必须在 userMap 中）
+func (s *CommentService) buildCommentDTO(comment *model.Comment, userMap map[uint]*model.User) *dto.Comment {
+	commentDTO := &dto.Comment{
+		ID:         comment.ID,
+		User:       commentUserInfo(userMap[comment.UserID]),
 		Content:    comment.Content,
 		CreateDate: comment.CreatedAt.Format("01-02"), // MM-DD 格式
+		ParentID:   comment.ParentID,
+		RootID:     comment.RootID,
+		ReplyCount: comment.ReplyCount,
+	}
+	if comment.ReplyToUserID > 0 {
+		// 被回复用户已注销时只返回 ID
+		commentDTO.ReplyToUser = &dto.UserInfo{ID: comment.ReplyToUserID}
+		if user, ok := userMap[comment.ReplyToUserID]; ok {
+			commentDTO.ReplyToUser = commentUserInfo(user)
+		}
+	}
+	return commentDTO
+}
+
+// commentUserInfo 评论中展示的用户信息
+func commentUserInfo(user *model.User) *dto.UserInfo {
+	return &dto.UserInfo{
+		ID:        user.ID,
+		Username:  user.Username,
+		Avatar:    user.Avatar,
+		Signature: user.Signature,
 	}
 }
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/wangn-tech/tiny-douyin/internal/api/dto"
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/dao"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/model"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/counter"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/event"
+)
+
+// fakeCommentDAO 内存中的评论，删除为软删除
+type fakeCommentDAO struct {
+	dao.ICommentDAO
+	comments map[uint]*model.Comment
+	deleted  map[uint]bool
+}
+
+func (d *fakeCommentDAO) CreateComment(ctx context.Context, comment *model.Comment) error {
+	comment.ID = uint(len(d.comments) + 1)
+	d.comments[comment.ID] = comment
+	return nil
+}
+
+func (d *fakeCommentDAO) GetCommentByID(ctx context.Context, commentID uint) (*model.Comment, error) {
+	comment, ok := d.comments[commentID]
+	if !ok || d.deleted[commentID] {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *comment
+	return &copied, nil
+}
+
+func (d *fakeCommentDAO) DeleteComment(ctx context.Context, commentID uint) error {
+	d.deleted[commentID] = true
+	return nil
+}
+
+func (d *fakeCommentDAO) DeleteReplies(ctx context.Context, rootID uint) (int64, error) {
+	var removed int64
+	for id, comment := range d.comments {
+		if comment.RootID == rootID && !d.deleted[id] {
+			d.deleted[id] = true
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func (d *fakeCommentDAO) IncrReplyCount(ctx context.Context, rootID uint, delta int64) error {
+	d.comments[rootID].ReplyCount += delta
+	return nil
+}
+
+// fakeVideoDAO 只提供按 ID 查询视频
+type fakeVideoDAO struct {
+	dao.IVideoDAO
+	videos map[uint]*model.Video
+}
+
+func (d *fakeVideoDAO) GetVideoByID(ctx context.Context, videoID uint) (*model.Video, error) {
+	video, ok := d.videos[videoID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	video.ID = videoID
+	return video, nil
+}
+
+// fakeUserDAO 按 ID 返回同 ID 的用户
+type fakeUserDAO struct {
+	dao.IUserDAO
+}
+
+func (d *fakeUserDAO) GetUsersByIDs(ctx context.Context, userIDs []uint) ([]*model.User, error) {
+	users := make([]*model.User, 0, len(userIDs))
+	for _, id := range userIDs {
+		user := &model.User{}
+		user.ID = id
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// fakeCounter 汇总写入的视频计数增量
+type fakeCounter struct {
+	counter.ICounter
+	video map[uint]int64
+}
+
+func (c *fakeCounter) IncrVideo(ctx context.Context, videoID uint, field string, delta int64) error {
+	if field == counter.FieldCommentCount {
+		c.video[videoID] += delta
+	}
+	return nil
+}
+
+// fakePublisher 记录发布的领域事件
+type fakePublisher struct {
+	events []event.Event
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, events ...event.Event) error {
+	p.events = append(p.events, events...)
+	return nil
+}
+
+// commentTestEnv 使用内存 DAO 的评论服务
+type commentTestEnv struct {
+	service    *CommentService
+	commentDAO *fakeCommentDAO
+	counter    *fakeCounter
+}
+
+const (
+	testVideoID  = 100
+	testAuthorID = 1
+)
+
+func newCommentTestEnv() *commentTestEnv {
+	global.Logger = zap.NewNop()
+	env := &commentTestEnv{
+		commentDAO: &fakeCommentDAO{comments: make(map[uint]*model.Comment), deleted: make(map[uint]bool)},
+		counter:    &fakeCounter{video: make(map[uint]int64)},
+	}
+	env.service = &CommentService{
+		commentDAO: env.commentDAO,
+		videoDAO: &fakeVideoDAO{videos: map[uint]*model.Video{
+			testVideoID: {AuthorID: testAuthorID, Status: constant.VideoStatusReady},
+			200:         {AuthorID: testAuthorID, Status: constant.VideoStatusReady},
+		}},
+		userDAO:   &fakeUserDAO{},
+		txManager: &fakeTxManager{},
+		counter:   env.counter,
+		events:    &fakePublisher{},
+	}
+	return env
+}
+
+// publish 以 userID 发布评论（replyTo 不为 0 时为回复），返回评论 ID
+func (e *commentTestEnv) publish(t *testing.T, userID, replyTo uint) uint {
+	t.Helper()
+	comment, err := e.service.CommentAction(context.Background(), userID, &dto.CommentActionRequest{
+		VideoID:     testVideoID,
+		ActionType:  constant.CommentActionPublish,
+		CommentText: "hello",
+		ReplyToID:   replyTo,
+	})
+	if err != nil {
+		t.Fatalf("publish comment error = %v", err)
+	}
+	return comment.ID
+}
+
+// remove 以 userID 删除评论
+func (e *commentTestEnv) remove(userID, videoID, commentID uint) error {
+	_, err := e.service.CommentAction(context.Background(), userID, &dto.CommentActionRequest{
+		VideoID:    videoID,
+		ActionType: constant.CommentActionDelete,
+		CommentID:  commentID,
+	})
+	return err
+}
+
+func TestCommentService_DeleteAdjustsCounts(t *testing.T) {
+	tests := []struct {
+		name        string
+		deleteIndex int  // 删除的评论（thread 中的下标）
+		userID      uint // 删除者
+		videoID     uint
+		wantErr     bool
+		wantCount   int64 // 删除后视频评论数
+		wantReplies int64 // 删除后顶级评论的回复数
+	}{
+		// thread: 0 顶级评论(用户 2)，1 回复 0(用户 3)，2 回复 1(用户 4)，3 回复 0(用户 3，已删除)
+		{name: "delete root removes live replies", deleteIndex: 0, userID: 2, videoID: testVideoID, wantCount: 0, wantReplies: 2},
+		{name: "delete reply", deleteIndex: 1, userID: 3, videoID: testVideoID, wantCount: 2, wantReplies: 1},
+		{name: "delete nested reply", deleteIndex: 2, userID: 4, videoID: testVideoID, wantCount: 2, wantReplies: 1},
+		{name: "delete already deleted reply", deleteIndex: 3, userID: 3, videoID: testVideoID, wantErr: true, wantCount: 3, wantReplies: 2},
+		{name: "delete others comment", deleteIndex: 1, userID: 2, videoID: testVideoID, wantErr: true, wantCount: 3, wantReplies: 2},
+		{name: "delete from wrong video", deleteIndex: 1, userID: 3, videoID: 200, wantErr: true, wantCount: 3, wantReplies: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newCommentTestEnv()
+			root := env.publish(t, 2, 0)
+			reply := env.publish(t, 3, root)
+			nested := env.publish(t, 4, reply)
+			removed := env.publish(t, 3, root)
+			if err := env.remove(3, testVideoID, removed); err != nil {
+				t.Fatalf("delete reply error = %v", err)
+			}
+			thread := []uint{root, reply, nested, removed}
+
+			if got := env.commentDAO.comments[nested].RootID; got != root {
+				t.Fatalf("nested reply root = %d, want %d", got, root)
+			}
+			if got := env.counter.video[testVideoID]; got != 3 {
+				t.Fatalf("comment count before delete = %d, want 3", got)
+			}
+
+			err := env.remove(tt.userID, tt.videoID, thread[tt.deleteIndex])
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("delete error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := env.counter.video[testVideoID]; got != tt.wantCount {
+				t.Errorf("comment count = %d, want %d", got, tt.wantCount)
+			}
+			if got := env.commentDAO.comments[root].ReplyCount; got != tt.wantReplies {
+				t.Errorf("root reply count = %d, want %d", got, tt.wantReplies)
+			}
+		})
+	}
+}
+
+func TestCommentService_DeleteReplyOfDeletedRoot(t *testing.T) {
+	env := newCommentTestEnv()
+	root := env.publish(t, 2, 0)
+	reply := env.publish(t, 3, root)
+
+	// 顶级评论已被并发删除（其回复已计入那次删除的数量），再删除回复时不重复减少评论数
+	env.commentDAO.deleted[root] = true
+	if err := env.remove(3, testVideoID, reply); err == nil {
+		t.Fatal("delete reply of deleted root succeeded, want error")
+	}
+	if got := env.counter.video[testVideoID]; got != 2 {
+		t.Errorf("comment count = %d, want 2 (unchanged)", got)
+	}
+	if got := env.commentDAO.comments[root].ReplyCount; got != 1 {
+		t.Errorf("root reply count = %d, want 1 (unchanged)", got)
+	}
+}
@@ -11,6 +11,9 @@ import (
 	"github.com/wangn-tech/tiny-douyin/internal/dao"
 	"github.com/wangn-tech/tiny-douyin/internal/global"
 	"github.com/wangn-tech/tiny-douyin/internal/model"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/counter"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/event"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/storage"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -29,6 +32,10 @@ type FavoriteService struct {
 	videoDAO    dao.IVideoDAO
 	userDAO     dao.IUserDAO
 	relationDAO dao.IRelationDAO
+	txManager   dao.ITxManager
+	counter     counter.ICounter
+	events      event.IPublisher
+	urlSigner   storage.IURLSigner
 }
 
 // NewFavoriteService 创建 FavoriteService 实例
@@ -37,12 +44,20 @@ func NewFavoriteService(
 	videoDAO dao.IVideoDAO,
 	userDAO dao.IUserDAO,
 	relationDAO dao.IRelationDAO,
+	txManager dao.ITxManager,
+	counter counter.ICounter,
+	events event.IPublisher,
+	urlSigner storage.IURLSigner,
 ) IFavoriteService {
 	return &FavoriteService{
 		favoriteDAO: favoriteDAO,
 		videoDAO:    videoDAO,
 		userDAO:     userDAO,
 		relationDAO: relationDAO,
+		txManager:   txManager,
+		counter:     counter,
+		events:      events,
+		urlSigner:   urlSigner,
 	}
 }
 
@@ -64,7 +79,7 @@ func (s *FavoriteService) FavoriteAction(ctx context.Context, userID, videoID ui
 		return errors.New(errc.ErrMsg[errc.ErrInvalidActionType])
 	}
 
-	// 2. 检查视频是否存在
+	// 2. 检查视频是否存在且已就绪
 	video, err := s.videoDAO.GetVideoByID(ctx, videoID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -79,97 +94,71 @@ func (s *FavoriteService) FavoriteAction(ctx context.Context, userID, videoID ui
 		)
 		return err
 	}
-
-	// 3. 检查当前点赞状态
-	isFavorited, err := s.favoriteDAO.IsFavorite(ctx, userID, videoID)
-	if err != nil {
-		global.Logger.Error("service.FavoriteAction.check_favorite_error",
-			zap.Uint("user_id", userID),
+	if video.Status != constant.VideoStatusReady {
+		global.Logger.Warn("service.FavoriteAction.video_not_ready",
 			zap.Uint("video_id", videoID),
-			zap.Error(err),
+			zap.String("status", video.Status),
 		)
-		return err
+		return errors.New(errc.ErrMsg[errc.ErrVideoNotReady])
 	}
 
-	// 4. 根据操作类型和当前状态处理
-	if actionType == constant.FavoriteActionLike {
-		// 点赞操作
-		if isFavorited {
-			// 已经点赞过，幂等返回成功
-			global.Logger.Info("service.FavoriteAction.already_favorited",
-				zap.Uint("user_id", userID),
-				zap.Uint("video_id", videoID),
-			)
-			return nil
-		}
-
-		// 执行点赞：创建点赞记录 + 增加视频点赞数
-		if err := s.favoriteDAO.CreateFavorite(ctx, userID, videoID); err != nil {
-			global.Logger.Error("service.FavoriteAction.create_favorite_error",
-				zap.Uint("user_id", userID),
-				zap.Uint("video_id", videoID),
-				zap.Error(err),
-			)
-			return err
+	// 3. 使用事务：变更点赞记录 + 更新视频点赞数
+	// 点赞记录的变更本身是幂等的，只有真正改变了点赞状态的请求才会更新计数，
+	// 因此并发的重复点赞/取消点赞不会导致计数偏差；
+	// 计数增量记录随事务提交，提交后写入 Redis，由计数回写器定期批量回写 MySQL；
+	// 点赞事件随事务写入发件箱，提交后投递给订阅方
+	var changed bool
+	err = s.txManager.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		if actionType == constant.FavoriteActionLike {
+			// 点赞：创建（或恢复）点赞记录 + 增加视频点赞数
+			changed, err = s.favoriteDAO.CreateFavorite(ctx, userID, videoID)
+			if err != nil || !changed {
+				return err
+			}
+			if err := s.counter.IncrVideo(ctx, videoID, counter.FieldFavoriteCount, 1); err != nil {
+				return err
+			}
+			return s.events.Publish(ctx, event.VideoLiked{
+				UserID:   userID,
+				VideoID:  videoID,
+				AuthorID: video.AuthorID,
+			})
 		}
 
-		if err := s.videoDAO.IncrementFavoriteCount(ctx, videoID); err != nil {
-			global.Logger.Error("service.FavoriteAction.increment_count_error",
-				zap.Uint("video_id", videoID),
-				zap.Error(err),
-			)
-			// 注意：这里可能导致数据不一致，实际应该用事务处理
-			// 为了简化，这里先不回滚，后续可以优化
+		// 取消点赞：删除点赞记录 + 减少视频点赞数
+		changed, err = s.favoriteDAO.DeleteFavorite(ctx, userID, videoID)
+		if err != nil || !changed {
 			return err
 		}
+		return s.counter.IncrVideo(ctx, videoID, counter.FieldFavoriteCount, -1)
+	})
 
-		global.Logger.Info("service.FavoriteAction.like_success",
+	if err != nil {
+		global.Logger.Error("service.FavoriteAction.transaction_error",
 			zap.Uint("user_id", userID),
 			zap.Uint("video_id", videoID),
-			zap.Duration("duration", time.Since(start)),
+			zap.Int32("action_type", actionType),
+			zap.Error(err),
 		)
+		return err
+	}
 
-	} else {
-		// 取消点赞操作
-		if !isFavorited {
-			// 未点赞过，幂等返回成功
-			global.Logger.Info("service.FavoriteAction.not_favorited",
-				zap.Uint("user_id", userID),
-				zap.Uint("video_id", videoID),
-			)
-			return nil
-		}
-
-		// 执行取消点赞：删除点赞记录 + 减少视频点赞数
-		if err := s.favoriteDAO.DeleteFavorite(ctx, userID, videoID); err != nil {
-			global.Logger.Error("service.FavoriteAction.delete_favorite_error",
-				zap.Uint("user_id", userID),
-				zap.Uint("video_id", videoID),
-				zap.Error(err),
-			)
-			return err
-		}
-
-		if err := s.videoDAO.DecrementFavoriteCount(ctx, videoID); err != nil {
-			global.Logger.Error("service.FavoriteAction.decrement_count_error",
-				zap.Uint("video_id", videoID),
-				zap.Error(err),
-			)
-			// 注意：这里可能导致数据不一致，实际应该用事务处理
-			return err
-		}
-
-		global.Logger.Info("service.FavoriteAction.unlike_success",
+	if !changed {
+		// 点赞状态未变化（重复点赞或重复取消），幂等返回成功
+		global.Logger.Info("service.FavoriteAction.unchanged",
 			zap.Uint("user_id", userID),
 			zap.Uint("video_id", videoID),
-			zap.Duration("duration", time.Since(start)),
+			zap.Int32("action_type", actionType),
 		)
+		return nil
 	}
 
-	// 记录最终视频点赞数（用于调试）
-	global.Logger.Info("service.FavoriteAction.final_count",
+	global.Logger.Info("service.FavoriteAction.success",
+		zap.Uint("user_id", userID),
 		zap.Uint("video_id", videoID),
-		zap.Int64("favorite_count", video.FavoriteCount),
+		zap.Int32("action_type", actionType),
+		zap.Duration("duration", time.Since(start)),
 	)
 
 	return nil
@@ -307,6 +296,19 @@ func (s *FavoriteService) buildVideoDTOListFromModels(ctx context.Context, video
 		}
 	}
 
+	// 批量查询尚未回写 MySQL 的计数增量，合并到作者和视频的计数上
+	authorIDList := make([]uint, 0, len(authorMap))
+	for authorID := range authorMap {
+		authorIDList = append(authorIDList, authorID)
+	}
+	userDeltas := s.counter.PendingUserDeltas(ctx, authorIDList)
+	for authorID, author := range authorMap {
+		deltas := userDeltas[authorID]
+		author.FollowCount = deltas.Apply(counter.FieldFollowCount, author.FollowCount)
+		author.FollowerCount = deltas.Apply(counter.FieldFollowerCount, author.FollowerCount)
+	}
+	videoDeltas := s.counter.PendingVideoDeltas(ctx, videoIDs)
+
 	// 组装视频DTO
 	for _, video := range videos {
 		author := authorMap[video.AuthorID]
@@ -314,14 +316,17 @@ func (s *FavoriteService) buildVideoDTOListFromModels(ctx context.Context, video
 			continue // 跳过作者不存在的视频
 		}
 
+		deltas := videoDeltas[video.ID]
 		videoDTO := dto.Video{
 			ID:            video.ID,
-			PlayURL:       video.PlayURL,
-			CoverURL:      video.CoverURL,
+			PlayURL:       s.urlSigner.Sign(ctx, video.PlayURL),
+			HLSURL:        s.urlSigner.Sign(ctx, video.StreamURL()),
+			CoverURL:      s.urlSigner.Sign(ctx, video.CoverURL),
 			Title:         video.Title,
+			Status:        video.Status,
 			Author:        *author,
-			FavoriteCount: video.FavoriteCount,
-			CommentCount:  video.CommentCount,
+			FavoriteCount: deltas.Apply(counter.FieldFavoriteCount, video.FavoriteCount),
+			CommentCount:  deltas.Apply(counter.FieldCommentCount, video.CommentCount),
 			IsFavorite:    favoriteMap[video.ID],
 		}
 
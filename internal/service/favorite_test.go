@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"testing"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/config"
+	"github.com/wangn-tech/tiny-douyin/internal/dao"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/model"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/counter"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/outbox"
+)
+
+// 点赞并发测试需要 MySQL 和 Redis，未设置 TEST_MYSQL_DSN / TEST_REDIS_ADDR 时跳过，例如：
+//
+//	TEST_MYSQL_DSN='root:root@tcp(127.0.0.1:3306)/tiny_douyin_test?charset=utf8mb4&parseTime=True&loc=Local' \
+//	TEST_REDIS_ADDR=127.0.0.1:6379 go test ./internal/service/
+
+const (
+	stormUsers      = 4  // 并发点赞的用户数
+	stormWorkers    = 8  // 每个用户的并发协程数
+	stormOpsPerWork = 25 // 每个协程的点赞/取消点赞次数
+)
+
+// favoriteTestEnv 点赞服务测试环境
+type favoriteTestEnv struct {
+	db      *gorm.DB
+	rdb     *redis.Client
+	service IFavoriteService
+	flusher counter.IFlusher
+	counter counter.ICounter
+}
+
+// newFavoriteTestEnv 连接测试数据库和 Redis，组装真实的点赞服务、计数器和回写器
+func newFavoriteTestEnv(t *testing.T) *favoriteTestEnv {
+	t.Helper()
+
+	dsn, addr := os.Getenv("TEST_MYSQL_DSN"), os.Getenv("TEST_REDIS_ADDR")
+	if dsn == "" || addr == "" {
+		t.Skip("TEST_MYSQL_DSN or TEST_REDIS_ADDR not set, skipping integration test")
+	}
+	global.Logger = zap.NewNop()
+	global.Config = &config.AppConfig{}
+
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get sql.DB: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(stormUsers * stormWorkers)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	if err := db.AutoMigrate(
+		&model.Video{},
+		&model.Favorite{},
+		&model.CounterDelta{},
+		&model.CounterFlushLog{},
+		&model.OutboxMessage{},
+	); err != nil {
+		t.Fatalf("failed to migrate test tables: %v", err)
+	}
+
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("failed to connect to test redis: %v", err)
+	}
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	videoDAO := dao.NewVideoDAO(db)
+	userDAO := dao.NewUserDAO(db)
+	counterDAO := dao.NewCounterDAO(db)
+	txManager := dao.NewTxManager(db)
+	c := counter.NewCounter(rdb, counterDAO)
+
+	return &favoriteTestEnv{
+		db:  db,
+		rdb: rdb,
+		service: NewFavoriteService(
+			dao.NewFavoriteDAO(db),
+			videoDAO,
+			userDAO,
+			dao.NewRelationDAO(db),
+			txManager,
+			c,
+			outbox.NewEventPublisher(outbox.NewOutbox(dao.NewOutboxDAO(db), nil)),
+			nil,
+		),
+		flusher: counter.NewFlusher(rdb, videoDAO, userDAO, counterDAO, txManager),
+		counter: c,
+	}
+}
+
+// isLockConflict 判断是否为死锁或锁等待超时（事务整体回滚，客户端可重试）
+func isLockConflict(err error) bool {
+	var mysqlErr *mysqldriver.MySQLError
+	return errors.As(err, &mysqlErr) && (mysqlErr.Number == 1213 || mysqlErr.Number == 1205)
+}
+
+func TestFavoriteAction_ConcurrentLikeUnlikeStorm(t *testing.T) {
+	env := newFavoriteTestEnv(t)
+	ctx := context.Background()
+
+	video := &model.Video{AuthorID: 1, PlayURL: "test.mp4", Status: constant.VideoStatusReady}
+	if err := env.db.Create(video).Error; err != nil {
+		t.Fatalf("failed to create test video: %v", err)
+	}
+	t.Cleanup(func() {
+		env.db.Unscoped().Where("video_id = ?", video.ID).Delete(&model.Favorite{})
+		env.db.Unscoped().Delete(&model.Video{}, video.ID)
+	})
+
+	userIDs := make([]uint, stormUsers)
+	for u := range userIDs {
+		userIDs[u] = video.ID*100 + uint(u) + 1
+	}
+
+	var mu sync.Mutex
+	var conflicts int
+	var wg sync.WaitGroup
+	for _, userID := range userIDs {
+		for range stormWorkers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range stormOpsPerWork {
+					action := int32(constant.FavoriteActionLike)
+					if rand.IntN(2) == 0 {
+						action = constant.FavoriteActionUnlike
+					}
+
+					err := env.service.FavoriteAction(ctx, userID, video.ID, action)
+					switch {
+					case err == nil:
+					case isLockConflict(err):
+						mu.Lock()
+						conflicts++
+						mu.Unlock()
+					default:
+						t.Errorf("user %d: unexpected error: %v", userID, err)
+					}
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+	t.Logf("lock conflicts rolled back: %d", conflicts)
+
+	var active int64
+	for _, userID := range userIDs {
+		var rows int64
+		env.db.Unscoped().Model(&model.Favorite{}).Where("user_id = ? AND video_id = ?", userID, video.ID).Count(&rows)
+		if rows > 1 {
+			t.Errorf("user %d: got %d favorite rows, want at most 1", userID, rows)
+		}
+	}
+	env.db.Model(&model.Favorite{}).Where("video_id = ?", video.ID).Count(&active)
+
+	// 回写前：MySQL 计数 + 尚未回写的增量 = 有效点赞数
+	var deltas []*model.CounterDelta
+	env.db.Where("target = ? AND object_id = ?", "video", video.ID).Find(&deltas)
+	var logged int64
+	for _, delta := range deltas {
+		logged += delta.Delta
+	}
+	if logged != active {
+		t.Errorf("counter delta rows sum to %d, want %d active favorites", logged, active)
+	}
+	pending := env.counter.PendingVideoDeltas(ctx, []uint{video.ID})[video.ID]
+	if got := pending.Apply(counter.FieldFavoriteCount, 0); got != active {
+		t.Errorf("pending favorite_count in redis = %d, want %d", got, active)
+	}
+
+	// 回写后：增量全部落库，videos.favorite_count 与点赞记录一致
+	env.flusher.Flush(ctx)
+
+	var got model.Video
+	if err := env.db.Select("favorite_count").First(&got, video.ID).Error; err != nil {
+		t.Fatalf("failed to load video: %v", err)
+	}
+	if got.FavoriteCount != active {
+		t.Errorf("favorite_count = %d, want %d active favorites", got.FavoriteCount, active)
+	}
+
+	var remaining int64
+	env.db.Model(&model.CounterDelta{}).Where("target = ? AND object_id = ?", "video", video.ID).Count(&remaining)
+	if remaining != 0 {
+		t.Errorf("got %d undrained counter delta rows after flush, want 0", remaining)
+	}
+	if pending := env.counter.PendingVideoDeltas(ctx, []uint{video.ID})[video.ID]; pending[counter.FieldFavoriteCount] != 0 {
+		t.Errorf("pending favorite_count in redis after flush = %d, want 0", pending[counter.FieldFavoriteCount])
+	}
+}
@@ -2,52 +2,101 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/wangn-tech/tiny-douyin/internal/api/dto"
 	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
 	"github.com/wangn-tech/tiny-douyin/internal/dao"
 	"github.com/wangn-tech/tiny-douyin/internal/global"
 	"github.com/wangn-tech/tiny-douyin/internal/model"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/chat"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/event"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/media"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/storage"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/upload"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 // IMessageService 消息服务接口
 type IMessageService interface {
-	// SendMessage 发送消息
-	SendMessage(ctx context.Context, fromUserID uint, req *dto.MessageActionRequest) error
+	// SendMessage 发送消息，image 为已保存到临时目录的图片（仅图片消息，由调用方清理）
+	SendMessage(ctx context.Context, fromUserID uint, req *dto.MessageActionRequest, image *upload.TempFile) error
 	// GetChatMessages 获取聊天记录
 	GetChatMessages(ctx context.Context, currentUserID uint, req *dto.MessageChatRequest) (*dto.MessageChatResponse, error)
+	// SyncMessages 增量同步所有会话中客户端已收到的最新消息之后的消息
+	SyncMessages(ctx context.Context, userID uint, req *dto.MessageSyncRequest) (*dto.MessageSyncResponse, error)
+	// GetConversationList 分页获取会话列表（按最新消息倒序）
+	GetConversationList(ctx context.Context, userID uint, req *dto.ConversationListRequest) (*dto.ConversationListResponse, error)
+	// MarkRead 标记会话已读
+	MarkRead(ctx context.Context, userID uint, req *dto.MessageReadRequest) (*dto.MessageReadResponse, error)
+	// RecallMessage 撤回自己发送的消息（发送后一定时间内），双方看到的内容都被替换为撤回提示
+	RecallMessage(ctx context.Context, userID uint, req *dto.MessageRecallRequest) error
+	// DeleteMessage 删除消息，只对自己隐藏
+	DeleteMessage(ctx context.Context, userID uint, req *dto.MessageDeleteRequest) error
+	// ClearConversation 清空与某个用户的聊天记录，只对自己生效
+	ClearConversation(ctx context.Context, userID uint, req *dto.MessageClearRequest) error
+	// PushMessage 处理消息发送事件，将新消息实时推送到收发双方的在线连接
+	PushMessage(ctx context.Context, env *event.Envelope) error
 }
 
 // MessageService 消息服务实现
 type MessageService struct {
 	messageDAO  dao.IMessageDAO
+	convDAO     dao.IConversationDAO
 	userDAO     dao.IUserDAO
+	videoDAO    dao.IVideoDAO
 	relationSvc IRelationService
+	txManager   dao.ITxManager
+	events      event.IPublisher
+	hub         chat.IHub
+	uploadSvc   upload.IUploadService
+	urlSigner   storage.IURLSigner
 }
 
 // NewMessageService 创建 MessageService 实例
 func NewMessageService(
 	messageDAO dao.IMessageDAO,
+	convDAO dao.IConversationDAO,
 	userDAO dao.IUserDAO,
+	videoDAO dao.IVideoDAO,
 	relationSvc IRelationService,
+	txManager dao.ITxManager,
+	events event.IPublisher,
+	hub chat.IHub,
+	uploadSvc upload.IUploadService,
+	urlSigner storage.IURLSigner,
 ) IMessageService {
 	return &MessageService{
 		messageDAO:  messageDAO,
+		convDAO:     convDAO,
 		userDAO:     userDAO,
+		videoDAO:    videoDAO,
 		relationSvc: relationSvc,
+		txManager:   txManager,
+		events:      events,
+		hub:         hub,
+		uploadSvc:   uploadSvc,
+		urlSigner:   urlSigner,
 	}
 }
 
 // SendMessage 发送消息
-func (s *MessageService) SendMessage(ctx context.Context, fromUserID uint, req *dto.MessageActionRequest) error {
+// 文本、回复消息必须有内容，图片、视频、名片消息的内容为可选的附言
+func (s *MessageService) SendMessage(ctx context.Context, fromUserID uint, req *dto.MessageActionRequest, image *upload.TempFile) error {
+	msgType := req.MsgType
+	if msgType == "" {
+		msgType = constant.MessageTypeText
+	}
+
 	// 验证消息内容
 	content := strings.TrimSpace(req.Content)
-	if len(content) == 0 {
+	if len(content) == 0 && (msgType == constant.MessageTypeText || msgType == constant.MessageTypeReply) {
 		global.Logger.Warn("service.SendMessage.content_empty",
 			zap.Uint("from_user_id", fromUserID),
 			zap.Uint("to_user_id", req.ToUserID),
@@ -99,14 +148,58 @@ func (s *MessageService) SendMessage(ctx context.Context, fromUserID uint, req *
 		return fmt.Errorf("只能给好友发送消息")
 	}
 
+	// 按消息类型校验引用的视频、用户或消息，生成附加数据
+	payload, err := s.buildPayload(ctx, fromUserID, msgType, req, image)
+	if err != nil {
+		return err
+	}
+
+	// 校验通过后再上传图片，避免产生无主的对象
+	if msgType == constant.MessageTypeImage {
+		objectName := s.uploadSvc.GenerateMessageImageObjectName(fromUserID, filepath.Ext(image.Path))
+		if payload.ImageURL, err = s.uploadSvc.UploadFile(ctx, image.Path, objectName, image.ContentType); err != nil {
+			global.Logger.Error("service.SendMessage.upload_image_error",
+				zap.Uint("from_user_id", fromUserID),
+				zap.Error(err),
+			)
+			return fmt.Errorf("上传图片失败")
+		}
+		defer func() {
+			// 消息未保存时删除已上传的图片
+			if err != nil {
+				_ = s.uploadSvc.RemoveObject(context.WithoutCancel(ctx), objectName)
+			}
+		}()
+	}
+
 	// 创建消息记录
 	message := &model.Message{
 		FromUserID: fromUserID,
 		ToUserID:   req.ToUserID,
+		Type:       msgType,
 		Content:    content,
 	}
+	if payload != nil {
+		data, _ := json.Marshal(payload)
+		message.Payload = string(data)
+	}
 
-	err = s.messageDAO.CreateMessage(ctx, message)
+	// 使用事务：创建消息记录 + 更新会话 + 发布消息事件
+	err = s.txManager.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.messageDAO.CreateMessage(ctx, message); err != nil {
+			return err
+		}
+		if err := s.convDAO.RecordMessage(ctx, message); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, event.MessageSent{
+			MessageID:  message.ID,
+			FromUserID: fromUserID,
+			ToUserID:   req.ToUserID,
+			Type:       msgType,
+			Content:    content,
+		})
+	})
 	if err != nil {
 		global.Logger.Error("service.SendMessage.create_message_error",
 			zap.Uint("from_user_id", fromUserID),
@@ -121,11 +214,89 @@ func (s *MessageService) SendMessage(ctx context.Context, fromUserID uint, req *
 		zap.Uint("to_user_id", req.ToUserID),
 		zap.String("to_username", toUser.Username),
 		zap.Uint("message_id", message.ID),
+		zap.String("type", msgType),
 	)
 
 	return nil
 }
 
+// buildPayload 按消息类型校验并生成附加数据，文本消息返回 nil
+func (s *MessageService) buildPayload(ctx context.Context, fromUserID uint, msgType string, req *dto.MessageActionRequest, image *upload.TempFile) (*model.MessagePayload, error) {
+	switch msgType {
+	case constant.MessageTypeImage:
+		if image == nil {
+			return nil, fmt.Errorf("请上传图片")
+		}
+		width, height := media.ImageSize(image.Path)
+		return &model.MessagePayload{
+			ImageType:   image.ContentType,
+			ImageWidth:  width,
+			ImageHeight: height,
+			ImageSize:   image.Size,
+		}, nil
+
+	case constant.MessageTypeVideo:
+		if req.VideoID == 0 {
+			return nil, fmt.Errorf("请指定分享的视频")
+		}
+		video, err := s.videoDAO.GetVideoByID(ctx, req.VideoID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("分享的视频不存在")
+			}
+			global.Logger.Error("service.SendMessage.get_video_error",
+				zap.Uint("video_id", req.VideoID),
+				zap.Error(err),
+			)
+			return nil, fmt.Errorf("查询视频失败")
+		}
+		if video.Status != constant.VideoStatusReady {
+			return nil, fmt.Errorf("分享的视频不存在")
+		}
+		return &model.MessagePayload{VideoID: video.ID}, nil
+
+	case constant.MessageTypeUser:
+		if req.CardUserID == 0 {
+			return nil, fmt.Errorf("请指定分享的用户")
+		}
+		if _, err := s.userDAO.GetUserByID(ctx, req.CardUserID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("分享的用户不存在")
+			}
+			global.Logger.Error("service.SendMessage.get_card_user_error",
+				zap.Uint("card_user_id", req.CardUserID),
+				zap.Error(err),
+			)
+			return nil, fmt.Errorf("查询用户失败")
+		}
+		return &model.MessagePayload{UserID: req.CardUserID}, nil
+
+	case constant.MessageTypeReply:
+		if req.ReplyToID == 0 {
+			return nil, fmt.Errorf("请指定回复的消息")
+		}
+		messages, err := s.messageDAO.GetMessagesByIDs(ctx, []uint{req.ReplyToID})
+		if err != nil {
+			return nil, fmt.Errorf("查询消息失败")
+		}
+		// 只能回复同一会话中的消息
+		if len(messages) == 0 || !isBetween(messages[0], fromUserID, req.ToUserID) {
+			return nil, fmt.Errorf("回复的消息不存在")
+		}
+		if messages[0].Recalled() {
+			return nil, fmt.Errorf("回复的消息已撤回")
+		}
+		return &model.MessagePayload{ReplyToID: req.ReplyToID}, nil
+	}
+	return nil, nil
+}
+
+// isBetween 消息是否属于两个用户之间的会话
+func isBetween(msg *model.Message, userID1, userID2 uint) bool {
+	return (msg.FromUserID == userID1 && msg.ToUserID == userID2) ||
+		(msg.FromUserID == userID2 && msg.ToUserID == userID1)
+}
+
 // GetChatMessages 获取聊天记录
 func (s *MessageService) GetChatMessages(ctx context.Context, currentUserID uint, req *dto.MessageChatRequest) (*dto.MessageChatResponse, error) {
 	// 验证对方用户是否存在
@@ -144,29 +315,56 @@ func (s *MessageService) GetChatMessages(ctx context.Context, currentUserID uint
 		return nil, fmt.Errorf("查询用户失败")
 	}
 
-	// 查询聊天记录（双向消息）
-	messages, err := s.messageDAO.GetChatMessages(ctx, currentUserID, req.ToUserID, req.PreMsgTime)
+	// 查询会话获取双方的已读位置（用于已读回执）和当前用户清空会话的位置
+	conversation, err := s.convDAO.GetConversation(ctx, currentUserID, req.ToUserID)
+	if err != nil {
+		global.Logger.Error("service.GetChatMessages.get_conversation_error",
+			zap.Uint("current_user_id", currentUserID),
+			zap.Uint("to_user_id", req.ToUserID),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("查询聊天记录失败")
+	}
+	var clearedID uint
+	if conversation != nil {
+		clearedID = conversation.ClearedID(currentUserID)
+	}
+
+	// 按消息 ID 游标查询聊天记录（双向消息，不包含当前用户已删除的消息），多查一条用于判断是否还有更多
+	limit := req.Limit
+	if limit <= 0 {
+		limit = constant.MessagePageSize
+	}
+	query := &dao.MessageQuery{
+		BeforeID:  req.BeforeID,
+		AfterID:   req.AfterID,
+		ClearedID: clearedID,
+		Ascending: req.AfterID > 0,
+		Limit:     limit + 1,
+	}
+	// 兼容旧客户端按时间翻页
+	if req.BeforeID == 0 && req.AfterID == 0 {
+		query.PreMsgTime = req.PreMsgTime
+	}
+	messages, err := s.messageDAO.GetChatMessages(ctx, currentUserID, req.ToUserID, query)
 	if err != nil {
 		global.Logger.Error("service.GetChatMessages.query_error",
 			zap.Uint("current_user_id", currentUserID),
 			zap.Uint("to_user_id", req.ToUserID),
+			zap.Uint("before_id", req.BeforeID),
+			zap.Uint("after_id", req.AfterID),
 			zap.Int64("pre_msg_time", req.PreMsgTime),
 			zap.Error(err),
 		)
 		return nil, fmt.Errorf("查询聊天记录失败")
 	}
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
 
 	// 转换为 DTO
-	messageList := make([]dto.Message, 0, len(messages))
-	for _, msg := range messages {
-		messageList = append(messageList, dto.Message{
-			ID:         msg.ID,
-			ToUserID:   msg.ToUserID,
-			FromUserID: msg.FromUserID,
-			Content:    msg.Content,
-			CreateTime: msg.CreatedAt.Unix(), // 转换为 Unix 时间戳（秒）
-		})
-	}
+	messageList := s.renderMessages(ctx, messages, conversation)
 
 	global.Logger.Info("service.GetChatMessages.success",
 		zap.Uint("current_user_id", currentUserID),
@@ -179,5 +377,545 @@ func (s *MessageService) GetChatMessages(ctx context.Context, currentUserID uint
 		StatusCode:  0,
 		StatusMsg:   "success",
 		MessageList: messageList,
+		HasMore:     hasMore,
 	}, nil
 }
+
+// SyncMessages 增量同步所有会话中客户端已收到的最新消息之后的消息
+// 用于断线重连或切换设备后补齐消息；撤回、删除等对已同步消息的变更不会再次返回
+// 最近 MessageSyncSettleWindow 内发送的消息可能在下次同步时重复返回
+func (s *MessageService) SyncMessages(ctx context.Context, userID uint, req *dto.MessageSyncRequest) (*dto.MessageSyncResponse, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = constant.MessagePageSize
+	}
+
+	// 多查一条用于判断是否还有更多
+	messages, err := s.messageDAO.SyncMessages(ctx, userID, req.LastMessageID, limit+1)
+	if err != nil {
+		global.Logger.Error("service.SyncMessages.query_error",
+			zap.Uint("user_id", userID),
+			zap.Uint("last_message_id", req.LastMessageID),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("同步消息失败")
+	}
+	resp := &dto.MessageSyncResponse{
+		StatusCode:    0,
+		StatusMsg:     "success",
+		MessageList:   []dto.Message{},
+		LastMessageID: req.LastMessageID,
+	}
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
+	if len(messages) == 0 {
+		return resp, nil
+	}
+
+	// 游标停在第一条仍在安全窗口内的消息之前（即使已过窗口的消息因清空会话被过滤）：
+	// 消息 ID 在插入时分配，ID 更小的消息可能晚于它提交，游标不能越过窗口内的消息，
+	// 窗口内及之后的消息在下次同步时重新扫描，客户端按 ID 去重
+	settledBefore := time.Now().Add(-constant.MessageSyncSettleWindow)
+	for _, msg := range messages {
+		if !msg.CreatedAt.Before(settledBefore) {
+			break
+		}
+		resp.LastMessageID = msg.ID
+	}
+	// 游标未前进时本批只能等待消息过安全窗口后再同步，避免客户端反复拉取同一批
+	resp.HasMore = hasMore && resp.LastMessageID > req.LastMessageID
+
+	// 查询涉及的会话，过滤清空会话前的消息并填充已读回执
+	peerIDs := make([]uint, 0, len(messages))
+	for _, msg := range messages {
+		peerIDs = append(peerIDs, messagePeerID(msg, userID))
+	}
+	conversations, err := s.convDAO.GetConversationsByPeers(ctx, userID, peerIDs)
+	if err != nil {
+		global.Logger.Error("service.SyncMessages.get_conversations_error",
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("同步消息失败")
+	}
+
+	visible := make([]*model.Message, 0, len(messages))
+	for _, msg := range messages {
+		if conversation, ok := conversations[messagePeerID(msg, userID)]; !ok || conversation.MessageVisible(msg, userID) {
+			visible = append(visible, msg)
+		}
+	}
+	resp.MessageList = s.renderMessages(ctx, visible, nil)
+	for i := range resp.MessageList {
+		msg := &resp.MessageList[i]
+		if conversation, ok := conversations[messagePeerID(visible[i], userID)]; ok {
+			msg.IsRead = msg.ID <= conversation.LastReadID(msg.ToUserID)
+		}
+	}
+
+	global.Logger.Info("service.SyncMessages.success",
+		zap.Uint("user_id", userID),
+		zap.Uint("last_message_id", resp.LastMessageID),
+		zap.Int("message_count", len(resp.MessageList)),
+	)
+	return resp, nil
+}
+
+// messagePeerID 返回消息在 userID 所在会话中的对方用户ID
+func messagePeerID(msg *model.Message, userID uint) uint {
+	if msg.FromUserID == userID {
+		return msg.ToUserID
+	}
+	return msg.FromUserID
+}
+
+// PushMessage 处理消息发送事件，将新消息实时推送到收发双方的在线连接
+// 发送方的其他设备也会收到该消息；推送可能重复，客户端按消息 ID 去重
+func (s *MessageService) PushMessage(ctx context.Context, env *event.Envelope) error {
+	var evt event.MessageSent
+	if err := env.Decode(&evt); err != nil {
+		return err
+	}
+
+	// 查询消息以渲染图片、视频等预览
+	messages, err := s.messageDAO.GetMessagesByIDs(ctx, []uint{evt.MessageID})
+	if err != nil {
+		return err
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	frame := &chat.Frame{
+		Type: constant.ChatFrameMessage,
+		Data: s.renderMessages(ctx, messages, nil)[0],
+	}
+	if err := s.hub.Push(ctx, []uint{evt.ToUserID, evt.FromUserID}, frame); err != nil {
+		global.Logger.Error("service.PushMessage.push_error",
+			zap.Uint("message_id", evt.MessageID),
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
+
+// GetConversationList 分页获取会话列表（按最新消息倒序）
+func (s *MessageService) GetConversationList(ctx context.Context, userID uint, req *dto.ConversationListRequest) (*dto.ConversationListResponse, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = constant.ConversationDefaultPageSize
+	}
+
+	// 多查一条用于判断是否还有下一页
+	conversations, err := s.convDAO.ListConversations(ctx, userID, req.Cursor, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("查询会话列表失败")
+	}
+	hasMore := len(conversations) > limit
+	if hasMore {
+		conversations = conversations[:limit]
+	}
+
+	unread, err := s.convDAO.SumUnread(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询未读消息数失败")
+	}
+
+	// 批量查询对方用户和最新消息
+	peerIDs := make([]uint, 0, len(conversations))
+	messageIDs := make([]uint, 0, len(conversations))
+	for _, conversation := range conversations {
+		peerIDs = append(peerIDs, conversation.PeerID(userID))
+		messageIDs = append(messageIDs, conversation.LastMessageID)
+	}
+	userMap := make(map[uint]*model.User, len(peerIDs))
+	if len(peerIDs) > 0 {
+		users, err := s.userDAO.GetUsersByIDs(ctx, peerIDs)
+		if err != nil {
+			global.Logger.Error("service.GetConversationList.get_users_error",
+				zap.Uint("user_id", userID),
+				zap.Error(err),
+			)
+			return nil, fmt.Errorf("查询用户信息失败")
+		}
+		for _, user := range users {
+			userMap[user.ID] = user
+		}
+	}
+	messages, err := s.messageDAO.GetMessagesByIDs(ctx, messageIDs)
+	if err != nil {
+		global.Logger.Error("service.GetConversationList.get_messages_error",
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("查询会话列表失败")
+	}
+	messageMap := make(map[uint]dto.Message, len(messages))
+	for _, msg := range s.renderMessages(ctx, messages, nil) {
+		messageMap[msg.ID] = msg
+	}
+	visible := make(map[uint]bool, len(messages))
+	for _, msg := range messages {
+		visible[msg.ID] = !msg.DeletedFor(userID)
+	}
+
+	resp := &dto.ConversationListResponse{
+		StatusCode:       0,
+		StatusMsg:        "success",
+		ConversationList: make([]dto.Conversation, 0, len(conversations)),
+		HasMore:          hasMore,
+		UnreadCount:      unread,
+	}
+	for _, conversation := range conversations {
+		peerID := conversation.PeerID(userID)
+		item := dto.Conversation{
+			User:                  dto.UserInfo{ID: peerID},
+			UnreadCount:           conversation.UnreadCount(userID),
+			LastReadMessageID:     conversation.LastReadID(userID),
+			PeerLastReadMessageID: conversation.LastReadID(peerID),
+			UpdateTime:            conversation.LastMessageAt.Unix(),
+		}
+		if user, ok := userMap[peerID]; ok {
+			item.User = dto.UserInfo{
+				ID:            user.ID,
+				Username:      user.Username,
+				Avatar:        user.Avatar,
+				Signature:     user.Signature,
+				FollowCount:   user.FollowCount,
+				FollowerCount: user.FollowerCount,
+			}
+		}
+		// 最新消息已删除或会话已清空时不展示
+		if msg, ok := messageMap[conversation.LastMessageID]; ok && visible[msg.ID] && msg.ID > conversation.ClearedID(userID) {
+			msg.IsRead = msg.ID <= conversation.LastReadID(msg.ToUserID)
+			item.LastMessage = &msg
+		}
+		resp.ConversationList = append(resp.ConversationList, item)
+		resp.NextCursor = conversation.LastMessageID
+	}
+	return resp, nil
+}
+
+// RecallMessage 撤回自己发送的消息
+// 撤回后清空内容和附加数据（图片消息同时删除图片），向会话双方的在线连接推送撤回通知
+func (s *MessageService) RecallMessage(ctx context.Context, userID uint, req *dto.MessageRecallRequest) error {
+	message, err := s.messageDAO.GetMessageByID(ctx, req.MessageID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("消息不存在")
+		}
+		return fmt.Errorf("查询消息失败")
+	}
+	if message.FromUserID != userID {
+		global.Logger.Warn("service.RecallMessage.not_sender",
+			zap.Uint("user_id", userID),
+			zap.Uint("message_id", req.MessageID),
+		)
+		return fmt.Errorf("只能撤回自己发送的消息")
+	}
+	if message.Recalled() {
+		return nil
+	}
+	if window := global.Config.Message.RecallLimit(); time.Since(message.CreatedAt) > window {
+		return fmt.Errorf("消息发送超过%d分钟，无法撤回", int(window.Minutes()))
+	}
+
+	if err := s.messageDAO.RecallMessage(ctx, message.ID, time.Now()); err != nil {
+		global.Logger.Error("service.RecallMessage.update_error",
+			zap.Uint("user_id", userID),
+			zap.Uint("message_id", req.MessageID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("撤回消息失败")
+	}
+
+	// 图片删除失败不影响撤回结果，撤回后不再返回图片地址
+	if message.Type == constant.MessageTypeImage {
+		if imageURL := message.GetPayload().ImageURL; imageURL != "" {
+			if err := s.uploadSvc.RemoveObjectByURL(ctx, imageURL); err != nil {
+				global.Logger.Warn("service.RecallMessage.remove_image_error",
+					zap.Uint("message_id", message.ID),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+
+	// 推送失败时客户端可通过聊天记录接口的 recalled 获取
+	_ = s.hub.Push(ctx, []uint{message.ToUserID, message.FromUserID}, &chat.Frame{
+		Type: constant.ChatFrameRecall,
+		Data: dto.MessageRecallNotice{
+			MessageID:  message.ID,
+			FromUserID: message.FromUserID,
+			ToUserID:   message.ToUserID,
+		},
+	})
+
+	global.Logger.Info("service.RecallMessage.success",
+		zap.Uint("user_id", userID),
+		zap.Uint("message_id", message.ID),
+	)
+	return nil
+}
+
+// DeleteMessage 删除消息，只对自己隐藏
+func (s *MessageService) DeleteMessage(ctx context.Context, userID uint, req *dto.MessageDeleteRequest) error {
+	message, err := s.messageDAO.GetMessageByID(ctx, req.MessageID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("消息不存在")
+		}
+		return fmt.Errorf("查询消息失败")
+	}
+	if message.FromUserID != userID && message.ToUserID != userID {
+		global.Logger.Warn("service.DeleteMessage.not_participant",
+			zap.Uint("user_id", userID),
+			zap.Uint("message_id", req.MessageID),
+		)
+		return fmt.Errorf("消息不存在")
+	}
+	if message.DeletedFor(userID) {
+		return nil
+	}
+
+	if err := s.messageDAO.HideMessage(ctx, message, userID); err != nil {
+		global.Logger.Error("service.DeleteMessage.update_error",
+			zap.Uint("user_id", userID),
+			zap.Uint("message_id", req.MessageID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("删除消息失败")
+	}
+
+	global.Logger.Info("service.DeleteMessage.success",
+		zap.Uint("user_id", userID),
+		zap.Uint("message_id", message.ID),
+	)
+	return nil
+}
+
+// ClearConversation 清空与某个用户的聊天记录，只对自己生效
+// 清空位置之前的消息不再返回，同时标记会话全部已读并向双方推送已读回执
+func (s *MessageService) ClearConversation(ctx context.Context, userID uint, req *dto.MessageClearRequest) error {
+	var readID uint
+	err := s.txManager.Transaction(ctx, func(ctx context.Context) error {
+		conversation, err := s.convDAO.GetConversation(ctx, userID, req.ToUserID)
+		if err != nil || conversation == nil {
+			return err
+		}
+		if conversation.LastMessageID > conversation.LastReadID(userID) {
+			readID = conversation.LastMessageID
+		}
+		return s.convDAO.ClearConversation(ctx, conversation, userID)
+	})
+	if err != nil {
+		global.Logger.Error("service.ClearConversation.update_error",
+			zap.Uint("user_id", userID),
+			zap.Uint("to_user_id", req.ToUserID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("清空聊天记录失败")
+	}
+
+	if readID > 0 {
+		_ = s.hub.Push(ctx, []uint{req.ToUserID, userID}, &chat.Frame{
+			Type: constant.ChatFrameRead,
+			Data: dto.MessageReadReceipt{
+				UserID:            userID,
+				PeerID:            req.ToUserID,
+				LastReadMessageID: readID,
+			},
+		})
+	}
+
+	global.Logger.Info("service.ClearConversation.success",
+		zap.Uint("user_id", userID),
+		zap.Uint("to_user_id", req.ToUserID),
+	)
+	return nil
+}
+
+// MarkRead 标记会话已读，已读位置只前进不后退
+// 标记成功后向会话双方的在线连接推送已读回执
+func (s *MessageService) MarkRead(ctx context.Context, userID uint, req *dto.MessageReadRequest) (*dto.MessageReadResponse, error) {
+	resp := &dto.MessageReadResponse{
+		StatusCode: 0,
+		StatusMsg:  "success",
+	}
+
+	advanced := false
+	err := s.txManager.Transaction(ctx, func(ctx context.Context) error {
+		conversation, err := s.convDAO.GetConversation(ctx, userID, req.ToUserID)
+		if err != nil || conversation == nil {
+			return err
+		}
+
+		readID := conversation.LastMessageID
+		if req.MessageID > 0 && req.MessageID < readID {
+			readID = req.MessageID
+		}
+		resp.LastReadMessageID = conversation.LastReadID(userID)
+		resp.UnreadCount = conversation.UnreadCount(userID)
+		if readID <= resp.LastReadMessageID {
+			return nil
+		}
+
+		// 已读到最新消息时未读数清零，否则统计之后对方发来的消息数
+		unread := int64(0)
+		if readID < conversation.LastMessageID {
+			if unread, err = s.messageDAO.CountMessagesAfter(ctx, req.ToUserID, userID, readID); err != nil {
+				return err
+			}
+		}
+		if err := s.convDAO.UpdateReadState(ctx, conversation, userID, readID, int(unread)); err != nil {
+			return err
+		}
+
+		resp.LastReadMessageID = readID
+		resp.UnreadCount = int(unread)
+		advanced = true
+		return nil
+	})
+	if err != nil {
+		global.Logger.Error("service.MarkMessagesRead.update_error",
+			zap.Uint("user_id", userID),
+			zap.Uint("to_user_id", req.ToUserID),
+			zap.Uint("message_id", req.MessageID),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("标记已读失败")
+	}
+
+	if advanced {
+		// 回执推送失败不影响标记结果，对方可通过聊天记录接口的 is_read 获取
+		_ = s.hub.Push(ctx, []uint{req.ToUserID, userID}, &chat.Frame{
+			Type: constant.ChatFrameRead,
+			Data: dto.MessageReadReceipt{
+				UserID:            userID,
+				PeerID:            req.ToUserID,
+				LastReadMessageID: resp.LastReadMessageID,
+			},
+		})
+	}
+
+	global.Logger.Info("service.MarkMessagesRead.success",
+		zap.Uint("user_id", userID),
+		zap.Uint("to_user_id", req.ToUserID),
+		zap.Uint("last_read_message_id", resp.LastReadMessageID),
+		zap.Int("unread_count", resp.UnreadCount),
+	)
+	return resp, nil
+}
+
+// renderMessages 转换消息 DTO，批量查询分享的视频、用户名片和被回复的消息生成预览（查询失败时对应预览为空）
+// conversation 不为空时根据接收方的已读位置填充已读回执
+func (s *MessageService) renderMessages(ctx context.Context, messages []*model.Message, conversation *model.Conversation) []dto.Message {
+	payloads := make([]*model.MessagePayload, len(messages))
+	var videoIDs, userIDs, replyIDs []uint
+	for i, msg := range messages {
+		payloads[i] = msg.GetPayload()
+		if msg.Recalled() {
+			continue
+		}
+		switch msg.Type {
+		case constant.MessageTypeVideo:
+			videoIDs = append(videoIDs, payloads[i].VideoID)
+		case constant.MessageTypeUser:
+			userIDs = append(userIDs, payloads[i].UserID)
+		case constant.MessageTypeReply:
+			replyIDs = append(replyIDs, payloads[i].ReplyToID)
+		}
+	}
+
+	videoMap := make(map[uint]*model.Video, len(videoIDs))
+	if len(videoIDs) > 0 {
+		videos, err := s.videoDAO.GetVideosByIDs(ctx, videoIDs)
+		if err != nil {
+			global.Logger.Warn("service.renderMessages.get_videos_error", zap.Error(err))
+		}
+		for _, video := range videos {
+			videoMap[video.ID] = video
+		}
+	}
+	userMap := make(map[uint]*model.User, len(userIDs))
+	if len(userIDs) > 0 {
+		users, err := s.userDAO.GetUsersByIDs(ctx, userIDs)
+		if err != nil {
+			global.Logger.Warn("service.renderMessages.get_users_error", zap.Error(err))
+		}
+		for _, user := range users {
+			userMap[user.ID] = user
+		}
+	}
+	replyMap := make(map[uint]*model.Message, len(replyIDs))
+	if len(replyIDs) > 0 {
+		replies, err := s.messageDAO.GetMessagesByIDs(ctx, replyIDs)
+		if err != nil {
+			global.Logger.Warn("service.renderMessages.get_replies_error", zap.Error(err))
+		}
+		for _, reply := range replies {
+			replyMap[reply.ID] = reply
+		}
+	}
+
+	result := make([]dto.Message, 0, len(messages))
+	for i, msg := range messages {
+		payload := payloads[i]
+		m := dto.Message{
+			ID:         msg.ID,
+			ToUserID:   msg.ToUserID,
+			FromUserID: msg.FromUserID,
+			Type:       msg.Type,
+			Content:    msg.Content,
+			CreateTime: msg.CreatedAt.Unix(), // 转换为 Unix 时间戳（秒）
+		}
+		if conversation != nil {
+			m.IsRead = msg.ID <= conversation.LastReadID(msg.ToUserID)
+		}
+		// 已撤回的消息只保留撤回标记
+		if msg.Recalled() {
+			m.Recalled = true
+			result = append(result, m)
+			continue
+		}
+
+		switch msg.Type {
+		case constant.MessageTypeImage:
+			m.Image = &dto.MessageImage{
+				URL:    s.urlSigner.Sign(ctx, payload.ImageURL),
+				Width:  payload.ImageWidth,
+				Height: payload.ImageHeight,
+			}
+		case constant.MessageTypeVideo:
+			// 视频已删除或不可见时只返回 ID
+			m.Video = &dto.MessageVideo{ID: payload.VideoID}
+			if video, ok := videoMap[payload.VideoID]; ok && video.Status == constant.VideoStatusReady {
+				m.Video.Available = true
+				m.Video.AuthorID = video.AuthorID
+				m.Video.Title = video.Title
+				m.Video.CoverURL = s.urlSigner.Sign(ctx, video.CoverURL)
+			}
+		case constant.MessageTypeUser:
+			m.User = &dto.UserInfo{ID: payload.UserID}
+			if user, ok := userMap[payload.UserID]; ok {
+				m.User.Username = user.Username
+				m.User.Avatar = user.Avatar
+				m.User.Signature = user.Signature
+				m.User.FollowCount = user.FollowCount
+				m.User.FollowerCount = user.FollowerCount
+			}
+		case constant.MessageTypeReply:
+			m.ReplyTo = &dto.MessageReply{ID: payload.ReplyToID}
+			if reply, ok := replyMap[payload.ReplyToID]; ok {
+				m.ReplyTo.FromUserID = reply.FromUserID
+				m.ReplyTo.Type = reply.Type
+				m.ReplyTo.Content = reply.Preview()
+			}
+		}
+		result = append(result, m)
+	}
+	return result
+}
@@ -0,0 +1,380 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/wangn-tech/tiny-douyin/internal/api/dto"
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/dao"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/model"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/event"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/outbox"
+	"go.uber.org/zap"
+)
+
+// NotificationEventTypes 通知中心订阅的领域事件
+var NotificationEventTypes = []string{
+	event.TypeVideoLiked,
+	event.TypeCommentPosted,
+	event.TypeUserFollowed,
+}
+
+// INotificationService 站内通知服务接口
+type INotificationService interface {
+	// HandleEvent 处理领域事件并生成通知（订阅 NotificationEventTypes）
+	HandleEvent(ctx context.Context, env *event.Envelope) error
+	// GetNotificationList 分页获取通知列表
+	GetNotificationList(ctx context.Context, userID uint, req *dto.NotificationListRequest) (*dto.NotificationListData, error)
+	// GetUnreadCount 获取未读通知数
+	GetUnreadCount(ctx context.Context, userID uint) (*dto.NotificationUnreadData, error)
+	// MarkRead 标记通知已读，返回标记的通知数
+	MarkRead(ctx context.Context, userID uint, req *dto.NotificationReadRequest) (int64, error)
+}
+
+// NotificationService 站内通知服务实现
+type NotificationService struct {
+	notificationDAO dao.INotificationDAO
+	userDAO         dao.IUserDAO
+	txManager       dao.ITxManager
+	dedup           outbox.IDeduplicator
+}
+
+// NewNotificationService 创建 NotificationService 实例
+func NewNotificationService(
+	notificationDAO dao.INotificationDAO,
+	userDAO dao.IUserDAO,
+	txManager dao.ITxManager,
+	dedup outbox.IDeduplicator,
+) INotificationService {
+	return &NotificationService{
+		notificationDAO: notificationDAO,
+		userDAO:         userDAO,
+		txManager:       txManager,
+		dedup:           dedup,
+	}
+}
+
+// HandleEvent 处理领域事件并生成通知
+// 事件可能重复投递，按事件 ID 去重；自己对自己的操作不产生通知
+func (s *NotificationService) HandleEvent(ctx context.Context, env *event.Envelope) error {
+	dedupKey := constant.NotificationDedupPrefix + env.ID
+	if s.dedup.Seen(ctx, dedupKey) {
+		return nil
+	}
+
+	var notifications []*model.Notification
+	var groupKey string
+	switch env.Type {
+	case event.TypeVideoLiked:
+		var e event.VideoLiked
+		if err := env.Decode(&e); err != nil {
+			return err
+		}
+		notifications = append(notifications, &model.Notification{
+			UserID:  e.AuthorID,
+			Type:    constant.NotificationTypeLike,
+			VideoID: e.VideoID,
+			ActorID: e.UserID,
+		})
+		groupKey = fmt.Sprintf("%s:%d", constant.NotificationTypeLike, e.VideoID)
+	case event.TypeCommentPosted:
+		var e event.CommentPosted
+		if err := env.Decode(&e); err != nil {
+			return err
+		}
+		// 回复评论时通知被回复的用户；被回复的用户是视频作者时只发回复通知
+		if e.ReplyToUserID != 0 {
+			notifications = append(notifications, &model.Notification{
+				UserID:    e.ReplyToUserID,
+				Type:      constant.NotificationTypeReply,
+				VideoID:   e.VideoID,
+				CommentID: e.CommentID,
+				Content:   e.Content,
+				ActorID:   e.UserID,
+			})
+		}
+		if e.AuthorID != e.ReplyToUserID {
+			notifications = append(notifications, &model.Notification{
+				UserID:    e.AuthorID,
+				Type:      constant.NotificationTypeComment,
+				VideoID:   e.VideoID,
+				CommentID: e.CommentID,
+				Content:   e.Content,
+				ActorID:   e.UserID,
+			})
+		}
+	case event.TypeUserFollowed:
+		var e event.UserFollowed
+		if err := env.Decode(&e); err != nil {
+			return err
+		}
+		notifications = append(notifications, &model.Notification{
+			UserID:  e.FolloweeID,
+			Type:    constant.NotificationTypeFollow,
+			ActorID: e.FollowerID,
+		})
+		groupKey = constant.NotificationTypeFollow
+	default:
+		return nil
+	}
+
+	// 同一事件的多条通知一起写入，重复投递时不会只补写其中一部分
+	err := s.txManager.Transaction(ctx, func(ctx context.Context) error {
+		for _, notification := range notifications {
+			if notification.UserID == notification.ActorID {
+				continue
+			}
+			if err := s.notify(ctx, notification, groupKey); err != nil {
+				global.Logger.Error("service.HandleNotificationEvent.notify_error",
+					zap.String("event_id", env.ID),
+					zap.String("event_type", env.Type),
+					zap.Uint("user_id", notification.UserID),
+					zap.Error(err),
+				)
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.dedup.MarkDone(ctx, dedupKey)
+	return nil
+}
+
+// notify 写入通知，groupKey 不为空时与该用户同一聚合键的未读通知合并
+// 合并时删除旧通知并写入新通知（ID 递增），使聚合后的通知排在列表最前
+func (s *NotificationService) notify(ctx context.Context, notification *model.Notification, groupKey string) error {
+	actors := []uint{notification.ActorID}
+	notification.ActorCount = 1
+	if groupKey == "" {
+		notification.ActorIDs = encodeActorIDs(actors)
+		return s.notificationDAO.CreateNotification(ctx, notification)
+	}
+
+	return s.txManager.Transaction(ctx, func(ctx context.Context) error {
+		existing, err := s.notificationDAO.GetUnreadGroup(ctx, notification.UserID, groupKey)
+		if err != nil {
+			return err
+		}
+		notification.GroupKey = &groupKey
+
+		if existing == nil {
+			// 新的聚合组，以第一条通知的 ID 作为组 ID
+			notification.ActorIDs = encodeActorIDs(actors)
+			if err := s.notificationDAO.CreateNotification(ctx, notification); err != nil {
+				return err
+			}
+			notification.GroupID = notification.ID
+			if err := s.notificationDAO.SetGroupID(ctx, notification.ID, notification.GroupID); err != nil {
+				return err
+			}
+			_, err := s.notificationDAO.AddGroupActor(ctx, notification.GroupID, notification.ActorID)
+			return err
+		}
+
+		recent := decodeActorIDs(existing.ActorIDs)
+		groupID := existing.GroupID
+		if groupID == 0 {
+			// 没有组 ID 的旧聚合通知：以该通知为组，并补记最近的触发用户
+			groupID = existing.ID
+			for _, actorID := range recent {
+				if _, err := s.notificationDAO.AddGroupActor(ctx, groupID, actorID); err != nil {
+					return err
+				}
+			}
+		}
+
+		// 按聚合组的触发用户集合去重，ActorIDs 只保留最近的用户
+		added, err := s.notificationDAO.AddGroupActor(ctx, groupID, notification.ActorID)
+		if err != nil {
+			return err
+		}
+		if !added {
+			// 同一用户重复触发（如取消点赞后再次点赞），不重复计数
+			return nil
+		}
+
+		actors = append(actors, recent...)
+		notification.GroupID = groupID
+		notification.ActorCount = existing.ActorCount + 1
+		notification.ActorIDs = encodeActorIDs(actors[:min(len(actors), constant.NotificationMaxActors)])
+		if err := s.notificationDAO.DeleteNotification(ctx, existing.ID); err != nil {
+			return err
+		}
+		return s.notificationDAO.CreateNotification(ctx, notification)
+	})
+}
+
+// GetNotificationList 分页获取通知列表
+func (s *NotificationService) GetNotificationList(ctx context.Context, userID uint, req *dto.NotificationListRequest) (*dto.NotificationListData, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = constant.NotificationDefaultPageSize
+	}
+	var types []string
+	if req.Type != "" {
+		types = []string{req.Type}
+	}
+
+	// 多查一条用于判断是否还有下一页
+	notifications, err := s.notificationDAO.ListNotifications(ctx, userID, req.Cursor, limit+1, types)
+	if err != nil {
+		return nil, fmt.Errorf("查询通知失败")
+	}
+	hasMore := len(notifications) > limit
+	if hasMore {
+		notifications = notifications[:limit]
+	}
+
+	unread, err := s.notificationDAO.CountUnread(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询未读通知数失败")
+	}
+
+	// 批量查询触发通知的用户
+	var actorIDs []uint
+	for _, notification := range notifications {
+		for _, id := range decodeActorIDs(notification.ActorIDs) {
+			if !slices.Contains(actorIDs, id) {
+				actorIDs = append(actorIDs, id)
+			}
+		}
+	}
+	userMap := make(map[uint]*model.User, len(actorIDs))
+	if len(actorIDs) > 0 {
+		users, err := s.userDAO.GetUsersByIDs(ctx, actorIDs)
+		if err != nil {
+			global.Logger.Error("service.GetNotificationList.get_users_error",
+				zap.Uint("user_id", userID),
+				zap.Error(err),
+			)
+			return nil, fmt.Errorf("查询用户信息失败")
+		}
+		for _, user := range users {
+			userMap[user.ID] = user
+		}
+	}
+
+	data := &dto.NotificationListData{
+		Notifications: make([]dto.Notification, 0, len(notifications)),
+		HasMore:       hasMore,
+	}
+	for _, notification := range notifications {
+		data.Notifications = append(data.Notifications, buildNotificationDTO(notification, userMap))
+		data.NextCursor = notification.ID
+	}
+	for _, count := range unread {
+		data.UnreadCount += count
+	}
+	return data, nil
+}
+
+// GetUnreadCount 获取未读通知数
+func (s *NotificationService) GetUnreadCount(ctx context.Context, userID uint) (*dto.NotificationUnreadData, error) {
+	unread, err := s.notificationDAO.CountUnread(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询未读通知数失败")
+	}
+
+	data := &dto.NotificationUnreadData{
+		Like:    unread[constant.NotificationTypeLike],
+		Comment: unread[constant.NotificationTypeComment],
+		Reply:   unread[constant.NotificationTypeReply],
+		Follow:  unread[constant.NotificationTypeFollow],
+	}
+	data.Total = data.Like + data.Comment + data.Reply + data.Follow
+	return data, nil
+}
+
+// MarkRead 标记通知已读
+func (s *NotificationService) MarkRead(ctx context.Context, userID uint, req *dto.NotificationReadRequest) (int64, error) {
+	var types []string
+	if req.NotificationID == 0 && req.Type != "" {
+		types = []string{req.Type}
+	}
+
+	var updated int64
+	err := s.txManager.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		updated, err = s.notificationDAO.MarkRead(ctx, userID, req.NotificationID, types)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("标记已读失败")
+	}
+
+	global.Logger.Info("service.MarkNotificationsRead.success",
+		zap.Uint("user_id", userID),
+		zap.Uint("notification_id", req.NotificationID),
+		zap.String("type", req.Type),
+		zap.Int64("updated", updated),
+	)
+	return updated, nil
+}
+
+// buildNotificationDTO 构建通知 DTO
+func buildNotificationDTO(notification *model.Notification, userMap map[uint]*model.User) dto.Notification {
+	actors := make([]dto.UserInfo, 0, constant.NotificationMaxActors)
+	for _, id := range decodeActorIDs(notification.ActorIDs) {
+		if user, ok := userMap[id]; ok {
+			actors = append(actors, dto.UserInfo{
+				ID:        user.ID,
+				Username:  user.Username,
+				Avatar:    user.Avatar,
+				Signature: user.Signature,
+			})
+		}
+	}
+
+	name := "有人"
+	if len(actors) > 0 {
+		name = actors[0].Username
+	}
+	if notification.ActorCount > 1 {
+		name = fmt.Sprintf("%s 和其他 %d 人", name, notification.ActorCount-1)
+	}
+	var summary string
+	switch notification.Type {
+	case constant.NotificationTypeLike:
+		summary = name + " 赞了你的作品"
+	case constant.NotificationTypeComment:
+		summary = name + " 评论了你的作品"
+	case constant.NotificationTypeReply:
+		summary = name + " 回复了你的评论"
+	case constant.NotificationTypeFollow:
+		summary = name + " 关注了你"
+	}
+
+	return dto.Notification{
+		ID:         notification.ID,
+		Type:       notification.Type,
+		Summary:    summary,
+		Actors:     actors,
+		ActorCount: notification.ActorCount,
+		VideoID:    notification.VideoID,
+		CommentID:  notification.CommentID,
+		Content:    notification.Content,
+		IsRead:     notification.IsRead,
+		CreateTime: notification.CreatedAt.Unix(),
+	}
+}
+
+// encodeActorIDs 序列化最近触发通知的用户ID
+func encodeActorIDs(ids []uint) string {
+	data, _ := json.Marshal(ids)
+	return string(data)
+}
+
+// decodeActorIDs 解析最近触发通知的用户ID
+func decodeActorIDs(raw string) []uint {
+	var ids []uint
+	_ = json.Unmarshal([]byte(raw), &ids)
+	return ids
+}
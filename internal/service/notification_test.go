@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/wangn-tech/tiny-douyin/internal/api/dto"
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/dao"
+	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/model"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/event"
+)
+
+// fakeTxManager 直接执行 fn，记录事务次数
+type fakeTxManager struct {
+	calls int
+}
+
+func (m *fakeTxManager) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.calls++
+	return fn(ctx)
+}
+
+// fakeDedup 内存中的事件去重记录
+type fakeDedup map[string]bool
+
+func (d fakeDedup) Seen(ctx context.Context, messageID string) bool { return d[messageID] }
+func (d fakeDedup) MarkDone(ctx context.Context, messageID string)  { d[messageID] = true }
+
+// fakeNotificationDAO 内存中的通知和聚合组触发用户，约束与 NotificationDAO 的唯一键一致
+type fakeNotificationDAO struct {
+	dao.INotificationDAO
+	nextID        uint
+	notifications map[uint]*model.Notification
+	actors        map[[2]uint]bool // (group_id, actor_id)
+}
+
+func newFakeNotificationDAO() *fakeNotificationDAO {
+	return &fakeNotificationDAO{
+		notifications: make(map[uint]*model.Notification),
+		actors:        make(map[[2]uint]bool),
+	}
+}
+
+func (d *fakeNotificationDAO) CreateNotification(ctx context.Context, notification *model.Notification) error {
+	if notification.GroupKey != nil {
+		if existing, _ := d.GetUnreadGroup(ctx, notification.UserID, *notification.GroupKey); existing != nil {
+			return fmt.Errorf("duplicate entry for uk_user_group")
+		}
+	}
+	d.nextID++
+	notification.ID = d.nextID
+	copied := *notification
+	d.notifications[copied.ID] = &copied
+	return nil
+}
+
+func (d *fakeNotificationDAO) GetUnreadGroup(ctx context.Context, userID uint, groupKey string) (*model.Notification, error) {
+	for _, n := range d.notifications {
+		if n.UserID == userID && n.GroupKey != nil && *n.GroupKey == groupKey {
+			copied := *n
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (d *fakeNotificationDAO) DeleteNotification(ctx context.Context, id uint) error {
+	delete(d.notifications, id)
+	return nil
+}
+
+func (d *fakeNotificationDAO) SetGroupID(ctx context.Context, id, groupID uint) error {
+	d.notifications[id].GroupID = groupID
+	return nil
+}
+
+func (d *fakeNotificationDAO) AddGroupActor(ctx context.Context, groupID, actorID uint) (bool, error) {
+	key := [2]uint{groupID, actorID}
+	if d.actors[key] {
+		return false, nil
+	}
+	d.actors[key] = true
+	return true, nil
+}
+
+func (d *fakeNotificationDAO) MarkRead(ctx context.Context, userID, id uint, types []string) (int64, error) {
+	var updated int64
+	for _, n := range d.notifications {
+		if n.UserID != userID || n.IsRead || (id > 0 && n.ID != id) || (len(types) > 0 && !slices.Contains(types, n.Type)) {
+			continue
+		}
+		for key := range d.actors {
+			if n.GroupID > 0 && key[0] == n.GroupID {
+				delete(d.actors, key)
+			}
+		}
+		n.IsRead = true
+		n.GroupKey = nil
+		updated++
+	}
+	return updated, nil
+}
+
+// userNotifications 按 ID 顺序返回用户的通知
+func (d *fakeNotificationDAO) userNotifications(userID uint) []*model.Notification {
+	var result []*model.Notification
+	for _, n := range d.notifications {
+		if n.UserID == userID {
+			result = append(result, n)
+		}
+	}
+	slices.SortFunc(result, func(a, b *model.Notification) int { return int(a.ID) - int(b.ID) })
+	return result
+}
+
+// newTestNotificationService 创建使用内存 DAO 的通知服务
+func newTestNotificationService() (*NotificationService, *fakeNotificationDAO) {
+	global.Logger = zap.NewNop()
+	notificationDAO := newFakeNotificationDAO()
+	return &NotificationService{
+		notificationDAO: notificationDAO,
+		txManager:       &fakeTxManager{},
+		dedup:           fakeDedup{},
+	}, notificationDAO
+}
+
+// handle 以指定事件 ID 投递事件
+func handle(t *testing.T, s *NotificationService, id string, evt event.Event) {
+	t.Helper()
+	env, err := event.NewEnvelope(evt)
+	if err != nil {
+		t.Fatalf("NewEnvelope() error = %v", err)
+	}
+	env.ID = id
+	if err := s.HandleEvent(context.Background(), env); err != nil {
+		t.Fatalf("HandleEvent(%s) error = %v", id, err)
+	}
+}
+
+func TestNotificationService_LikeAggregation(t *testing.T) {
+	const author, video = 1, 100
+	like := func(userID uint) event.Event {
+		return event.VideoLiked{UserID: userID, VideoID: video, AuthorID: author}
+	}
+
+	tests := []struct {
+		name       string
+		likers     []uint
+		wantCount  int
+		wantActors []uint
+	}{
+		{name: "single like", likers: []uint{2}, wantCount: 1, wantActors: []uint{2}},
+		{name: "distinct likers", likers: []uint{2, 3, 4}, wantCount: 3, wantActors: []uint{4, 3, 2}},
+		{name: "repeat liker in recent list", likers: []uint{2, 3, 2}, wantCount: 2, wantActors: []uint{3, 2}},
+		{
+			// 重复点赞的用户已不在最近的触发用户中，仍不重复计数
+			name:       "repeat liker beyond recent list",
+			likers:     []uint{2, 3, 4, 5, 6, 2, 3},
+			wantCount:  5,
+			wantActors: []uint{6, 5, 4},
+		},
+		{name: "self like ignored", likers: []uint{author}, wantCount: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, notificationDAO := newTestNotificationService()
+			for i, liker := range tt.likers {
+				handle(t, s, fmt.Sprintf("evt-%d", i), like(liker))
+			}
+
+			got := notificationDAO.userNotifications(author)
+			if tt.wantCount == 0 {
+				if len(got) != 0 {
+					t.Fatalf("got %d notifications, want 0", len(got))
+				}
+				return
+			}
+			if len(got) != 1 {
+				t.Fatalf("got %d notifications, want 1 aggregated", len(got))
+			}
+			if got[0].ActorCount != tt.wantCount {
+				t.Errorf("actor count = %d, want %d", got[0].ActorCount, tt.wantCount)
+			}
+			if actors := decodeActorIDs(got[0].ActorIDs); !slices.Equal(actors, tt.wantActors) {
+				t.Errorf("recent actors = %v, want %v", actors, tt.wantActors)
+			}
+			if got[0].GroupID == 0 {
+				t.Error("group id not set")
+			}
+		})
+	}
+}
+
+func TestNotificationService_DuplicateEvent(t *testing.T) {
+	s, notificationDAO := newTestNotificationService()
+	evt := event.UserFollowed{FollowerID: 2, FolloweeID: 1}
+	handle(t, s, "evt-1", evt)
+	handle(t, s, "evt-1", evt)
+
+	got := notificationDAO.userNotifications(1)
+	if len(got) != 1 {
+		t.Fatalf("got %d notifications, want 1", len(got))
+	}
+	if got[0].ActorCount != 1 {
+		t.Errorf("actor count = %d, want 1", got[0].ActorCount)
+	}
+}
+
+func TestNotificationService_MarkReadStartsNewGroup(t *testing.T) {
+	s, notificationDAO := newTestNotificationService()
+	follow := func(followerID uint) event.Event {
+		return event.UserFollowed{FollowerID: followerID, FolloweeID: 1}
+	}
+	handle(t, s, "evt-1", follow(2))
+	handle(t, s, "evt-2", follow(3))
+
+	updated, err := s.MarkRead(context.Background(), 1, &dto.NotificationReadRequest{})
+	if err != nil || updated != 1 {
+		t.Fatalf("MarkRead() = %d, %v, want 1", updated, err)
+	}
+	if len(notificationDAO.actors) != 0 {
+		t.Errorf("%d group actors left after mark read, want 0", len(notificationDAO.actors))
+	}
+
+	// 已读后同一用户再次关注生成新通知，重新计数
+	handle(t, s, "evt-3", follow(2))
+	got := notificationDAO.userNotifications(1)
+	if len(got) != 2 {
+		t.Fatalf("got %d notifications, want 2", len(got))
+	}
+	if !got[0].IsRead || got[1].IsRead {
+		t.Errorf("read flags = %v, %v, want true, false", got[0].IsRead, got[1].IsRead)
+	}
+	if got[1].ActorCount != 1 || got[1].GroupID == got[0].GroupID {
+		t.Errorf("new group count = %d group = %d, want 1 in a new group", got[1].ActorCount, got[1].GroupID)
+	}
+}
+
+func TestNotificationService_LegacyGroup(t *testing.T) {
+	s, notificationDAO := newTestNotificationService()
+	groupKey := constant.NotificationTypeFollow
+	legacy := &model.Notification{
+		UserID:     1,
+		Type:       constant.NotificationTypeFollow,
+		GroupKey:   &groupKey,
+		ActorID:    3,
+		ActorIDs:   encodeActorIDs([]uint{3, 2}),
+		ActorCount: 5,
+	}
+	_ = notificationDAO.CreateNotification(context.Background(), legacy)
+
+	// 没有组 ID 的旧通知：最近的触发用户补记到以该通知为组的集合中
+	handle(t, s, "evt-1", event.UserFollowed{FollowerID: 2, FolloweeID: 1})
+	if got := notificationDAO.userNotifications(1); len(got) != 1 || got[0].ActorCount != 5 {
+		t.Fatalf("repeat follower changed the aggregated notification: %+v", got)
+	}
+
+	handle(t, s, "evt-2", event.UserFollowed{FollowerID: 9, FolloweeID: 1})
+	got := notificationDAO.userNotifications(1)
+	if len(got) != 1 {
+		t.Fatalf("got %d notifications, want 1", len(got))
+	}
+	if got[0].ActorCount != 6 || got[0].GroupID != legacy.ID {
+		t.Errorf("count = %d group = %d, want 6 in group %d", got[0].ActorCount, got[0].GroupID, legacy.ID)
+	}
+}
+
+func TestNotificationService_CommentNotifications(t *testing.T) {
+	tests := []struct {
+		name  string
+		event event.CommentPosted
+		want  map[uint]string // 接收用户 -> 通知类型
+	}{
+		{
+			name:  "top level comment",
+			event: event.CommentPosted{CommentID: 1, VideoID: 100, AuthorID: 1, UserID: 2},
+			want:  map[uint]string{1: constant.NotificationTypeComment},
+		},
+		{
+			name:  "reply to another user",
+			event: event.CommentPosted{CommentID: 2, VideoID: 100, AuthorID: 1, UserID: 2, ParentID: 1, ReplyToUserID: 3},
+			want:  map[uint]string{1: constant.NotificationTypeComment, 3: constant.NotificationTypeReply},
+		},
+		{
+			name:  "reply to author",
+			event: event.CommentPosted{CommentID: 3, VideoID: 100, AuthorID: 1, UserID: 2, ParentID: 1, ReplyToUserID: 1},
+			want:  map[uint]string{1: constant.NotificationTypeReply},
+		},
+		{
+			name:  "author replies to commenter",
+			event: event.CommentPosted{CommentID: 4, VideoID: 100, AuthorID: 1, UserID: 1, ParentID: 1, ReplyToUserID: 2},
+			want:  map[uint]string{2: constant.NotificationTypeReply},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, notificationDAO := newTestNotificationService()
+			handle(t, s, "evt-1", tt.event)
+
+			got := make(map[uint]string)
+			for _, n := range notificationDAO.notifications {
+				got[n.UserID] = n.Type
+				if n.GroupKey != nil {
+					t.Errorf("comment notification aggregated with key %q", *n.GroupKey)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("notifications = %v, want %v", got, tt.want)
+			}
+			for userID, typ := range tt.want {
+				if got[userID] != typ {
+					t.Errorf("user %d notification = %q, want %q", userID, got[userID], typ)
+				}
+			}
+		})
+	}
+}
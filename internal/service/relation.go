@@ -10,6 +10,8 @@ import (
 	"github.com/wangn-tech/tiny-douyin/internal/dao"
 	"github.com/wangn-tech/tiny-douyin/internal/global"
 	"github.com/wangn-tech/tiny-douyin/internal/model"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/counter"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/event"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -33,7 +35,10 @@ type RelationService struct {
 	relationDAO dao.IRelationDAO
 	userDAO     dao.IUserDAO
 	messageDAO  dao.IMessageDAO
-	db          *gorm.DB
+	convDAO     dao.IConversationDAO
+	txManager   dao.ITxManager
+	counter     counter.ICounter
+	events      event.IPublisher
 }
 
 // NewRelationService 创建 RelationService 实例
@@ -41,13 +46,19 @@ func NewRelationService(
 	relationDAO dao.IRelationDAO,
 	userDAO dao.IUserDAO,
 	messageDAO dao.IMessageDAO,
-	db *gorm.DB,
+	convDAO dao.IConversationDAO,
+	txManager dao.ITxManager,
+	counter counter.ICounter,
+	events event.IPublisher,
 ) IRelationService {
 	return &RelationService{
 		relationDAO: relationDAO,
 		userDAO:     userDAO,
 		messageDAO:  messageDAO,
-		db:          db,
+		convDAO:     convDAO,
+		txManager:   txManager,
+		counter:     counter,
+		events:      events,
 	}
 }
 
@@ -118,24 +129,25 @@ func (s *RelationService) followUser(ctx context.Context, followerID, followeeID
 		FolloweeID: followeeID,
 	}
 
-	// 使用事务：创建关注关系 + 更新双方统计数
-	err = s.db.Transaction(func(tx *gorm.DB) error {
+	// 使用事务：创建关注关系 + 更新双方统计数 + 发布关注事件
+	err = s.txManager.Transaction(ctx, func(ctx context.Context) error {
 		// 创建关注记录
 		if err := s.relationDAO.CreateRelation(ctx, relation); err != nil {
 			return err
 		}
 
-		// 增加关注者的关注数
-		if err := s.userDAO.IncrementFollowCount(ctx, followerID); err != nil {
+		// 增加关注者的关注数、被关注者的粉丝数（增量记录随事务提交）
+		if err := s.counter.IncrUser(ctx, followerID, counter.FieldFollowCount, 1); err != nil {
 			return err
 		}
-
-		// 增加被关注者的粉丝数
-		if err := s.userDAO.IncrementFollowerCount(ctx, followeeID); err != nil {
+		if err := s.counter.IncrUser(ctx, followeeID, counter.FieldFollowerCount, 1); err != nil {
 			return err
 		}
 
-		return nil
+		return s.events.Publish(ctx, event.UserFollowed{
+			FollowerID: followerID,
+			FolloweeID: followeeID,
+		})
 	})
 
 	if err != nil {
@@ -178,23 +190,17 @@ func (s *RelationService) unfollowUser(ctx context.Context, followerID, followee
 	}
 
 	// 使用事务：删除关注关系 + 更新双方统计数
-	err = s.db.Transaction(func(tx *gorm.DB) error {
+	err = s.txManager.Transaction(ctx, func(ctx context.Context) error {
 		// 删除关注记录
 		if err := s.relationDAO.DeleteRelation(ctx, followerID, followeeID); err != nil {
 			return err
 		}
 
-		// 减少关注者的关注数
-		if err := s.userDAO.DecrementFollowCount(ctx, followerID); err != nil {
+		// 减少关注者的关注数、被关注者的粉丝数（增量记录随事务提交）
+		if err := s.counter.IncrUser(ctx, followerID, counter.FieldFollowCount, -1); err != nil {
 			return err
 		}
-
-		// 减少被关注者的粉丝数
-		if err := s.userDAO.DecrementFollowerCount(ctx, followeeID); err != nil {
-			return err
-		}
-
-		return nil
+		return s.counter.IncrUser(ctx, followeeID, counter.FieldFollowerCount, -1)
 	})
 
 	if err != nil {
@@ -373,6 +379,9 @@ func (s *RelationService) GetFriendList(ctx context.Context, userID uint) ([]*dt
 		return nil, fmt.Errorf("查询用户信息失败")
 	}
 
+	// 批量查询与好友的会话及最新消息（查询失败不阻断流程，只记录日志）
+	conversations, latestMessages := s.getFriendConversations(ctx, userID, friendIDs)
+
 	// 构建好友信息 DTO 列表
 	friendList := make([]*dto.FriendInfo, 0, len(users))
 	for _, user := range users {
@@ -390,23 +399,17 @@ func (s *RelationService) GetFriendList(ctx context.Context, userID uint) ([]*dt
 			MsgType: 0,  // 默认为0：当前用户接收的消息
 		}
 
-		// 获取与该好友的最新一条消息
-		latestMsg, err := s.messageDAO.GetLatestMessage(ctx, userID, user.ID)
-		if err != nil {
-			// 查询失败不阻断流程，只记录日志
-			global.Logger.Warn("service.GetFriendList.get_latest_message_error",
-				zap.Uint("user_id", userID),
-				zap.Uint("friend_id", user.ID),
-				zap.Error(err),
-			)
-		} else if latestMsg != nil {
-			// 填充最新消息
-			friendInfo.Message = latestMsg.Content
-			// 判断消息类型：0-当前用户接收的消息，1-当前用户发送的消息
-			if latestMsg.FromUserID == userID {
-				friendInfo.MsgType = 1 // 当前用户发送的
-			} else {
-				friendInfo.MsgType = 0 // 当前用户接收的
+		if conversation, ok := conversations[user.ID]; ok {
+			friendInfo.UnreadCount = conversation.UnreadCount(userID)
+			if latestMsg, ok := latestMessages[conversation.LastMessageID]; ok {
+				// 填充最新消息
+				friendInfo.Message = latestMsg.Preview()
+				// 判断消息类型：0-当前用户接收的消息，1-当前用户发送的消息
+				if latestMsg.FromUserID == userID {
+					friendInfo.MsgType = 1 // 当前用户发送的
+				} else {
+					friendInfo.MsgType = 0 // 当前用户接收的
+				}
 			}
 		}
 
@@ -421,6 +424,41 @@ func (s *RelationService) GetFriendList(ctx context.Context, userID uint) ([]*dt
 	return friendList, nil
 }
 
+// getFriendConversations 批量查询与好友的会话（friendID -> 会话）和会话的最新消息（messageID -> 消息）
+func (s *RelationService) getFriendConversations(ctx context.Context, userID uint, friendIDs []uint) (map[uint]*model.Conversation, map[uint]*model.Message) {
+	conversations, err := s.convDAO.GetConversationsByPeers(ctx, userID, friendIDs)
+	if err != nil {
+		global.Logger.Warn("service.GetFriendList.get_conversations_error",
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		return nil, nil
+	}
+
+	messageIDs := make([]uint, 0, len(conversations))
+	for _, conversation := range conversations {
+		messageIDs = append(messageIDs, conversation.LastMessageID)
+	}
+	messages, err := s.messageDAO.GetMessagesByIDs(ctx, messageIDs)
+	if err != nil {
+		global.Logger.Warn("service.GetFriendList.get_latest_messages_error",
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		return conversations, nil
+	}
+
+	latestMessages := make(map[uint]*model.Message, len(messages))
+	for _, message := range messages {
+		// 已删除或清空会话前的消息不再展示
+		if conversation, ok := conversations[messagePeerID(message, userID)]; ok && !conversation.MessageVisible(message, userID) {
+			continue
+		}
+		latestMessages[message.ID] = message
+	}
+	return conversations, latestMessages
+}
+
 // IsFriend 判断两个用户是否为好友（双向关注）
 func (s *RelationService) IsFriend(ctx context.Context, userID1, userID2 uint) (bool, error) {
 	// 检查 userID1 是否关注 userID2
@@ -9,6 +9,7 @@ import (
 	"github.com/wangn-tech/tiny-douyin/internal/dao"
 	"github.com/wangn-tech/tiny-douyin/internal/global"
 	"github.com/wangn-tech/tiny-douyin/internal/model"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/counter"
 	"github.com/wangn-tech/tiny-douyin/internal/pkg/hash"
 	"github.com/wangn-tech/tiny-douyin/internal/pkg/jwt"
 	"go.uber.org/zap"
@@ -26,13 +27,15 @@ type IUserService interface {
 type UserService struct {
 	userDAO     dao.IUserDAO
 	relationDAO dao.IRelationDAO
+	counter     counter.ICounter
 }
 
 // NewUserService 创建 UserService 实例（依赖注入）
-func NewUserService(userDAO dao.IUserDAO, relationDAO dao.IRelationDAO) IUserService {
+func NewUserService(userDAO dao.IUserDAO, relationDAO dao.IRelationDAO, counter counter.ICounter) IUserService {
 	return &UserService{
 		userDAO:     userDAO,
 		relationDAO: relationDAO,
+		counter:     counter,
 	}
 }
 
@@ -41,6 +44,7 @@ func NewUserServiceDefault() IUserService {
 	return NewUserService(
 		dao.NewUserDAO(global.DB),
 		dao.NewRelationDAO(global.DB),
+		counter.NewCounter(global.RedisClient, dao.NewCounterDAO(global.DB)),
 	)
 }
 
@@ -207,6 +211,9 @@ func (s *UserService) GetUserInfo(ctx context.Context, currentUserID uint, req *
 		}
 	}
 
+	// 合并尚未回写 MySQL 的计数增量
+	deltas := s.counter.PendingUserDeltas(ctx, []uint{user.ID})[user.ID]
+
 	global.Logger.Info("service.GetUserInfo.success",
 		zap.Uint("user_id", req.UserID),
 		zap.Bool("is_follow", isFollow),
@@ -218,8 +225,8 @@ func (s *UserService) GetUserInfo(ctx context.Context, currentUserID uint, req *
 		Username:      user.Username,
 		Avatar:        user.Avatar,
 		Signature:     user.Signature,
-		FollowCount:   user.FollowCount,
-		FollowerCount: user.FollowerCount,
+		FollowCount:   deltas.Apply(counter.FieldFollowCount, user.FollowCount),
+		FollowerCount: deltas.Apply(counter.FieldFollowerCount, user.FollowerCount),
 		IsFollow:      isFollow,
 	}, nil
 }
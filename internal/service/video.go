@@ -6,21 +6,33 @@ import (
 	"time"
 
 	"github.com/wangn-tech/tiny-douyin/internal/api/dto"
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
+	"github.com/wangn-tech/tiny-douyin/internal/common/errc"
 	"github.com/wangn-tech/tiny-douyin/internal/dao"
 	"github.com/wangn-tech/tiny-douyin/internal/global"
 	"github.com/wangn-tech/tiny-douyin/internal/model"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/counter"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/outbox"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/storage"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/upload"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 // IVideoService 视频服务接口
 type IVideoService interface {
-	// PublishVideo 发布视频，返回视频 ID
-	PublishVideo(ctx context.Context, req *dto.VideoPublishRequest, authorID uint, playURL, coverURL string) (uint, error)
+	// PublishVideo 发布视频：在同一事务中创建处于 uploading 状态的视频记录并将上传任务写入发件箱，返回视频 ID
+	PublishVideo(ctx context.Context, req *dto.VideoPublishRequest, authorID uint, checksum string, size int64, task *upload.VideoUploadTask) (uint, error)
 	// GetVideoFeed 获取视频流
 	GetVideoFeed(ctx context.Context, req *dto.VideoFeedRequest, currentUserID uint) (*dto.VideoFeedData, error)
 	// GetVideoList 获取用户发布的视频列表
 	GetVideoList(ctx context.Context, req *dto.VideoListRequest, currentUserID uint) (*dto.VideoListData, error)
+	// GetVideoStatus 获取视频处理状态（未就绪的视频只有作者可以查询）
+	GetVideoStatus(ctx context.Context, videoID, currentUserID uint) (*dto.VideoStatus, error)
+	// DeleteVideo 删除视频（只有作者可以删除，任意状态的视频都可删除）
+	DeleteVideo(ctx context.Context, videoID, currentUserID uint) error
+	// GetVideoURL 获取已就绪视频的限时访问地址（urlType: play, hls, cover）
+	GetVideoURL(ctx context.Context, videoID uint, urlType string) (string, error)
 }
 
 // VideoService 视频服务实现
@@ -29,6 +41,10 @@ type VideoService struct {
 	userDAO     dao.IUserDAO
 	favoriteDAO dao.IFavoriteDAO
 	relationDAO dao.IRelationDAO
+	counter     counter.ICounter
+	txManager   dao.ITxManager
+	outbox      outbox.IOutbox
+	urlSigner   storage.IURLSigner
 }
 
 // NewVideoService 创建 VideoService 实例
@@ -37,33 +53,52 @@ func NewVideoService(
 	userDAO dao.IUserDAO,
 	favoriteDAO dao.IFavoriteDAO,
 	relationDAO dao.IRelationDAO,
+	counter counter.ICounter,
+	txManager dao.ITxManager,
+	outbox outbox.IOutbox,
+	urlSigner storage.IURLSigner,
 ) IVideoService {
 	return &VideoService{
 		videoDAO:    videoDAO,
 		userDAO:     userDAO,
 		favoriteDAO: favoriteDAO,
 		relationDAO: relationDAO,
+		counter:     counter,
+		txManager:   txManager,
+		outbox:      outbox,
+		urlSigner:   urlSigner,
 	}
 }
 
 // PublishVideo 发布视频，返回视频 ID
-func (s *VideoService) PublishVideo(ctx context.Context, req *dto.VideoPublishRequest, authorID uint, playURL, coverURL string) (uint, error) {
+// 视频记录与上传任务在同一事务中写入，不会出现有记录无任务（或相反）的情况；
+// 播放地址、封面等由 upload worker 处理完成后回填
+func (s *VideoService) PublishVideo(ctx context.Context, req *dto.VideoPublishRequest, authorID uint, checksum string, size int64, task *upload.VideoUploadTask) (uint, error) {
 	start := time.Now()
 
 	global.Logger.Info("service.PublishVideo.start",
 		zap.Uint("author_id", authorID),
 		zap.String("title", req.Title),
+		zap.Int64("size", size),
 	)
 
 	// 创建视频记录
 	video := &model.Video{
 		AuthorID: authorID,
-		PlayURL:  playURL,
-		CoverURL: coverURL,
 		Title:    req.Title,
+		Status:   constant.VideoStatusUploading,
+		Checksum: checksum,
+		FileSize: size,
 	}
 
-	if err := s.videoDAO.CreateVideo(ctx, video); err != nil {
+	err := s.txManager.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.videoDAO.CreateVideo(ctx, video); err != nil {
+			return err
+		}
+		task.VideoID = video.ID
+		return s.outbox.Add(ctx, constant.OutboxTopicVideoUpload, task)
+	})
+	if err != nil {
 		global.Logger.Error("service.PublishVideo.create_error",
 			zap.Uint("author_id", authorID),
 			zap.Error(err),
@@ -136,8 +171,9 @@ func (s *VideoService) GetVideoList(ctx context.Context, req *dto.VideoListReque
 		zap.Uint("current_user_id", currentUserID),
 	)
 
-	// 查询用户视频列表
-	videos, err := s.videoDAO.GetVideosByUserID(ctx, req.UserID)
+	// 查询用户视频列表（作者查看自己的列表时包含未就绪的视频）
+	includePending := currentUserID != 0 && currentUserID == req.UserID
+	videos, err := s.videoDAO.GetVideosByUserID(ctx, req.UserID, includePending)
 	if err != nil {
 		global.Logger.Error("service.GetVideoList.query_error",
 			zap.Uint("user_id", req.UserID),
@@ -166,6 +202,136 @@ func (s *VideoService) GetVideoList(ctx context.Context, req *dto.VideoListReque
 	}, nil
 }
 
+// GetVideoStatus 获取视频处理状态
+func (s *VideoService) GetVideoStatus(ctx context.Context, videoID, currentUserID uint) (*dto.VideoStatus, error) {
+	video, err := s.videoDAO.GetVideoByID(ctx, videoID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(errc.ErrMsg[errc.ErrVideoNotFound])
+		}
+		global.Logger.Error("service.GetVideoStatus.get_video_error",
+			zap.Uint("video_id", videoID),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	// 已删除的视频不可见；未就绪的视频只有作者可见
+	if video.Status == constant.VideoStatusDeleted ||
+		video.Status != constant.VideoStatusReady && video.AuthorID != currentUserID {
+		global.Logger.Warn("service.GetVideoStatus.not_visible",
+			zap.Uint("video_id", videoID),
+			zap.String("status", video.Status),
+			zap.Uint("current_user_id", currentUserID),
+		)
+		return nil, errors.New(errc.ErrMsg[errc.ErrVideoNotFound])
+	}
+
+	status := &dto.VideoStatus{
+		VideoID: video.ID,
+		Status:  video.Status,
+	}
+	if video.Status == constant.VideoStatusReady {
+		status.PlayURL = s.urlSigner.Sign(ctx, video.PlayURL)
+		status.HLSURL = s.urlSigner.Sign(ctx, video.StreamURL())
+		status.CoverURL = s.urlSigner.Sign(ctx, video.CoverURL)
+		status.DurationMs = video.DurationMs
+		status.Width = video.Width
+		status.Height = video.Height
+	}
+
+	return status, nil
+}
+
+// DeleteVideo 删除视频
+// 只将状态置为 deleted（对象存储中的文件保留），视频流、列表、评论、点赞和访问地址都只面向 ready 的视频；
+// 处理中的视频被删除后，worker 写入处理结果时的条件更新会失败，不再发布
+func (s *VideoService) DeleteVideo(ctx context.Context, videoID, currentUserID uint) error {
+	video, err := s.videoDAO.GetVideoByID(ctx, videoID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New(errc.ErrMsg[errc.ErrVideoNotFound])
+		}
+		global.Logger.Error("service.DeleteVideo.get_video_error",
+			zap.Uint("video_id", videoID),
+			zap.Error(err),
+		)
+		return err
+	}
+	if video.Status == constant.VideoStatusDeleted {
+		return errors.New(errc.ErrMsg[errc.ErrVideoNotFound])
+	}
+	if video.AuthorID != currentUserID {
+		global.Logger.Warn("service.DeleteVideo.permission_denied",
+			zap.Uint("video_id", videoID),
+			zap.Uint("author_id", video.AuthorID),
+			zap.Uint("current_user_id", currentUserID),
+		)
+		return errors.New(errc.ErrMsg[errc.ErrVideoPermission])
+	}
+
+	deleted, err := s.videoDAO.UpdateVideoStatus(ctx, videoID, constant.VideoStatusDeleted,
+		constant.VideoStatusUploading, constant.VideoStatusProcessing,
+		constant.VideoStatusReady, constant.VideoStatusFailed)
+	if err != nil {
+		global.Logger.Error("service.DeleteVideo.update_status_error",
+			zap.Uint("video_id", videoID),
+			zap.Error(err),
+		)
+		return err
+	}
+	if !deleted {
+		// 并发删除
+		return errors.New(errc.ErrMsg[errc.ErrVideoNotFound])
+	}
+
+	global.Logger.Info("service.DeleteVideo.success",
+		zap.Uint("video_id", videoID),
+		zap.String("previous_status", video.Status),
+	)
+
+	return nil
+}
+
+// GetVideoURL 获取已就绪视频的限时访问地址
+// 供缓存了视频地址的客户端使用：地址过期后通过该接口重定向到新签名的地址
+func (s *VideoService) GetVideoURL(ctx context.Context, videoID uint, urlType string) (string, error) {
+	video, err := s.videoDAO.GetVideoByID(ctx, videoID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", errors.New(errc.ErrMsg[errc.ErrVideoNotFound])
+		}
+		global.Logger.Error("service.GetVideoURL.get_video_error",
+			zap.Uint("video_id", videoID),
+			zap.Error(err),
+		)
+		return "", err
+	}
+	// 只为已就绪的视频签名：处理中、失败和已删除的视频对任何人都不返回访问地址
+	if video.Status != constant.VideoStatusReady {
+		global.Logger.Warn("service.GetVideoURL.not_visible",
+			zap.Uint("video_id", videoID),
+			zap.String("status", video.Status),
+		)
+		return "", errors.New(errc.ErrMsg[errc.ErrVideoNotFound])
+	}
+
+	var rawURL string
+	switch urlType {
+	case constant.VideoURLTypeHLS:
+		rawURL = video.StreamURL()
+	case constant.VideoURLTypeCover:
+		rawURL = video.CoverURL
+	default:
+		rawURL = video.PlayURL
+	}
+	if rawURL == "" {
+		return "", errors.New(errc.ErrMsg[errc.ErrVideoNotFound])
+	}
+
+	return s.urlSigner.Sign(ctx, rawURL), nil
+}
+
 // buildVideoDTOList 构建视频DTO列表（包含作者信息、统计信息）
 func (s *VideoService) buildVideoDTOList(ctx context.Context, videos []*model.Video, currentUserID uint) ([]dto.Video, int64, error) {
 	if len(videos) == 0 {
@@ -233,6 +399,14 @@ func (s *VideoService) buildVideoDTOList(ctx context.Context, videos []*model.Vi
 		followMap = make(map[uint]bool)
 	}
 
+	// 批量查询尚未回写 MySQL 的计数增量
+	authorIDList := make([]uint, 0, len(authorMap))
+	for authorID := range authorMap {
+		authorIDList = append(authorIDList, authorID)
+	}
+	userDeltas := s.counter.PendingUserDeltas(ctx, authorIDList)
+	videoDeltas := s.counter.PendingVideoDeltas(ctx, videoIDs)
+
 	// 组装视频DTO
 	for _, video := range videos {
 		author := authorMap[video.AuthorID]
@@ -240,22 +414,26 @@ func (s *VideoService) buildVideoDTOList(ctx context.Context, videos []*model.Vi
 			continue // 跳过作者不存在的视频
 		}
 
+		authorDeltas := userDeltas[author.ID]
+		deltas := videoDeltas[video.ID]
 		videoDTO := dto.Video{
 			ID:       video.ID,
-			PlayURL:  video.PlayURL,
-			CoverURL: video.CoverURL,
+			PlayURL:  s.urlSigner.Sign(ctx, video.PlayURL),
+			HLSURL:   s.urlSigner.Sign(ctx, video.StreamURL()),
+			CoverURL: s.urlSigner.Sign(ctx, video.CoverURL),
 			Title:    video.Title,
+			Status:   video.Status,
 			Author: dto.UserInfo{
 				ID:            author.ID,
 				Username:      author.Username,
 				Avatar:        author.Avatar,
 				Signature:     author.Signature,
-				FollowCount:   author.FollowCount,
-				FollowerCount: author.FollowerCount,
+				FollowCount:   authorDeltas.Apply(counter.FieldFollowCount, author.FollowCount),
+				FollowerCount: authorDeltas.Apply(counter.FieldFollowerCount, author.FollowerCount),
 				IsFollow:      followMap[author.ID],
 			},
-			FavoriteCount: video.FavoriteCount,
-			CommentCount:  video.CommentCount,
+			FavoriteCount: deltas.Apply(counter.FieldFavoriteCount, video.FavoriteCount),
+			CommentCount:  deltas.Apply(counter.FieldCommentCount, video.CommentCount),
 			IsFavorite:    favoriteMap[video.ID],
 		}
 
@@ -5,9 +5,18 @@ package wire
 
 import (
 	"github.com/google/wire"
+	"github.com/redis/go-redis/v9"
 	"github.com/wangn-tech/tiny-douyin/internal/api/handler"
 	"github.com/wangn-tech/tiny-douyin/internal/dao"
 	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/chat"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/counter"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/event"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/media"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/outbox"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/queue"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/retention"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/storage"
 	"github.com/wangn-tech/tiny-douyin/internal/pkg/upload"
 	"github.com/wangn-tech/tiny-douyin/internal/service"
 	"gorm.io/gorm"
@@ -18,20 +27,79 @@ func ProvideDB() *gorm.DB {
 	return global.DB
 }
 
+// ProvideRedis 提供 Redis 客户端
+func ProvideRedis() *redis.Client {
+	return global.RedisClient
+}
+
+// ProvideTaskQueue 提供任务队列
+func ProvideTaskQueue() queue.ITaskQueue {
+	return global.TaskQueue
+}
+
+// ProvideEventBus 提供领域事件总线
+func ProvideEventBus() event.IBus {
+	return global.EventBus
+}
+
+// ProvideOutboxNotifier 提供本进程的发件箱投递器唤醒入口（未启动 relay 时为 nil）
+func ProvideOutboxNotifier() outbox.Notifier {
+	return global.OutboxNotifier
+}
+
+// ProvideChatHub 提供实时聊天连接中心
+func ProvideChatHub() chat.IHub {
+	return global.ChatHub
+}
+
 // UploadSet Upload 层 Provider Set
 var UploadSet = wire.NewSet(
+	ProvideTaskQueue,
 	upload.NewUploadService,
 	upload.NewWorker,
+	upload.NewDeadLetterService,
+	upload.NewChunkUploadService,
+	upload.NewChunkCleaner,
+	upload.NewDirectUploadService,
+	media.NewProcessor,
 )
 
-// DAOSet DAO 层 Provider Set（只注入 DB）
+// OutboxSet 事务性发件箱 Provider Set
+var OutboxSet = wire.NewSet(
+	outbox.NewOutbox,
+	outbox.NewRelay,
+	ProvideOutboxNotifier,
+	outbox.NewDeduplicator,
+	outbox.NewEventPublisher,
+	ProvideEventBus,
+)
+
+// StorageSet 对象存储 Provider Set
+var StorageSet = wire.NewSet(
+	storage.NewObjectStore,
+	storage.NewURLSigner,
+)
+
+// CounterSet 计数器 Provider Set
+var CounterSet = wire.NewSet(
+	counter.NewCounter,
+	counter.NewFlusher,
+	counter.NewReconciler,
+)
+
+// DAOSet DAO 层 Provider Set（注入 DB 和 Redis，用户和视频 DAO 使用带缓存的实现）
 var DAOSet = wire.NewSet(
-	dao.NewUserDAO,
-	dao.NewVideoDAO,
+	dao.NewTxManager,
+	dao.NewUserCacheDAO,
+	dao.NewVideoCacheDAO,
 	dao.NewFavoriteDAO,
 	dao.NewCommentDAO,
 	dao.NewRelationDAO,
 	dao.NewMessageDAO,
+	dao.NewConversationDAO,
+	dao.NewCounterDAO,
+	dao.NewOutboxDAO,
+	dao.NewNotificationDAO,
 )
 
 // ServiceSet Service 层 Provider Set（只注入 DAO）
@@ -42,7 +110,10 @@ var ServiceSet = wire.NewSet(
 	service.NewCommentService,
 	service.NewRelationService,
 	service.NewMessageService,
+	service.NewNotificationService,
+	ProvideChatHub,
 	DAOSet,
+	CounterSet,
 )
 
 // HandlerSet Handler 层 Provider Set（只注入 Service 和 Upload）
@@ -53,16 +124,24 @@ var HandlerSet = wire.NewSet(
 	handler.NewCommentHandler,
 	handler.NewRelationHandler,
 	handler.NewMessageHandler,
+	handler.NewNotificationHandler,
+	handler.NewAdminHandler,
+	handler.NewStorageHandler,
 	ServiceSet,
 	UploadSet,
+	StorageSet,
+	OutboxSet,
 )
 
 // InitUserHandler 初始化 UserHandler（Wire 自动生成实现）
 func InitUserHandler() *handler.UserHandler {
 	wire.Build(
 		ProvideDB,
-		dao.NewUserDAO,
+		ProvideRedis,
+		dao.NewUserCacheDAO,
 		dao.NewRelationDAO,
+		dao.NewCounterDAO,
+		counter.NewCounter,
 		service.NewUserService,
 		handler.NewUserHandler,
 	)
@@ -73,12 +152,23 @@ func InitUserHandler() *handler.UserHandler {
 func InitVideoHandler() *handler.VideoHandler {
 	wire.Build(
 		ProvideDB,
-		dao.NewUserDAO,
-		dao.NewVideoDAO,
+		ProvideRedis,
+		dao.NewTxManager,
+		dao.NewUserCacheDAO,
+		dao.NewVideoCacheDAO,
 		dao.NewFavoriteDAO,
 		dao.NewRelationDAO,
+		dao.NewOutboxDAO,
+		dao.NewCounterDAO,
+		counter.NewCounter,
+		outbox.NewOutbox,
+		ProvideOutboxNotifier,
+		storage.NewObjectStore,
+		storage.NewURLSigner,
 		service.NewVideoService,
 		upload.NewUploadService,
+		upload.NewChunkUploadService,
+		upload.NewDirectUploadService,
 		handler.NewVideoHandler,
 	)
 	return nil
@@ -88,21 +178,73 @@ func InitVideoHandler() *handler.VideoHandler {
 func InitUploadWorker() upload.IUploadWorker {
 	wire.Build(
 		ProvideDB,
-		dao.NewVideoDAO,
+		ProvideRedis,
+		dao.NewTxManager,
+		dao.NewVideoCacheDAO,
+		dao.NewOutboxDAO,
+		outbox.NewOutbox,
+		ProvideOutboxNotifier,
+		outbox.NewEventPublisher,
+		media.NewProcessor,
+		storage.NewObjectStore,
+		ProvideTaskQueue,
+		outbox.NewDeduplicator,
 		upload.NewUploadService,
 		upload.NewWorker,
 	)
 	return nil
 }
 
+// InitOutboxRelay 初始化发件箱投递器（Wire 自动生成实现）
+func InitOutboxRelay() outbox.IRelay {
+	wire.Build(
+		ProvideDB,
+		dao.NewTxManager,
+		dao.NewOutboxDAO,
+		ProvideTaskQueue,
+		ProvideEventBus,
+		outbox.NewRelay,
+	)
+	return nil
+}
+
+// InitChunkCleaner 初始化过期分片清理器（Wire 自动生成实现）
+func InitChunkCleaner() upload.IChunkCleaner {
+	wire.Build(
+		ProvideRedis,
+		upload.NewChunkCleaner,
+	)
+	return nil
+}
+
+// InitStorageHandler 初始化 StorageHandler（Wire 自动生成实现）
+func InitStorageHandler() *handler.StorageHandler {
+	wire.Build(
+		storage.NewObjectStore,
+		storage.NewURLSigner,
+		handler.NewStorageHandler,
+	)
+	return nil
+}
+
 // InitFavoriteHandler 初始化 FavoriteHandler（Wire 自动生成实现）
 func InitFavoriteHandler() *handler.FavoriteHandler {
 	wire.Build(
 		ProvideDB,
-		dao.NewUserDAO,
-		dao.NewVideoDAO,
+		ProvideRedis,
+		dao.NewTxManager,
+		dao.NewUserCacheDAO,
+		dao.NewVideoCacheDAO,
 		dao.NewFavoriteDAO,
 		dao.NewRelationDAO,
+		counter.NewCounter,
+		dao.NewOutboxDAO,
+		dao.NewCounterDAO,
+		outbox.NewOutbox,
+		ProvideOutboxNotifier,
+		outbox.NewEventPublisher,
+		storage.NewObjectStore,
+		storage.NewURLSigner,
 		service.NewFavoriteService,
 		handler.NewFavoriteHandler,
 	)
@@ -113,9 +255,17 @@ func InitFavoriteHandler() *handler.FavoriteHandler {
 func InitCommentHandler() *handler.CommentHandler {
 	wire.Build(
 		ProvideDB,
-		dao.NewUserDAO,
-		dao.NewVideoDAO,
+		ProvideRedis,
+		dao.NewTxManager,
+		dao.NewUserCacheDAO,
+		dao.NewVideoCacheDAO,
 		dao.NewCommentDAO,
+		counter.NewCounter,
+		dao.NewOutboxDAO,
+		dao.NewCounterDAO,
+		outbox.NewOutbox,
+		ProvideOutboxNotifier,
+		outbox.NewEventPublisher,
 		service.NewCommentService,
 		handler.NewCommentHandler,
 	)
@@ -126,9 +276,18 @@ func InitCommentHandler() *handler.CommentHandler {
 func InitRelationHandler() *handler.RelationHandler {
 	wire.Build(
 		ProvideDB,
-		dao.NewUserDAO,
+		ProvideRedis,
+		dao.NewTxManager,
+		dao.NewUserCacheDAO,
 		dao.NewRelationDAO,
 		dao.NewMessageDAO,
+		dao.NewConversationDAO,
+		counter.NewCounter,
+		dao.NewOutboxDAO,
+		dao.NewCounterDAO,
+		outbox.NewOutbox,
+		ProvideOutboxNotifier,
+		outbox.NewEventPublisher,
 		service.NewRelationService,
 		handler.NewRelationHandler,
 	)
@@ -139,12 +298,137 @@ func InitRelationHandler() *handler.RelationHandler {
 func InitMessageHandler() *handler.MessageHandler {
 	wire.Build(
 		ProvideDB,
-		dao.NewUserDAO,
+		ProvideRedis,
+		dao.NewTxManager,
+		dao.NewUserCacheDAO,
+		dao.NewVideoCacheDAO,
 		dao.NewRelationDAO,
 		dao.NewMessageDAO,
+		dao.NewConversationDAO,
+		counter.NewCounter,
+		dao.NewOutboxDAO,
+		dao.NewCounterDAO,
+		outbox.NewOutbox,
+		ProvideOutboxNotifier,
+		outbox.NewEventPublisher,
+		ProvideChatHub,
+		storage.NewObjectStore,
+		storage.NewURLSigner,
+		upload.NewUploadService,
 		service.NewRelationService,
 		service.NewMessageService,
 		handler.NewMessageHandler,
 	)
 	return nil
 }
+
+// InitMessageService 初始化 MessageService（订阅消息事件实时推送，Wire 自动生成实现）
+func InitMessageService() service.IMessageService {
+	wire.Build(
+		ProvideDB,
+		ProvideRedis,
+		dao.NewTxManager,
+		dao.NewUserCacheDAO,
+		dao.NewVideoCacheDAO,
+		dao.NewRelationDAO,
+		dao.NewMessageDAO,
+		dao.NewConversationDAO,
+		counter.NewCounter,
+		dao.NewOutboxDAO,
+		dao.NewCounterDAO,
+		outbox.NewOutbox,
+		ProvideOutboxNotifier,
+		outbox.NewEventPublisher,
+		ProvideChatHub,
+		storage.NewObjectStore,
+		storage.NewURLSigner,
+		upload.NewUploadService,
+		service.NewRelationService,
+		service.NewMessageService,
+	)
+	return nil
+}
+
+// InitMessagePurger 初始化过期私信清理器（Wire 自动生成实现）
+func InitMessagePurger() retention.IMessagePurger {
+	wire.Build(
+		ProvideDB,
+		dao.NewMessageDAO,
+		dao.NewConversationDAO,
+		storage.NewObjectStore,
+		upload.NewUploadService,
+		retention.NewMessagePurger,
+	)
+	return nil
+}
+
+// InitNotificationService 初始化 NotificationService（订阅领域事件生成通知，Wire 自动生成实现）
+func InitNotificationService() service.INotificationService {
+	wire.Build(
+		ProvideDB,
+		ProvideRedis,
+		dao.NewTxManager,
+		dao.NewUserCacheDAO,
+		dao.NewNotificationDAO,
+		outbox.NewDeduplicator,
+		service.NewNotificationService,
+	)
+	return nil
+}
+
+// InitNotificationHandler 初始化 NotificationHandler（Wire 自动生成实现）
+func InitNotificationHandler() *handler.NotificationHandler {
+	wire.Build(
+		ProvideDB,
+		ProvideRedis,
+		dao.NewTxManager,
+		dao.NewUserCacheDAO,
+		dao.NewNotificationDAO,
+		outbox.NewDeduplicator,
+		service.NewNotificationService,
+		handler.NewNotificationHandler,
+	)
+	return nil
+}
+
+// InitCounterFlusher 初始化计数回写器（Wire 自动生成实现）
+func InitCounterFlusher() counter.IFlusher {
+	wire.Build(
+		ProvideDB,
+		ProvideRedis,
+		dao.NewTxManager,
+		dao.NewUserCacheDAO,
+		dao.NewVideoCacheDAO,
+		dao.NewCounterDAO,
+		counter.NewFlusher,
+	)
+	return nil
+}
+
+// InitCounterReconciler 初始化计数对账器（Wire 自动生成实现）
+func InitCounterReconciler() counter.IReconciler {
+	wire.Build(
+		ProvideDB,
+		ProvideRedis,
+		dao.NewTxManager,
+		dao.NewUserCacheDAO,
+		dao.NewVideoCacheDAO,
+		dao.NewCounterDAO,
+		counter.NewCounter,
+		counter.NewReconciler,
+	)
+	return nil
+}
+
+// InitAdminHandler 初始化 AdminHandler（Wire 自动生成实现）
+func InitAdminHandler() *handler.AdminHandler {
+	wire.Build(
+		ProvideDB,
+		ProvideRedis,
+		dao.NewVideoCacheDAO,
+		ProvideTaskQueue,
+		upload.NewDeadLetterService,
+		handler.NewAdminHandler,
+	)
+	return nil
+}
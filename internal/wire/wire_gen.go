@@ -8,9 +8,18 @@ package wire
 
 import (
 	"github.com/google/wire"
+	"github.com/redis/go-redis/v9"
 	"github.com/wangn-tech/tiny-douyin/internal/api/handler"
 	"github.com/wangn-tech/tiny-douyin/internal/dao"
 	"github.com/wangn-tech/tiny-douyin/internal/global"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/chat"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/counter"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/event"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/media"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/outbox"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/queue"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/retention"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/storage"
 	"github.com/wangn-tech/tiny-douyin/internal/pkg/upload"
 	"github.com/wangn-tech/tiny-douyin/internal/service"
 	"gorm.io/gorm"
@@ -21,9 +30,12 @@ import (
 // InitUserHandler 初始化 UserHandler（Wire 自动生成实现）
 func InitUserHandler() *handler.UserHandler {
 	db := ProvideDB()
-	iUserDAO := dao.NewUserDAO(db)
+	client := ProvideRedis()
+	iUserDAO := dao.NewUserCacheDAO(db, client)
 	iRelationDAO := dao.NewRelationDAO(db)
-	iUserService := service.NewUserService(iUserDAO, iRelationDAO)
+	iCounterDAO := dao.NewCounterDAO(db)
+	iCounter := counter.NewCounter(client, iCounterDAO)
+	iUserService := service.NewUserService(iUserDAO, iRelationDAO, iCounter)
 	userHandler := handler.NewUserHandler(iUserService)
 	return userHandler
 }
@@ -31,33 +43,90 @@ func InitUserHandler() *handler.UserHandler {
 // InitVideoHandler 初始化 VideoHandler（Wire 自动生成实现）
 func InitVideoHandler() *handler.VideoHandler {
 	db := ProvideDB()
-	iVideoDAO := dao.NewVideoDAO(db)
-	iUserDAO := dao.NewUserDAO(db)
+	client := ProvideRedis()
+	iVideoDAO := dao.NewVideoCacheDAO(db, client)
+	iUserDAO := dao.NewUserCacheDAO(db, client)
 	iFavoriteDAO := dao.NewFavoriteDAO(db)
 	iRelationDAO := dao.NewRelationDAO(db)
-	iVideoService := service.NewVideoService(iVideoDAO, iUserDAO, iFavoriteDAO, iRelationDAO)
-	iUploadService := upload.NewUploadService()
-	videoHandler := handler.NewVideoHandler(iVideoService, iUploadService)
+	iCounterDAO := dao.NewCounterDAO(db)
+	iCounter := counter.NewCounter(client, iCounterDAO)
+	iTxManager := dao.NewTxManager(db)
+	iOutboxDAO := dao.NewOutboxDAO(db)
+	notifier := ProvideOutboxNotifier()
+	iOutbox := outbox.NewOutbox(iOutboxDAO, notifier)
+	iObjectStore := storage.NewObjectStore()
+	iurlSigner := storage.NewURLSigner(iObjectStore)
+	iVideoService := service.NewVideoService(iVideoDAO, iUserDAO, iFavoriteDAO, iRelationDAO, iCounter, iTxManager, iOutbox, iurlSigner)
+	iUploadService := upload.NewUploadService(iObjectStore)
+	iChunkUploadService := upload.NewChunkUploadService(client, iUploadService)
+	iDirectUploadService := upload.NewDirectUploadService(client, iUploadService)
+	videoHandler := handler.NewVideoHandler(iVideoService, iUploadService, iChunkUploadService, iDirectUploadService)
 	return videoHandler
 }
 
 // InitUploadWorker 初始化 UploadWorker（Wire 自动生成实现）
 func InitUploadWorker() upload.IUploadWorker {
-	iUploadService := upload.NewUploadService()
+	iObjectStore := storage.NewObjectStore()
+	iUploadService := upload.NewUploadService(iObjectStore)
 	db := ProvideDB()
-	iVideoDAO := dao.NewVideoDAO(db)
-	iUploadWorker := upload.NewWorker(iUploadService, iVideoDAO)
+	client := ProvideRedis()
+	iVideoDAO := dao.NewVideoCacheDAO(db, client)
+	iTxManager := dao.NewTxManager(db)
+	iOutboxDAO := dao.NewOutboxDAO(db)
+	notifier := ProvideOutboxNotifier()
+	iOutbox := outbox.NewOutbox(iOutboxDAO, notifier)
+	iPublisher := outbox.NewEventPublisher(iOutbox)
+	iProcessor := media.NewProcessor()
+	iTaskQueue := ProvideTaskQueue()
+	iDeduplicator := outbox.NewDeduplicator(client)
+	iUploadWorker := upload.NewWorker(iUploadService, iVideoDAO, iTxManager, iPublisher, iProcessor, iTaskQueue, iDeduplicator)
 	return iUploadWorker
 }
 
+// InitOutboxRelay 初始化发件箱投递器（Wire 自动生成实现）
+func InitOutboxRelay() outbox.IRelay {
+	db := ProvideDB()
+	iOutboxDAO := dao.NewOutboxDAO(db)
+	iTxManager := dao.NewTxManager(db)
+	iTaskQueue := ProvideTaskQueue()
+	iBus := ProvideEventBus()
+	iRelay := outbox.NewRelay(iOutboxDAO, iTxManager, iTaskQueue, iBus)
+	return iRelay
+}
+
+// InitChunkCleaner 初始化过期分片清理器（Wire 自动生成实现）
+func InitChunkCleaner() upload.IChunkCleaner {
+	client := ProvideRedis()
+	iChunkCleaner := upload.NewChunkCleaner(client)
+	return iChunkCleaner
+}
+
+// InitStorageHandler 初始化 StorageHandler（Wire 自动生成实现）
+func InitStorageHandler() *handler.StorageHandler {
+	iObjectStore := storage.NewObjectStore()
+	iurlSigner := storage.NewURLSigner(iObjectStore)
+	storageHandler := handler.NewStorageHandler(iObjectStore, iurlSigner)
+	return storageHandler
+}
+
 // InitFavoriteHandler 初始化 FavoriteHandler（Wire 自动生成实现）
 func InitFavoriteHandler() *handler.FavoriteHandler {
 	db := ProvideDB()
 	iFavoriteDAO := dao.NewFavoriteDAO(db)
-	iVideoDAO := dao.NewVideoDAO(db)
-	iUserDAO := dao.NewUserDAO(db)
+	client := ProvideRedis()
+	iVideoDAO := dao.NewVideoCacheDAO(db, client)
+	iUserDAO := dao.NewUserCacheDAO(db, client)
 	iRelationDAO := dao.NewRelationDAO(db)
-	iFavoriteService := service.NewFavoriteService(iFavoriteDAO, iVideoDAO, iUserDAO, iRelationDAO)
+	iTxManager := dao.NewTxManager(db)
+	iCounterDAO := dao.NewCounterDAO(db)
+	iCounter := counter.NewCounter(client, iCounterDAO)
+	iOutboxDAO := dao.NewOutboxDAO(db)
+	notifier := ProvideOutboxNotifier()
+	iOutbox := outbox.NewOutbox(iOutboxDAO, notifier)
+	iPublisher := outbox.NewEventPublisher(iOutbox)
+	iObjectStore := storage.NewObjectStore()
+	iurlSigner := storage.NewURLSigner(iObjectStore)
+	iFavoriteService := service.NewFavoriteService(iFavoriteDAO, iVideoDAO, iUserDAO, iRelationDAO, iTxManager, iCounter, iPublisher, iurlSigner)
 	favoriteHandler := handler.NewFavoriteHandler(iFavoriteService)
 	return favoriteHandler
 }
@@ -66,9 +135,17 @@ func InitFavoriteHandler() *handler.FavoriteHandler {
 func InitCommentHandler() *handler.CommentHandler {
 	db := ProvideDB()
 	iCommentDAO := dao.NewCommentDAO(db)
-	iVideoDAO := dao.NewVideoDAO(db)
-	iUserDAO := dao.NewUserDAO(db)
-	iCommentService := service.NewCommentService(iCommentDAO, iVideoDAO, iUserDAO, db)
+	client := ProvideRedis()
+	iVideoDAO := dao.NewVideoCacheDAO(db, client)
+	iUserDAO := dao.NewUserCacheDAO(db, client)
+	iTxManager := dao.NewTxManager(db)
+	iCounterDAO := dao.NewCounterDAO(db)
+	iCounter := counter.NewCounter(client, iCounterDAO)
+	iOutboxDAO := dao.NewOutboxDAO(db)
+	notifier := ProvideOutboxNotifier()
+	iOutbox := outbox.NewOutbox(iOutboxDAO, notifier)
+	iPublisher := outbox.NewEventPublisher(iOutbox)
+	iCommentService := service.NewCommentService(iCommentDAO, iVideoDAO, iUserDAO, iTxManager, iCounter, iPublisher)
 	commentHandler := handler.NewCommentHandler(iCommentService)
 	return commentHandler
 }
@@ -77,9 +154,18 @@ func InitCommentHandler() *handler.CommentHandler {
 func InitRelationHandler() *handler.RelationHandler {
 	db := ProvideDB()
 	iRelationDAO := dao.NewRelationDAO(db)
-	iUserDAO := dao.NewUserDAO(db)
+	client := ProvideRedis()
+	iUserDAO := dao.NewUserCacheDAO(db, client)
 	iMessageDAO := dao.NewMessageDAO(db)
-	iRelationService := service.NewRelationService(iRelationDAO, iUserDAO, iMessageDAO, db)
+	iConversationDAO := dao.NewConversationDAO(db)
+	iTxManager := dao.NewTxManager(db)
+	iCounterDAO := dao.NewCounterDAO(db)
+	iCounter := counter.NewCounter(client, iCounterDAO)
+	iOutboxDAO := dao.NewOutboxDAO(db)
+	notifier := ProvideOutboxNotifier()
+	iOutbox := outbox.NewOutbox(iOutboxDAO, notifier)
+	iPublisher := outbox.NewEventPublisher(iOutbox)
+	iRelationService := service.NewRelationService(iRelationDAO, iUserDAO, iMessageDAO, iConversationDAO, iTxManager, iCounter, iPublisher)
 	relationHandler := handler.NewRelationHandler(iRelationService)
 	return relationHandler
 }
@@ -88,14 +174,125 @@ func InitRelationHandler() *handler.RelationHandler {
 func InitMessageHandler() *handler.MessageHandler {
 	db := ProvideDB()
 	iMessageDAO := dao.NewMessageDAO(db)
-	iUserDAO := dao.NewUserDAO(db)
+	iConversationDAO := dao.NewConversationDAO(db)
+	client := ProvideRedis()
+	iUserDAO := dao.NewUserCacheDAO(db, client)
+	iVideoDAO := dao.NewVideoCacheDAO(db, client)
 	iRelationDAO := dao.NewRelationDAO(db)
-	iRelationService := service.NewRelationService(iRelationDAO, iUserDAO, iMessageDAO, db)
-	iMessageService := service.NewMessageService(iMessageDAO, iUserDAO, iRelationService)
-	messageHandler := handler.NewMessageHandler(iMessageService)
+	iTxManager := dao.NewTxManager(db)
+	iCounterDAO := dao.NewCounterDAO(db)
+	iCounter := counter.NewCounter(client, iCounterDAO)
+	iOutboxDAO := dao.NewOutboxDAO(db)
+	notifier := ProvideOutboxNotifier()
+	iOutbox := outbox.NewOutbox(iOutboxDAO, notifier)
+	iPublisher := outbox.NewEventPublisher(iOutbox)
+	iRelationService := service.NewRelationService(iRelationDAO, iUserDAO, iMessageDAO, iConversationDAO, iTxManager, iCounter, iPublisher)
+	iHub := ProvideChatHub()
+	iObjectStore := storage.NewObjectStore()
+	iUploadService := upload.NewUploadService(iObjectStore)
+	iurlSigner := storage.NewURLSigner(iObjectStore)
+	iMessageService := service.NewMessageService(iMessageDAO, iConversationDAO, iUserDAO, iVideoDAO, iRelationService, iTxManager, iPublisher, iHub, iUploadService, iurlSigner)
+	messageHandler := handler.NewMessageHandler(iMessageService, iUploadService, iHub)
 	return messageHandler
 }
 
+// InitMessageService 初始化 MessageService（订阅消息事件实时推送，Wire 自动生成实现）
+func InitMessageService() service.IMessageService {
+	db := ProvideDB()
+	iMessageDAO := dao.NewMessageDAO(db)
+	iConversationDAO := dao.NewConversationDAO(db)
+	client := ProvideRedis()
+	iUserDAO := dao.NewUserCacheDAO(db, client)
+	iVideoDAO := dao.NewVideoCacheDAO(db, client)
+	iRelationDAO := dao.NewRelationDAO(db)
+	iTxManager := dao.NewTxManager(db)
+	iCounterDAO := dao.NewCounterDAO(db)
+	iCounter := counter.NewCounter(client, iCounterDAO)
+	iOutboxDAO := dao.NewOutboxDAO(db)
+	notifier := ProvideOutboxNotifier()
+	iOutbox := outbox.NewOutbox(iOutboxDAO, notifier)
+	iPublisher := outbox.NewEventPublisher(iOutbox)
+	iRelationService := service.NewRelationService(iRelationDAO, iUserDAO, iMessageDAO, iConversationDAO, iTxManager, iCounter, iPublisher)
+	iHub := ProvideChatHub()
+	iObjectStore := storage.NewObjectStore()
+	iUploadService := upload.NewUploadService(iObjectStore)
+	iurlSigner := storage.NewURLSigner(iObjectStore)
+	iMessageService := service.NewMessageService(iMessageDAO, iConversationDAO, iUserDAO, iVideoDAO, iRelationService, iTxManager, iPublisher, iHub, iUploadService, iurlSigner)
+	return iMessageService
+}
+
+// InitMessagePurger 初始化过期私信清理器（Wire 自动生成实现）
+func InitMessagePurger() retention.IMessagePurger {
+	db := ProvideDB()
+	iMessageDAO := dao.NewMessageDAO(db)
+	iConversationDAO := dao.NewConversationDAO(db)
+	iObjectStore := storage.NewObjectStore()
+	iUploadService := upload.NewUploadService(iObjectStore)
+	iMessagePurger := retention.NewMessagePurger(iMessageDAO, iConversationDAO, iUploadService)
+	return iMessagePurger
+}
+
+// InitNotificationService 初始化 NotificationService（订阅领域事件生成通知，Wire 自动生成实现）
+func InitNotificationService() service.INotificationService {
+	db := ProvideDB()
+	iNotificationDAO := dao.NewNotificationDAO(db)
+	client := ProvideRedis()
+	iUserDAO := dao.NewUserCacheDAO(db, client)
+	iTxManager := dao.NewTxManager(db)
+	iDeduplicator := outbox.NewDeduplicator(client)
+	iNotificationService := service.NewNotificationService(iNotificationDAO, iUserDAO, iTxManager, iDeduplicator)
+	return iNotificationService
+}
+
+// InitNotificationHandler 初始化 NotificationHandler（Wire 自动生成实现）
+func InitNotificationHandler() *handler.NotificationHandler {
+	db := ProvideDB()
+	iNotificationDAO := dao.NewNotificationDAO(db)
+	client := ProvideRedis()
+	iUserDAO := dao.NewUserCacheDAO(db, client)
+	iTxManager := dao.NewTxManager(db)
+	iDeduplicator := outbox.NewDeduplicator(client)
+	iNotificationService := service.NewNotificationService(iNotificationDAO, iUserDAO, iTxManager, iDeduplicator)
+	notificationHandler := handler.NewNotificationHandler(iNotificationService)
+	return notificationHandler
+}
+
+// InitCounterFlusher 初始化计数回写器（Wire 自动生成实现）
+func InitCounterFlusher() counter.IFlusher {
+	client := ProvideRedis()
+	db := ProvideDB()
+	iVideoDAO := dao.NewVideoCacheDAO(db, client)
+	iUserDAO := dao.NewUserCacheDAO(db, client)
+	iCounterDAO := dao.NewCounterDAO(db)
+	iTxManager := dao.NewTxManager(db)
+	iFlusher := counter.NewFlusher(client, iVideoDAO, iUserDAO, iCounterDAO, iTxManager)
+	return iFlusher
+}
+
+// InitCounterReconciler 初始化计数对账器（Wire 自动生成实现）
+func InitCounterReconciler() counter.IReconciler {
+	client := ProvideRedis()
+	db := ProvideDB()
+	iVideoDAO := dao.NewVideoCacheDAO(db, client)
+	iUserDAO := dao.NewUserCacheDAO(db, client)
+	iCounterDAO := dao.NewCounterDAO(db)
+	iTxManager := dao.NewTxManager(db)
+	iCounter := counter.NewCounter(client, iCounterDAO)
+	iReconciler := counter.NewReconciler(client, iVideoDAO, iUserDAO, iCounterDAO, iTxManager, iCounter)
+	return iReconciler
+}
+
+// InitAdminHandler 初始化 AdminHandler（Wire 自动生成实现）
+func InitAdminHandler() *handler.AdminHandler {
+	db := ProvideDB()
+	client := ProvideRedis()
+	iVideoDAO := dao.NewVideoCacheDAO(db, client)
+	iTaskQueue := ProvideTaskQueue()
+	iDeadLetterService := upload.NewDeadLetterService(iVideoDAO, iTaskQueue)
+	adminHandler := handler.NewAdminHandler(iDeadLetterService)
+	return adminHandler
+}
+
 // wire.go:
 
 // ProvideDB 提供数据库连接
@@ -103,16 +300,57 @@ func ProvideDB() *gorm.DB {
 	return global.DB
 }
 
+// ProvideRedis 提供 Redis 客户端
+func ProvideRedis() *redis.Client {
+	return global.RedisClient
+}
+
+// ProvideTaskQueue 提供任务队列
+func ProvideTaskQueue() queue.ITaskQueue {
+	return global.TaskQueue
+}
+
+// ProvideEventBus 提供领域事件总线
+func ProvideEventBus() event.IBus {
+	return global.EventBus
+}
+
+// ProvideOutboxNotifier 提供本进程的发件箱投递器唤醒入口（未启动 relay 时为 nil）
+func ProvideOutboxNotifier() outbox.Notifier {
+	return global.OutboxNotifier
+}
+
+// ProvideChatHub 提供实时聊天连接中心
+func ProvideChatHub() chat.IHub {
+	return global.ChatHub
+}
+
 // UploadSet Upload 层 Provider Set
-var UploadSet = wire.NewSet(upload.NewUploadService, upload.NewWorker)
+var UploadSet = wire.NewSet(
+	ProvideTaskQueue, upload.NewUploadService, upload.NewWorker, upload.NewDeadLetterService, upload.NewChunkUploadService, upload.NewChunkCleaner, upload.NewDirectUploadService, media.NewProcessor,
+)
+
+// OutboxSet 事务性发件箱 Provider Set
+var OutboxSet = wire.NewSet(outbox.NewOutbox, outbox.NewRelay, ProvideOutboxNotifier, outbox.NewDeduplicator, outbox.NewEventPublisher, ProvideEventBus)
+
+// StorageSet 对象存储 Provider Set
+var StorageSet = wire.NewSet(storage.NewObjectStore, storage.NewURLSigner)
 
-// DAOSet DAO 层 Provider Set（只注入 DB）
-var DAOSet = wire.NewSet(dao.NewUserDAO, dao.NewVideoDAO, dao.NewFavoriteDAO, dao.NewCommentDAO, dao.NewRelationDAO, dao.NewMessageDAO)
+// CounterSet 计数器 Provider Set
+var CounterSet = wire.NewSet(counter.NewCounter, counter.NewFlusher, counter.NewReconciler)
+
+// DAOSet DAO 层 Provider Set（注入 DB 和 Redis，用户和视频 DAO 使用带缓存的实现）
+var DAOSet = wire.NewSet(dao.NewTxManager, dao.NewUserCacheDAO, dao.NewVideoCacheDAO, dao.NewFavoriteDAO, dao.NewCommentDAO, dao.NewRelationDAO, dao.NewMessageDAO, dao.NewConversationDAO, dao.NewCounterDAO, dao.NewOutboxDAO, dao.NewNotificationDAO)
 
 // ServiceSet Service 层 Provider Set（只注入 DAO）
-var ServiceSet = wire.NewSet(service.NewUserService, service.NewVideoService, service.NewFavoriteService, service.NewCommentService, service.NewRelationService, service.NewMessageService, DAOSet)
+var ServiceSet = wire.NewSet(service.NewUserService, service.NewVideoService, service.NewFavoriteService, service.NewCommentService, service.NewRelationService, service.NewMessageService, service.NewNotificationService, ProvideChatHub,
+	DAOSet,
+	CounterSet,
+)
 
 // HandlerSet Handler 层 Provider Set（只注入 Service 和 Upload）
-var HandlerSet = wire.NewSet(handler.NewUserHandler, handler.NewVideoHandler, handler.NewFavoriteHandler, handler.NewCommentHandler, handler.NewRelationHandler, handler.NewMessageHandler, ServiceSet,
+var HandlerSet = wire.NewSet(handler.NewUserHandler, handler.NewVideoHandler, handler.NewFavoriteHandler, handler.NewCommentHandler, handler.NewRelationHandler, handler.NewMessageHandler, handler.NewNotificationHandler, handler.NewAdminHandler, handler.NewStorageHandler, ServiceSet,
 	UploadSet,
+	StorageSet,
+	OutboxSet,
 )
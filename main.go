@@ -2,28 +2,108 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
 
+	"github.com/wangn-tech/tiny-douyin/internal/common/constant"
 	"github.com/wangn-tech/tiny-douyin/internal/global"
 	"github.com/wangn-tech/tiny-douyin/internal/initialize"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/chat"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/counter"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/event"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/outbox"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/retention"
+	"github.com/wangn-tech/tiny-douyin/internal/pkg/upload"
 	"github.com/wangn-tech/tiny-douyin/internal/router"
+	"github.com/wangn-tech/tiny-douyin/internal/service"
 	"github.com/wangn-tech/tiny-douyin/internal/wire"
 )
 
+// 子命令
+const cmdReconcile = "reconcile"
+
 func main() {
+	// 命令行参数（与 --env 一起在 config.Init 中解析）
+	repair := pflag.Bool("repair", false, "Repair counter discrepancies found by the reconcile command")
+
 	// 初始化
 	initialize.InitAll()
 
+	// 子命令：计数对账，执行完成后退出
+	if pflag.Arg(0) == cmdReconcile {
+		runReconcile(*repair)
+		return
+	}
+
+	// 收到 SIGINT/SIGTERM 后取消 ctx，触发优雅停机
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// 启动发件箱投递器（使用 Wire 依赖注入）
+	// 先于其他组件创建，使其写入的发件箱消息在事务提交后立即唤醒投递
+	relay := wire.InitOutboxRelay()
+	global.OutboxNotifier = relay
+	if err := relay.Start(ctx); err != nil {
+		panic(fmt.Sprintf("Failed to start outbox relay: %v", err))
+	}
+
 	// 启动上传 worker（使用 Wire 依赖注入）
-	ctx := context.Background()
 	worker := wire.InitUploadWorker()
 	if err := worker.Start(ctx); err != nil {
 		panic(fmt.Sprintf("Failed to start upload worker: %v", err))
 	}
 
+	// 订阅领域事件生成站内通知（使用 Wire 依赖注入）
+	notificationService := wire.InitNotificationService()
+	if err := global.EventBus.Subscribe(ctx, constant.NotificationEventGroup, service.NotificationEventTypes, notificationService.HandleEvent); err != nil {
+		panic(fmt.Sprintf("Failed to subscribe notification events: %v", err))
+	}
+
+	// 启动实时聊天连接中心，并订阅消息事件推送给在线连接（使用 Wire 依赖注入）
+	hub := global.ChatHub
+	if err := hub.Start(ctx); err != nil {
+		panic(fmt.Sprintf("Failed to start chat hub: %v", err))
+	}
+	messageService := wire.InitMessageService()
+	if err := global.EventBus.Subscribe(ctx, constant.ChatEventGroup, []string{event.TypeMessageSent}, messageService.PushMessage); err != nil {
+		panic(fmt.Sprintf("Failed to subscribe message events: %v", err))
+	}
+
+	// 启动计数回写器（使用 Wire 依赖注入）
+	flusher := wire.InitCounterFlusher()
+	if err := flusher.Start(ctx); err != nil {
+		panic(fmt.Sprintf("Failed to start counter flusher: %v", err))
+	}
+
+	// 启动定时计数对账（使用 Wire 依赖注入）
+	reconciler := wire.InitCounterReconciler()
+	if err := reconciler.Start(ctx); err != nil {
+		panic(fmt.Sprintf("Failed to start counter reconciler: %v", err))
+	}
+
+	// 启动过期分片清理（使用 Wire 依赖注入）
+	chunkCleaner := wire.InitChunkCleaner()
+	if err := chunkCleaner.Start(ctx); err != nil {
+		panic(fmt.Sprintf("Failed to start chunk cleaner: %v", err))
+	}
+
+	// 启动过期私信清理（使用 Wire 依赖注入）
+	messagePurger := wire.InitMessagePurger()
+	if err := messagePurger.Start(ctx); err != nil {
+		panic(fmt.Sprintf("Failed to start message purger: %v", err))
+	}
+
 	// 初始化路由
 	gin.SetMode(global.Config.Server.Mode)
 	r := gin.New()
@@ -32,9 +112,87 @@ func main() {
 	router.Init(r)
 
 	// 启动服务
-	port := fmt.Sprintf(":%d", global.Config.Server.Port)
-	log.Println("Server starting on port " + port)
-	if err := r.Run(port); err != nil {
-		panic(fmt.Sprintf("Failed to start server: %v", err))
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%d", global.Config.Server.Port),
+		Handler: r,
+	}
+	go func() {
+		log.Println("Server starting on port " + srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			panic(fmt.Sprintf("Failed to start server: %v", err))
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	log.Println("Shutting down server...")
+	shutdown(srv, hub, worker, relay, flusher, reconciler, chunkCleaner, messagePurger)
+}
+
+// shutdown 优雅停机：停止接收请求，等待处理中的请求和后台任务完成后释放资源
+// 上传 worker 和计数任务在 ctx 取消时已停止拉取新任务，这里只需等待其退出
+func shutdown(
+	srv *http.Server,
+	hub chat.IHub,
+	worker upload.IUploadWorker,
+	relay outbox.IRelay,
+	flusher counter.IFlusher,
+	reconciler counter.IReconciler,
+	chunkCleaner upload.IChunkCleaner,
+	messagePurger retention.IMessagePurger,
+) {
+	timeout := time.Duration(global.Config.Server.ShutdownTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = constant.ServerDefaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		global.Logger.Error("Server forced to shutdown", zap.Error(err))
+	}
+	// srv.Shutdown 不会等待已升级的 WebSocket 连接，由连接中心在 ctx 取消时关闭
+	if err := hub.Wait(ctx); err != nil {
+		global.Logger.Error("Chat hub did not finish in time", zap.Error(err))
+	}
+	if err := worker.Wait(ctx); err != nil {
+		global.Logger.Error("Upload worker did not finish in time", zap.Error(err))
+	}
+	// 停机后才提交的发件箱消息保留在数据库中，下次启动时投递
+	if err := relay.Wait(ctx); err != nil {
+		global.Logger.Error("Outbox relay did not finish in time", zap.Error(err))
+	}
+	if err := chunkCleaner.Wait(ctx); err != nil {
+		global.Logger.Error("Chunk cleaner did not finish in time", zap.Error(err))
+	}
+	if err := messagePurger.Wait(ctx); err != nil {
+		global.Logger.Error("Message purger did not finish in time", zap.Error(err))
+	}
+	if err := reconciler.Wait(ctx); err != nil {
+		global.Logger.Error("Counter reconciler did not finish in time", zap.Error(err))
+	}
+	// 计数回写器退出前会做最后一次回写，放在最后等待
+	if err := flusher.Wait(ctx); err != nil {
+		global.Logger.Error("Counter flusher did not finish in time", zap.Error(err))
+	}
+
+	initialize.CloseAll()
+	log.Println("Server exited")
+}
+
+// runReconcile 执行一次计数对账并输出报告
+// 用法: go run . reconcile [--repair] [--env dev]
+func runReconcile(repair bool) {
+	reports, err := wire.InitCounterReconciler().Reconcile(context.Background(), repair)
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(reports)
+
+	// os.Exit 不执行 defer，退出前先释放资源
+	initialize.CloseAll()
+	if err != nil {
+		log.Printf("Counter reconcile failed: %v", err)
+		os.Exit(1)
 	}
 }